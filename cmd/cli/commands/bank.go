@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// bankCmd represents the bank command
+var bankCmd = &cobra.Command{
+	Use:   "bank",
+	Short: "bank commands",
+	Long:  `Import bank statements and record the payments they hold`,
+}
+
+func init() {
+	rootCmd.AddCommand(bankCmd)
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/bank"
+
+	"github.com/spf13/cobra"
+)
+
+// bankImportCmd represents the bank import command
+var bankImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Record the payments of a bank statement",
+	Long: `Read a bank statement in CAMT.053, OFX or CSV and match its credits to the
+	invoices still owed, by the invoice ID in the remittance text, the amount
+	and the name of the client. Each proposed match is recorded as a payment
+	once confirmed, or right away with --yes. Credits left unmatched are
+	listed to be recorded by hand with invoice pay. The columns of CSV
+	statements are set in bank.csv.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+
+		yes, err := cmd.Flags().GetBool("yes")
+		cobra.CheckErr(err)
+
+		data, err := os.ReadFile(args[0])
+		cobra.CheckErr(err)
+
+		if format == "" {
+			format = bank.DetectFormat(args[0], data)
+		}
+
+		transactions, err := bank.Parse(format, data, repository.CfgRepo{}.GetBankCSVLayout())
+		cobra.CheckErr(err)
+
+		bs := container.NewBankService()
+		matches, unmatched := bs.Reconcile(transactions)
+		in := bufio.NewReader(cmd.InOrStdin())
+		recorded := 0
+
+		fmt.Printf("%d transactions, %d matched\n", len(transactions), len(matches))
+
+		for _, m := range matches {
+			t := m.Transaction
+
+			fmt.Printf("\n%s %12.2f %-3s %s\n  %s\n  -> %s %s, %.2f owed (%s)\n",
+				t.Date.Format("2006-01-02"), t.Amount, t.Currency, t.Counterparty, t.Remittance,
+				m.Invoice.ID, m.Invoice.To.Name, m.Balance, strings.Join(m.Reasons, ", "))
+
+			if !yes && !confirm(in, "  Record the payment? [y/N] ") {
+				continue
+			}
+
+			invoice, credit, err := bs.Record(m)
+			cobra.CheckErr(err)
+
+			recorded++
+
+			fmt.Printf("  Invoice %s %s\n", invoice.ID, strings.ToLower(strings.ReplaceAll(invoice.Status, "_", " ")))
+
+			if credit > 0 {
+				fmt.Printf("  %.2f kept as credit of client %s\n", credit, invoice.To.ID)
+			}
+		}
+
+		fmt.Printf("\n%d payments recorded\n", recorded)
+
+		if len(unmatched) == 0 {
+			return
+		}
+
+		fmt.Printf("\nUnmatched credits\n")
+
+		for _, t := range unmatched {
+			fmt.Printf("%s %12.2f %-3s %-24.24s %s\n", t.Date.Format("2006-01-02"), t.Amount, t.Currency, t.Counterparty, t.Remittance)
+		}
+	},
+}
+
+func init() {
+	bankCmd.AddCommand(bankImportCmd)
+
+	bankImportCmd.Flags().StringP("format", "f", "", "Statement format: camt053, ofx or csv (default from the file)")
+	bankImportCmd.Flags().BoolP("yes", "y", false, "Record every proposed match without asking")
+}
+
+// confirm asks a yes or no question, no unless the answer starts with y.
+func confirm(in *bufio.Reader, question string) bool {
+	fmt.Print(question)
+
+	answer, _ := in.ReadString('\n') //nolint:errcheck //no answer is no
+
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
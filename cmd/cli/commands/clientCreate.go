@@ -1,7 +1,12 @@
 package commands
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/service"
 	"github.com/spf13/cobra"
 )
 
@@ -10,7 +15,8 @@ var clientCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Creates a new client entry",
 	Long: `Creates a new client entry. If id is not provided, the vat id
-will be used to compose a unique id`,
+will be used to compose a unique id. EU VAT numbers are checked in VIES
+when vies.enabled is set`,
 	Run: func(cmd *cobra.Command, _ []string) {
 		id, err := cmd.Flags().GetString("id")
 		cobra.CheckErr(err)
@@ -27,9 +33,17 @@ will be used to compose a unique id`,
 		address2, err := cmd.Flags().GetString("address2")
 		cobra.CheckErr(err)
 
+		dir3, err := dir3Flags(cmd)
+		cobra.CheckErr(err)
+
 		cs := container.NewClientService()
 
-		err = cs.Create(id, name, vatID, address1, address2)
+		err = cs.Create(cmd.Context(), id, name, vatID, address1, address2, dir3)
+		if errors.Is(err, service.ErrVatNotChecked) {
+			fmt.Printf("Warning: %v. Run \"client verify\" later\n", err)
+			return
+		}
+
 		cobra.CheckErr(err)
 	},
 }
@@ -43,6 +57,9 @@ func init() {
 	clientCreateCmd.Flags().StringP("vat_id", "v", "", "Client VAT ID [req]")
 	clientCreateCmd.Flags().StringP("address1", "s", "", "Client address street info [req]")
 	clientCreateCmd.Flags().StringP("address2", "c", "", "Client address region state country")
+	clientCreateCmd.Flags().String("dir3-accounting", "", "DIR3 code of the accounting office (public administrations)")
+	clientCreateCmd.Flags().String("dir3-management", "", "DIR3 code of the management body (public administrations)")
+	clientCreateCmd.Flags().String("dir3-processing", "", "DIR3 code of the processing unit (public administrations)")
 
 	err := clientCreateCmd.MarkFlagRequired("name")
 	cobra.CheckErr(err)
@@ -53,3 +70,32 @@ func init() {
 	err = clientCreateCmd.MarkFlagRequired("address1")
 	cobra.CheckErr(err)
 }
+
+// dir3Flags returns the DIR3 codes of the client, nil when none is given.
+// FACe needs the three of them so they are set together.
+func dir3Flags(cmd *cobra.Command) (*model.Dir3, error) {
+	dir3 := model.Dir3{}
+
+	for flag, value := range map[string]*string{
+		"dir3-accounting": &dir3.AccountingOffice,
+		"dir3-management": &dir3.ManagementBody,
+		"dir3-processing": &dir3.ProcessingUnit,
+	} {
+		v, err := cmd.Flags().GetString(flag)
+		if err != nil {
+			return nil, err
+		}
+
+		*value = v
+	}
+
+	if dir3 == (model.Dir3{}) {
+		return nil, nil //nolint:nilnil //no codes means the client is not a public administration
+	}
+
+	if dir3.AccountingOffice == "" || dir3.ManagementBody == "" || dir3.ProcessingUnit == "" {
+		return nil, errors.New("the three DIR3 codes are required for public administrations")
+	}
+
+	return &dir3, nil
+}
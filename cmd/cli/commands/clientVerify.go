@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/service"
+	"github.com/spf13/cobra"
+)
+
+// clientVerifyCmd represents the client verify command
+var clientVerifyCmd = &cobra.Command{
+	Use:   "verify <client id>",
+	Short: "Check the VAT number of a client in VIES",
+	Long: `Check in VIES that the VAT number of a client is active for
+	intra-community trade, which makes reverse charge apply. The result is
+	stored on the client and reused while newer than vies.max_age.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		force, err := cmd.Flags().GetBool("force")
+		cobra.CheckErr(err)
+
+		cs := container.NewClientService()
+
+		client, err := cs.Verify(cmd.Context(), args[0], force)
+		if errors.Is(err, service.ErrVatNotChecked) && client != nil && client.Vies != nil {
+			fmt.Printf("Warning: %v. Showing the last result\n", err)
+		} else {
+			cobra.CheckErr(err)
+		}
+
+		status := "not active"
+		if client.Vies.Valid {
+			status = "active"
+		}
+
+		fmt.Printf("%s: %s is %s for intra-community trade\n", client.ID, client.VatID, status)
+		fmt.Printf("  Checked at:   %s\n", client.Vies.CheckedAt.Format("2006-01-02 15:04:05"))
+
+		if client.Vies.ConsultationNumber != "" {
+			fmt.Printf("  Consultation: %s\n", client.Vies.ConsultationNumber)
+		}
+
+		if client.Vies.Name != "" {
+			fmt.Printf("  Name:         %s\n", client.Vies.Name)
+		}
+	},
+}
+
+func init() {
+	clientCmd.AddCommand(clientVerifyCmd)
+
+	clientVerifyCmd.Flags().BoolP("force", "f", false, "Check again even if the last result is recent")
+}
@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// exchangeCmd represents the exchange command
+var exchangeCmd = &cobra.Command{
+	Use:   "exchange",
+	Short: "exchange rate commands",
+	Long:  `Keep the exchange rates used to convert invoices in foreign currencies`,
+}
+
+func init() {
+	rootCmd.AddCommand(exchangeCmd)
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/exchange"
+
+	"github.com/spf13/cobra"
+)
+
+// exchangeImportCmd represents the exchange import command
+var exchangeImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import the euro reference rates of the ECB",
+	Long: `Import the euro foreign exchange reference rates of an ECB eurofxref
+	XML file, of the last day (eurofxref-daily.xml), the last 90 days
+	(eurofxref-hist-90d.xml) or since 1999 (eurofxref-hist.xml), from
+	https://www.ecb.europa.eu/stats/eurofxref/. Rates already stored for the
+	same currency and day are replaced.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		data, err := os.ReadFile(args[0])
+		cobra.CheckErr(err)
+
+		rates, err := exchange.ParseECB(data)
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(container.NewExchangeService().Import(rates))
+
+		first, last := rates[0].Date, rates[0].Date
+
+		for _, r := range rates {
+			if r.Date.Before(first) {
+				first = r.Date
+			}
+
+			if r.Date.After(last) {
+				last = r.Date
+			}
+		}
+
+		fmt.Printf("Imported %d rates from %s to %s\n", len(rates), first.Format(time.DateOnly), last.Format(time.DateOnly))
+	},
+}
+
+func init() {
+	exchangeCmd.AddCommand(exchangeImportCmd)
+}
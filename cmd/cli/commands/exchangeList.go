@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// exchangeListCmd represents the exchange list command
+var exchangeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the exchange rates",
+	Long: `List the exchange rates stored, as the value of one euro in each
+	currency, and the rate of the currency to the home currency.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		currency, err := cmd.Flags().GetString("currency")
+		cobra.CheckErr(err)
+
+		from, err := optionalDateFlag(cmd, "from")
+		cobra.CheckErr(err)
+
+		xs := container.NewExchangeService()
+
+		fmt.Printf("%-10s %-8s %12s %14s  %s\n", "Date", "Currency", "1 EUR", xs.Home()+" per unit", "Source")
+
+		for _, r := range xs.List(currency, from) {
+			home := ""
+			if x, err := xs.Rate(r.Currency, r.Date); err == nil && x != nil {
+				home = fmt.Sprintf("%.6f", x.Rate)
+			}
+
+			fmt.Printf("%-10s %-8s %12g %14s  %s\n", r.Date.Format(time.DateOnly), r.Currency, r.Rate, home, r.Source)
+		}
+	},
+}
+
+func init() {
+	exchangeCmd.AddCommand(exchangeListCmd)
+
+	exchangeListCmd.Flags().StringP("currency", "c", "", "List the rates of this currency only")
+	exchangeListCmd.Flags().String("from", "", "List the rates from this date, YYYY-MM-DD")
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// exchangeSetCmd represents the exchange set command
+var exchangeSetCmd = &cobra.Command{
+	Use:   "set <currency> <rate>",
+	Short: "Set the exchange rate of a currency by hand",
+	Long: `Set the value of one euro in a currency on a day, as the ECB quotes its
+	reference rates, for currencies the ECB does not publish or days to
+	correct. It replaces the rate stored for that day.`,
+	Example: `  invoiceling exchange set USD 1.0865 --date 2025-11-03`,
+	Args:    cobra.ExactArgs(2), //nolint:mnd //currency and rate
+	Run: func(cmd *cobra.Command, args []string) {
+		rate, err := strconv.ParseFloat(args[1], 64)
+		cobra.CheckErr(err)
+
+		date, err := dateFlag(cmd, "date")
+		cobra.CheckErr(err)
+
+		r, err := container.NewExchangeService().Set(args[0], date, rate)
+		cobra.CheckErr(err)
+
+		fmt.Printf("1 EUR = %g %s on %s\n", r.Rate, r.Currency, r.Date.Format(time.DateOnly))
+	},
+}
+
+func init() {
+	exchangeCmd.AddCommand(exchangeSetCmd)
+
+	exchangeSetCmd.Flags().String("date", "", "Day of the rate, YYYY-MM-DD (default today)")
+}
@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export commands",
+	Long:  `Export invoices to structured e-invoicing formats`,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/service"
+	"github.com/Inmovilizame/invoiceling/pkg/sheet"
+
+	"github.com/spf13/cobra"
+)
+
+// exportCsvCmd represents the export csv command
+var exportCsvCmd = &cobra.Command{
+	Use:   "csv",
+	Short: "Export invoices, invoice lines or clients as CSV",
+	Long: `Export a row per invoice, per invoice line or per client as CSV. The
+	columns are those given with --columns, else those set in
+	export.columns.<kind>, else every column of the kind.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		delimiter, err := cmd.Flags().GetString("delimiter")
+		cobra.CheckErr(err)
+
+		s, es := sheetFlags(cmd)
+
+		out, err := s.CSV(delimiter)
+		cobra.CheckErr(err)
+
+		writeSheet(cmd, es, s, ".csv", out)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportCsvCmd)
+
+	sheetFlagSet(exportCsvCmd)
+	exportCsvCmd.Flags().StringP("delimiter", "d", ",", "Field delimiter")
+}
+
+// sheetFlagSet adds the flags choosing the sheet exported.
+func sheetFlagSet(cmd *cobra.Command) {
+	cmd.Flags().StringP("kind", "k", sheet.KindInvoices, "Rows to export: invoices, lines or clients")
+	cmd.Flags().StringSliceP("columns", "c", nil, "Columns to export, comma separated")
+	cmd.Flags().StringP("output", "o", "", "Output file path, use '-' to write to stdout")
+}
+
+// sheetFlags returns the sheet chosen by the flags of cmd.
+func sheetFlags(cmd *cobra.Command) (*sheet.Sheet, *service.Export) {
+	kind, err := cmd.Flags().GetString("kind")
+	cobra.CheckErr(err)
+
+	columns, err := cmd.Flags().GetStringSlice("columns")
+	cobra.CheckErr(err)
+
+	if !cmd.Flags().Changed("columns") {
+		columns = repository.CfgRepo{}.GetExportColumns(kind)
+	}
+
+	es := container.NewExportService()
+
+	s, err := es.Sheet(kind, columns)
+	cobra.CheckErr(err)
+
+	return s, es
+}
+
+// writeSheet writes an exported sheet to the --output file, by default a file
+// named after its kind with extension ext in the export directory.
+func writeSheet(cmd *cobra.Command, es *service.Export, s *sheet.Sheet, ext string, out []byte) {
+	output, err := cmd.Flags().GetString("output")
+	cobra.CheckErr(err)
+
+	switch output {
+	case "-":
+		_, err = os.Stdout.Write(out)
+		cobra.CheckErr(err)
+
+		return
+	case "":
+		output = es.Path(s.Name + ext)
+	}
+
+	cobra.CheckErr(es.WriteFile(output, out))
+
+	fmt.Fprintln(os.Stderr, "Exported", s.Name, "to", output)
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/einvoice"
+
+	"github.com/spf13/cobra"
+)
+
+// exportFacturaeCmd represents the export facturae command
+var exportFacturaeCmd = &cobra.Command{
+	Use:   "facturae",
+	Short: "Export an invoice as signed Facturae 3.2.2 XML",
+	Long: `Export an invoice or credit note as Facturae 3.2.2, the format used to
+	invoice Spanish public administrations through FACe. The document is signed
+	with XAdES-EPES using a PKCS#12 certificate, taken from --cert or the
+	signing.certificate setting. The password is read from --password, the
+	INVOICELING_SIGNING_PASSWORD variable or the signing.password setting.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		invoiceID, err := cmd.Flags().GetString("invoice")
+		cobra.CheckErr(err)
+
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		cert, err := cmd.Flags().GetString("cert")
+		cobra.CheckErr(err)
+
+		password, err := cmd.Flags().GetString("password")
+		cobra.CheckErr(err)
+
+		unsigned, err := cmd.Flags().GetBool("unsigned")
+		cobra.CheckErr(err)
+
+		var signer *einvoice.Signer
+		if !unsigned {
+			signer, err = container.NewSigner(cert, password)
+			cobra.CheckErr(err)
+		}
+
+		is := container.NewInvoiceService()
+		invoice := is.Read(invoiceID)
+
+		es := container.NewExportService()
+		out, err := es.Facturae(invoice, signer)
+		cobra.CheckErr(err)
+
+		switch output {
+		case "-":
+			_, err = os.Stdout.Write(out)
+			cobra.CheckErr(err)
+
+			return
+		case "":
+			output = es.OutputPath(invoice, ".xsig")
+			if unsigned {
+				output = es.OutputPath(invoice, "_facturae.xml")
+			}
+		}
+
+		cobra.CheckErr(es.WriteFile(output, out))
+
+		fmt.Fprintln(os.Stderr, "Exported Facturae for", invoiceID, "to", output)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportFacturaeCmd)
+
+	exportFacturaeCmd.Flags().StringP("invoice", "i", "", "Invoice id to export")
+	exportFacturaeCmd.Flags().StringP("output", "o", "", "Output file path, use '-' to write to stdout")
+	exportFacturaeCmd.Flags().String("cert", "", "PKCS#12 certificate (.p12, .pfx) used to sign")
+	exportFacturaeCmd.Flags().String("password", "", "Password of the certificate")
+	exportFacturaeCmd.Flags().Bool("unsigned", false, "Export without signing")
+
+	err := exportFacturaeCmd.MarkFlagRequired("invoice")
+	cobra.CheckErr(err)
+}
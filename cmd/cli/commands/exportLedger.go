@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/ledger"
+
+	"github.com/spf13/cobra"
+)
+
+// exportLedgerCmd represents the export ledger command
+var exportLedgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "Export the journal entries of invoices and payments",
+	Long: `Export a double-entry journal entry for each issued invoice, credit note
+	and payment, as Beancount, hledger (also read by ledger) or a generic
+	journal CSV. The accounts are set in ledger.accounts, and invoice IDs are
+	kept as links or tags to trace each entry back to its invoice.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		from, err := optionalDateFlag(cmd, "from")
+		cobra.CheckErr(err)
+
+		to, err := optionalDateFlag(cmd, "to")
+		cobra.CheckErr(err)
+
+		es := container.NewExportService()
+
+		out, err := ledger.Write(format, es.Journal(repository.CfgRepo{}.GetLedgerAccounts(), from, to))
+		cobra.CheckErr(err)
+
+		switch output {
+		case "-":
+			_, err = os.Stdout.Write(out)
+			cobra.CheckErr(err)
+
+			return
+		case "":
+			output = es.Path("ledger" + ledger.Extension(format))
+		}
+
+		cobra.CheckErr(es.WriteFile(output, out))
+
+		fmt.Fprintln(os.Stderr, "Exported journal to", output)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportLedgerCmd)
+
+	exportLedgerCmd.Flags().StringP("format", "f", ledger.FormatBeancount, "Journal format: beancount, hledger or csv")
+	exportLedgerCmd.Flags().String("from", "", "Export the entries from this date, YYYY-MM-DD")
+	exportLedgerCmd.Flags().String("to", "", "Export the entries up to this date, YYYY-MM-DD")
+	exportLedgerCmd.Flags().StringP("output", "o", "", "Output file path, use '-' to write to stdout")
+}
+
+// optionalDateFlag parses a YYYY-MM-DD flag, the zero time when it is empty.
+func optionalDateFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
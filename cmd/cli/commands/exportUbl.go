@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// exportUblCmd represents the export ubl command
+var exportUblCmd = &cobra.Command{
+	Use:   "ubl",
+	Short: "Export an invoice as UBL 2.1 (Peppol BIS Billing 3.0)",
+	Long: `Export an invoice or credit note as a UBL 2.1 document following Peppol
+	BIS Billing 3.0. The document is checked against the EN 16931 and Peppol
+	business rules and is not written when a rule is broken unless --force is set.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		invoiceID, err := cmd.Flags().GetString("invoice")
+		cobra.CheckErr(err)
+
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		force, err := cmd.Flags().GetBool("force")
+		cobra.CheckErr(err)
+
+		is := container.NewInvoiceService()
+		invoice := is.Read(invoiceID)
+
+		es := container.NewExportService()
+		out, violations, err := es.UBL(invoice)
+		cobra.CheckErr(err)
+
+		for _, v := range violations {
+			fmt.Fprintln(os.Stderr, "RULE", v)
+		}
+
+		if len(violations) > 0 && !force {
+			cobra.CheckErr(fmt.Errorf("invoice %s breaks %d business rules, use --force to export anyway", invoiceID, len(violations)))
+		}
+
+		switch output {
+		case "-":
+			_, err = os.Stdout.Write(out)
+			cobra.CheckErr(err)
+
+			return
+		case "":
+			output = es.OutputPath(invoice, "_ubl.xml")
+		}
+
+		cobra.CheckErr(es.WriteFile(output, out))
+
+		fmt.Fprintln(os.Stderr, "Exported UBL for", invoiceID, "to", output)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportUblCmd)
+
+	exportUblCmd.Flags().StringP("invoice", "i", "", "Invoice id to export")
+	exportUblCmd.Flags().StringP("output", "o", "", "Output file path, use '-' to write to stdout")
+	exportUblCmd.Flags().Bool("force", false, "Export even if the invoice breaks business rules")
+
+	err := exportUblCmd.MarkFlagRequired("invoice")
+	cobra.CheckErr(err)
+}
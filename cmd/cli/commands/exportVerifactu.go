@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// exportVerifactuCmd represents the export verifactu command
+var exportVerifactuCmd = &cobra.Command{
+	Use:   "verifactu",
+	Short: "Export the Verifactu submission payload",
+	Long: `Export the XML payload submitting Verifactu records to the AEAT. By default
+	it holds every record not accepted yet; with --invoice only the records of
+	that invoice.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		invoiceID, err := cmd.Flags().GetString("invoice")
+		cobra.CheckErr(err)
+
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		vs := container.NewVerifactuService(rootCmd.Version)
+
+		records := vs.Pending()
+		name := "verifactu.xml"
+
+		if invoiceID != "" {
+			records = vs.ForInvoice(invoiceID)
+			name = invoiceID + "_verifactu.xml"
+		}
+
+		if len(records) == 0 {
+			cobra.CheckErr(errors.New("no Verifactu records to export"))
+		}
+
+		out, err := vs.Payload(records)
+		cobra.CheckErr(err)
+
+		es := container.NewExportService()
+
+		switch output {
+		case "-":
+			_, err = os.Stdout.Write(out)
+			cobra.CheckErr(err)
+
+			return
+		case "":
+			output = es.Path(name)
+		}
+
+		cobra.CheckErr(es.WriteFile(output, out))
+
+		fmt.Fprintln(os.Stderr, "Exported", len(records), "Verifactu records to", output)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportVerifactuCmd)
+
+	exportVerifactuCmd.Flags().StringP("invoice", "i", "", "Only export the records of this invoice")
+	exportVerifactuCmd.Flags().StringP("output", "o", "", "Output file path, use '-' to write to stdout")
+}
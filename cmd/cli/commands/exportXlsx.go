@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// exportXlsxCmd represents the export xlsx command
+var exportXlsxCmd = &cobra.Command{
+	Use:   "xlsx",
+	Short: "Export invoices, invoice lines or clients as an Excel workbook",
+	Long: `Export a row per invoice, per invoice line or per client as an XLSX
+	workbook, with amounts as numbers and dates as dates. The columns are
+	chosen as in export csv.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		s, es := sheetFlags(cmd)
+
+		out, err := s.XLSX()
+		cobra.CheckErr(err)
+
+		writeSheet(cmd, es, s, ".xlsx", out)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportXlsxCmd)
+
+	sheetFlagSet(exportXlsxCmd)
+}
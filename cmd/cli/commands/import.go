@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import commands",
+	Long:  `Import clients and invoice lines from other tools`,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/service"
+	"github.com/Inmovilizame/invoiceling/pkg/sheet"
+	"github.com/spf13/viper"
+
+	"github.com/spf13/cobra"
+)
+
+// importCsvCmd represents the import csv command
+var importCsvCmd = &cobra.Command{
+	Use:   "csv <file>",
+	Short: "Import clients or invoice lines from a CSV file",
+	Long: `Import the clients or the invoice lines of a CSV file with a header row.
+	Clients are checked as in client create, and lines are added to draft
+	invoices, created when they do not exist. Rows with errors are reported
+	and left out; the lines of an invoice are imported together or not at
+	all. Use --dry-run to check a file without storing anything. See
+	docs/spreadsheets.md for the columns read.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		kind, err := cmd.Flags().GetString("kind")
+		cobra.CheckErr(err)
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		cobra.CheckErr(err)
+
+		delimiter, err := cmd.Flags().GetString("delimiter")
+		cobra.CheckErr(err)
+
+		retention, err := cmd.Flags().GetFloat64("retention")
+		cobra.CheckErr(err)
+
+		note, err := cmd.Flags().GetString("note")
+		cobra.CheckErr(err)
+
+		data, err := os.ReadFile(args[0])
+		cobra.CheckErr(err)
+
+		records, err := sheet.ReadCSV(data, delimiter)
+		cobra.CheckErr(err)
+
+		is := container.NewImportService()
+
+		var results []service.ImportResult
+
+		switch kind {
+		case sheet.KindClients:
+			results = is.Clients(cmd.Context(), records, dryRun)
+		case sheet.KindLines:
+			results = is.Lines(records, dryRun, retention, note)
+		default:
+			cobra.CheckErr(fmt.Errorf("import kind '%s' not allowed, use clients or lines", kind))
+		}
+
+		failed := 0
+
+		for _, r := range results {
+			if r.Err != nil {
+				failed++
+
+				fmt.Printf("line %-4d %-16s error: %v\n", r.Line, r.ID, r.Err)
+
+				continue
+			}
+
+			if dryRun {
+				r.Action = "would " + r.Action
+			}
+
+			fmt.Printf("line %-4d %-16s %s\n", r.Line, r.ID, r.Action)
+		}
+
+		if dryRun {
+			fmt.Println("\nDry run, nothing was stored")
+		}
+
+		if failed > 0 {
+			cobra.CheckErr(fmt.Errorf("%d of %d imports with errors", failed, len(results)))
+		}
+
+		if !dryRun {
+			fmt.Printf("\n%d imported\n", len(results))
+		}
+	},
+}
+
+func init() {
+	importCmd.AddCommand(importCsvCmd)
+
+	importCsvCmd.Flags().StringP("kind", "k", sheet.KindLines, "Rows to import: clients or lines")
+	importCsvCmd.Flags().Bool("dry-run", false, "Report what would be imported without storing anything")
+	importCsvCmd.Flags().Float64P("retention", "r", viper.GetFloat64("retention"), "Retention of the invoices created (Spanish IRPF)")
+	importCsvCmd.Flags().StringP("note", "n",
+		"Thank you for your business. Please add the invoice number to your payment description.", "Note of the invoices created")
+	importCsvCmd.Flags().StringP("delimiter", "d", "", "Field delimiter (default ',' or ';' from the header)")
+}
@@ -6,6 +6,13 @@ import (
 	"os"
 	"slices"
 
+	"github.com/Inmovilizame/invoiceling/pkg/bank"
+	"github.com/Inmovilizame/invoiceling/pkg/ledger"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/payment"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/verifactu"
+	"github.com/Inmovilizame/invoiceling/pkg/vies"
 	"github.com/spf13/viper"
 
 	"github.com/spf13/cobra"
@@ -13,7 +20,7 @@ import (
 
 var (
 	allowedFormats = []string{"yaml", "yml", "json"}
-	dirs           = []string{"client", "invoice", "pdf", "static"}
+	dirs           = []string{"client", "invoice", "pdf", "export", "verifactu", "exchange", "static"}
 
 	defaultMask = os.FileMode(0o755) //nolint:mnd //static value
 )
@@ -35,6 +42,14 @@ var initCmd = &cobra.Command{
 			cobra.CheckErr(fmt.Errorf("format option '%s' not allowed", format))
 		}
 
+		iban, err := cmd.Flags().GetString("iban")
+		cobra.CheckErr(err)
+
+		bic, err := cmd.Flags().GetString("bic")
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(payment.Validate(model.Payment{Iban: iban, Swift: bic}))
+
 		fmt.Println("Generating folder structure...")
 		for _, dir := range dirs {
 			err := os.Mkdir(dir, defaultMask)
@@ -48,8 +63,14 @@ var initCmd = &cobra.Command{
 
 		fmt.Println("Generating default configuration file...")
 		defaultConfig()
-		err := viper.WriteConfigAs(fmt.Sprintf("./config.%s", format))
+		viper.Set("payment.iban", iban)
+		viper.Set("payment.swift", bic)
+		err = viper.WriteConfigAs(fmt.Sprintf("./config.%s", format))
 		cobra.CheckErr(err)
+
+		if iban == "" {
+			fmt.Println("Set payment.iban in the configuration file before issuing invoices paid by transfer")
+		}
 	},
 }
 
@@ -57,6 +78,8 @@ func init() {
 	rootCmd.AddCommand(initCmd)
 
 	initCmd.Flags().StringP("format", "f", "yaml", "Configuration file format: yaml, json, toml")
+	initCmd.Flags().String("iban", "", "IBAN of the account receiving payments")
+	initCmd.Flags().String("bic", "", "BIC of the account receiving payments")
 }
 
 func defaultConfig() {
@@ -72,6 +95,7 @@ func defaultConfig() {
 	viper.SetDefault("invoice.currency", "EUR")
 	viper.SetDefault("invoice.logo", "./static/logo.png")
 	viper.SetDefault("invoice.id_format", "F%s-%03d")
+	viper.SetDefault("invoice.pdf_pattern", "{id}{draft}")
 
 	viper.SetDefault("freelancer.company", "Your Company Name")
 	viper.SetDefault("freelancer.name", "Your Full Name")
@@ -81,12 +105,59 @@ func defaultConfig() {
 	viper.SetDefault("freelancer.address1", "Your Street Address")
 	viper.SetDefault("freelancer.address2", "City, ST, Zip Code")
 
+	viper.SetDefault("signing.certificate", "")
+
+	viper.SetDefault("verifactu.enabled", false)
+	viper.SetDefault("verifactu.qr_url", verifactu.QRURLProduction)
+	viper.SetDefault("verifactu.endpoint", verifactu.DefaultEndpoint)
+	viper.SetDefault("verifactu.installation", "1")
+	viper.SetDefault("verifactu.producer_name", "")
+	viper.SetDefault("verifactu.producer_id", "")
+
+	viper.SetDefault("tax.supply", tax.SupplyServices)
+	viper.SetDefault("tax.oss", false)
+
+	viper.SetDefault("vies.enabled", false)
+	viper.SetDefault("vies.endpoint", vies.ProductionEndpoint)
+	viper.SetDefault("vies.requester", "")
+	viper.SetDefault("vies.max_age", "720h")
+
 	viper.SetDefault("payment.holder", "Bank account holder")
-	viper.SetDefault("payment.iban", "CC00 1234 1234 12 1234567890")
-	viper.SetDefault("payment.swift", "ABCDDEFFXXX")
+	viper.SetDefault("payment.iban", "")
+	viper.SetDefault("payment.swift", "")
+	viper.SetDefault("payment.qr", false)
+	viper.SetDefault("payment.qr_bill", false)
+	viper.SetDefault("payment.show_status", false)
+
+	viper.SetDefault("exchange.home_currency", model.ExchangeBase)
+	viper.SetDefault("exchange.show_vat", false)
+
+	csvLayout := bank.DefaultCSVLayout()
+	viper.SetDefault("bank.csv.delimiter", csvLayout.Delimiter)
+	viper.SetDefault("bank.csv.date", csvLayout.Date)
+	viper.SetDefault("bank.csv.date_format", csvLayout.DateFormat)
+	viper.SetDefault("bank.csv.amount", csvLayout.Amount)
+	viper.SetDefault("bank.csv.decimal_comma", csvLayout.DecimalComma)
+	viper.SetDefault("bank.csv.counterparty", csvLayout.Counterparty)
+	viper.SetDefault("bank.csv.remittance", csvLayout.Remittance)
+	viper.SetDefault("bank.csv.reference", csvLayout.Reference)
+
+	accounts := ledger.DefaultAccounts()
+	viper.SetDefault("ledger.accounts.receivable", accounts.Receivable)
+	viper.SetDefault("ledger.accounts.revenue", accounts.Revenue)
+	viper.SetDefault("ledger.accounts.vat", accounts.Vat)
+	viper.SetDefault("ledger.accounts.surcharge", accounts.Surcharge)
+	viper.SetDefault("ledger.accounts.sales_tax", accounts.SalesTax)
+	viper.SetDefault("ledger.accounts.withholding", accounts.Withholding)
+	viper.SetDefault("ledger.accounts.bank", accounts.Bank)
+	viper.SetDefault("ledger.accounts.cash", accounts.Cash)
+	viper.SetDefault("ledger.accounts.credit", accounts.Credit)
 
 	viper.SetDefault("notes.no_due", "Please send payment within 28 days of receiving this invoice.")
 	viper.SetDefault("notes.vat_0", "Invoice exempt from VAT pursuant to EU Directive 2006/112/EC and art. 25 of Spanish VAT Law 37 /1992.")
+	viper.SetDefault("notes.reverse_charge", tax.DefaultNotes[tax.NoteReverseCharge])
+	viper.SetDefault("notes.intra_eu_goods", tax.DefaultNotes[tax.NoteIntraEUGoods])
+	viper.SetDefault("notes.export", tax.DefaultNotes[tax.NoteExport])
 	viper.SetDefault(
 		"notes.retention_not_0",
 		"Profesionales de nuevo inicio (en el año de inicio y en los dos siguientes) (art. 101.5.a LIRPF y 95.1 RIRPF).",
@@ -14,7 +14,10 @@ import (
 var invoiceAddItemCmd = &cobra.Command{
 	Use:   "item",
 	Short: "Add billable item to invoice",
-	Long:  `Add a single billable item to a created invoice.`,
+	Long: `Add a single billable item to a created invoice.
+		The price is given net with --rate or including VAT with --gross, in which
+		case the net amount is derived from it. The rate of invoices created with
+		--gross always includes VAT.`,
 	Run: func(cmd *cobra.Command, _ []string) {
 		invoiceID, err := cmd.Flags().GetString("invoice")
 		cobra.CheckErr(err)
@@ -31,6 +34,9 @@ var invoiceAddItemCmd = &cobra.Command{
 		vat, err := cmd.Flags().GetFloat64("vat")
 		cobra.CheckErr(err)
 
+		gross, err := cmd.Flags().GetFloat64("gross")
+		cobra.CheckErr(err)
+
 		is := container.NewInvoiceService()
 		invoice := is.Read(invoiceID)
 
@@ -39,9 +45,11 @@ var invoiceAddItemCmd = &cobra.Command{
 			Quantity:    qty,
 			Rate:        rate,
 			Vat:         vat,
+			Gross:       gross,
 		}
 
-		invoice = is.AddItems(invoice, []model.Item{item})
+		invoice, err = is.AddItems(invoice, []model.Item{item})
+		cobra.CheckErr(err)
 
 		fmt.Printf("Invoice %s updated\n", invoice.ID)
 	},
@@ -53,6 +61,7 @@ func init() {
 	invoiceAddItemCmd.Flags().StringP("invoice", "i", "", "Invoice ID")
 	invoiceAddItemCmd.Flags().StringP("desc", "d", "", "Item description")
 	invoiceAddItemCmd.Flags().Float64P("rate", "r", 0.0, "Item price")
+	invoiceAddItemCmd.Flags().Float64("gross", 0.0, "Item price including VAT")
 	invoiceAddItemCmd.Flags().IntP("quantity", "q", 1, "Item quantity")
 	invoiceAddItemCmd.Flags().Float64P("vat", "v", 0, "Item VAT")
 
@@ -62,6 +71,6 @@ func init() {
 	err = invoiceAddItemCmd.MarkFlagRequired("desc")
 	cobra.CheckErr(err)
 
-	err = invoiceAddItemCmd.MarkFlagRequired("rate")
-	cobra.CheckErr(err)
+	invoiceAddItemCmd.MarkFlagsOneRequired("rate", "gross")
+	invoiceAddItemCmd.MarkFlagsMutuallyExclusive("rate", "gross")
 }
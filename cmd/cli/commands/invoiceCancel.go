@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// invoiceCancelCmd represents the invoice cancel command
+var invoiceCancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Cancel an issued invoice",
+	Long: `Mark an issued invoice as cancelled. Invoices registered in Verifactu get
+	a cancellation record. Use a credit note instead when the invoice was already
+	delivered to the client.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		invoiceID, err := cmd.Flags().GetString("invoice")
+		cobra.CheckErr(err)
+
+		is := container.NewInvoiceService()
+		invoice, err := is.Cancel(invoiceID)
+		cobra.CheckErr(err)
+
+		fmt.Printf("Invoice %s cancelled\n", invoice.ID)
+	},
+}
+
+func init() {
+	invoiceCmd.AddCommand(invoiceCancelCmd)
+
+	invoiceCancelCmd.Flags().StringP("invoice", "i", "", "Invoice ID")
+
+	err := invoiceCancelCmd.MarkFlagRequired("invoice")
+	cobra.CheckErr(err)
+}
@@ -2,10 +2,13 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 
 	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/service"
 
 	"github.com/Inmovilizame/invoiceling/internal/container"
 
@@ -17,7 +20,9 @@ var invoiceCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a new invoice file",
 	Long: `Create a new invoice file to be stored as JSON file.
-	The name of the file matches invoice number.`,
+	The name of the file matches invoice number. The VAT rate, reverse charge
+	or OSS treatment and legal note are picked from the countries of the
+	freelancer and the client unless --vat is given.`,
 	Run: func(cmd *cobra.Command, _ []string) {
 		clientID, err := cmd.Flags().GetString("client")
 		cobra.CheckErr(err)
@@ -28,7 +33,15 @@ var invoiceCreateCmd = &cobra.Command{
 		invoiceID, err := cmd.Flags().GetInt("id")
 		cobra.CheckErr(err)
 
-		vat, err := cmd.Flags().GetFloat64("vat")
+		rate, err := cmd.Flags().GetFloat64("vat")
+		cobra.CheckErr(err)
+
+		var vat *float64
+		if cmd.Flags().Changed("vat") {
+			vat = &rate
+		}
+
+		supply, err := cmd.Flags().GetString("supply")
 		cobra.CheckErr(err)
 
 		retention, err := cmd.Flags().GetFloat64("retention")
@@ -37,11 +50,39 @@ var invoiceCreateCmd = &cobra.Command{
 		note, err := cmd.Flags().GetString("note")
 		cobra.CheckErr(err)
 
+		reference, err := cmd.Flags().GetString("reference")
+		cobra.CheckErr(err)
+
+		taxes, err := taxFlags(cmd)
+		cobra.CheckErr(err)
+
+		gross, err := cmd.Flags().GetBool("gross")
+		cobra.CheckErr(err)
+
 		is := container.NewInvoiceService()
-		invoice, err := is.Create(invoiceID, clientID, due, note, vat, retention)
+		invoice, err := is.Create(service.CreateOptions{
+			ID:        invoiceID,
+			ClientID:  clientID,
+			DueDays:   due,
+			Note:      note,
+			Reference: reference,
+			Supply:    supply,
+			Vat:       vat,
+			Retention: retention,
+			Inclusive: gross,
+			Taxes:     taxes,
+		})
 		cobra.CheckErr(err)
 
 		fmt.Printf("InvoiceService created: %s\n", invoice.ID)
+
+		if invoice.Tax.Inclusive {
+			fmt.Println("Item prices include VAT")
+		}
+
+		if invoice.Tax.Treatment != "" {
+			fmt.Printf("VAT: %s at %g%%\n", invoice.Tax.Treatment, invoice.Tax.VatRate())
+		}
 	},
 }
 
@@ -51,15 +92,59 @@ func init() {
 	defaultDue := model.DefaultDueSpan
 	defaultNote := "Thank you for your business. Please add the invoice number to your payment description."
 	defaultRet := viper.GetFloat64("retention")
-	defaultVat := viper.GetFloat64("vat")
 
 	invoiceCreateCmd.Flags().IntP("id", "i", 0, "InvoiceService ID")
 	invoiceCreateCmd.Flags().StringP("client", "c", "", "InvoiceService client")
 	invoiceCreateCmd.Flags().IntP("due", "d", defaultDue, "InvoiceService due date")
-	invoiceCreateCmd.Flags().Float64P("vat", "v", defaultVat, "InvoiceService VAT")
+	invoiceCreateCmd.Flags().Float64P("vat", "v", 0, "InvoiceService VAT, overrides the tax rules")
+	invoiceCreateCmd.Flags().String("supply", "", "Kind of supply for the tax rules: services, electronic or goods (default tax.supply)")
 	invoiceCreateCmd.Flags().Float64P("retention", "r", defaultRet, "InvoiceService Retention (Spanish IRPF)")
 	invoiceCreateCmd.Flags().StringP("note", "n", defaultNote, "Add invoice note")
+	invoiceCreateCmd.Flags().String("reference", "", "Buyer reference or purchase order number")
+	invoiceCreateCmd.Flags().Bool("gross", false, "Item prices include VAT")
+	invoiceCreateCmd.Flags().StringArray("tax", nil,
+		"Additional tax as kind[:rate[:base]], kind being surcharge, sales_tax or withholding")
 
 	err := invoiceCreateCmd.MarkFlagRequired("client")
 	cobra.CheckErr(err)
 }
+
+// taxFlags parses the additional taxes given as kind[:rate[:base]]. The rate
+// can be left out for the equivalence surcharge, which follows the VAT rate.
+func taxFlags(cmd *cobra.Command) ([]model.Tax, error) {
+	values, err := cmd.Flags().GetStringArray("tax")
+	if err != nil {
+		return nil, err
+	}
+
+	taxes := make([]model.Tax, 0, len(values))
+
+	for _, value := range values {
+		parts := strings.Split(value, ":")
+
+		kind := parts[0]
+		if kind != model.TaxKindSurcharge && kind != model.TaxKindSalesTax && kind != model.TaxKindWithholding {
+			return nil, fmt.Errorf("unknown tax kind %q", kind)
+		}
+
+		t := model.NewTax(kind, 0)
+
+		if len(parts) > 1 {
+			t.Rate, err = strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("tax %q: %w", value, err)
+			}
+		}
+
+		if len(parts) > 2 { //nolint:mnd //optional base
+			t.Base, err = strconv.ParseFloat(parts[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("tax %q: %w", value, err)
+			}
+		}
+
+		taxes = append(taxes, t)
+	}
+
+	return taxes, nil
+}
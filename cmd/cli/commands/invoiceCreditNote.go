@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// invoiceCreditNoteCmd represents the invoice credit-note command
+var invoiceCreditNoteCmd = &cobra.Command{
+	Use:   "credit-note",
+	Short: "Create a credit note for an invoice",
+	Long: `Create a credit note that rectifies an existing invoice. Client, taxes
+	and items are copied from the invoice and can be edited afterwards.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		invoiceID, err := cmd.Flags().GetString("invoice")
+		cobra.CheckErr(err)
+
+		id, err := cmd.Flags().GetInt("id")
+		cobra.CheckErr(err)
+
+		note, err := cmd.Flags().GetString("note")
+		cobra.CheckErr(err)
+
+		is := container.NewInvoiceService()
+		creditNote, err := is.CreateCreditNote(id, invoiceID, note)
+		cobra.CheckErr(err)
+
+		fmt.Printf("Credit note %s created for invoice %s\n", creditNote.ID, invoiceID)
+	},
+}
+
+func init() {
+	invoiceCmd.AddCommand(invoiceCreditNoteCmd)
+
+	invoiceCreditNoteCmd.Flags().StringP("invoice", "i", "", "Invoice ID to rectify")
+	invoiceCreditNoteCmd.Flags().Int("id", 0, "Credit note ID")
+	invoiceCreditNoteCmd.Flags().StringP("note", "n", "Credit note rectifying the referenced invoice.", "Add credit note note")
+
+	err := invoiceCreditNoteCmd.MarkFlagRequired("invoice")
+	cobra.CheckErr(err)
+}
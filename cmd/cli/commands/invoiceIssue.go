@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// invoiceIssueCmd represents the invoice issue command
+var invoiceIssueCmd = &cobra.Command{
+	Use:   "issue",
+	Short: "Issue an invoice",
+	Long: `Mark an invoice as issued, after which it can not be modified. With
+	verifactu.enabled set, the Verifactu registration record of the invoice is
+	created and the verification QR code is printed on its PDF.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		invoiceID, err := cmd.Flags().GetString("invoice")
+		cobra.CheckErr(err)
+
+		checkPaymentConfig()
+
+		is := container.NewInvoiceService()
+		invoice, err := is.Issue(invoiceID)
+		cobra.CheckErr(err)
+
+		fmt.Printf("Invoice %s issued\n", invoice.ID)
+
+		if invoice.Verifactu != nil {
+			fmt.Printf("Verifactu record %s\n", invoice.Verifactu.Hash)
+		}
+	},
+}
+
+func init() {
+	invoiceCmd.AddCommand(invoiceIssueCmd)
+
+	invoiceIssueCmd.Flags().StringP("invoice", "i", "", "Invoice ID")
+
+	err := invoiceIssueCmd.MarkFlagRequired("invoice")
+	cobra.CheckErr(err)
+}
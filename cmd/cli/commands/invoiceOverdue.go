@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// invoiceOverdueCmd represents the invoice overdue command
+var invoiceOverdueCmd = &cobra.Command{
+	Use:   "overdue",
+	Short: "List the overdue invoices",
+	Long: `List the issued invoices past their due date with an amount still owed.
+	The command exits with a non-zero code when any invoice is overdue, so it
+	can be run from cron to raise an alert.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		now, err := dateFlag(cmd, "date")
+		cobra.CheckErr(err)
+
+		receivables, err := container.NewReportService().Receivables(now)
+		cobra.CheckErr(err)
+
+		overdue := 0
+		total := 0.
+
+		for _, r := range receivables {
+			if !r.Overdue() {
+				continue
+			}
+
+			if overdue == 0 {
+				fmt.Printf("%-12s %-24s %-10s %5s %12s\n", "ID", "Client", "Due", "Days", "Outstanding")
+			}
+
+			fmt.Printf("%-12s %-24.24s %-10s %5d %12.2f\n",
+				r.Invoice.ID, r.Invoice.To.Name, r.Invoice.DueDate().Format("2006-01-02"), r.DaysLate, r.Outstanding)
+
+			overdue++
+			total += r.Outstanding
+		}
+
+		if overdue > 0 {
+			cobra.CheckErr(fmt.Errorf("%d invoices overdue, %.2f outstanding", overdue, total))
+		}
+
+		fmt.Println("No overdue invoices")
+	},
+}
+
+func init() {
+	invoiceCmd.AddCommand(invoiceOverdueCmd)
+
+	invoiceOverdueCmd.Flags().String("date", "", "Check the invoices on this date, YYYY-MM-DD (default today)")
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+
+	"github.com/spf13/cobra"
+)
+
+// invoicePayCmd represents the invoice pay command
+var invoicePayCmd = &cobra.Command{
+	Use:   "pay <invoice id>",
+	Short: "Record a payment of an invoice",
+	Long: `Record a payment received for an issued invoice, by default of its whole
+	balance, today and by transfer. The invoice becomes partially paid or paid.
+	The amount paid over the balance is kept as credit of the client, which
+	can pay later invoices with --method credit.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		amount, err := cmd.Flags().GetFloat64("amount")
+		cobra.CheckErr(err)
+
+		date, err := dateFlag(cmd, "date")
+		cobra.CheckErr(err)
+
+		method, err := cmd.Flags().GetString("method")
+		cobra.CheckErr(err)
+
+		reference, err := cmd.Flags().GetString("reference")
+		cobra.CheckErr(err)
+
+		is := container.NewInvoiceService()
+
+		invoice, credit, err := is.Pay(args[0], model.InvoicePayment{
+			Date:      date,
+			Amount:    amount,
+			Method:    method,
+			Reference: reference,
+		})
+		cobra.CheckErr(err)
+
+		fmt.Printf("Invoice %s %s, %.2f paid, %.2f due\n",
+			invoice.ID, strings.ToLower(strings.ReplaceAll(invoice.Status, "_", " ")), invoice.Paid(), max(is.Balance(invoice), 0))
+
+		if credit > 0 {
+			fmt.Printf("%.2f kept as credit of client %s\n", credit, invoice.To.ID)
+		}
+	},
+}
+
+func init() {
+	invoiceCmd.AddCommand(invoicePayCmd)
+
+	invoicePayCmd.Flags().Float64("amount", 0, "Amount paid (default the balance of the invoice)")
+	invoicePayCmd.Flags().String("date", "", "Date of the payment, YYYY-MM-DD (default today)")
+	invoicePayCmd.Flags().String("method", model.PaymentMethodTransfer, "Payment method: "+strings.Join(model.PaymentMethods, ", "))
+	invoicePayCmd.Flags().String("reference", "", "Reference of the payment, such as the bank transaction")
+}
@@ -1,10 +1,18 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"runtime"
+	"time"
 
 	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/einvoice"
 	"github.com/Inmovilizame/invoiceling/pkg/i18n"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/service"
 
 	"github.com/spf13/cobra"
 )
@@ -32,19 +40,55 @@ to quickly create a Cobra application.`,
 		languageStr, err := cmd.Flags().GetString("language")
 		cobra.CheckErr(err)
 
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		facturXStr, err := cmd.Flags().GetString("facturx")
+		cobra.CheckErr(err)
+
 		language, err := i18n.ParseLanguage(languageStr)
 		cobra.CheckErr(err)
 
-		doc, err := container.NewDocumentService(renderer, draft, language)
+		var facturX einvoice.Profile
+		if facturXStr != "" {
+			facturX, err = einvoice.ParseProfile(facturXStr)
+			cobra.CheckErr(err)
+		}
+
+		checkPaymentConfig()
+
+		doc, err := container.NewDocumentService(renderer, draft, language, facturX)
 		cobra.CheckErr(err)
 
+		if isBatchPdf(cmd) {
+			if output != "" {
+				cobra.CheckErr(errors.New("--output can not be used when rendering several invoices"))
+			}
+
+			renderBatch(cmd, doc)
+
+			return
+		}
+
+		if invoiceID == "" {
+			cobra.CheckErr(errors.New("an invoice id or one of --all, --filter, --since is required"))
+		}
+
 		is := container.NewInvoiceService()
 		invoice := is.Read(invoiceID)
 
-		err = doc.Render(invoice)
+		switch output {
+		case "":
+			err = doc.Render(invoice)
+		case "-":
+			err = doc.RenderTo(os.Stdout, invoice)
+		default:
+			err = doc.RenderToFile(invoice, output)
+		}
+
 		cobra.CheckErr(err)
 
-		fmt.Println("Generated PDF for:", invoiceID)
+		fmt.Fprintln(os.Stderr, "Generated PDF for:", invoiceID)
 	},
 }
 
@@ -55,4 +99,73 @@ func init() {
 	pdfCmd.Flags().BoolP("draft", "d", false, "Generate draft PFD")
 	pdfCmd.Flags().StringP("renderer", "r", "Basic", "Generate draft PFD")
 	pdfCmd.Flags().StringP("language", "l", "en", "Language for the PDF (en, es)")
+	pdfCmd.Flags().StringP("output", "o", "", "Output file path, use '-' to write to stdout")
+	pdfCmd.Flags().BoolP("all", "a", false, "Render every invoice")
+	pdfCmd.Flags().StringP("filter", "f", "", "Render invoices matching the filter")
+	pdfCmd.Flags().StringP("since", "s", "", "Render invoices dated on or after this date (YYYY-MM-DD)")
+	pdfCmd.Flags().IntP("workers", "w", runtime.NumCPU(), "Number of invoices rendered in parallel")
+	pdfCmd.Flags().Bool("force", false, "Render invoices even if their PDF is up to date")
+	pdfCmd.Flags().String("facturx", "", "Embed Factur-X XML with profile: minimum, basicwl, basic, en16931")
+}
+
+func isBatchPdf(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("all") || cmd.Flags().Changed("filter") || cmd.Flags().Changed("since")
+}
+
+func renderBatch(cmd *cobra.Command, doc *service.Document) {
+	filter, err := cmd.Flags().GetString("filter")
+	cobra.CheckErr(err)
+
+	sinceStr, err := cmd.Flags().GetString("since")
+	cobra.CheckErr(err)
+
+	workers, err := cmd.Flags().GetInt("workers")
+	cobra.CheckErr(err)
+
+	force, err := cmd.Flags().GetBool("force")
+	cobra.CheckErr(err)
+
+	since := time.Time{}
+	if sinceStr != "" {
+		since, err = time.ParseInLocation(time.DateOnly, sinceStr, time.Local)
+		cobra.CheckErr(err)
+	}
+
+	is := container.NewInvoiceService()
+	invoices := is.List(filterInvoiceSince(filter, since))
+
+	results := doc.RenderAll(invoices, service.BatchOptions{
+		Workers:       workers,
+		Force:         force,
+		SourceModTime: is.ModTime,
+	})
+
+	failed := 0
+
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+
+			fmt.Printf("FAIL %s: %v\n", r.InvoiceID, r.Err)
+		case r.Skipped:
+			fmt.Printf("SKIP %s: %s is up to date\n", r.InvoiceID, r.Path)
+		default:
+			fmt.Printf("OK   %s: %s\n", r.InvoiceID, r.Path)
+		}
+	}
+
+	fmt.Printf("Processed %d invoices, %d failed\n", len(results), failed)
+
+	if failed > 0 {
+		cobra.CheckErr(fmt.Errorf("%d invoices could not be rendered", failed))
+	}
+}
+
+func filterInvoiceSince(filter string, since time.Time) repository.Filter[*model.Invoice] {
+	byText := filterInvoice(filter)
+
+	return func(i *model.Invoice) bool {
+		return byText(i) && !i.Date.Before(since)
+	}
 }
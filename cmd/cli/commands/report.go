@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+
+	"github.com/spf13/cobra"
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "report commands",
+	Long:  `Reports and tax returns built from the stored invoices`,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+}
+
+// warnDrafts prints the invoices not issued yet that a report leaves out to
+// stderr, which is not mixed with the report or AEAT file on stdout.
+func warnDrafts(drafts []*model.Invoice) {
+	if len(drafts) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(drafts))
+	for _, draft := range drafts {
+		ids = append(ids, draft.ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "Left out %d invoices not issued yet: %s. Issue them with \"invoice issue\" to report them.\n",
+		len(drafts), strings.Join(ids, ", "))
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/report"
+
+	"github.com/spf13/cobra"
+)
+
+// reportAgingCmd represents the report aging command
+var reportAgingCmd = &cobra.Command{
+	Use:   "aging",
+	Short: "Show the amounts owed by each client by age",
+	Long: `Show the amounts still owed on issued invoices, by client, in buckets of
+	days past the due date: current, 1-30, 31-60, 61-90 and over 90 days.
+	Credit notes reduce the amount owed on the invoice they correct.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		now, err := dateFlag(cmd, "date")
+		cobra.CheckErr(err)
+
+		receivables, err := container.NewReportService().Receivables(now)
+		cobra.CheckErr(err)
+
+		clients := report.Aging(receivables)
+
+		fmt.Printf("%-16s %-24s", "VAT ID", "Name")
+
+		for _, bucket := range report.AgingBuckets {
+			fmt.Printf(" %11s", bucket)
+		}
+
+		fmt.Printf(" %11s\n", "Total")
+
+		totals := make([]float64, len(report.AgingBuckets)+1)
+
+		for _, c := range clients {
+			fmt.Printf("%-16s %-24.24s", c.VatID, c.Name)
+
+			for i, amount := range c.Buckets {
+				fmt.Printf(" %11.2f", amount)
+				totals[i] += amount
+			}
+
+			fmt.Printf(" %11.2f\n", c.Total)
+			totals[len(totals)-1] += c.Total
+		}
+
+		fmt.Printf("%-41s", "Total")
+
+		for _, amount := range totals {
+			fmt.Printf(" %11.2f", amount)
+		}
+
+		fmt.Println()
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(reportAgingCmd)
+
+	reportAgingCmd.Flags().String("date", "", "Age the invoices on this date, YYYY-MM-DD (default today)")
+}
+
+// dateFlag parses a YYYY-MM-DD flag, today when it is empty.
+func dateFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil || value == "" {
+		return time.Now(), err
+	}
+
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
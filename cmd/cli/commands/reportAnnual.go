@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/report"
+
+	"github.com/spf13/cobra"
+)
+
+// reportAnnualCmd represents the report annual command
+var reportAnnualCmd = &cobra.Command{
+	Use:   "annual",
+	Short: "Fill the annual tax returns",
+	Long: `Fill the Spanish annual VAT summary (Modelo 390) and sum the invoices
+	of the year by client, flagging the clients above the threshold of the
+	declaration of operations with third parties (Modelo 347). With --csv the
+	client summary is written as CSV and with --output the 347 and 390 are
+	written as AEAT files to a directory.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		year, err := cmd.Flags().GetInt("year")
+		cobra.CheckErr(err)
+
+		csvPath, err := cmd.Flags().GetString("csv")
+		cobra.CheckErr(err)
+
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		if year == 0 {
+			year = time.Now().Year() - 1
+		}
+
+		rs := container.NewReportService()
+
+		ret, err := rs.Model390(year)
+		cobra.CheckErr(err)
+
+		start := report.Quarter{Year: year, Number: 1}.Start()
+		warnDrafts(rs.Drafts(start, start.AddDate(1, 0, 0)))
+
+		fmt.Printf("Modelo %s %d\n", ret.Model, ret.Year)
+
+		for _, box := range ret.Boxes {
+			fmt.Printf("[%s] %-48s %14.2f\n", box.Number, box.Label, box.Value)
+		}
+
+		clients, err := rs.Summary(year)
+		cobra.CheckErr(err)
+
+		fmt.Printf("\nClients %d (* declared in the Modelo 347, over %.2f)\n", year, report.Threshold347)
+		fmt.Printf("  %-16s %-24s %12s %12s %12s %12s %12s\n", "VAT ID", "Name", "Q1", "Q2", "Q3", "Q4", "347")
+
+		for _, c := range clients {
+			mark := " "
+			if c.Declared() {
+				mark = "*"
+			}
+
+			fmt.Printf("%s %-16s %-24.24s %12.2f %12.2f %12.2f %12.2f %12.2f\n",
+				mark, c.VatID, c.Name, c.Quarters[0], c.Quarters[1], c.Quarters[2], c.Quarters[3], c.Amount347())
+		}
+
+		es := container.NewExportService()
+
+		if csvPath != "" {
+			out, err := report.SummaryCSV(clients)
+			cobra.CheckErr(err)
+			cobra.CheckErr(es.WriteFile(csvPath, out))
+
+			fmt.Println("Exported client summary to", csvPath)
+		}
+
+		if output != "" {
+			suffix := "-" + strconv.Itoa(year) + ".txt"
+
+			cobra.CheckErr(es.WriteFile(filepath.Join(output, "347"+suffix), rs.File347(clients, year)))
+			cobra.CheckErr(es.WriteFile(filepath.Join(output, "390"+suffix), rs.File(ret)))
+
+			fmt.Println("Exported Modelo 347 and 390 to", output)
+		}
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(reportAnnualCmd)
+
+	reportAnnualCmd.Flags().IntP("year", "y", 0, "Year to report (default the previous year)")
+	reportAnnualCmd.Flags().String("csv", "", "Write the client summary as CSV to this path")
+	reportAnnualCmd.Flags().StringP("output", "o", "", "Write the Modelo 347 and 390 AEAT files to this directory")
+}
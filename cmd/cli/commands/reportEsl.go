@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/report"
+
+	"github.com/spf13/cobra"
+)
+
+// reportEslCmd represents the report esl command
+var reportEslCmd = &cobra.Command{
+	Use:     "esl",
+	Aliases: []string{"349"},
+	Short:   "Build the EU recapitulative statement (Modelo 349)",
+	Long: `Build the recapitulative statement of intra-Community operations (EC Sales
+	List, Modelo 349 in Spain) of a quarter: the invoices without VAT to clients
+	with a valid VAT number of another EU member state, grouped by client and
+	operation key, E for goods and S for services. Credit notes of earlier
+	periods are listed as rectifications. With --output the statement is also
+	written in the AEAT text format.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		period, err := cmd.Flags().GetString("quarter")
+		cobra.CheckErr(err)
+
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		quarter := report.LastQuarter(time.Now())
+		if period != "" {
+			quarter, err = report.ParseQuarter(period)
+			cobra.CheckErr(err)
+		}
+
+		rs := container.NewReportService()
+		statement, err := rs.Model349(quarter)
+		cobra.CheckErr(err)
+
+		warnDrafts(rs.Drafts(quarter.Start(), quarter.End()))
+
+		if output == "-" {
+			_, err = os.Stdout.Write(rs.File349(statement))
+			cobra.CheckErr(err)
+
+			return
+		}
+
+		fmt.Printf("Modelo 349 %d %s\n", quarter.Year, quarter.Period())
+		fmt.Printf("%-3s  %-16s  %-32s  %12s\n", "Key", "VAT ID", "Name", "Amount")
+
+		for _, op := range statement.Operations {
+			fmt.Printf("%-3s  %-16s  %-32.32s  %12.2f\n", op.Key, op.VatID, op.Name, op.Amount)
+		}
+
+		fmt.Printf("%-3s  %-16s  %-32s  %12.2f\n", "", "", "Total", statement.Total())
+
+		if len(statement.Rectifications) > 0 {
+			fmt.Println("\nRectifications")
+			fmt.Printf("%-3s  %-16s  %-32s  %-7s  %12s  %12s\n", "Key", "VAT ID", "Name", "Period", "Previous", "Corrected")
+
+			for _, r := range statement.Rectifications {
+				fmt.Printf("%-3s  %-16s  %-32.32s  %-7s  %12.2f  %12.2f\n",
+					r.Key, r.VatID, r.Name, fmt.Sprintf("%d %s", r.Year, r.Period), r.Previous, r.Amount)
+			}
+		}
+
+		if output != "" {
+			cobra.CheckErr(container.NewExportService().WriteFile(output, rs.File349(statement)))
+
+			fmt.Println("Exported Modelo 349 to", output)
+		}
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(reportEslCmd)
+
+	reportEslCmd.Flags().StringP("quarter", "q", "", "Quarter such as 2025Q3 (default the last finished quarter)")
+	reportEslCmd.Flags().StringP("output", "o", "", "Write the AEAT file to this path, use '-' to write to stdout")
+}
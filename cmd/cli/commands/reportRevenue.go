@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/report"
+
+	"github.com/spf13/cobra"
+)
+
+const chartWidth = 50
+
+// reportRevenueCmd represents the report revenue command
+var reportRevenueCmd = &cobra.Command{
+	Use:   "revenue",
+	Short: "Show the revenue of a year",
+	Long: `Show the net, VAT, retention and gross amounts of the invoices issued in
+	a year, grouped by month, quarter, client or currency, compared with the
+	year before, and the average days the paid invoices took to be paid,
+	followed by the top clients. Credit notes are subtracted.
+	The report can be printed as a table, CSV, JSON or an ASCII bar chart of
+	the gross amounts.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		year, err := cmd.Flags().GetInt("year")
+		cobra.CheckErr(err)
+
+		group, err := cmd.Flags().GetString("group")
+		cobra.CheckErr(err)
+
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+
+		top, err := cmd.Flags().GetInt("top")
+		cobra.CheckErr(err)
+
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		if year == 0 {
+			year = time.Now().Year()
+		}
+
+		rs := container.NewReportService()
+
+		revenue, err := rs.Revenue(year, group, top)
+		cobra.CheckErr(err)
+
+		var out []byte
+
+		switch format {
+		case "table":
+			out = []byte(revenueTable(revenue))
+		case "csv":
+			out, err = revenue.CSV()
+		case "json":
+			out, err = revenue.JSON()
+		case "chart":
+			out = []byte(revenue.Chart(chartWidth))
+		default:
+			err = fmt.Errorf("unknown format %q, use table, csv, json or chart", format)
+		}
+
+		cobra.CheckErr(err)
+
+		if output == "" || output == "-" {
+			_, err = os.Stdout.Write(out)
+			cobra.CheckErr(err)
+
+			return
+		}
+
+		cobra.CheckErr(container.NewExportService().WriteFile(output, out))
+
+		fmt.Println("Exported revenue report to", output)
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(reportRevenueCmd)
+
+	reportRevenueCmd.Flags().IntP("year", "y", 0, "Year to report (default the current year)")
+	reportRevenueCmd.Flags().StringP("group", "g", report.GroupMonth, "Group by month, quarter, client or currency")
+	reportRevenueCmd.Flags().StringP("format", "f", "table", "Output format: table, csv, json or chart")
+	reportRevenueCmd.Flags().Int("top", 5, "Number of top clients to show, 0 for all") //nolint:mnd //default top clients
+	reportRevenueCmd.Flags().StringP("output", "o", "", "Write the report to this path instead of stdout")
+}
+
+// revenueTable formats the revenue rows, the totals by currency and the top
+// clients as text tables.
+func revenueTable(r *report.Revenue) string {
+	line := func(label string, row report.RevenueRow) string {
+		growth := ""
+		if g, ok := row.Growth(); ok {
+			growth = fmt.Sprintf("%+.1f%%", g)
+		}
+
+		days := ""
+		if row.Paid > 0 {
+			days = fmt.Sprintf("%.0f", row.DaysToPay)
+		}
+
+		return fmt.Sprintf("%-24.24s %-3s %4d %12.2f %11.2f %11.2f %12.2f %12.2f %8s %6s\n",
+			label, row.Currency, row.Invoices, row.Net, row.Vat, row.Retention, row.Gross, row.PreviousGross, growth, days)
+	}
+	header := fmt.Sprintf("%-24s %-3s %4s %12s %11s %11s %12s %12s %8s %6s\n",
+		"", "Cur", "Inv", "Net", "VAT", "Retention", "Gross", fmt.Sprint(r.Year-1), "YoY", "Days")
+
+	s := fmt.Sprintf("Revenue %d by %s\n", r.Year, r.GroupBy) + header
+
+	for _, row := range r.Rows {
+		label := row.Key
+		if row.Name != "" {
+			label = row.Name
+		}
+
+		s += line(label, row)
+	}
+
+	for _, row := range r.Totals {
+		s += line("Total", row)
+	}
+
+	s += "\nTop clients\n" + header
+
+	for _, row := range r.TopClients {
+		s += line(row.Name, row)
+	}
+
+	return s
+}
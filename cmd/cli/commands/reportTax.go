@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/report"
+
+	"github.com/spf13/cobra"
+)
+
+// reportTaxCmd represents the report tax command
+var reportTaxCmd = &cobra.Command{
+	Use:   "tax",
+	Short: "Fill a quarterly tax return",
+	Long: `Fill the Spanish quarterly VAT return (--model 303) or income tax
+	prepayment (--model 130) from the invoices and credit notes issued in a
+	quarter, printing the amount of each box. With --output the return is also
+	written as the AEAT fixed-width file to import in the online form.
+	Expenses are not tracked: the 303 deducts nothing and the 130 takes the
+	expenses of the year from --expenses.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		form, err := cmd.Flags().GetString("model")
+		cobra.CheckErr(err)
+
+		period, err := cmd.Flags().GetString("quarter")
+		cobra.CheckErr(err)
+
+		expenses, err := cmd.Flags().GetFloat64("expenses")
+		cobra.CheckErr(err)
+
+		previous, err := cmd.Flags().GetFloat64("paid")
+		cobra.CheckErr(err)
+
+		var paid *float64
+		if cmd.Flags().Changed("paid") {
+			paid = &previous
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		quarter := report.LastQuarter(time.Now())
+		if period != "" {
+			quarter, err = report.ParseQuarter(period)
+			cobra.CheckErr(err)
+		}
+
+		rs := container.NewReportService()
+
+		var ret *report.Return
+
+		switch form {
+		case "303":
+			ret, err = rs.Model303(quarter)
+			cobra.CheckErr(err)
+
+			warnDrafts(rs.Drafts(quarter.Start(), quarter.End()))
+		case "130":
+			ret, err = rs.Model130(quarter, expenses, paid)
+			cobra.CheckErr(err)
+
+			warnDrafts(rs.Drafts(report.Quarter{Year: quarter.Year, Number: 1}.Start(), quarter.End()))
+		default:
+			cobra.CheckErr(fmt.Errorf("unknown model %q, use 303 or 130", form))
+		}
+
+		if output == "-" {
+			_, err = os.Stdout.Write(rs.File(ret))
+			cobra.CheckErr(err)
+
+			return
+		}
+
+		fmt.Printf("Modelo %s %d %s\n", ret.Model, ret.Year, ret.Period)
+
+		for _, box := range ret.Boxes {
+			value := fmt.Sprintf("%.2f", box.Value)
+			if box.Percent {
+				value += "%"
+			}
+
+			fmt.Printf("[%s] %-48s %14s\n", box.Number, box.Label, value)
+		}
+
+		if output != "" {
+			cobra.CheckErr(container.NewExportService().WriteFile(output, rs.File(ret)))
+
+			fmt.Println("Exported", "Modelo", ret.Model, "to", output)
+		}
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(reportTaxCmd)
+
+	reportTaxCmd.Flags().StringP("model", "m", "", "Tax return: 303 (VAT) or 130 (income tax prepayment)")
+	reportTaxCmd.Flags().StringP("quarter", "q", "", "Quarter such as 2025Q3 (default the last finished quarter)")
+	reportTaxCmd.Flags().Float64("expenses", 0, "Deductible expenses of the year up to the quarter, for the 130")
+	reportTaxCmd.Flags().Float64("paid", 0, "Prepayments of the previous quarters, for the 130 (default computed from the invoices)")
+	reportTaxCmd.Flags().StringP("output", "o", "", "Write the AEAT file to this path, use '-' to write to stdout")
+
+	err := reportTaxCmd.MarkFlagRequired("model")
+	cobra.CheckErr(err)
+}
@@ -3,6 +3,9 @@ package commands
 import (
 	"fmt"
 
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/payment"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -39,8 +42,20 @@ func initConfig() {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
+	// Keep the certificate password out of the config file if wanted
+	_ = viper.BindEnv("signing.password", "INVOICELING_SIGNING_PASSWORD") //nolint:errcheck //only fails without key
+
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err != nil {
 		fmt.Println("Could not load configuration", err)
+		return
+	}
+}
+
+// checkPaymentConfig stops the commands printing the payment details of the
+// configuration on invoices when its IBAN or BIC is not valid.
+func checkPaymentConfig() {
+	if err := payment.Validate(repository.CfgRepo{}.GetPaymentInfo()); err != nil {
+		cobra.CheckErr(fmt.Errorf("check the payment settings of the configuration: %w", err))
 	}
 }
@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// verifactuCmd represents the verifactu command
+var verifactuCmd = &cobra.Command{
+	Use:   "verifactu",
+	Short: "verifactu commands",
+	Long:  `Manage the Verifactu record chain required in Spain`,
+}
+
+func init() {
+	rootCmd.AddCommand(verifactuCmd)
+}
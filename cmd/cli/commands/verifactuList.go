@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+
+	"github.com/spf13/cobra"
+)
+
+// verifactuListCmd represents the verifactu list command
+var verifactuListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List Verifactu records",
+	Long:  `List the records of the Verifactu chain with their submission status.`,
+	Run: func(_ *cobra.Command, _ []string) {
+		vs := container.NewVerifactuService(rootCmd.Version)
+
+		for _, r := range vs.List(func(*model.VerifactuRecord) bool { return true }) {
+			fmt.Printf("%6d  %-9s  %-12s  %-8s  %s\n", r.Sequence, r.Kind, r.InvoiceID, r.Status, r.Hash)
+
+			if r.Error != "" {
+				fmt.Printf("        %s\n", r.Error)
+			}
+		}
+	},
+}
+
+func init() {
+	verifactuCmd.AddCommand(verifactuListCmd)
+}
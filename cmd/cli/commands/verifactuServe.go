@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/verifactu"
+
+	"github.com/spf13/cobra"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+// verifactuServeCmd represents the verifactu serve command
+var verifactuServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a local stand-in of the Verifactu service",
+	Long: `Run a local stand-in of the AEAT Verifactu submission service to try
+	submissions. It checks the hash and chaining of every record but registers
+	nothing.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		addr, err := cmd.Flags().GetString("addr")
+		cobra.CheckErr(err)
+
+		mux := http.NewServeMux()
+		mux.Handle("/verifactu", &verifactu.MockHandler{})
+
+		server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+
+		fmt.Printf("Verifactu stand-in listening on http://%s/verifactu\n", addr)
+		cobra.CheckErr(server.ListenAndServe())
+	},
+}
+
+func init() {
+	verifactuCmd.AddCommand(verifactuServeCmd)
+
+	verifactuServeCmd.Flags().String("addr", "localhost:8765", "Address to listen on")
+}
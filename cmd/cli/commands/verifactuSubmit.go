@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Inmovilizame/invoiceling/internal/container"
+
+	"github.com/spf13/cobra"
+)
+
+// verifactuSubmitCmd represents the verifactu submit command
+var verifactuSubmitCmd = &cobra.Command{
+	Use:   "submit",
+	Short: "Submit pending Verifactu records",
+	Long: `Submit the records not accepted yet to the endpoint set in
+	verifactu.endpoint, a local stand-in started with "verifactu serve" by
+	default, and store the status of every record.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		vs := container.NewVerifactuService(rootCmd.Version)
+
+		records := vs.Pending()
+		if len(records) == 0 {
+			fmt.Println("No pending Verifactu records")
+			return
+		}
+
+		response, err := vs.Submit(cmd.Context(), records)
+		cobra.CheckErr(err)
+
+		fmt.Printf("Submission %s: %s\n", response.CSV, response.Status)
+
+		for _, line := range response.Lines {
+			fmt.Printf("  %-12s %-10s %s\n", line.InvoiceID, line.Status, line.Description)
+		}
+	},
+}
+
+func init() {
+	verifactuCmd.AddCommand(verifactuSubmitCmd)
+}
@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// viesCmd represents the vies command
+var viesCmd = &cobra.Command{
+	Use:   "vies",
+	Short: "vies commands",
+	Long:  `Tools for the VIES checks of EU VAT numbers`,
+}
+
+func init() {
+	rootCmd.AddCommand(viesCmd)
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+	"github.com/Inmovilizame/invoiceling/pkg/vies"
+
+	"github.com/spf13/cobra"
+)
+
+// viesServeCmd represents the vies serve command
+var viesServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a local stand-in of the VIES service",
+	Long: `Run a local stand-in of the VIES REST API for testing and offline use.
+	VAT numbers with valid check digits are reported active, except the ones
+	given with --inactive.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		addr, err := cmd.Flags().GetString("addr")
+		cobra.CheckErr(err)
+
+		inactive, err := cmd.Flags().GetStringSlice("inactive")
+		cobra.CheckErr(err)
+
+		handler := &vies.MockHandler{Inactive: map[string]bool{}}
+		for _, vatID := range inactive {
+			handler.Inactive[vat.Normalize(vatID)] = true
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle(vies.MockPath, handler)
+
+		server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+
+		fmt.Printf("VIES stand-in listening on http://%s%s\n", addr, vies.MockPath)
+		cobra.CheckErr(server.ListenAndServe())
+	},
+}
+
+func init() {
+	viesCmd.AddCommand(viesServeCmd)
+
+	viesServeCmd.Flags().String("addr", "localhost:8766", "Address to listen on")
+	viesServeCmd.Flags().StringSlice("inactive", nil, "VAT numbers to report as not active")
+}
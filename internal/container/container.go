@@ -1,13 +1,21 @@
 package container
 
 import (
+	"errors"
+	"os"
+
 	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/einvoice"
 	"github.com/Inmovilizame/invoiceling/pkg/i18n"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
 	"github.com/Inmovilizame/invoiceling/pkg/render"
 	"github.com/Inmovilizame/invoiceling/pkg/service"
+	"github.com/Inmovilizame/invoiceling/pkg/vies"
 	"github.com/spf13/viper"
 )
 
+var ErrNoCertificate = errors.New("signing: no certificate configured, set signing.certificate or use --cert")
+
 func NewInvoiceService() *service.InvoiceService {
 	invoiceRepo := repository.NewFsInvoice(
 		viper.GetString("dirs.invoice"),
@@ -17,25 +25,143 @@ func NewInvoiceService() *service.InvoiceService {
 		viper.GetString("dirs.client"),
 	)
 
+	verifactuRepo := repository.NewFsVerifactu(
+		repository.CfgRepo{}.GetVerifactuDir(),
+	)
+
+	exchangeRepo := repository.NewFsExchange(
+		repository.CfgRepo{}.GetExchangeDir(),
+	)
+
 	return service.NewInvoiceService(
 		invoiceRepo,
 		clientRepo,
+		verifactuRepo,
+		exchangeRepo,
 		repository.CfgRepo{},
 	)
 }
 
+// NewExchangeService builds the service keeping the exchange rates.
+func NewExchangeService() *service.Exchange {
+	repo := repository.CfgRepo{}
+
+	return service.NewExchangeService(
+		repository.NewFsExchange(repo.GetExchangeDir()),
+		repo.GetHomeCurrency(),
+	)
+}
+
+// NewVerifactuService builds the Verifactu service. version is the version of
+// the program, reported on every record.
+func NewVerifactuService(version string) *service.Verifactu {
+	repo := repository.CfgRepo{}
+
+	system := repo.GetVerifactuSystem()
+	system.Name = "invoiceling"
+	system.ID = "IL"
+	system.Version = version
+
+	return service.NewVerifactuService(
+		repository.NewFsVerifactu(repo.GetVerifactuDir()),
+		repository.NewFsInvoice(viper.GetString("dirs.invoice")),
+		repo.GetFreelancer(),
+		system,
+		repo.GetVerifactuEndpoint(),
+	)
+}
+
+// NewClientService builds the client service, checking VAT numbers in VIES
+// when vies.enabled is set.
 func NewClientService() *service.Client {
+	repo := repository.CfgRepo{}
+
+	clientRepo := repository.NewFsClient(
+		viper.GetString("dirs.client"),
+	)
+
+	var checker service.VatChecker
+	if repo.GetViesEnabled() {
+		checker = vies.NewChecker(repo.GetViesEndpoint(), repo.GetViesRequester())
+	}
+
+	return service.NewClientService(clientRepo, checker, repo.GetViesMaxAge())
+}
+
+// NewReportService builds the service filling tax returns from the invoices.
+func NewReportService() *service.Report {
+	return service.NewReportService(NewInvoiceService(), repository.CfgRepo{})
+}
+
+// NewBankService builds the service recording the payments of bank statements.
+func NewBankService() *service.Bank {
+	return service.NewBankService(NewInvoiceService())
+}
+
+// NewImportService builds the service importing clients and invoice lines
+// from CSV files.
+func NewImportService() *service.Import {
+	return service.NewImportService(NewClientService(), NewInvoiceService())
+}
+
+func NewExportService() *service.Export {
+	invoiceRepo := repository.NewFsInvoice(
+		viper.GetString("dirs.invoice"),
+	)
+
 	clientRepo := repository.NewFsClient(
 		viper.GetString("dirs.client"),
 	)
 
-	return service.NewClientService(clientRepo)
+	return service.NewExportService(
+		repository.CfgRepo{}.GetExportDir(),
+		invoiceRepo,
+		clientRepo,
+	)
 }
 
-func NewDocumentService(renderType string, draft bool, language i18n.Language) (*service.Document, error) {
+// NewSigner loads the signing certificate. Empty arguments fall back to the
+// signing settings of the configuration.
+func NewSigner(certificate, password string) (*einvoice.Signer, error) {
 	repo := repository.CfgRepo{}
 
-	doc, err := service.NewDocumentService(repo.GetDebug(), draft, repo.GetPdfOutputDir())
+	if certificate == "" {
+		certificate = repo.GetSigningCertificate()
+	}
+
+	if password == "" {
+		password = repo.GetSigningPassword()
+	}
+
+	if certificate == "" {
+		return nil, ErrNoCertificate
+	}
+
+	data, err := os.ReadFile(certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return einvoice.ParsePKCS12(data, password)
+}
+
+// NewDocumentService builds the PDF document service. When facturX is not empty
+// the PDF is produced as a Factur-X hybrid invoice with that profile.
+func NewDocumentService(
+	renderType string,
+	draft bool,
+	language i18n.Language,
+	facturX einvoice.Profile,
+) (*service.Document, error) {
+	repo := repository.CfgRepo{}
+
+	doc, err := service.NewDocumentService(
+		repo.GetDebug(),
+		draft,
+		repo.GetPdfOutputDir(),
+		repo.GetPdfFilenamePattern(),
+		language,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -43,22 +169,50 @@ func NewDocumentService(renderType string, draft bool, language i18n.Language) (
 	translator := i18n.NewTranslator()
 	translator.SetLanguage(language)
 
+	var factory service.RendererFactory
+
+	balance := NewInvoiceService().Balance
+
 	switch renderType {
 	case "Basic":
+		factory = newPdfBasicFactory(translator, balance)
+	default:
+		factory = newPdfBasicFactory(translator, balance)
+	}
+
+	if facturX != "" {
+		factory = newFacturXFactory(factory, facturX)
+	}
+
+	doc.SetRendererFactory(factory)
+
+	return doc, nil
+}
+
+func newPdfBasicFactory(translator i18n.Translator, balance func(*model.Invoice) float64) service.RendererFactory {
+	return func() (service.RendererInterface, error) {
 		r, err := render.NewPdfBasicRender(translator)
 		if err != nil {
 			return nil, err
 		}
 
-		doc.SetRenderer(r)
-	default:
-		r, err := render.NewPdfBasicRender(translator)
+		r.SetPaymentQR(repository.CfgRepo{}.GetPaymentQR())
+		r.SetSwissQRBill(repository.CfgRepo{}.GetSwissQRBill())
+		r.SetPaymentStatus(repository.CfgRepo{}.GetPaymentStatus())
+		r.SetExchangeVat(repository.CfgRepo{}.GetExchangeShowVat())
+		r.SetBalance(balance)
+
+		return r, nil
+	}
+}
+
+func newFacturXFactory(pdf service.RendererFactory, profile einvoice.Profile) service.RendererFactory {
+	return func() (service.RendererInterface, error) {
+		r, err := pdf()
 		if err != nil {
 			return nil, err
 		}
 
-		doc.SetRenderer(r)
+		return render.NewFacturXRender(r, profile), nil
 	}
-
-	return doc, nil
 }
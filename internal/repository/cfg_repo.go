@@ -1,7 +1,15 @@
 package repository
 
 import (
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/bank"
+	"github.com/Inmovilizame/invoiceling/pkg/ledger"
 	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+	"github.com/Inmovilizame/invoiceling/pkg/verifactu"
+	"github.com/Inmovilizame/invoiceling/pkg/vies"
 	"github.com/spf13/viper"
 )
 
@@ -23,10 +31,138 @@ func (c CfgRepo) GetPdfOutputDir() string {
 	return viper.GetString("dirs.pdf")
 }
 
+func (c CfgRepo) GetExportDir() string {
+	return viper.GetString("dirs.export")
+}
+
+func (c CfgRepo) GetSigningCertificate() string {
+	return viper.GetString("signing.certificate")
+}
+
+func (c CfgRepo) GetSigningPassword() string {
+	return viper.GetString("signing.password")
+}
+
+// GetVerifactuDir falls back to ./verifactu so the record chain never mixes
+// with other files in configurations created before Verifactu support.
+func (c CfgRepo) GetVerifactuDir() string {
+	if dir := viper.GetString("dirs.verifactu"); dir != "" {
+		return dir
+	}
+
+	return "./verifactu"
+}
+
+func (c CfgRepo) GetVerifactuEnabled() bool {
+	return viper.GetBool("verifactu.enabled")
+}
+
+func (c CfgRepo) GetVerifactuQRURL() string {
+	if url := viper.GetString("verifactu.qr_url"); url != "" {
+		return url
+	}
+
+	return verifactu.QRURLProduction
+}
+
+func (c CfgRepo) GetVerifactuEndpoint() string {
+	if endpoint := viper.GetString("verifactu.endpoint"); endpoint != "" {
+		return endpoint
+	}
+
+	return verifactu.DefaultEndpoint
+}
+
+func (c CfgRepo) GetVerifactuSystem() verifactu.System {
+	return verifactu.System{
+		ProducerName: viper.GetString("verifactu.producer_name"),
+		ProducerID:   viper.GetString("verifactu.producer_id"),
+		Installation: viper.GetString("verifactu.installation"),
+	}
+}
+
+func (c CfgRepo) GetViesEnabled() bool {
+	return viper.GetBool("vies.enabled")
+}
+
+func (c CfgRepo) GetViesEndpoint() string {
+	if endpoint := viper.GetString("vies.endpoint"); endpoint != "" {
+		return endpoint
+	}
+
+	return vies.ProductionEndpoint
+}
+
+// GetViesRequester falls back to the VAT number of the freelancer, which VIES
+// needs to give a consultation number.
+func (c CfgRepo) GetViesRequester() string {
+	if requester := viper.GetString("vies.requester"); requester != "" {
+		return requester
+	}
+
+	return viper.GetString("freelancer.vat_id")
+}
+
+func (c CfgRepo) GetViesMaxAge() time.Duration {
+	return viper.GetDuration("vies.max_age")
+}
+
+// GetTaxRate is the VAT rate charged to domestic clients, the standard rate
+// of the country of the freelancer unless tax.rate is set.
+func (c CfgRepo) GetTaxRate() float64 {
+	if viper.IsSet("tax.rate") {
+		return viper.GetFloat64("tax.rate")
+	}
+
+	return tax.StandardRate(vat.Country(viper.GetString("freelancer.vat_id")))
+}
+
+func (c CfgRepo) GetTaxOSS() bool {
+	return viper.GetBool("tax.oss")
+}
+
+func (c CfgRepo) GetTaxSupply() string {
+	if supply := viper.GetString("tax.supply"); supply != "" {
+		return supply
+	}
+
+	return tax.SupplyServices
+}
+
+func (c CfgRepo) GetPdfFilenamePattern() string {
+	return viper.GetString("invoice.pdf_pattern")
+}
+
 func (c CfgRepo) GetCurrency() string {
 	return viper.GetString("invoice.currency")
 }
 
+// GetHomeCurrency is the currency of reports and taxes, the euro unless
+// exchange.home_currency is set.
+func (c CfgRepo) GetHomeCurrency() string {
+	if home := viper.GetString("exchange.home_currency"); home != "" {
+		return home
+	}
+
+	return model.ExchangeBase
+}
+
+// GetExchangeDir falls back to ./exchange for configurations created before
+// exchange rates were kept.
+func (c CfgRepo) GetExchangeDir() string {
+	if dir := viper.GetString("dirs.exchange"); dir != "" {
+		return dir
+	}
+
+	return "./exchange"
+}
+
+// GetExchangeShowVat reports whether PDFs of invoices in a foreign currency
+// show the VAT in the home currency.
+func (c CfgRepo) GetExchangeShowVat() bool {
+	return viper.GetBool("exchange.show_vat")
+}
+
 func (c CfgRepo) GetIDFormat() string {
 	return viper.GetString("invoice.id_format")
 }
@@ -36,6 +172,18 @@ func (c CfgRepo) GetLogo() string {
 }
 
 func (c CfgRepo) GetFreelancer() model.Freelancer {
+	var address *model.PostalAddress
+
+	if viper.GetString("freelancer.address.town") != "" {
+		address = &model.PostalAddress{
+			Street:         viper.GetString("freelancer.address.street"),
+			BuildingNumber: viper.GetString("freelancer.address.building_number"),
+			PostCode:       viper.GetString("freelancer.address.post_code"),
+			Town:           viper.GetString("freelancer.address.town"),
+			Country:        viper.GetString("freelancer.address.country"),
+		}
+	}
+
 	return model.Freelancer{
 		Company:  viper.GetString("freelancer.company"),
 		Name:     viper.GetString("freelancer.name"),
@@ -44,7 +192,77 @@ func (c CfgRepo) GetFreelancer() model.Freelancer {
 		VatID:    viper.GetString("freelancer.vat_id"),
 		Address1: viper.GetString("freelancer.address1"),
 		Address2: viper.GetString("freelancer.address2"),
+		Address:  address,
+	}
+}
+
+func (c CfgRepo) GetPaymentQR() bool {
+	return viper.GetBool("payment.qr")
+}
+
+func (c CfgRepo) GetSwissQRBill() bool {
+	return viper.GetBool("payment.qr_bill")
+}
+
+// GetPaymentStatus reports whether PDFs show the date of payment or the amount
+// still due of invoices with payments.
+func (c CfgRepo) GetPaymentStatus() bool {
+	return viper.GetBool("payment.show_status")
+}
+
+// GetBankCSVLayout returns the columns of CSV bank statements, the default
+// layout overridden by the bank.csv settings.
+func (c CfgRepo) GetBankCSVLayout() bank.CSVLayout {
+	layout := bank.DefaultCSVLayout()
+
+	for key, field := range map[string]*string{
+		"delimiter":    &layout.Delimiter,
+		"date":         &layout.Date,
+		"date_format":  &layout.DateFormat,
+		"amount":       &layout.Amount,
+		"counterparty": &layout.Counterparty,
+		"remittance":   &layout.Remittance,
+		"reference":    &layout.Reference,
+		"currency":     &layout.Currency,
+	} {
+		if viper.IsSet("bank.csv." + key) {
+			*field = viper.GetString("bank.csv." + key)
+		}
+	}
+
+	layout.DecimalComma = viper.GetBool("bank.csv.decimal_comma")
+
+	return layout
+}
+
+// GetLedgerAccounts returns the accounts of the journal export, the default
+// accounts overridden by the ledger.accounts settings.
+func (c CfgRepo) GetLedgerAccounts() ledger.Accounts {
+	accounts := ledger.DefaultAccounts()
+
+	for key, field := range map[string]*string{
+		"receivable":  &accounts.Receivable,
+		"revenue":     &accounts.Revenue,
+		"vat":         &accounts.Vat,
+		"surcharge":   &accounts.Surcharge,
+		"sales_tax":   &accounts.SalesTax,
+		"withholding": &accounts.Withholding,
+		"bank":        &accounts.Bank,
+		"cash":        &accounts.Cash,
+		"credit":      &accounts.Credit,
+	} {
+		if viper.IsSet("ledger.accounts." + key) {
+			*field = viper.GetString("ledger.accounts." + key)
+		}
 	}
+
+	return accounts
+}
+
+// GetExportColumns returns the columns of the sheets of a kind set in
+// export.columns, none to export every column.
+func (c CfgRepo) GetExportColumns(kind string) []string {
+	return viper.GetStringSlice("export.columns." + kind)
 }
 
 func (c CfgRepo) GetPaymentInfo() model.Payment {
@@ -9,8 +9,9 @@ import (
 )
 
 const (
-	roMask = 0o400
-	rwMask = 0o600
+	roMask  = 0o400
+	rwMask  = 0o600
+	dirMask = 0o700
 )
 
 type Filter[T any] func(T) bool
@@ -59,6 +60,22 @@ func readInvoiceFromFile(invoicePath string) (*model.Invoice, error) {
 	return invoice, nil
 }
 
+func readVerifactuRecordFromFile(recordPath string) (*model.VerifactuRecord, error) {
+	jsonBytes, err := os.ReadFile(recordPath)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &model.VerifactuRecord{}
+
+	err = json.Unmarshal(jsonBytes, record)
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
 func checkFileExists(pathname string) bool {
 	_, err := os.Stat(pathname)
 	return !os.IsNotExist(err)
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// FsExchange stores the exchange rates, one file per currency with its rates
+// by date.
+type FsExchange struct {
+	basePath string
+}
+
+func NewFsExchange(baseDir string) *FsExchange {
+	basePath, err := filepath.Abs(baseDir)
+	if err != nil {
+		fmt.Printf("Error while getting absolute path for exchange dir. %v", err)
+		return nil
+	}
+
+	return &FsExchange{
+		basePath: basePath,
+	}
+}
+
+// List returns the rates matching filter by currency and date.
+func (fx *FsExchange) List(filter Filter[model.ExchangeRate]) []model.ExchangeRate {
+	rates := make([]model.ExchangeRate, 0)
+
+	files, err := os.ReadDir(fx.basePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error while opening exchange dir. %v", err)
+		}
+
+		return rates
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		for _, rate := range fx.read(strings.TrimSuffix(file.Name(), ".json")) {
+			if filter(rate) {
+				rates = append(rates, rate)
+			}
+		}
+	}
+
+	return rates
+}
+
+// Find returns the last rate of a currency published on or before date, nil
+// when there is none.
+func (fx *FsExchange) Find(currency string, date time.Time) *model.ExchangeRate {
+	rates := fx.read(currency)
+
+	i, found := slices.BinarySearchFunc(rates, date, func(r model.ExchangeRate, date time.Time) int {
+		return r.Date.Compare(date)
+	})
+	if found {
+		return &rates[i]
+	}
+
+	if i == 0 {
+		return nil
+	}
+
+	return &rates[i-1]
+}
+
+// Save adds rates to those stored, replacing the rates of the same currency
+// and day.
+func (fx *FsExchange) Save(rates []model.ExchangeRate) error {
+	byCurrency := map[string][]model.ExchangeRate{}
+	for _, rate := range rates {
+		byCurrency[rate.Currency] = append(byCurrency[rate.Currency], rate)
+	}
+
+	err := os.MkdirAll(fx.basePath, dirMask)
+	if err != nil {
+		return err
+	}
+
+	for currency, added := range byCurrency {
+		stored := slices.DeleteFunc(fx.read(currency), func(r model.ExchangeRate) bool {
+			return slices.ContainsFunc(added, func(a model.ExchangeRate) bool { return sameDay(a.Date, r.Date) })
+		})
+
+		stored = append(stored, added...)
+		slices.SortStableFunc(stored, func(a, b model.ExchangeRate) int { return a.Date.Compare(b.Date) })
+		stored = slices.CompactFunc(stored, func(a, b model.ExchangeRate) bool { return sameDay(a.Date, b.Date) })
+
+		jsonBytes, err := json.MarshalIndent(stored, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(fx.path(currency), jsonBytes, rwMask)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// read returns the rates of a currency by date.
+func (fx *FsExchange) read(currency string) []model.ExchangeRate {
+	var rates []model.ExchangeRate
+
+	jsonBytes, err := os.ReadFile(fx.path(currency))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error while loading exchange rates of %s. %v", currency, err)
+		}
+
+		return rates
+	}
+
+	err = json.Unmarshal(jsonBytes, &rates)
+	if err != nil {
+		fmt.Printf("Error while loading exchange rates of %s. %v", currency, err)
+	}
+
+	return rates
+}
+
+func (fx *FsExchange) path(currency string) string {
+	return filepath.Join(fx.basePath, strings.ToUpper(currency)+".json")
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format(time.DateOnly) == b.Format(time.DateOnly)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/Inmovilizame/invoiceling/pkg/model"
 )
@@ -121,3 +122,14 @@ func (fi *FsInvoice) Delete(invoiceID string) error {
 
 	return nil
 }
+
+func (fi *FsInvoice) ModTime(invoiceID string) (time.Time, error) {
+	invoicePath := filepath.Join(fi.basePath, invoiceID+".json")
+
+	info, err := os.Stat(invoicePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return info.ModTime(), nil
+}
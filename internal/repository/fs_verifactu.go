@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// FsVerifactu stores the Verifactu record chain, one file per record named
+// after its sequence number.
+type FsVerifactu struct {
+	basePath string
+}
+
+func NewFsVerifactu(baseDir string) *FsVerifactu {
+	basePath, err := filepath.Abs(baseDir)
+	if err != nil {
+		fmt.Printf("Error while getting absolute path for verifactu dir. %v", err)
+		return nil
+	}
+
+	return &FsVerifactu{
+		basePath: basePath,
+	}
+}
+
+// List returns the records matching filter in chain order.
+func (fv *FsVerifactu) List(filter Filter[*model.VerifactuRecord]) []*model.VerifactuRecord {
+	records := make([]*model.VerifactuRecord, 0)
+
+	files, err := os.ReadDir(fv.basePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error while opening verifactu dir. %v", err)
+		}
+
+		return records
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		record, err := readVerifactuRecordFromFile(filepath.Join(fv.basePath, file.Name()))
+		if err != nil {
+			fmt.Printf("Error while loading verifactu record %s. %v", file.Name(), err)
+			continue
+		}
+
+		if filter(record) {
+			records = append(records, record)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Sequence < records[j].Sequence })
+
+	return records
+}
+
+// Last returns the last record of the chain, or nil when there is none.
+func (fv *FsVerifactu) Last() *model.VerifactuRecord {
+	records := fv.List(func(*model.VerifactuRecord) bool { return true })
+	if len(records) == 0 {
+		return nil
+	}
+
+	return records[len(records)-1]
+}
+
+func (fv *FsVerifactu) Create(record *model.VerifactuRecord) error {
+	jsonBytes, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(fv.basePath, dirMask)
+	if err != nil {
+		return err
+	}
+
+	recordPath := fv.path(record.Sequence)
+	if checkFileExists(recordPath) {
+		return errors.New("verifactu record already exists")
+	}
+
+	return os.WriteFile(recordPath, jsonBytes, rwMask)
+}
+
+func (fv *FsVerifactu) Update(record *model.VerifactuRecord) *model.VerifactuRecord {
+	jsonBytes, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		fmt.Printf("Error while marshaling verifactu record %d. %v", record.Sequence, err)
+		return nil
+	}
+
+	err = os.WriteFile(fv.path(record.Sequence), jsonBytes, rwMask)
+	if err != nil {
+		fmt.Printf("Error while updating verifactu record %d. %v", record.Sequence, err)
+		return nil
+	}
+
+	return record
+}
+
+func (fv *FsVerifactu) path(sequence int) string {
+	return filepath.Join(fv.basePath, fmt.Sprintf("%06d.json", sequence))
+}
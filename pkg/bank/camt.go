@@ -0,0 +1,143 @@
+package bank
+
+import (
+	"cmp"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// camtDocument holds the parts of an ISO 20022 bank to customer statement
+// (camt.053) needed to match payments. Names are matched without namespace,
+// so every version of the message is read.
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount      camtAmount    `xml:"Amt"`
+	Indicator   string        `xml:"CdtDbtInd"`
+	BookingDate camtDate      `xml:"BookgDt"`
+	ValueDate   camtDate      `xml:"ValDt"`
+	Reference   string        `xml:"AcctSvcrRef"`
+	Info        string        `xml:"AddtlNtryInf"`
+	Details     []camtDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtAmount struct {
+	Value    float64 `xml:",chardata"`
+	Currency string  `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtDetails struct {
+	Amount        *camtAmount `xml:"Amt"`
+	TxAmount      *camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	EndToEndID    string      `xml:"Refs>EndToEndId"`
+	Unstructured  []string    `xml:"RmtInf>Ustrd"`
+	Structured    []string    `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	Debtor        string      `xml:"RltdPties>Dbtr>Nm"`
+	DebtorParty   string      `xml:"RltdPties>Dbtr>Pty>Nm"`
+	Creditor      string      `xml:"RltdPties>Cdtr>Nm"`
+	CreditorParty string      `xml:"RltdPties>Cdtr>Pty>Nm"`
+}
+
+// ParseCAMT053 reads the entries of a camt.053 statement. Batch entries with
+// the details of several transfers give a transaction for each of them.
+func ParseCAMT053(data []byte) ([]Transaction, error) {
+	var doc camtDocument
+
+	err := xml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+
+	for _, statement := range doc.Statements {
+		for _, entry := range statement.Entries {
+			transactions = append(transactions, entry.transactions()...)
+		}
+	}
+
+	return transactions, nil
+}
+
+func (e camtEntry) transactions() []Transaction {
+	sign := 1.
+	if e.Indicator == "DBIT" {
+		sign = -1
+	}
+
+	date := e.BookingDate.time()
+	if date.IsZero() {
+		date = e.ValueDate.time()
+	}
+
+	entry := Transaction{
+		Date:       date,
+		Amount:     sign * e.Amount.Value,
+		Currency:   e.Amount.Currency,
+		Remittance: e.Info,
+		Reference:  e.Reference,
+	}
+
+	if len(e.Details) == 0 {
+		return []Transaction{entry}
+	}
+
+	transactions := make([]Transaction, 0, len(e.Details))
+
+	for i, details := range e.Details {
+		t := entry
+		t.Remittance = strings.Join(append(details.Unstructured, details.Structured...), " ")
+		t.Counterparty = cmp.Or(details.Debtor, details.DebtorParty)
+
+		if sign < 0 {
+			t.Counterparty = cmp.Or(details.Creditor, details.CreditorParty)
+		}
+
+		if t.Remittance == "" {
+			t.Remittance = e.Info
+		}
+
+		if amount := cmp.Or(details.Amount, details.TxAmount); amount != nil && len(e.Details) > 1 {
+			t.Amount = sign * amount.Value
+			t.Currency = amount.Currency
+		}
+
+		switch {
+		case details.EndToEndID != "" && details.EndToEndID != "NOTPROVIDED":
+			t.Reference = details.EndToEndID
+		case len(e.Details) > 1:
+			t.Reference = e.Reference + "/" + strconv.Itoa(i+1)
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	return transactions
+}
+
+func (d camtDate) time() time.Time {
+	if d.Date != "" {
+		t, err := time.ParseInLocation(time.DateOnly, d.Date, time.Local)
+		if err == nil {
+			return t
+		}
+	}
+
+	t, err := time.Parse("2006-01-02T15:04:05", d.DateTime[:min(len(d.DateTime), len("2006-01-02T15:04:05"))])
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
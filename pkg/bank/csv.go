@@ -0,0 +1,128 @@
+package bank
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrColumn = errors.New("bank: column not found in the CSV header")
+
+// CSVLayout describes the columns of the CSV statements of a bank, by the
+// names in their header. Counterparty, Remittance, Reference and Currency are
+// optional.
+type CSVLayout struct {
+	Delimiter    string
+	Date         string
+	DateFormat   string
+	Amount       string
+	DecimalComma bool
+	Counterparty string
+	Remittance   string
+	Reference    string
+	Currency     string
+}
+
+// DefaultCSVLayout reads statements with columns date, amount, name,
+// description and reference, and ISO dates.
+func DefaultCSVLayout() CSVLayout {
+	return CSVLayout{
+		Delimiter:    ",",
+		Date:         "date",
+		DateFormat:   time.DateOnly,
+		Amount:       "amount",
+		Counterparty: "name",
+		Remittance:   "description",
+		Reference:    "reference",
+	}
+}
+
+// ParseCSV reads the transactions of a CSV statement with a header row.
+// Column names are compared ignoring case.
+func ParseCSV(data []byte, layout CSVLayout) ([]Transaction, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	if layout.Delimiter != "" {
+		r.Comma, _ = utf8.DecodeRuneInString(layout.Delimiter)
+	}
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	column := func(name string, required bool) (int, error) {
+		i := slices.IndexFunc(header, func(h string) bool { return name != "" && strings.EqualFold(strings.TrimSpace(h), name) })
+		if i < 0 && required {
+			return 0, fmt.Errorf("%w: %s", ErrColumn, name)
+		}
+
+		return i, nil
+	}
+
+	date, err := column(layout.Date, true)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := column(layout.Amount, true)
+	if err != nil {
+		return nil, err
+	}
+
+	counterparty, _ := column(layout.Counterparty, false) //nolint:errcheck //optional column
+	remittance, _ := column(layout.Remittance, false)     //nolint:errcheck //optional column
+	reference, _ := column(layout.Reference, false)       //nolint:errcheck //optional column
+	currency, _ := column(layout.Currency, false)         //nolint:errcheck //optional column
+
+	dateFormat := cmp.Or(layout.DateFormat, time.DateOnly)
+	transactions := make([]Transaction, 0, len(rows)-1)
+
+	for line, row := range rows[1:] {
+		field := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+
+			return strings.TrimSpace(row[i])
+		}
+
+		if field(date) == "" && field(amount) == "" {
+			continue
+		}
+
+		t := Transaction{
+			Counterparty: field(counterparty),
+			Remittance:   field(remittance),
+			Reference:    field(reference),
+			Currency:     field(currency),
+		}
+
+		t.Date, err = time.ParseInLocation(dateFormat, field(date), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("bank: line %d: %w", line+2, err) //nolint:mnd //header and 1-based lines
+		}
+
+		t.Amount, err = parseAmount(field(amount), layout.DecimalComma)
+		if err != nil {
+			return nil, fmt.Errorf("bank: line %d: %w", line+2, err) //nolint:mnd //header and 1-based lines
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	return transactions, nil
+}
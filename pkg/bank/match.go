@@ -0,0 +1,177 @@
+package bank
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// Reasons for matching a transaction to an invoice.
+const (
+	ReasonID     = "id"
+	ReasonAmount = "amount"
+	ReasonName   = "name"
+)
+
+// Weights of the reasons: the invoice ID alone makes a match, the amount only
+// along with the name of the client.
+const (
+	weightID     = 4
+	weightAmount = 2
+	weightName   = 1
+	minScore     = 3
+)
+
+// legalForms are left out when looking for the name of a client in the
+// counterparty of a transaction.
+var legalForms = []string{"sl", "slu", "sa", "sau", "sc", "scp", "cb", "gmbh", "ag", "ltd", "llc", "inc", "bv", "sas", "sarl", "srl", "spa"}
+
+// Open is an invoice with an amount still owed.
+type Open struct {
+	Invoice *model.Invoice
+	Balance float64
+}
+
+// Match is a credit proposed as payment of an invoice.
+type Match struct {
+	Transaction Transaction
+	Open
+	// Reasons are the reasons for the match, strongest first.
+	Reasons []string
+
+	line  int
+	score int
+}
+
+// Reconcile matches the credits of a statement to the open invoices, each
+// credit and invoice at most once and the best matches first: a credit
+// matches an invoice whose ID is in its remittance text or reference, or
+// whose balance it pays when it comes from the client. Credits in another
+// currency than the invoice are never matched. It returns the matches in
+// the order of the statement and the credits left unmatched.
+func Reconcile(transactions []Transaction, open []Open) ([]Match, []Transaction) {
+	var candidates []Match
+
+	for i, t := range transactions {
+		if !t.IsCredit() {
+			continue
+		}
+
+		for _, o := range open {
+			if m := match(t, o); m.score >= minScore {
+				m.line = i
+				candidates = append(candidates, m)
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b Match) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), a.Transaction.Date.Compare(b.Transaction.Date))
+	})
+
+	matched := map[int]bool{}
+	paid := map[string]bool{}
+
+	var matches []Match
+
+	for _, m := range candidates {
+		if matched[m.line] || paid[m.Invoice.ID] {
+			continue
+		}
+
+		matched[m.line] = true
+		paid[m.Invoice.ID] = true
+
+		matches = append(matches, m)
+	}
+
+	slices.SortFunc(matches, func(a, b Match) int { return cmp.Compare(a.line, b.line) })
+
+	var unmatched []Transaction
+
+	for i, t := range transactions {
+		if t.IsCredit() && !matched[i] {
+			unmatched = append(unmatched, t)
+		}
+	}
+
+	return matches, unmatched
+}
+
+func match(t Transaction, o Open) Match {
+	m := Match{Transaction: t, Open: o}
+
+	if t.Currency != "" && o.Invoice.Currency != "" && !strings.EqualFold(t.Currency, o.Invoice.Currency) {
+		return m
+	}
+
+	if mentions(t.Remittance+" "+t.Reference, o.Invoice.ID) {
+		m.Reasons = append(m.Reasons, ReasonID)
+		m.score += weightID
+	}
+
+	if math.Abs(t.Amount-o.Balance) < 0.005 { //nolint:mnd //half a cent
+		m.Reasons = append(m.Reasons, ReasonAmount)
+		m.score += weightAmount
+	}
+
+	if fromClient(t.Counterparty+" "+t.Remittance, o.Invoice.To.Name) {
+		m.Reasons = append(m.Reasons, ReasonName)
+		m.score += weightName
+	}
+
+	return m
+}
+
+// mentions reports whether a text holds an invoice ID as a word, ignoring
+// case and the separators inside the ID, so F25-012 is found in
+// "FRA F25012".
+func mentions(text, id string) bool {
+	id = normalizeID(id)
+	if id == "" {
+		return false
+	}
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '/' && r != '.'
+	})
+
+	return slices.ContainsFunc(words, func(word string) bool { return normalizeID(word) == id })
+}
+
+// fromClient reports whether every word of the name of a client but its legal
+// form is in a text.
+func fromClient(text, name string) bool {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	words = slices.DeleteFunc(words, func(word string) bool { return slices.Contains(legalForms, word) })
+
+	if len(words) == 0 {
+		return false
+	}
+
+	text = strings.ToLower(text)
+
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeID returns an invoice ID, or a word of a remittance text, upper-cased
+// and without separators, so IDs compare equal however they are written.
+func normalizeID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return -1
+		}
+
+		return unicode.ToUpper(r)
+	}, s)
+}
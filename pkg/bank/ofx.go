@@ -0,0 +1,73 @@
+package bank
+
+import (
+	"cmp"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ofxTransaction = regexp.MustCompile(`(?s)<STMTTRN>(.*?)(?:</STMTTRN>|<STMTTRN>|</BANKTRANLIST>)`)
+	ofxField       = regexp.MustCompile(`<([A-Z0-9.]+)>([^<\r\n]*)`)
+	ofxCurrency    = regexp.MustCompile(`<CURDEF>([A-Z]{3})`)
+)
+
+// ParseOFX reads the transactions of an OFX statement, both the SGML of OFX
+// 1.x, where elements are not closed, and the XML of OFX 2.
+func ParseOFX(data []byte) ([]Transaction, error) {
+	text := string(data)
+
+	currency := ""
+	if m := ofxCurrency.FindStringSubmatch(text); m != nil {
+		currency = m[1]
+	}
+
+	var transactions []Transaction
+
+	for _, block := range ofxTransaction.FindAllStringSubmatch(text, -1) {
+		fields := map[string]string{}
+		for _, field := range ofxField.FindAllStringSubmatch(block[1], -1) {
+			fields[field[1]] = strings.TrimSpace(field[2])
+		}
+
+		// OFX allows both decimal separators, the last one is the decimal one
+		trnamt := fields["TRNAMT"]
+
+		amount, err := parseAmount(trnamt, strings.LastIndex(trnamt, ",") > strings.LastIndex(trnamt, "."))
+		if err != nil {
+			return nil, err
+		}
+
+		remittance := fields["MEMO"]
+		if name := fields["NAME"]; remittance == "" || strings.Contains(name, remittance) {
+			remittance = name
+		}
+
+		transactions = append(transactions, Transaction{
+			Date:         ofxDate(fields["DTPOSTED"]),
+			Amount:       amount,
+			Currency:     cmp.Or(fields["CURRENCY"], currency),
+			Counterparty: cmp.Or(fields["PAYEE"], fields["NAME"]),
+			Remittance:   remittance,
+			Reference:    fields["FITID"],
+		})
+	}
+
+	return transactions, nil
+}
+
+// ofxDate reads the date of an OFX date time, YYYYMMDD followed by the
+// optional time and time zone.
+func ofxDate(s string) time.Time {
+	if len(s) < len("20060102") {
+		return time.Time{}
+	}
+
+	t, err := time.ParseInLocation("20060102", s[:len("20060102")], time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
@@ -0,0 +1,105 @@
+// Package bank reads bank statements and matches their credits to the
+// invoices still owed.
+package bank
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statement formats.
+const (
+	FormatCAMT053 = "camt053"
+	FormatOFX     = "ofx"
+	FormatCSV     = "csv"
+)
+
+var (
+	ErrFormat = errors.New("bank: unknown statement format, use camt053, ofx or csv")
+	ErrAmount = errors.New("bank: invalid amount")
+)
+
+// Transaction is a line of a bank statement. Amount is positive for credits
+// and negative for debits.
+type Transaction struct {
+	Date         time.Time
+	Amount       float64
+	Currency     string
+	Counterparty string
+	// Remittance is the free text sent with the transfer, which usually
+	// carries the invoice ID.
+	Remittance string
+	// Reference identifies the transaction at the bank, so it is not recorded
+	// twice when a statement is imported again.
+	Reference string
+}
+
+// IsCredit reports whether the transaction is money received.
+func (t Transaction) IsCredit() bool {
+	return t.Amount > 0
+}
+
+// DetectFormat guesses the format of a statement from its file name, or its
+// content when the extension is not known.
+func DetectFormat(name string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".csv", ".txt":
+		return FormatCSV
+	case ".xml", ".053":
+		return FormatCAMT053
+	}
+
+	switch {
+	case bytes.Contains(data, []byte("camt.053")):
+		return FormatCAMT053
+	case bytes.Contains(data, []byte("OFXHEADER")), bytes.Contains(data, []byte("<OFX>")):
+		return FormatOFX
+	}
+
+	return FormatCSV
+}
+
+// Parse reads the transactions of a statement. layout is only used by CSV
+// statements.
+func Parse(format string, data []byte, layout CSVLayout) ([]Transaction, error) {
+	switch format {
+	case FormatCAMT053:
+		return ParseCAMT053(data)
+	case FormatOFX:
+		return ParseOFX(data)
+	case FormatCSV:
+		return ParseCSV(data, layout)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrFormat, format)
+}
+
+// parseAmount reads an amount written with a decimal point, or a decimal
+// comma when decimalComma is set, ignoring thousands separators, spaces and
+// currency signs.
+func parseAmount(s string, decimalComma bool) (float64, error) {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r == '-', r == '+':
+			return r
+		case r == ',' && decimalComma, r == '.' && !decimalComma:
+			return '.'
+		}
+
+		return -1
+	}, s)
+
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrAmount, s)
+	}
+
+	return amount, nil
+}
@@ -0,0 +1,146 @@
+package bank
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func TestParseCSV(t *testing.T) {
+	layout := CSVLayout{
+		Delimiter:    ";",
+		Date:         "Fecha",
+		DateFormat:   "02/01/2006",
+		Amount:       "Importe",
+		DecimalComma: true,
+		Counterparty: "Ordenante",
+		Remittance:   "Concepto",
+	}
+
+	data := "\xef\xbb\xbfFecha;Importe;Ordenante;Concepto\n" +
+		"05/03/2025;1.210,00 €;ACME SL;Pago FRA F25-001\n" +
+		";;;\n" +
+		"06/03/2025;-12,50;;Comisión\n"
+
+	transactions, err := ParseCSV([]byte(data), layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+
+	first := transactions[0]
+	if first.Amount != 1210 || first.Counterparty != "ACME SL" || !first.Date.Equal(time.Date(2025, 3, 5, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("got %+v", first)
+	}
+
+	if transactions[1].Amount != -12.5 || transactions[1].IsCredit() {
+		t.Errorf("got %+v, want a debit of 12.50", transactions[1])
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	if _, err := ParseCSV([]byte("when,amount\n2025-03-05,10\n"), DefaultCSVLayout()); !errors.Is(err, ErrColumn) {
+		t.Errorf("got %v, want ErrColumn", err)
+	}
+
+	if _, err := ParseCSV([]byte("date,amount\n2025-03-05,ten\n"), DefaultCSVLayout()); !errors.Is(err, ErrAmount) {
+		t.Errorf("got %v, want ErrAmount", err)
+	}
+}
+
+func TestParseCAMT053(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Ntry>
+        <Amt Ccy="EUR">300.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <BookgDt><Dt>2025-03-05</Dt></BookgDt>
+        <AcctSvcrRef>REF1</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">100.00</Amt>
+            <RmtInf><Ustrd>F25-001</Ustrd></RmtInf>
+            <RltdPties><Dbtr><Nm>Acme</Nm></Dbtr></RltdPties>
+          </TxDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">200.00</Amt>
+            <Refs><EndToEndId>E2E-2</EndToEndId></Refs>
+            <RmtInf><Ustrd>F25-002</Ustrd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">9.99</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <ValDt><Dt>2025-03-06</Dt></ValDt>
+        <AddtlNtryInf>Fee</AddtlNtryInf>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+	transactions, err := ParseCAMT053([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		amount     float64
+		reference  string
+		remittance string
+	}{
+		{100, "REF1/1", "F25-001"},
+		{200, "E2E-2", "F25-002"},
+		{-9.99, "", "Fee"},
+	}
+
+	if len(transactions) != len(want) {
+		t.Fatalf("got %+v", transactions)
+	}
+
+	for n, w := range want {
+		got := transactions[n]
+		if got.Amount != w.amount || got.Reference != w.reference || got.Remittance != w.remittance {
+			t.Errorf("transaction %d: got %+v, want %+v", n, got, w)
+		}
+	}
+
+	if transactions[0].Counterparty != "Acme" || transactions[2].Date.Day() != 6 {
+		t.Errorf("got %+v", transactions)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	open := []Open{
+		{Invoice: &model.Invoice{ID: "F25-001", Currency: "EUR", To: model.Client{Name: "Acme, S.L."}}, Balance: 121},
+		{Invoice: &model.Invoice{ID: "F25-002", Currency: "EUR", To: model.Client{Name: "Globex"}}, Balance: 50},
+	}
+
+	transactions := []Transaction{
+		{Amount: 121, Counterparty: "ACME SL", Remittance: "transfer"},
+		{Amount: 50, Counterparty: "Someone", Remittance: "FRA F25002"},
+		{Amount: 50, Currency: "USD", Remittance: "F25-002"},
+		{Amount: -10, Remittance: "F25-001"},
+	}
+
+	matches, unmatched := Reconcile(transactions, open)
+
+	if len(matches) != 2 || matches[0].Invoice.ID != "F25-001" || matches[1].Invoice.ID != "F25-002" {
+		t.Fatalf("got matches %+v", matches)
+	}
+
+	if matches[0].Reasons[0] != ReasonAmount || matches[1].Reasons[0] != ReasonID {
+		t.Errorf("got reasons %v and %v", matches[0].Reasons, matches[1].Reasons)
+	}
+
+	if len(unmatched) != 1 || unmatched[0].Currency != "USD" {
+		t.Errorf("got unmatched %+v, want the USD credit", unmatched)
+	}
+}
@@ -0,0 +1,201 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	xmlnsPrefix = "xmlns"
+)
+
+var ErrNoRootElement = errors.New("einvoice: xml has no root element")
+
+// canonicalize serializes an XML fragment following Canonical XML 1.0 without
+// comments (http://www.w3.org/TR/2001/REC-xml-c14n-20010315). inherited holds
+// the namespaces in scope where the fragment lives in its document, which
+// inclusive canonicalization renders on the apex element.
+//
+// Only what the signed documents of this package use is supported: no DTDs,
+// entity references beyond the predefined ones or xml:* attribute inheritance.
+func canonicalize(fragment []byte, inherited map[string]string) ([]byte, error) {
+	type scope struct {
+		inScope  map[string]string
+		rendered map[string]string
+	}
+
+	out := bytes.Buffer{}
+	dec := xml.NewDecoder(bytes.NewReader(fragment))
+	stack := []scope{{inScope: inherited, rendered: map[string]string{}}}
+
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		depth := len(stack) - 1
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			parent := stack[depth]
+			current := scope{inScope: copyNamespaces(parent.inScope), rendered: copyNamespaces(parent.rendered)}
+			attrs := make([]xml.Attr, 0, len(t.Attr))
+
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == xmlnsPrefix:
+					current.inScope[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == xmlnsPrefix:
+					current.inScope[""] = a.Value
+				default:
+					attrs = append(attrs, a)
+				}
+			}
+
+			out.WriteString("<" + qualifiedName(t.Name))
+			writeNamespaces(&out, current.inScope, current.rendered)
+			writeAttributes(&out, attrs, current.inScope)
+			out.WriteString(">")
+
+			stack = append(stack, current)
+		case xml.EndElement:
+			out.WriteString("</" + qualifiedName(t.Name) + ">")
+
+			stack = stack[:depth]
+		case xml.CharData:
+			if depth > 0 {
+				out.WriteString(escapeC14NText(string(t)))
+			}
+		}
+	}
+
+	if out.Len() == 0 {
+		return nil, ErrNoRootElement
+	}
+
+	return out.Bytes(), nil
+}
+
+// rootElement returns the qualified name of the root element of doc and the
+// namespaces it declares.
+func rootElement(doc []byte) (string, map[string]string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			return "", nil, ErrNoRootElement
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		namespaces := map[string]string{}
+
+		for _, a := range start.Attr {
+			switch {
+			case a.Name.Space == xmlnsPrefix:
+				namespaces[a.Name.Local] = a.Value
+			case a.Name.Space == "" && a.Name.Local == xmlnsPrefix:
+				namespaces[""] = a.Value
+			}
+		}
+
+		return qualifiedName(start.Name), namespaces, nil
+	}
+}
+
+func writeNamespaces(out *bytes.Buffer, inScope, rendered map[string]string) {
+	prefixes := make([]string, 0, len(inScope))
+
+	for prefix, uri := range inScope {
+		if value, ok := rendered[prefix]; ok && value == uri {
+			continue
+		}
+
+		if prefix == "" && uri == "" && rendered[""] == "" {
+			continue
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		rendered[prefix] = inScope[prefix]
+
+		if prefix == "" {
+			out.WriteString(` xmlns="` + escapeC14NAttr(inScope[prefix]) + `"`)
+			continue
+		}
+
+		out.WriteString(" xmlns:" + prefix + `="` + escapeC14NAttr(inScope[prefix]) + `"`)
+	}
+}
+
+func writeAttributes(out *bytes.Buffer, attrs []xml.Attr, inScope map[string]string) {
+	sort.SliceStable(attrs, func(i, j int) bool {
+		ui, uj := attributeNamespace(attrs[i], inScope), attributeNamespace(attrs[j], inScope)
+		if ui != uj {
+			return ui < uj
+		}
+
+		return attrs[i].Name.Local < attrs[j].Name.Local
+	})
+
+	for _, a := range attrs {
+		out.WriteString(" " + qualifiedName(a.Name) + `="` + escapeC14NAttr(a.Value) + `"`)
+	}
+}
+
+func attributeNamespace(a xml.Attr, inScope map[string]string) string {
+	if a.Name.Space == "" {
+		return ""
+	}
+
+	return inScope[a.Name.Space]
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func copyNamespaces(namespaces map[string]string) map[string]string {
+	out := make(map[string]string, len(namespaces))
+	for k, v := range namespaces {
+		out[k] = v
+	}
+
+	return out
+}
+
+var (
+	c14nTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	c14nAttrEscaper = strings.NewReplacer(
+		"&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;",
+	)
+)
+
+func escapeC14NText(s string) string {
+	return c14nTextEscaper.Replace(s)
+}
+
+func escapeC14NAttr(s string) string {
+	return c14nAttrEscaper.Replace(s)
+}
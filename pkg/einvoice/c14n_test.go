@@ -0,0 +1,93 @@
+package einvoice
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		inherited map[string]string
+		want      string
+	}{
+		{
+			name: "namespaces by prefix, attributes by namespace URI and name",
+			in:   `<r xmlns:b="urn:y" xmlns:a="urn:z" z="1" a:x="3" b:y="2" c="4"/>`,
+			want: `<r xmlns:a="urn:z" xmlns:b="urn:y" c="4" z="1" b:y="2" a:x="3"></r>`,
+		},
+		{
+			name: "declarations already in scope are dropped",
+			in:   `<r xmlns="urn:d" xmlns:p="urn:p"><p:c xmlns:p="urn:p" xmlns:q="urn:q"><d xmlns=""/></p:c></r>`,
+			want: `<r xmlns="urn:d" xmlns:p="urn:p"><p:c xmlns:q="urn:q"><d xmlns=""></d></p:c></r>`,
+		},
+		{
+			name:      "inherited namespaces are rendered on the apex",
+			in:        `<p:c><d/></p:c>`,
+			inherited: map[string]string{"p": "urn:p", "": "urn:d"},
+			want:      `<p:c xmlns="urn:d" xmlns:p="urn:p"><d></d></p:c>`,
+		},
+		{
+			name: "escaping of text and attributes",
+			in:   `<r a="&#9;&#10;&#13;&quot;&lt;&gt;&amp;'">&amp;&lt;&gt;&#13;"'</r>`,
+			want: `<r a="&#x9;&#xA;&#xD;&quot;&lt;>&amp;'">&amp;&lt;&gt;&#xD;"'</r>`,
+		},
+		{
+			name: "declaration, comments and whitespace outside the root are removed",
+			in:   "<?xml version=\"1.0\"?>\n<!-- c -->\n<r>\n  <e/><!-- x -->\n</r>\n",
+			want: "<r>\n  <e></e>\n</r>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalize([]byte(tt.in), tt.inherited)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := canonicalize([]byte("<?xml version=\"1.0\"?>\n"), nil); !errors.Is(err, ErrNoRootElement) {
+		t.Errorf("got %v, want ErrNoRootElement", err)
+	}
+}
+
+// TestCanonicalizeMatchesXmllint compares the canonical form of a Facturae
+// document, the bytes its signature digests, with the one of libxml2.
+func TestCanonicalizeMatchesXmllint(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not found")
+	}
+
+	doc := newTestFacturae(t)
+
+	path := filepath.Join(t.TempDir(), "facturae.xml")
+	if err := os.WriteFile(path, doc, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := exec.Command(xmllint, "--nonet", "--c14n", path).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := canonicalize(doc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
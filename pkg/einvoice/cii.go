@@ -0,0 +1,355 @@
+package einvoice
+
+import (
+	"encoding/xml"
+)
+
+const (
+	nsRsm = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	nsRam = "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100"
+	nsQdt = "urn:un:unece:uncefact:data:standard:QualifiedDataType:100"
+	nsUdt = "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100"
+
+	taxTypeVat     = "VAT"
+	schemeVatID    = "VA"
+	dateFormatCode = "102"
+)
+
+// CrossIndustryInvoice is the UN/CEFACT CII D16B syntax used by Factur-X.
+type CrossIndustryInvoice struct {
+	XMLName  xml.Name `xml:"rsm:CrossIndustryInvoice"`
+	XmlnsRsm string   `xml:"xmlns:rsm,attr"`
+	XmlnsRam string   `xml:"xmlns:ram,attr"`
+	XmlnsQdt string   `xml:"xmlns:qdt,attr"`
+	XmlnsUdt string   `xml:"xmlns:udt,attr"`
+
+	Context     ciiContext     `xml:"rsm:ExchangedDocumentContext"`
+	Document    ciiDocument    `xml:"rsm:ExchangedDocument"`
+	Transaction ciiTransaction `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+type ciiContext struct {
+	GuidelineID string `xml:"ram:GuidelineSpecifiedDocumentContextParameter>ram:ID"`
+}
+
+type ciiDocument struct {
+	ID        string    `xml:"ram:ID"`
+	TypeCode  string    `xml:"ram:TypeCode"`
+	IssueDate ciiDate   `xml:"ram:IssueDateTime"`
+	Notes     []ciiNote `xml:"ram:IncludedNote"`
+}
+
+type ciiDate struct {
+	Value ciiDateString `xml:"udt:DateTimeString"`
+}
+
+type ciiDateString struct {
+	Format string `xml:"format,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiNote struct {
+	Content string `xml:"ram:Content"`
+}
+
+type ciiTransaction struct {
+	Lines      []ciiLine     `xml:"ram:IncludedSupplyChainTradeLineItem"`
+	Agreement  ciiAgreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	Delivery   struct{}      `xml:"ram:ApplicableHeaderTradeDelivery"`
+	Settlement ciiSettlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+}
+
+type ciiLine struct {
+	LineID     string            `xml:"ram:AssociatedDocumentLineDocument>ram:LineID"`
+	Name       string            `xml:"ram:SpecifiedTradeProduct>ram:Name"`
+	NetPrice   string            `xml:"ram:SpecifiedLineTradeAgreement>ram:NetPriceProductTradePrice>ram:ChargeAmount"`
+	Quantity   ciiQuantity       `xml:"ram:SpecifiedLineTradeDelivery>ram:BilledQuantity"`
+	Settlement ciiLineSettlement `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+type ciiQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiLineSettlement struct {
+	Tax       ciiTradeTax `xml:"ram:ApplicableTradeTax"`
+	LineTotal string      `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation>ram:LineTotalAmount"`
+}
+
+type ciiTradeTax struct {
+	CalculatedAmount string `xml:"ram:CalculatedAmount,omitempty"`
+	TypeCode         string `xml:"ram:TypeCode"`
+	ExemptionReason  string `xml:"ram:ExemptionReason,omitempty"`
+	BasisAmount      string `xml:"ram:BasisAmount,omitempty"`
+	CategoryCode     string `xml:"ram:CategoryCode"`
+	ExemptionCode    string `xml:"ram:ExemptionReasonCode,omitempty"`
+	Rate             string `xml:"ram:RateApplicablePercent,omitempty"`
+}
+
+type ciiAgreement struct {
+	BuyerReference string   `xml:"ram:BuyerReference,omitempty"`
+	Seller         ciiParty `xml:"ram:SellerTradeParty"`
+	Buyer          ciiParty `xml:"ram:BuyerTradeParty"`
+}
+
+type ciiParty struct {
+	Name            string              `xml:"ram:Name"`
+	Contact         *ciiContact         `xml:"ram:DefinedTradeContact,omitempty"`
+	Address         *ciiAddress         `xml:"ram:PostalTradeAddress,omitempty"`
+	Endpoint        *ciiSchemeID        `xml:"ram:URIUniversalCommunication>ram:URIID,omitempty"`
+	TaxRegistration *ciiTaxRegistration `xml:"ram:SpecifiedTaxRegistration,omitempty"`
+}
+
+// ciiContact points to its phone and email, as encoding/xml writes the parent
+// of an a>b field even when the field itself is empty.
+type ciiContact struct {
+	PersonName string    `xml:"ram:PersonName,omitempty"`
+	Phone      *ciiPhone `xml:"ram:TelephoneUniversalCommunication,omitempty"`
+	Email      *ciiURI   `xml:"ram:EmailURIUniversalCommunication,omitempty"`
+}
+
+type ciiPhone struct {
+	Number string `xml:"ram:CompleteNumber"`
+}
+
+type ciiURI struct {
+	ID string `xml:"ram:URIID"`
+}
+
+type ciiAddress struct {
+	PostCode    string `xml:"ram:PostcodeCode,omitempty"`
+	Line1       string `xml:"ram:LineOne,omitempty"`
+	Line2       string `xml:"ram:LineTwo,omitempty"`
+	City        string `xml:"ram:CityName,omitempty"`
+	CountryCode string `xml:"ram:CountryID"`
+}
+
+type ciiTaxRegistration struct {
+	ID ciiSchemeID `xml:"ram:ID"`
+}
+
+type ciiSchemeID struct {
+	SchemeID string `xml:"schemeID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiSettlement struct {
+	PaymentReference string            `xml:"ram:PaymentReference,omitempty"`
+	Currency         string            `xml:"ram:InvoiceCurrencyCode"`
+	PaymentMeans     *ciiPaymentMeans  `xml:"ram:SpecifiedTradeSettlementPaymentMeans,omitempty"`
+	Taxes            []ciiTradeTax     `xml:"ram:ApplicableTradeTax"`
+	PaymentTerms     *ciiPaymentTerms  `xml:"ram:SpecifiedTradePaymentTerms,omitempty"`
+	Summation        ciiSummation      `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+	Preceding        *ciiReferencedDoc `xml:"ram:InvoiceReferencedDocument,omitempty"`
+}
+
+type ciiPaymentMeans struct {
+	TypeCode    string          `xml:"ram:TypeCode"`
+	Account     *ciiAccount     `xml:"ram:PayeePartyCreditorFinancialAccount,omitempty"`
+	Institution *ciiInstitution `xml:"ram:PayeeSpecifiedCreditorFinancialInstitution,omitempty"`
+}
+
+type ciiInstitution struct {
+	BIC string `xml:"ram:BICID"`
+}
+
+type ciiAccount struct {
+	IBAN string `xml:"ram:IBANID"`
+	Name string `xml:"ram:AccountName,omitempty"`
+}
+
+type ciiPaymentTerms struct {
+	Description string   `xml:"ram:Description,omitempty"`
+	DueDate     *ciiDate `xml:"ram:DueDateDateTime,omitempty"`
+}
+
+type ciiSummation struct {
+	LineTotal  string      `xml:"ram:LineTotalAmount,omitempty"`
+	TaxBasis   string      `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotal   ciiCurrency `xml:"ram:TaxTotalAmount"`
+	GrandTotal string      `xml:"ram:GrandTotalAmount"`
+	Prepaid    string      `xml:"ram:TotalPrepaidAmount,omitempty"`
+	DuePayable string      `xml:"ram:DuePayableAmount"`
+}
+
+type ciiCurrency struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type ciiReferencedDoc struct {
+	ID string `xml:"ram:IssuerAssignedID"`
+}
+
+// ToCII maps the document to the CII syntax, keeping only the business terms
+// allowed by the profile.
+//
+//nolint:funlen //mapping is easier to follow in a single place
+func (d *Document) ToCII(profile Profile) *CrossIndustryInvoice {
+	inv := &CrossIndustryInvoice{
+		XmlnsRsm: nsRsm,
+		XmlnsRam: nsRam,
+		XmlnsQdt: nsQdt,
+		XmlnsUdt: nsUdt,
+		Context:  ciiContext{GuidelineID: profile.GuidelineID()},
+		Document: ciiDocument{
+			ID:        d.Number,
+			TypeCode:  d.TypeCode,
+			IssueDate: newCIIDate(d.IssueDate.Format(dateFormat102)),
+		},
+	}
+
+	if profile.includes(ProfileBasicWL) {
+		for _, note := range d.Notes {
+			inv.Document.Notes = append(inv.Document.Notes, ciiNote{Content: note})
+		}
+	}
+
+	if profile.includes(ProfileBasic) {
+		for _, line := range d.Lines {
+			inv.Transaction.Lines = append(inv.Transaction.Lines, ciiLine{
+				LineID:   line.ID,
+				Name:     line.Name,
+				NetPrice: formatPrice(line.Price),
+				Quantity: ciiQuantity{UnitCode: line.UnitCode, Value: formatPercent(line.Quantity)},
+				Settlement: ciiLineSettlement{
+					Tax: ciiTradeTax{
+						TypeCode:     taxTypeVat,
+						CategoryCode: line.VatCategory,
+						Rate:         vatRate(line.VatCategory, line.VatRate),
+					},
+					LineTotal: formatAmount(line.NetAmount),
+				},
+			})
+		}
+	}
+
+	inv.Transaction.Agreement = ciiAgreement{
+		BuyerReference: d.BuyerReference,
+		Seller:         d.Seller.toCII(profile, true),
+		Buyer:          d.Buyer.toCII(profile, false),
+	}
+
+	settlement := ciiSettlement{
+		Currency: d.Currency,
+		Summation: ciiSummation{
+			TaxBasis:   formatAmount(d.Totals.TaxExclusive),
+			TaxTotal:   ciiCurrency{CurrencyID: d.Currency, Value: formatAmount(d.Totals.TaxTotal)},
+			GrandTotal: formatAmount(d.Totals.TaxInclusive),
+			DuePayable: formatAmount(d.Totals.Payable),
+		},
+	}
+
+	if profile.includes(ProfileBasicWL) {
+		settlement.PaymentReference = d.Payment.RemittanceInfo
+		settlement.Summation.LineTotal = formatAmount(d.Totals.LineTotal)
+
+		if d.Totals.Prepaid != 0 {
+			settlement.Summation.Prepaid = formatAmount(d.Totals.Prepaid)
+		}
+
+		if d.Payment.IBAN != "" {
+			settlement.PaymentMeans = &ciiPaymentMeans{
+				TypeCode: d.Payment.MeansCode,
+				Account:  &ciiAccount{IBAN: d.Payment.IBAN},
+			}
+
+			if profile.includes(ProfileEN16931) {
+				settlement.PaymentMeans.Account.Name = d.Payment.AccountName
+
+				if d.Payment.BIC != "" {
+					settlement.PaymentMeans.Institution = &ciiInstitution{BIC: d.Payment.BIC}
+				}
+			}
+		}
+
+		for _, vat := range d.VatBreakdown {
+			settlement.Taxes = append(settlement.Taxes, ciiTradeTax{
+				CalculatedAmount: formatAmount(vat.TaxAmount),
+				TypeCode:         taxTypeVat,
+				ExemptionReason:  vat.ExemptionReason,
+				BasisAmount:      formatAmount(vat.TaxableAmount),
+				CategoryCode:     vat.Category,
+				ExemptionCode:    vat.ExemptionCode,
+				Rate:             vatRate(vat.Category, vat.Rate),
+			})
+		}
+
+		if !d.DueDate.IsZero() || d.PaymentTerms != "" {
+			settlement.PaymentTerms = &ciiPaymentTerms{Description: d.PaymentTerms}
+			if !d.DueDate.IsZero() {
+				dueDate := newCIIDate(d.DueDate.Format(dateFormat102))
+				settlement.PaymentTerms.DueDate = &dueDate
+			}
+		}
+
+		if d.PrecedingInvoice != "" {
+			settlement.Preceding = &ciiReferencedDoc{ID: d.PrecedingInvoice}
+		}
+	}
+
+	inv.Transaction.Settlement = settlement
+
+	return inv
+}
+
+// MarshalCII serializes the document to CII XML for the given profile.
+func (d *Document) MarshalCII(profile Profile) ([]byte, error) {
+	out, err := xml.MarshalIndent(d.ToCII(profile), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+func (p *Party) toCII(profile Profile, seller bool) ciiParty {
+	party := ciiParty{Name: p.Name}
+
+	if seller || profile.includes(ProfileBasicWL) {
+		party.Address = &ciiAddress{CountryCode: p.Address.CountryCode}
+
+		if profile.includes(ProfileBasicWL) {
+			party.Address.PostCode = p.Address.PostCode
+			party.Address.Line1 = p.Address.Line1
+			party.Address.Line2 = p.Address.Line2
+			party.Address.City = p.Address.City
+		}
+	}
+
+	if p.EndpointID != "" && profile.includes(ProfileBasicWL) {
+		party.Endpoint = &ciiSchemeID{SchemeID: p.EndpointScheme, Value: p.EndpointID}
+	}
+
+	if p.VatID != "" && (seller || profile.includes(ProfileBasicWL)) {
+		party.TaxRegistration = &ciiTaxRegistration{ID: ciiSchemeID{SchemeID: schemeVatID, Value: p.VatID}}
+	}
+
+	if profile.includes(ProfileEN16931) && (p.Contact != "" || p.Phone != "" || p.Email != "") {
+		party.Contact = &ciiContact{PersonName: p.Contact}
+
+		if p.Phone != "" {
+			party.Contact.Phone = &ciiPhone{Number: p.Phone}
+		}
+
+		if p.Email != "" {
+			party.Contact.Email = &ciiURI{ID: p.Email}
+		}
+	}
+
+	return party
+}
+
+func newCIIDate(value string) ciiDate {
+	return ciiDate{Value: ciiDateString{Format: dateFormatCode, Value: value}}
+}
+
+// vatRate omits the rate for categories that do not carry one.
+func vatRate(category string, rate float64) string {
+	if category == VatCategoryOutOfScope {
+		return ""
+	}
+
+	return formatPercent(rate)
+}
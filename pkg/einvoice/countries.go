@@ -0,0 +1,68 @@
+package einvoice
+
+import (
+	"regexp"
+	"strings"
+)
+
+// alpha3Countries maps ISO 3166-1 alpha-2 codes to the alpha-3 codes used by
+// Facturae.
+var alpha3Countries = map[string]string{
+	"AD": "AND", "AR": "ARG", "AT": "AUT", "AU": "AUS", "BE": "BEL", "BG": "BGR", "BR": "BRA",
+	"CA": "CAN", "CH": "CHE", "CN": "CHN", "CY": "CYP", "CZ": "CZE", "DE": "DEU", "DK": "DNK",
+	"EE": "EST", "ES": "ESP", "FI": "FIN", "FR": "FRA", "GB": "GBR", "GR": "GRC", "HR": "HRV",
+	"HU": "HUN", "IE": "IRL", "IN": "IND", "IS": "ISL", "IT": "ITA", "JP": "JPN", "LI": "LIE",
+	"LT": "LTU", "LU": "LUX", "LV": "LVA", "MC": "MCO", "MT": "MLT", "MX": "MEX", "NL": "NLD",
+	"NO": "NOR", "PL": "POL", "PT": "PRT", "RO": "ROU", "SE": "SWE", "SI": "SVN", "SK": "SVK",
+	"US": "USA",
+}
+
+// spanishProvinces maps the first two digits of a Spanish postal code to its
+// province.
+var spanishProvinces = map[string]string{
+	"01": "Araba/Álava", "02": "Albacete", "03": "Alicante", "04": "Almería", "05": "Ávila",
+	"06": "Badajoz", "07": "Illes Balears", "08": "Barcelona", "09": "Burgos", "10": "Cáceres",
+	"11": "Cádiz", "12": "Castellón", "13": "Ciudad Real", "14": "Córdoba", "15": "A Coruña",
+	"16": "Cuenca", "17": "Girona", "18": "Granada", "19": "Guadalajara", "20": "Gipuzkoa",
+	"21": "Huelva", "22": "Huesca", "23": "Jaén", "24": "León", "25": "Lleida",
+	"26": "La Rioja", "27": "Lugo", "28": "Madrid", "29": "Málaga", "30": "Murcia",
+	"31": "Navarra", "32": "Ourense", "33": "Asturias", "34": "Palencia", "35": "Las Palmas",
+	"36": "Pontevedra", "37": "Salamanca", "38": "Santa Cruz de Tenerife", "39": "Cantabria", "40": "Segovia",
+	"41": "Sevilla", "42": "Soria", "43": "Tarragona", "44": "Teruel", "45": "Toledo",
+	"46": "Valencia", "47": "Valladolid", "48": "Bizkaia", "49": "Zamora", "50": "Zaragoza",
+	"51": "Ceuta", "52": "Melilla",
+}
+
+var spanishPostCode = regexp.MustCompile(`\b(0[1-9]|[1-4][0-9]|5[0-2])[0-9]{3}\b`)
+
+// spanishAddress splits the free text address lines used by clients and the
+// freelancer into the postal code, town and province Facturae requires. The
+// town is the comma separated part holding the postal code, or the part before
+// it when the postal code stands alone ("Calle Mayor 1, 28001 Madrid" and
+// "Madrid, 28001" both give Madrid).
+func spanishAddress(line1, line2 string) (postCode, town, province string) {
+	for _, line := range []string{line2, line1} {
+		postCode = spanishPostCode.FindString(line)
+		if postCode == "" {
+			continue
+		}
+
+		parts := strings.Split(line, ",")
+		for i, part := range parts {
+			if !strings.Contains(part, postCode) {
+				continue
+			}
+
+			town = strings.TrimSpace(strings.Replace(part, postCode, "", 1))
+			if town == "" && i > 0 {
+				town = strings.TrimSpace(parts[i-1])
+			}
+
+			break
+		}
+
+		return postCode, town, spanishProvinces[postCode[:2]]
+	}
+
+	return "", "", ""
+}
@@ -0,0 +1,360 @@
+// Package einvoice maps invoices to the EN 16931 semantic model and serializes
+// it to the structured e-invoicing syntaxes.
+package einvoice
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+const (
+	TypeCodeInvoice    = "380"
+	TypeCodeCreditNote = "381"
+
+	PaymentMeansCreditTransfer = "30"
+	PaymentMeansSepaTransfer   = "58"
+
+	UnitCodeUnit = "C62"
+
+	// priceFactor rounds the unit prices derived from gross prices to four
+	// decimals.
+	priceFactor = 10000
+
+	VatCategoryStandard      = "S"
+	VatCategoryZero          = "Z"
+	VatCategoryExempt        = "E"
+	VatCategoryReverseCharge = "AE"
+	VatCategoryIntraEU       = "K"
+	VatCategoryExport        = "G"
+	VatCategoryOutOfScope    = "O"
+)
+
+// Document is the subset of the EN 16931 semantic model filled from an
+// invoice. Field comments reference the business terms (BT) of the norm.
+type Document struct {
+	Number           string    // BT-1
+	IssueDate        time.Time // BT-2
+	TypeCode         string    // BT-3
+	Currency         string    // BT-5
+	DueDate          time.Time // BT-9, zero when there is no due date
+	BuyerReference   string    // BT-10
+	PaymentTerms     string    // BT-20
+	Notes            []string  // BT-22
+	PrecedingInvoice string    // BT-25
+
+	Seller  Party // BG-4
+	Buyer   Party // BG-7
+	Payment PaymentInstructions
+
+	Lines        []Line         // BG-25
+	VatBreakdown []VatBreakdown // BG-23
+	Totals       DocumentTotals // BG-22
+}
+
+type Party struct {
+	Name           string
+	EndpointID     string // BT-34, BT-49
+	EndpointScheme string
+	VatID          string
+	Contact        string
+	Email          string
+	Phone          string
+	Address        Address
+}
+
+type Address struct {
+	Line1       string
+	Line2       string
+	City        string
+	PostCode    string
+	CountryCode string
+}
+
+type PaymentInstructions struct {
+	MeansCode      string // BT-81
+	RemittanceInfo string // BT-83
+	IBAN           string // BT-84
+	AccountName    string // BT-85
+	BIC            string // BT-86
+}
+
+type Line struct {
+	ID          string  // BT-126
+	Name        string  // BT-153
+	Quantity    float64 // BT-129
+	UnitCode    string  // BT-130
+	Price       float64 // BT-146
+	NetAmount   float64 // BT-131
+	VatCategory string  // BT-151
+	VatRate     float64 // BT-152
+}
+
+type VatBreakdown struct {
+	TaxableAmount   float64 // BT-116
+	TaxAmount       float64 // BT-117
+	Category        string  // BT-118
+	Rate            float64 // BT-119
+	ExemptionReason string  // BT-120
+	ExemptionCode   string  // BT-121
+}
+
+type DocumentTotals struct {
+	LineTotal    float64 // BT-106
+	TaxExclusive float64 // BT-109
+	TaxTotal     float64 // BT-110
+	TaxInclusive float64 // BT-112
+	Prepaid      float64 // BT-113
+	Payable      float64 // BT-115
+}
+
+// ErrUnsupportedTax is returned for invoices with taxes EN 16931 can not
+// carry, such as the Spanish equivalence surcharge.
+var ErrUnsupportedTax = errors.New("einvoice: tax not supported by EN 16931, use Facturae")
+
+// FromInvoice builds the semantic document of an invoice, with a VAT breakdown
+// for each category and rate of its items. Withholdings such as the Spanish
+// IRPF have no place in EN 16931, so they are reported as a prepaid amount and
+// explained in a note; this keeps the payable amount equal to the total
+// printed on the PDF. Other taxes than VAT and withholding are an
+// ErrUnsupportedTax.
+func FromInvoice(invoice *model.Invoice) (*Document, error) {
+	totals := invoice.Totals()
+
+	for _, tax := range totals.Taxes {
+		if tax.Kind != model.TaxKindVat && tax.Kind != model.TaxKindWithholding && tax.Amount != 0 {
+			return nil, fmt.Errorf("%w: %s of invoice %s", ErrUnsupportedTax, strings.ToLower(taxNames[tax.Kind]), invoice.ID)
+		}
+	}
+
+	zeroCategory, reason := zeroRateCategory(invoice)
+	categoryOf := func(rate float64) string {
+		if rate != 0 {
+			return VatCategoryStandard
+		}
+
+		return zeroCategory
+	}
+
+	typeCode := TypeCodeInvoice
+	if invoice.IsCreditNote() {
+		typeCode = TypeCodeCreditNote
+	}
+
+	taxInclusive := model.Round(totals.Subtotal + totals.Vat)
+
+	doc := &Document{
+		Number:           invoice.ID,
+		IssueDate:        invoice.Date,
+		TypeCode:         typeCode,
+		Currency:         invoice.Currency,
+		BuyerReference:   invoice.Reference,
+		PrecedingInvoice: invoice.Corrects,
+		Notes:            invoice.Notes.ToSlice(),
+		Seller: Party{
+			Name:    sellerName(&invoice.From),
+			VatID:   vat.Normalize(invoice.From.VatID),
+			Contact: invoice.From.Name,
+			Email:   invoice.From.Email,
+			Phone:   invoice.From.Phone,
+			Address: Address{
+				Line1:       invoice.From.Address1,
+				Line2:       invoice.From.Address2,
+				CountryCode: vat.Country(invoice.From.VatID),
+			},
+		},
+		Buyer: Party{
+			Name:  invoice.To.Name,
+			VatID: vat.Normalize(invoice.To.VatID),
+			Address: Address{
+				Line1:       invoice.To.Address1,
+				Line2:       invoice.To.Address2,
+				CountryCode: vat.Country(invoice.To.VatID),
+			},
+		},
+		Payment: PaymentInstructions{
+			MeansCode:      PaymentMeansSepaTransfer,
+			RemittanceInfo: invoice.ID,
+			IBAN:           strings.ReplaceAll(invoice.Payment.Iban, " ", ""),
+			AccountName:    invoice.Payment.Holder,
+			BIC:            invoice.Payment.Swift,
+		},
+		Totals: DocumentTotals{
+			LineTotal:    totals.Subtotal,
+			TaxExclusive: totals.Subtotal,
+			TaxTotal:     totals.Vat,
+			TaxInclusive: taxInclusive,
+			Prepaid:      totals.Retention,
+			Payable:      model.Round(taxInclusive - totals.Retention),
+		},
+	}
+
+	doc.Seller.EndpointID, doc.Seller.EndpointScheme = endpoint(doc.Seller.VatID, invoice.From.Email)
+	doc.Buyer.EndpointID, doc.Buyer.EndpointScheme = endpoint(doc.Buyer.VatID, "")
+
+	if invoice.Due > 0 {
+		doc.DueDate = invoice.DueDate()
+	} else {
+		doc.PaymentTerms = invoice.Notes.Default
+	}
+
+	for _, tax := range totals.Taxes {
+		switch {
+		case tax.Kind == model.TaxKindVat:
+			doc.addVat(tax, categoryOf(tax.Rate), reason)
+		case tax.Amount != 0:
+			doc.Notes = append(doc.Notes, withholdingNote(tax, invoice.Currency))
+		}
+	}
+
+	for idx, item := range invoice.Items {
+		doc.Lines = append(doc.Lines, Line{
+			ID:          strconv.Itoa(idx + 1),
+			Name:        item.Description,
+			Quantity:    float64(item.Quantity),
+			UnitCode:    UnitCodeUnit,
+			Price:       unitPrice(item),
+			NetAmount:   model.Round(item.GetAmount()),
+			VatCategory: categoryOf(item.Vat),
+			VatRate:     item.Vat,
+		})
+	}
+
+	return doc, nil
+}
+
+// addVat adds a VAT amount to the breakdown of its category and rate.
+func (d *Document) addVat(tax model.TaxAmount, category, reason string) {
+	for n, breakdown := range d.VatBreakdown {
+		if breakdown.Category == category && breakdown.Rate == tax.Rate {
+			d.VatBreakdown[n].TaxableAmount = model.Round(breakdown.TaxableAmount + tax.Base)
+			d.VatBreakdown[n].TaxAmount = model.Round(breakdown.TaxAmount + tax.Amount)
+
+			return
+		}
+	}
+
+	breakdown := VatBreakdown{
+		TaxableAmount: tax.Base,
+		TaxAmount:     tax.Amount,
+		Category:      category,
+		Rate:          tax.Rate,
+		ExemptionCode: exemptionCodes[category],
+	}
+
+	if category != VatCategoryStandard {
+		breakdown.ExemptionReason = reason
+	}
+
+	d.VatBreakdown = append(d.VatBreakdown, breakdown)
+}
+
+// unitPrice returns the net price of an item. Items priced gross get the net
+// amount of their line divided by the quantity, to four decimals.
+func unitPrice(item *model.Item) float64 {
+	if !item.IsGross() || item.Quantity == 0 {
+		return item.Rate
+	}
+
+	return math.Round(model.Round(item.GetAmount())/float64(item.Quantity)*priceFactor) / priceFactor
+}
+
+func sellerName(from *model.Freelancer) string {
+	if from.Company != "" {
+		return from.Company
+	}
+
+	return from.Name
+}
+
+// VatCategory returns the VAT category code of the rate of an invoice and, for
+// zero rated invoices, the exemption reason printed on the notes.
+func VatCategory(invoice *model.Invoice) (category, reason string) {
+	if invoice.Tax.VatRate() != 0 {
+		return VatCategoryStandard, ""
+	}
+
+	return zeroRateCategory(invoice)
+}
+
+// zeroRateCategory picks the VAT category code of the zero rated items of an
+// invoice and the exemption reason printed on the notes. The treatment picked
+// by the tax rules wins over the countries of the VAT numbers.
+func zeroRateCategory(invoice *model.Invoice) (category, reason string) {
+	reason = invoice.Notes.Vat0
+
+	switch invoice.Tax.Treatment {
+	case tax.TreatmentExempt:
+		return VatCategoryExempt, reason
+	case tax.TreatmentReverseCharge:
+		return VatCategoryReverseCharge, reason
+	case tax.TreatmentIntraEUGoods:
+		return VatCategoryIntraEU, reason
+	case tax.TreatmentExport:
+		return VatCategoryExport, reason
+	}
+
+	seller := vat.Country(invoice.From.VatID)
+	buyer := vat.Country(invoice.To.VatID)
+
+	switch {
+	case buyer == "" || buyer == seller:
+		return VatCategoryExempt, reason
+	case tax.IsEU(buyer):
+		return VatCategoryReverseCharge, reason
+	default:
+		return VatCategoryExport, reason
+	}
+}
+
+// exemptionCodes are the VATEX codes (BT-121) of the zero rated categories.
+var exemptionCodes = map[string]string{
+	VatCategoryReverseCharge: "VATEX-EU-AE",
+	VatCategoryIntraEU:       "VATEX-EU-IC",
+	VatCategoryExport:        "VATEX-EU-G",
+	VatCategoryOutOfScope:    "VATEX-EU-O",
+}
+
+// vatEndpointSchemes are the Peppol electronic address schemes (EAS) based on
+// the national VAT number.
+var vatEndpointSchemes = map[string]string{
+	"AT": "9914", "BE": "9925", "BG": "9926", "CY": "9928", "CZ": "9929", "DE": "9930",
+	"EE": "9931", "ES": "9920", "FR": "9957", "GB": "9932", "GR": "9933", "HR": "9934",
+	"HU": "9910", "IE": "9935", "IT": "9906", "LT": "9937", "LU": "9938", "LV": "9939",
+	"MT": "9943", "NL": "9944", "PL": "9945", "PT": "9946", "RO": "9947", "SE": "9955",
+	"SI": "9949", "SK": "9950",
+}
+
+// endpoint returns the electronic address of a party, preferring its VAT
+// number and falling back to its email.
+func endpoint(vatID, email string) (id, scheme string) {
+	if scheme, ok := vatEndpointSchemes[vat.Country(vatID)]; ok {
+		return vatID, scheme
+	}
+
+	if email != "" {
+		return email, "EM"
+	}
+
+	return "", ""
+}
+
+// withholdingNote explains a withholding, which EN 16931 can not carry and is
+// reported as the prepaid amount.
+func withholdingNote(tax model.TaxAmount, currency string) string {
+	return taxNames[tax.Kind] + " " + formatPercent(tax.Rate) + "%: " +
+		formatAmount(-tax.Amount) + " " + currency + " deducted from the payable amount."
+}
+
+var taxNames = map[string]string{
+	model.TaxKindWithholding: "Withholding tax",
+	model.TaxKindSurcharge:   "Equivalence surcharge",
+	model.TaxKindSalesTax:    "Sales tax",
+}
@@ -0,0 +1,186 @@
+package einvoice
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// newTestInvoice returns a domestic Spanish invoice at vat percent, with
+// retention percent withheld and no items.
+func newTestInvoice(vat, retention float64) *model.Invoice {
+	invoice := model.NewInvoice("F25-001", 30*24*time.Hour, "EUR", "Thank you for your business.", "")
+	invoice.Date = time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	invoice.From = model.Freelancer{
+		Name:     "Ana García",
+		Email:    "ana@example.com",
+		VatID:    "ES12345678Z",
+		Address1: "Calle Mayor 1",
+		Address2: "28013 Madrid",
+	}
+	invoice.To = model.Client{
+		ID:       "acme",
+		Name:     "Acme, S.L.",
+		VatID:    "ESB12345674",
+		Address1: "Gran Vía 2",
+		Address2: "28013 Madrid",
+	}
+	invoice.Payment = model.Payment{Holder: "Ana García", Iban: "ES91 2100 0418 4502 0005 1332", Swift: "CAIXESBBXXX"}
+	invoice.Reference = "PO-4711"
+	invoice.SetTaxes(vat, retention, map[string]string{"retention_not_0": "IRPF withheld.", "vat_0": "Exempt, article 20 LIVA."})
+
+	return invoice
+}
+
+func TestFromInvoiceBreaksDownVatByRate(t *testing.T) {
+	invoice := newTestInvoice(21, 0)
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 2, Rate: 100})
+	invoice.AddItem(model.Item{Description: "Books", Quantity: 1, Rate: 50, Vat: 4})
+
+	doc, err := FromInvoice(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []VatBreakdown{
+		{TaxableAmount: 200, TaxAmount: 42, Category: VatCategoryStandard, Rate: 21},
+		{TaxableAmount: 50, TaxAmount: 2, Category: VatCategoryStandard, Rate: 4},
+	}
+
+	if len(doc.VatBreakdown) != len(want) {
+		t.Fatalf("got %+v, want %+v", doc.VatBreakdown, want)
+	}
+
+	for n := range want {
+		if doc.VatBreakdown[n] != want[n] {
+			t.Errorf("breakdown %d: got %+v, want %+v", n, doc.VatBreakdown[n], want[n])
+		}
+	}
+
+	if doc.Lines[1].VatRate != 4 || doc.Lines[1].VatCategory != VatCategoryStandard {
+		t.Errorf("got line %+v, want the 4%% rate of the item", doc.Lines[1])
+	}
+
+	if doc.Totals.TaxTotal != 44 || doc.Totals.Payable != 294 {
+		t.Errorf("got totals %+v, want VAT 44 and payable 294", doc.Totals)
+	}
+
+	if violations := doc.Check(); len(violations) > 0 {
+		t.Errorf("got violations %v", violations)
+	}
+}
+
+func TestFromInvoiceZeroRatedLines(t *testing.T) {
+	invoice := newTestInvoice(21, 0)
+	invoice.Notes.Vat0 = "Exempt, article 20 LIVA."
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: 100})
+	invoice.Items = append(invoice.Items, &model.Item{Description: "Training", Quantity: 1, Rate: 300})
+
+	doc, err := FromInvoice(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exempt := doc.VatBreakdown[1]
+	if exempt.Category != VatCategoryExempt || exempt.TaxableAmount != 300 || exempt.ExemptionReason == "" {
+		t.Errorf("got %+v, want an exempt breakdown of 300 with its reason", exempt)
+	}
+
+	if doc.VatBreakdown[0].ExemptionReason != "" {
+		t.Errorf("got standard rated breakdown %+v with an exemption reason", doc.VatBreakdown[0])
+	}
+
+	if violations := doc.Check(); len(violations) > 0 {
+		t.Errorf("got violations %v", violations)
+	}
+}
+
+func TestFromInvoiceReportsWithholdingAsPrepaid(t *testing.T) {
+	invoice := newTestInvoice(21, 15)
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: 1000})
+
+	doc, err := FromInvoice(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DocumentTotals{LineTotal: 1000, TaxExclusive: 1000, TaxTotal: 210, TaxInclusive: 1210, Prepaid: 150, Payable: 1060}
+	if doc.Totals != want {
+		t.Errorf("got %+v, want %+v", doc.Totals, want)
+	}
+
+	if doc.Totals.Payable != invoice.Totals().Total {
+		t.Errorf("payable %v does not match the invoice total %v", doc.Totals.Payable, invoice.Totals().Total)
+	}
+
+	if violations := doc.Check(); len(violations) > 0 {
+		t.Errorf("got violations %v", violations)
+	}
+}
+
+func TestFromInvoiceRejectsUnsupportedTaxes(t *testing.T) {
+	for _, kind := range []string{model.TaxKindSurcharge, model.TaxKindSalesTax} {
+		t.Run(kind, func(t *testing.T) {
+			invoice := newTestInvoice(21, 0)
+			invoice.AddTax(model.Tax{Kind: kind, Rate: 5.2, Sign: 1})
+			invoice.AddItem(model.Item{Description: "Goods", Quantity: 1, Rate: 100})
+
+			_, err := FromInvoice(invoice)
+			if !errors.Is(err, ErrUnsupportedTax) {
+				t.Errorf("got %v, want ErrUnsupportedTax", err)
+			}
+		})
+	}
+}
+
+func TestFromInvoiceGrossPrices(t *testing.T) {
+	invoice := newTestInvoice(21, 0)
+	invoice.AddItem(model.Item{Description: "Coffee", Quantity: 3, Gross: 1})
+
+	doc, err := FromInvoice(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := doc.Lines[0]
+	if line.NetAmount != 2.48 || line.Price != 0.8267 {
+		t.Errorf("got net amount %v and price %v, want 2.48 and 0.8267", line.NetAmount, line.Price)
+	}
+
+	if doc.Totals.Payable != 3 {
+		t.Errorf("got payable %v, want the gross 3", doc.Totals.Payable)
+	}
+
+	if violations := doc.CheckPeppol(); len(violations) > 0 {
+		t.Errorf("got violations %v", violations)
+	}
+}
+
+func TestVatCategory(t *testing.T) {
+	tests := []struct {
+		name  string
+		buyer string
+		want  string
+	}{
+		{"domestic", "ESB12345674", VatCategoryExempt},
+		{"EU business", "DE136695976", VatCategoryReverseCharge},
+		{"outside the EU", "CHE123456789", VatCategoryExport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := newTestInvoice(0, 0)
+			invoice.To.VatID = tt.buyer
+
+			if got, _ := VatCategory(invoice); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got, _ := VatCategory(newTestInvoice(21, 0)); got != VatCategoryStandard {
+		t.Errorf("got %s for a 21%% invoice, want %s", got, VatCategoryStandard)
+	}
+}
@@ -0,0 +1,594 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+const (
+	nsFacturae = "http://www.facturae.gob.es/formato/Versiones/Facturaev3_2_2.xml"
+
+	FacturaeVersion = "3.2.2"
+
+	facturaeModalityIndividual = "I"
+	facturaeIssuerSeller       = "EM"
+	facturaeDocumentComplete   = "FC"
+	facturaeClassOriginal      = "OO"
+	facturaeClassCorrective    = "OR"
+	facturaeTaxVat             = "01"
+	facturaeTaxIrpf            = "04"
+	facturaeTaxOther           = "05"
+	facturaeUnitUnits          = "01"
+	facturaePaymentTransfer    = "04"
+	facturaeLanguage           = "es"
+	facturaeTaxCurrency        = "EUR"
+
+	facturaePersonIndividual = "F"
+	facturaePersonLegal      = "J"
+	facturaeResident         = "R"
+	facturaeResidentEU       = "U"
+	facturaeForeign          = "E"
+
+	facturaeRoleAccounting = "01"
+	facturaeRoleManagement = "02"
+	facturaeRoleProcessing = "03"
+
+	facturaeExempt     = "01"
+	facturaeNotSubject = "02"
+
+	// Credit notes rectify the taxable base by differences. The descriptions
+	// are enumerated by the schema and must match the codes.
+	facturaeReasonTaxableBase     = "16"
+	facturaeReasonTaxableBaseDesc = "Base imponible"
+	facturaeMethodDifferences     = "02"
+	facturaeMethodDifferencesDesc = "Rectificación por diferencias"
+	facturaeCountrySpain          = "ESP"
+	facturaeMaxSurnames           = 2
+
+	countrySpain = "ES"
+)
+
+// Facturae is a Facturae 3.2.2 document holding a single invoice, the format
+// required to invoice Spanish public administrations through FACe.
+type Facturae struct {
+	XMLName xml.Name `xml:"fe:Facturae"`
+	XmlnsFe string   `xml:"xmlns:fe,attr"`
+	XmlnsDs string   `xml:"xmlns:ds,attr"`
+
+	FileHeader facturaeFileHeader `xml:"FileHeader"`
+	Parties    facturaeParties    `xml:"Parties"`
+	Invoices   []facturaeInvoice  `xml:"Invoices>Invoice"`
+}
+
+// FacturaeOptions adds data not stored in the invoice itself.
+type FacturaeOptions struct {
+	// CorrectedDate is the issue date of the invoice rectified by a credit note.
+	CorrectedDate time.Time
+}
+
+type facturaeFileHeader struct {
+	SchemaVersion     string        `xml:"SchemaVersion"`
+	Modality          string        `xml:"Modality"`
+	InvoiceIssuerType string        `xml:"InvoiceIssuerType"`
+	Batch             facturaeBatch `xml:"Batch"`
+}
+
+type facturaeBatch struct {
+	BatchIdentifier        string `xml:"BatchIdentifier"`
+	InvoicesCount          int    `xml:"InvoicesCount"`
+	TotalInvoicesAmount    string `xml:"TotalInvoicesAmount>TotalAmount"`
+	TotalOutstandingAmount string `xml:"TotalOutstandingAmount>TotalAmount"`
+	TotalExecutableAmount  string `xml:"TotalExecutableAmount>TotalAmount"`
+	InvoiceCurrencyCode    string `xml:"InvoiceCurrencyCode"`
+}
+
+type facturaeParties struct {
+	Seller facturaeParty `xml:"SellerParty"`
+	Buyer  facturaeParty `xml:"BuyerParty"`
+}
+
+type facturaeParty struct {
+	TaxIdentification     facturaeTaxIdentification `xml:"TaxIdentification"`
+	AdministrativeCentres *facturaeCentres          `xml:"AdministrativeCentres,omitempty"`
+	LegalEntity           *facturaeLegalEntity      `xml:"LegalEntity,omitempty"`
+	Individual            *facturaeIndividual       `xml:"Individual,omitempty"`
+}
+
+type facturaeTaxIdentification struct {
+	PersonTypeCode          string `xml:"PersonTypeCode"`
+	ResidenceTypeCode       string `xml:"ResidenceTypeCode"`
+	TaxIdentificationNumber string `xml:"TaxIdentificationNumber"`
+}
+
+type facturaeCentres struct {
+	Centres []facturaeCentre `xml:"AdministrativeCentre"`
+}
+
+type facturaeCentre struct {
+	CentreCode      string            `xml:"CentreCode"`
+	RoleTypeCode    string            `xml:"RoleTypeCode"`
+	Address         *facturaeAddress  `xml:"AddressInSpain,omitempty"`
+	OverseasAddress *facturaeOverseas `xml:"OverseasAddress,omitempty"`
+}
+
+type facturaeLegalEntity struct {
+	CorporateName   string            `xml:"CorporateName"`
+	Address         *facturaeAddress  `xml:"AddressInSpain,omitempty"`
+	OverseasAddress *facturaeOverseas `xml:"OverseasAddress,omitempty"`
+	Contact         *facturaeContact  `xml:"ContactDetails,omitempty"`
+}
+
+type facturaeIndividual struct {
+	Name            string            `xml:"Name"`
+	FirstSurname    string            `xml:"FirstSurname"`
+	SecondSurname   string            `xml:"SecondSurname,omitempty"`
+	Address         *facturaeAddress  `xml:"AddressInSpain,omitempty"`
+	OverseasAddress *facturaeOverseas `xml:"OverseasAddress,omitempty"`
+	Contact         *facturaeContact  `xml:"ContactDetails,omitempty"`
+}
+
+type facturaeAddress struct {
+	Address     string `xml:"Address"`
+	PostCode    string `xml:"PostCode"`
+	Town        string `xml:"Town"`
+	Province    string `xml:"Province"`
+	CountryCode string `xml:"CountryCode"`
+}
+
+type facturaeOverseas struct {
+	Address         string `xml:"Address"`
+	PostCodeAndTown string `xml:"PostCodeAndTown"`
+	Province        string `xml:"Province"`
+	CountryCode     string `xml:"CountryCode"`
+}
+
+type facturaeContact struct {
+	Telephone      string `xml:"Telephone,omitempty"`
+	ElectronicMail string `xml:"ElectronicMail,omitempty"`
+}
+
+type facturaeInvoice struct {
+	Header        facturaeInvoiceHeader `xml:"InvoiceHeader"`
+	IssueData     facturaeIssueData     `xml:"InvoiceIssueData"`
+	TaxesOutputs  []facturaeTax         `xml:"TaxesOutputs>Tax"`
+	TaxesWithheld *facturaeTaxes        `xml:"TaxesWithheld,omitempty"`
+	Totals        facturaeTotals        `xml:"InvoiceTotals"`
+	Items         []facturaeLine        `xml:"Items>InvoiceLine"`
+	Payment       *facturaeInstallment  `xml:"PaymentDetails>Installment,omitempty"`
+	LegalLiterals *facturaeLiterals     `xml:"LegalLiterals,omitempty"`
+}
+
+type facturaeInvoiceHeader struct {
+	InvoiceNumber       string              `xml:"InvoiceNumber"`
+	InvoiceDocumentType string              `xml:"InvoiceDocumentType"`
+	InvoiceClass        string              `xml:"InvoiceClass"`
+	Corrective          *facturaeCorrective `xml:"Corrective,omitempty"`
+}
+
+type facturaeCorrective struct {
+	InvoiceNumber               string `xml:"InvoiceNumber"`
+	ReasonCode                  string `xml:"ReasonCode"`
+	ReasonDescription           string `xml:"ReasonDescription"`
+	StartDate                   string `xml:"TaxPeriod>StartDate"`
+	EndDate                     string `xml:"TaxPeriod>EndDate"`
+	CorrectionMethod            string `xml:"CorrectionMethod"`
+	CorrectionMethodDescription string `xml:"CorrectionMethodDescription"`
+}
+
+type facturaeIssueData struct {
+	IssueDate                    string `xml:"IssueDate"`
+	InvoiceCurrencyCode          string `xml:"InvoiceCurrencyCode"`
+	TaxCurrencyCode              string `xml:"TaxCurrencyCode"`
+	LanguageName                 string `xml:"LanguageName"`
+	ReceiverTransactionReference string `xml:"ReceiverTransactionReference,omitempty"`
+}
+
+type facturaeTax struct {
+	TaxTypeCode                string          `xml:"TaxTypeCode"`
+	TaxRate                    string          `xml:"TaxRate"`
+	TaxableBase                string          `xml:"TaxableBase>TotalAmount"`
+	TaxAmount                  string          `xml:"TaxAmount>TotalAmount"`
+	EquivalenceSurcharge       string          `xml:"EquivalenceSurcharge,omitempty"`
+	EquivalenceSurchargeAmount *facturaeAmount `xml:"EquivalenceSurchargeAmount,omitempty"`
+}
+
+type facturaeAmount struct {
+	TotalAmount string `xml:"TotalAmount"`
+}
+
+type facturaeTaxes struct {
+	Taxes []facturaeTax `xml:"Tax"`
+}
+
+type facturaeLiterals struct {
+	References []string `xml:"LegalReference"`
+}
+
+type facturaeTotals struct {
+	TotalGrossAmount            string `xml:"TotalGrossAmount"`
+	TotalGrossAmountBeforeTaxes string `xml:"TotalGrossAmountBeforeTaxes"`
+	TotalTaxOutputs             string `xml:"TotalTaxOutputs"`
+	TotalTaxesWithheld          string `xml:"TotalTaxesWithheld"`
+	InvoiceTotal                string `xml:"InvoiceTotal"`
+	TotalOutstandingAmount      string `xml:"TotalOutstandingAmount"`
+	TotalExecutableAmount       string `xml:"TotalExecutableAmount"`
+}
+
+type facturaeLine struct {
+	ItemDescription     string                `xml:"ItemDescription"`
+	Quantity            string                `xml:"Quantity"`
+	UnitOfMeasure       string                `xml:"UnitOfMeasure"`
+	UnitPriceWithoutTax string                `xml:"UnitPriceWithoutTax"`
+	TotalCost           string                `xml:"TotalCost"`
+	GrossAmount         string                `xml:"GrossAmount"`
+	TaxesWithheld       *facturaeTaxes        `xml:"TaxesWithheld,omitempty"`
+	TaxesOutputs        []facturaeTax         `xml:"TaxesOutputs>Tax"`
+	SpecialTaxableEvent *facturaeSpecialEvent `xml:"SpecialTaxableEvent,omitempty"`
+}
+
+type facturaeSpecialEvent struct {
+	Code   string `xml:"SpecialTaxableEventCode"`
+	Reason string `xml:"SpecialTaxableEventReason"`
+}
+
+type facturaeInstallment struct {
+	DueDate string           `xml:"InstallmentDueDate"`
+	Amount  string           `xml:"InstallmentAmount"`
+	Means   string           `xml:"PaymentMeans"`
+	Account *facturaeAccount `xml:"AccountToBeCredited,omitempty"`
+}
+
+type facturaeAccount struct {
+	IBAN string `xml:"IBAN"`
+	BIC  string `xml:"BIC,omitempty"`
+}
+
+// NewFacturae maps an invoice to Facturae. Credit notes are issued as
+// corrective invoices by differences, with negative quantities and amounts.
+// IRPF is reported as a withheld tax and the VAT exemption and IRPF reasons
+// from the notes as legal literals.
+//
+//nolint:funlen //mapping is easier to follow in a single place
+func NewFacturae(invoice *model.Invoice, opts FacturaeOptions) (*Facturae, error) {
+	c := checker{}
+	seller := facturaeParty{TaxIdentification: facturaeTaxID(invoice.From.VatID)}
+	buyer := facturaeParty{TaxIdentification: facturaeTaxID(invoice.To.VatID)}
+
+	sellerContact := &facturaeContact{Telephone: invoice.From.Phone, ElectronicMail: invoice.From.Email}
+	if *sellerContact == (facturaeContact{}) {
+		sellerContact = nil
+	}
+
+	c.fillParty(&seller, "seller", sellerName(&invoice.From), invoice.From.Name,
+		invoice.From.VatID, invoice.From.Address1, invoice.From.Address2, sellerContact)
+	c.fillParty(&buyer, "buyer", invoice.To.Name, invoice.To.Name,
+		invoice.To.VatID, invoice.To.Address1, invoice.To.Address2, nil)
+
+	if dir3 := invoice.To.Dir3; dir3 != nil {
+		address, overseas := facturaeAddresses(invoice.To.VatID, invoice.To.Address1, invoice.To.Address2)
+
+		buyer.AdministrativeCentres = &facturaeCentres{}
+
+		for _, centre := range []struct{ code, role string }{
+			{dir3.AccountingOffice, facturaeRoleAccounting},
+			{dir3.ManagementBody, facturaeRoleManagement},
+			{dir3.ProcessingUnit, facturaeRoleProcessing},
+		} {
+			buyer.AdministrativeCentres.Centres = append(buyer.AdministrativeCentres.Centres, facturaeCentre{
+				CentreCode:      centre.code,
+				RoleTypeCode:    centre.role,
+				Address:         address,
+				OverseasAddress: overseas,
+			})
+		}
+	}
+
+	if len(c.violations) > 0 {
+		return nil, &ValidationError{Violations: c.violations}
+	}
+
+	sign := 1.
+	if invoice.IsCreditNote() {
+		sign = -1
+	}
+
+	totals := invoice.Totals()
+	category, _ := VatCategory(invoice)
+	outputs, withheld := facturaeTaxLists(totals.Taxes, sign)
+
+	doc := facturaeInvoice{
+		Header: facturaeInvoiceHeader{
+			InvoiceNumber:       invoice.ID,
+			InvoiceDocumentType: facturaeDocumentComplete,
+			InvoiceClass:        facturaeClassOriginal,
+		},
+		IssueData: facturaeIssueData{
+			IssueDate:                    invoice.Date.Format(dateFormatISO),
+			InvoiceCurrencyCode:          invoice.Currency,
+			TaxCurrencyCode:              facturaeTaxCurrency,
+			LanguageName:                 facturaeLanguage,
+			ReceiverTransactionReference: invoice.Reference,
+		},
+		TaxesOutputs: outputs,
+		Totals: facturaeTotals{
+			TotalGrossAmount:            formatAmount(sign * totals.Subtotal),
+			TotalGrossAmountBeforeTaxes: formatAmount(sign * totals.Subtotal),
+			TotalTaxOutputs:             formatAmount(sign * (totals.Total - totals.Subtotal + totals.Retention)),
+			TotalTaxesWithheld:          formatAmount(sign * totals.Retention),
+			InvoiceTotal:                formatAmount(sign * totals.Total),
+			TotalOutstandingAmount:      formatAmount(sign * totals.Total),
+			TotalExecutableAmount:       formatAmount(sign * totals.Total),
+		},
+		Payment: &facturaeInstallment{
+			DueDate: invoice.DueDate().Format(dateFormatISO),
+			Amount:  formatAmount(sign * totals.Total),
+			Means:   facturaePaymentTransfer,
+		},
+	}
+
+	if iban := strings.ReplaceAll(invoice.Payment.Iban, " ", ""); iban != "" {
+		doc.Payment.Account = &facturaeAccount{IBAN: iban, BIC: invoice.Payment.Swift}
+	}
+
+	if invoice.IsCreditNote() {
+		corrected := opts.CorrectedDate
+		if corrected.IsZero() {
+			corrected = invoice.Date
+		}
+
+		start := time.Date(corrected.Year(), corrected.Month(), 1, 0, 0, 0, 0, corrected.Location())
+
+		doc.Header.InvoiceClass = facturaeClassCorrective
+		doc.Header.Corrective = &facturaeCorrective{
+			InvoiceNumber:               invoice.Corrects,
+			ReasonCode:                  facturaeReasonTaxableBase,
+			ReasonDescription:           facturaeReasonTaxableBaseDesc,
+			StartDate:                   start.Format(dateFormatISO),
+			EndDate:                     start.AddDate(0, 1, -1).Format(dateFormatISO),
+			CorrectionMethod:            facturaeMethodDifferences,
+			CorrectionMethodDescription: facturaeMethodDifferencesDesc,
+		}
+	}
+
+	if len(withheld) > 0 {
+		doc.TaxesWithheld = &facturaeTaxes{Taxes: withheld}
+	}
+
+	var event *facturaeSpecialEvent
+
+	switch category {
+	case VatCategoryExempt, VatCategoryIntraEU:
+		event = &facturaeSpecialEvent{Code: facturaeExempt, Reason: invoice.Notes.Vat0}
+	case VatCategoryReverseCharge, VatCategoryExport:
+		event = &facturaeSpecialEvent{Code: facturaeNotSubject, Reason: invoice.Notes.Vat0}
+	}
+
+	for _, item := range invoice.Items {
+		amount := model.Round(item.GetAmount())
+		lineOutputs, lineWithheld := facturaeTaxLists(lineTaxes(invoice.Tax.Taxes, amount), sign)
+		amount *= sign
+		line := facturaeLine{
+			ItemDescription:     item.Description,
+			Quantity:            formatPercent(sign * float64(item.Quantity)),
+			UnitOfMeasure:       facturaeUnitUnits,
+			UnitPriceWithoutTax: formatAmount(item.Rate),
+			TotalCost:           formatAmount(amount),
+			GrossAmount:         formatAmount(amount),
+			TaxesOutputs:        lineOutputs,
+			SpecialTaxableEvent: event,
+		}
+
+		if len(lineWithheld) > 0 {
+			line.TaxesWithheld = &facturaeTaxes{Taxes: lineWithheld}
+		}
+
+		doc.Items = append(doc.Items, line)
+	}
+
+	for _, literal := range []string{invoice.Notes.Vat0, invoice.Notes.RetentionNot0} {
+		if literal == "" {
+			continue
+		}
+
+		if doc.LegalLiterals == nil {
+			doc.LegalLiterals = &facturaeLiterals{}
+		}
+
+		doc.LegalLiterals.References = append(doc.LegalLiterals.References, literal)
+	}
+
+	return &Facturae{
+		XmlnsFe: nsFacturae,
+		XmlnsDs: nsDsig,
+		FileHeader: facturaeFileHeader{
+			SchemaVersion:     FacturaeVersion,
+			Modality:          facturaeModalityIndividual,
+			InvoiceIssuerType: facturaeIssuerSeller,
+			Batch: facturaeBatch{
+				BatchIdentifier:        seller.TaxIdentification.TaxIdentificationNumber + invoice.ID,
+				InvoicesCount:          1,
+				TotalInvoicesAmount:    doc.Totals.InvoiceTotal,
+				TotalOutstandingAmount: doc.Totals.TotalOutstandingAmount,
+				TotalExecutableAmount:  doc.Totals.TotalExecutableAmount,
+				InvoiceCurrencyCode:    invoice.Currency,
+			},
+		},
+		Parties:  facturaeParties{Seller: seller, Buyer: buyer},
+		Invoices: []facturaeInvoice{doc},
+	}, nil
+}
+
+// Marshal serializes the document to XML.
+func (f *Facturae) Marshal() ([]byte, error) {
+	out, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+// fillParty sets the legal entity or individual of a party. Spanish tax
+// numbers starting with a letter other than X, Y or Z belong to companies.
+func (c *checker) fillParty(party *facturaeParty, role, name, person, vatID, line1, line2 string, contact *facturaeContact) {
+	address, overseas := facturaeAddresses(vatID, line1, line2)
+	if address == nil && overseas == nil {
+		c.require("FACTURAE-ADDRESS", false,
+			"the "+role+" address shall contain a Spanish postal code followed by the town, e.g. '28001 Madrid'")
+	}
+
+	if party.TaxIdentification.PersonTypeCode == facturaePersonLegal {
+		party.LegalEntity = &facturaeLegalEntity{
+			CorporateName:   name,
+			Address:         address,
+			OverseasAddress: overseas,
+			Contact:         contact,
+		}
+
+		return
+	}
+
+	first, surname1, surname2 := splitPersonName(person)
+	c.require("FACTURAE-NAME", surname1 != "", "the "+role+" name shall contain at least one surname")
+
+	party.Individual = &facturaeIndividual{
+		Name:            first,
+		FirstSurname:    surname1,
+		SecondSurname:   surname2,
+		Address:         address,
+		OverseasAddress: overseas,
+		Contact:         contact,
+	}
+}
+
+func facturaeTaxID(vatID string) facturaeTaxIdentification {
+	vatID = vat.Normalize(vatID)
+	country := vat.Country(vatID)
+
+	id := facturaeTaxIdentification{
+		PersonTypeCode:          facturaePersonLegal,
+		ResidenceTypeCode:       facturaeForeign,
+		TaxIdentificationNumber: vatID,
+	}
+
+	switch {
+	case country == countrySpain || country == "":
+		number := strings.TrimPrefix(vatID, countrySpain)
+		id.ResidenceTypeCode = facturaeResident
+		id.TaxIdentificationNumber = number
+
+		if number != "" && strings.ContainsRune("0123456789XYZKLM", rune(number[0])) {
+			id.PersonTypeCode = facturaePersonIndividual
+		}
+	case tax.IsEU(country):
+		id.ResidenceTypeCode = facturaeResidentEU
+	}
+
+	return id
+}
+
+func facturaeAddresses(vatID, line1, line2 string) (*facturaeAddress, *facturaeOverseas) {
+	country := vat.Country(vatID)
+
+	if country == countrySpain || country == "" {
+		postCode, town, province := spanishAddress(line1, line2)
+		if postCode == "" || town == "" {
+			return nil, nil
+		}
+
+		return &facturaeAddress{
+			Address:     line1,
+			PostCode:    postCode,
+			Town:        town,
+			Province:    province,
+			CountryCode: facturaeCountrySpain,
+		}, nil
+	}
+
+	province, _, _ := strings.Cut(line2, ",")
+
+	return nil, &facturaeOverseas{
+		Address:         line1,
+		PostCodeAndTown: line2,
+		Province:        strings.TrimSpace(province),
+		CountryCode:     alpha3Countries[country],
+	}
+}
+
+// splitPersonName splits a Spanish full name into the given name and up to two
+// surnames, which are the last words: "José Luis García López" gives "José
+// Luis", "García" and "López".
+func splitPersonName(name string) (first, surname1, surname2 string) {
+	words := strings.Fields(name)
+
+	switch {
+	case len(words) <= 1:
+		return name, "", ""
+	case len(words) == facturaeMaxSurnames:
+		return words[0], words[1], ""
+	default:
+		n := len(words)
+		return strings.Join(words[:n-2], " "), words[n-2], words[n-1]
+	}
+}
+
+// facturaeTaxLists splits taxes into the taxes output and withheld of
+// Facturae. The equivalence surcharge is not a tax of its own but goes with
+// the VAT it is charged on.
+func facturaeTaxLists(taxes []model.TaxAmount, sign float64) (outputs, withheld []facturaeTax) {
+	var surcharge *model.TaxAmount
+
+	for i := range taxes {
+		if taxes[i].Kind == model.TaxKindSurcharge {
+			surcharge = &taxes[i]
+		}
+	}
+
+	for _, tax := range taxes {
+		entry := facturaeTax{
+			TaxTypeCode: facturaeTaxOther,
+			TaxRate:     formatAmount(tax.Rate),
+			TaxableBase: formatAmount(sign * tax.Base),
+			TaxAmount:   formatAmount(sign * math.Abs(tax.Amount)),
+		}
+
+		switch tax.Kind {
+		case model.TaxKindSurcharge:
+			continue
+		case model.TaxKindWithholding:
+			entry.TaxTypeCode = facturaeTaxIrpf
+			withheld = append(withheld, entry)
+
+			continue
+		case model.TaxKindVat:
+			entry.TaxTypeCode = facturaeTaxVat
+
+			if surcharge != nil {
+				entry.EquivalenceSurcharge = formatAmount(surcharge.Rate)
+				entry.EquivalenceSurchargeAmount = &facturaeAmount{TotalAmount: formatAmount(sign * surcharge.Amount)}
+			}
+		}
+
+		outputs = append(outputs, entry)
+	}
+
+	return outputs, withheld
+}
+
+// lineTaxes computes the taxes of an invoice on the amount of a line.
+func lineTaxes(taxes []model.Tax, amount float64) []model.TaxAmount {
+	amounts := make([]model.TaxAmount, 0, len(taxes))
+
+	for _, tax := range taxes {
+		tax.Base = 0
+		amounts = append(amounts, tax.Compute(amount))
+	}
+
+	return amounts
+}
@@ -0,0 +1,207 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// parsedFacturae holds the parts of a Facturae 3.2.2 document the tests check.
+type parsedFacturae struct {
+	XMLName xml.Name
+	Header  struct {
+		SchemaVersion string `xml:"SchemaVersion"`
+		Modality      string `xml:"Modality"`
+		IssuerType    string `xml:"InvoiceIssuerType"`
+		Batch         struct {
+			ID    string `xml:"BatchIdentifier"`
+			Count int    `xml:"InvoicesCount"`
+			Total string `xml:"TotalInvoicesAmount>TotalAmount"`
+		} `xml:"Batch"`
+	} `xml:"FileHeader"`
+	Seller struct {
+		TaxID      string `xml:"TaxIdentification>TaxIdentificationNumber"`
+		PersonType string `xml:"TaxIdentification>PersonTypeCode"`
+		Name       string `xml:"Individual>Name"`
+		Surname    string `xml:"Individual>FirstSurname"`
+		PostCode   string `xml:"Individual>AddressInSpain>PostCode"`
+	} `xml:"Parties>SellerParty"`
+	Buyer struct {
+		TaxID         string `xml:"TaxIdentification>TaxIdentificationNumber"`
+		PersonType    string `xml:"TaxIdentification>PersonTypeCode"`
+		CorporateName string `xml:"LegalEntity>CorporateName"`
+	} `xml:"Parties>BuyerParty"`
+	Invoice struct {
+		Number     string `xml:"InvoiceHeader>InvoiceNumber"`
+		Class      string `xml:"InvoiceHeader>InvoiceClass"`
+		Corrective *struct {
+			Number    string `xml:"InvoiceNumber"`
+			Reason    string `xml:"ReasonCode"`
+			StartDate string `xml:"TaxPeriod>StartDate"`
+			EndDate   string `xml:"TaxPeriod>EndDate"`
+		} `xml:"InvoiceHeader>Corrective"`
+		Outputs  []facturaeTax `xml:"TaxesOutputs>Tax"`
+		Withheld []facturaeTax `xml:"TaxesWithheld>Tax"`
+		Totals   struct {
+			Gross      string `xml:"TotalGrossAmountBeforeTaxes"`
+			TaxOutputs string `xml:"TotalTaxOutputs"`
+			Withheld   string `xml:"TotalTaxesWithheld"`
+			Total      string `xml:"InvoiceTotal"`
+			Executable string `xml:"TotalExecutableAmount"`
+		} `xml:"InvoiceTotals"`
+		Lines []struct {
+			Quantity string `xml:"Quantity"`
+			Total    string `xml:"TotalCost"`
+		} `xml:"Items>InvoiceLine"`
+		Installment struct {
+			Amount  string `xml:"InstallmentAmount"`
+			Account *struct {
+				IBAN string `xml:"IBAN"`
+				BIC  string `xml:"BIC"`
+			} `xml:"AccountToBeCredited"`
+		} `xml:"PaymentDetails>Installment"`
+		Literals []string `xml:"LegalLiterals>LegalReference"`
+	} `xml:"Invoices>Invoice"`
+}
+
+func marshalFacturae(t *testing.T, invoice *model.Invoice, opts FacturaeOptions) ([]byte, parsedFacturae) {
+	t.Helper()
+
+	doc, err := NewFacturae(invoice, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := doc.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed parsedFacturae
+	if err := xml.Unmarshal(out, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	return out, parsed
+}
+
+func TestNewFacturae(t *testing.T) {
+	invoice := newTestInvoice(21, 15)
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 2, Rate: 100})
+	invoice.AddItem(model.Item{Description: "Training", Quantity: 1, Rate: 50})
+
+	out, parsed := marshalFacturae(t, invoice, FacturaeOptions{})
+	golden(t, "invoice.facturae.xml", out)
+
+	if parsed.XMLName.Space != nsFacturae || parsed.XMLName.Local != "Facturae" {
+		t.Errorf("got root %v, want a Facturae 3.2.2 document", parsed.XMLName)
+	}
+
+	header := parsed.Header
+	if header.SchemaVersion != FacturaeVersion || header.Modality != "I" || header.IssuerType != "EM" {
+		t.Errorf("got header %+v, want a single invoice of version %s issued by the seller", header, FacturaeVersion)
+	}
+
+	if header.Batch.ID != "12345678ZF25-001" || header.Batch.Count != 1 || header.Batch.Total != "265.00" {
+		t.Errorf("got batch %+v, want F25-001 of 265.00", header.Batch)
+	}
+
+	seller := parsed.Seller
+	if seller.TaxID != "12345678Z" || seller.PersonType != "F" || seller.Name != "Ana" || seller.Surname != "García" || seller.PostCode != "28013" {
+		t.Errorf("got seller %+v, want the individual Ana García of 28013", seller)
+	}
+
+	if buyer := parsed.Buyer; buyer.TaxID != "B12345674" || buyer.PersonType != "J" || buyer.CorporateName != "Acme, S.L." {
+		t.Errorf("got buyer %+v, want the legal entity Acme, S.L.", buyer)
+	}
+
+	doc := parsed.Invoice
+	if doc.Class != "OO" || doc.Corrective != nil {
+		t.Errorf("got class %s, want an original invoice", doc.Class)
+	}
+
+	if len(doc.Outputs) != 1 || doc.Outputs[0].TaxTypeCode != "01" || doc.Outputs[0].TaxAmount != "52.50" {
+		t.Errorf("got outputs %+v, want a VAT of 52.50", doc.Outputs)
+	}
+
+	if len(doc.Withheld) != 1 || doc.Withheld[0].TaxTypeCode != "04" || doc.Withheld[0].TaxAmount != "37.50" {
+		t.Errorf("got withheld %+v, want an IRPF of 37.50", doc.Withheld)
+	}
+
+	totals := doc.Totals
+	total := amount(t, totals.Gross) + amount(t, totals.TaxOutputs) - amount(t, totals.Withheld)
+
+	if math.Abs(total-amount(t, totals.Total)) > 0.001 || totals.Executable != totals.Total || doc.Installment.Amount != totals.Total {
+		t.Errorf("got totals %+v and installment %s, want them to add up to %v", totals, doc.Installment.Amount, total)
+	}
+
+	var lines float64
+	for _, line := range doc.Lines {
+		lines += amount(t, line.Total)
+	}
+
+	if len(doc.Lines) != 2 || math.Abs(lines-amount(t, totals.Gross)) > 0.001 {
+		t.Errorf("got lines %+v, want two adding up to %s", doc.Lines, totals.Gross)
+	}
+
+	if account := doc.Installment.Account; account == nil || account.IBAN != "ES9121000418450200051332" || account.BIC != "CAIXESBBXXX" {
+		t.Errorf("got account %+v, want the IBAN without spaces and the BIC", account)
+	}
+
+	if len(doc.Literals) != 1 || doc.Literals[0] != "IRPF withheld." {
+		t.Errorf("got literals %q, want the IRPF note", doc.Literals)
+	}
+}
+
+func TestNewFacturaeCreditNote(t *testing.T) {
+	invoice := newTestInvoice(21, 0)
+	invoice.Type = model.TypeCreditNote
+	invoice.Corrects = "F25-000"
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: 100})
+
+	_, parsed := marshalFacturae(t, invoice, FacturaeOptions{CorrectedDate: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)})
+
+	doc := parsed.Invoice
+	if doc.Class != "OR" || doc.Corrective == nil {
+		t.Fatalf("got class %s, want a corrective invoice", doc.Class)
+	}
+
+	corrective := *doc.Corrective
+	if corrective.Number != "F25-000" || corrective.Reason != "16" || corrective.StartDate != "2025-02-01" || corrective.EndDate != "2025-02-28" {
+		t.Errorf("got corrective %+v, want F25-000 for February 2025", corrective)
+	}
+
+	if doc.Totals.Total != "-121.00" || doc.Lines[0].Quantity != "-1" || doc.Lines[0].Total != "-100.00" {
+		t.Errorf("got total %s and line %+v, want negative amounts", doc.Totals.Total, doc.Lines[0])
+	}
+}
+
+func TestNewFacturaeWithoutIBAN(t *testing.T) {
+	invoice := newTestInvoice(21, 0)
+	invoice.Payment = model.Payment{}
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: 100})
+
+	out, parsed := marshalFacturae(t, invoice, FacturaeOptions{})
+
+	if parsed.Invoice.Installment.Account != nil || bytes.Contains(out, []byte("AccountToBeCredited")) {
+		t.Errorf("got an account to be credited without an IBAN:\n%s", out)
+	}
+}
+
+func TestNewFacturaeRequiresASurname(t *testing.T) {
+	invoice := newTestInvoice(21, 0)
+	invoice.From.Name = "Ana"
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: 100})
+
+	var verr *ValidationError
+
+	_, err := NewFacturae(invoice, FacturaeOptions{})
+	if !errors.As(err, &verr) || len(verr.Violations) != 1 || verr.Violations[0].Rule != "FACTURAE-NAME" {
+		t.Errorf("got %v, want a FACTURAE-NAME violation", err)
+	}
+}
@@ -0,0 +1,30 @@
+package einvoice
+
+import (
+	"math"
+	"strconv"
+)
+
+const (
+	dateFormat102 = "20060102"
+)
+
+// formatAmount writes monetary amounts with two decimals as required by EN 16931.
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// formatPercent writes percentages and quantities without trailing zeros.
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatPrice writes unit prices with two decimals, or with all of them when
+// they have more, as the net prices derived from gross prices do.
+func formatPrice(v float64) string {
+	if math.Round(v*100) == v*100 { //nolint:mnd //cents
+		return formatAmount(v)
+	}
+
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,83 @@
+package einvoice
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile is a Factur-X / ZUGFeRD conformance level. Each level adds business
+// terms to the previous one.
+type Profile string
+
+const (
+	ProfileMinimum Profile = "minimum"
+	ProfileBasicWL Profile = "basicwl"
+	ProfileBasic   Profile = "basic"
+	ProfileEN16931 Profile = "en16931"
+)
+
+var profileLevels = map[Profile]int{
+	ProfileMinimum: 1,
+	ProfileBasicWL: 2,
+	ProfileBasic:   3,
+	ProfileEN16931: 4,
+}
+
+// GetSupportedProfiles returns the supported Factur-X profiles.
+func GetSupportedProfiles() []Profile {
+	return []Profile{ProfileMinimum, ProfileBasicWL, ProfileBasic, ProfileEN16931}
+}
+
+// ParseProfile parses a Factur-X profile name.
+func ParseProfile(p string) (Profile, error) {
+	profile := Profile(strings.ToLower(strings.ReplaceAll(p, " ", "")))
+	if _, ok := profileLevels[profile]; !ok {
+		return "", fmt.Errorf("unsupported factur-x profile: %s", p)
+	}
+
+	return profile, nil
+}
+
+// GuidelineID returns the specification identifier (BT-24) of the profile.
+func (p Profile) GuidelineID() string {
+	switch p {
+	case ProfileMinimum:
+		return "urn:factur-x.eu:1p0:minimum"
+	case ProfileBasicWL:
+		return "urn:factur-x.eu:1p0:basicwl"
+	case ProfileBasic:
+		return "urn:cen.eu:en16931:2017#compliant#urn:factur-x.eu:1p0:basic"
+	default:
+		return "urn:cen.eu:en16931:2017"
+	}
+}
+
+// ConformanceLevel returns the value stored in the PDF/A XMP metadata.
+func (p Profile) ConformanceLevel() string {
+	switch p {
+	case ProfileMinimum:
+		return "MINIMUM"
+	case ProfileBasicWL:
+		return "BASIC WL"
+	case ProfileBasic:
+		return "BASIC"
+	default:
+		return "EN 16931"
+	}
+}
+
+// AFRelationship returns how the XML of the profile relates to the PDF it is
+// embedded in: an alternative to it for the profiles carrying a full invoice,
+// only data for the MINIMUM and BASIC WL ones.
+func (p Profile) AFRelationship() string {
+	if p.includes(ProfileBasic) {
+		return "Alternative"
+	}
+
+	return "Data"
+}
+
+// includes reports whether the profile carries the business terms of other.
+func (p Profile) includes(other Profile) bool {
+	return profileLevels[p] >= profileLevels[other]
+}
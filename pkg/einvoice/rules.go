@@ -0,0 +1,186 @@
+package einvoice
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+const (
+	amountTolerance = 0.005
+	// vatTolerance is the difference the EN 16931 Schematron accepts between a
+	// VAT amount and its taxable amount times the rate, left by rounding each
+	// line and by gross prices.
+	vatTolerance = 1
+)
+
+// Violation is a business rule of EN 16931 not satisfied by a document.
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// ValidationError groups the violations found in a document.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+
+	return "einvoice: document not valid: " + strings.Join(msgs, "; ")
+}
+
+// Validate returns a *ValidationError when the document breaks a rule.
+func (d *Document) Validate() error {
+	violations := d.Check()
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Violations: violations}
+}
+
+// Check runs the core EN 16931 business rules (BR-xx, BR-CO-xx) on the
+// document and returns every violation found.
+func (d *Document) Check() []Violation {
+	c := checker{}
+
+	c.require("BR-02", d.Number != "", "an invoice shall have an invoice number")
+	c.require("BR-03", !d.IssueDate.IsZero(), "an invoice shall have an issue date")
+	c.require("BR-04", d.TypeCode != "", "an invoice shall have a type code")
+	c.require("BR-05", d.Currency != "", "an invoice shall have a currency code")
+	c.require("BR-06", d.Seller.Name != "", "an invoice shall contain the seller name")
+	c.require("BR-07", d.Buyer.Name != "", "an invoice shall contain the buyer name")
+	c.require("BR-09", d.Seller.Address.CountryCode != "", "the seller postal address shall contain a country code")
+	c.require("BR-11", d.Buyer.Address.CountryCode != "", "the buyer postal address shall contain a country code")
+	c.require("BR-16", len(d.Lines) > 0, "an invoice shall have at least one line")
+
+	for _, line := range d.Lines {
+		c.require("BR-21", line.ID != "", "each line shall have an identifier")
+		c.require("BR-23", line.UnitCode != "", fmt.Sprintf("line %s shall have a unit of measure", line.ID))
+		c.require("BR-25", line.Name != "", fmt.Sprintf("line %s shall contain the item name", line.ID))
+		c.require("BR-26", line.Price >= 0, fmt.Sprintf("line %s net price shall not be negative", line.ID))
+		c.require("BR-CO-04", line.VatCategory != "", fmt.Sprintf("line %s shall have a VAT category", line.ID))
+	}
+
+	lineTotal := 0.
+	for _, line := range d.Lines {
+		lineTotal += line.NetAmount
+	}
+
+	taxTotal := 0.
+	for _, vat := range d.VatBreakdown {
+		taxTotal += vat.TaxAmount
+	}
+
+	c.require("BR-CO-10", sameAmount(d.Totals.LineTotal, lineTotal), "sum of line net amounts shall equal the line total amount")
+	c.require("BR-CO-13", sameAmount(d.Totals.TaxExclusive, d.Totals.LineTotal), "total without VAT shall equal the line total amount")
+	c.require("BR-CO-14", sameAmount(d.Totals.TaxTotal, taxTotal), "invoice VAT total shall equal the sum of the VAT category amounts")
+	c.require("BR-CO-15", sameAmount(d.Totals.TaxInclusive, d.Totals.TaxExclusive+d.Totals.TaxTotal),
+		"total with VAT shall equal the total without VAT plus the VAT total")
+	c.require("BR-CO-16", sameAmount(d.Totals.Payable, d.Totals.TaxInclusive-d.Totals.Prepaid),
+		"amount due shall equal the total with VAT minus the paid amount")
+	c.require("BR-CO-18", len(d.VatBreakdown) > 0, "an invoice shall have at least one VAT breakdown")
+
+	if d.Payment.MeansCode == PaymentMeansCreditTransfer || d.Payment.MeansCode == PaymentMeansSepaTransfer {
+		c.require("BR-61", d.Payment.IBAN != "", "a credit transfer shall contain the payment account identifier")
+	}
+
+	c.checkVatCategories(d)
+
+	return c.violations
+}
+
+// CheckPeppol runs the core rules plus the Peppol BIS Billing 3.0 rules needed
+// to send the document through the Peppol network.
+func (d *Document) CheckPeppol() []Violation {
+	c := checker{violations: d.Check()}
+
+	c.require("PEPPOL-EN16931-R003", d.BuyerReference != "", "a buyer reference or purchase order reference shall be provided")
+	c.require("PEPPOL-EN16931-R010", d.Buyer.EndpointID != "", "buyer electronic address shall be provided")
+	c.require("PEPPOL-EN16931-R020", d.Seller.EndpointID != "", "seller electronic address shall be provided")
+
+	for _, line := range d.Lines {
+		c.require("PEPPOL-EN16931-R120", sameAmount(line.NetAmount, line.Quantity*line.Price),
+			fmt.Sprintf("line %s net amount shall equal quantity times net price", line.ID))
+	}
+
+	return c.violations
+}
+
+// checkVatCategories runs the rules of each VAT category present in the
+// breakdown (BR-S, BR-AE, BR-E and BR-G).
+func (c *checker) checkVatCategories(d *Document) {
+	hasVatID := func(p Party) bool { return p.VatID != "" }
+
+	for _, vat := range d.VatBreakdown {
+		expected := 0.
+		for _, line := range d.Lines {
+			if line.VatCategory == vat.Category && line.VatRate == vat.Rate {
+				expected += line.NetAmount
+			}
+		}
+
+		exempted := vat.ExemptionReason != "" || vat.ExemptionCode != ""
+
+		switch vat.Category {
+		case VatCategoryStandard:
+			c.require("BR-S-09", math.Abs(vat.TaxAmount-vat.TaxableAmount*vat.Rate/100) < vatTolerance, //nolint:mnd //percentage
+				"standard rated VAT amount shall equal the taxable amount times the rate")
+			c.require("BR-S-10", !exempted, "standard rated VAT shall not have an exemption reason")
+		case VatCategoryReverseCharge:
+			c.require("BR-AE-02", hasVatID(d.Seller) && hasVatID(d.Buyer),
+				"reverse charge invoices shall contain the seller and buyer VAT identifiers")
+			c.require("BR-AE-05", vat.Rate == 0, "reverse charge VAT rate shall be 0")
+			c.require("BR-AE-08", sameAmount(vat.TaxableAmount, expected),
+				"reverse charge taxable amount shall equal the sum of the reverse charge lines")
+			c.require("BR-AE-09", vat.TaxAmount == 0, "reverse charge VAT amount shall be 0")
+			c.require("BR-AE-10", exempted, "reverse charge VAT shall have an exemption reason")
+		case VatCategoryExempt:
+			c.require("BR-E-02", hasVatID(d.Seller), "exempt invoices shall contain the seller VAT identifier")
+			c.require("BR-E-05", vat.Rate == 0, "exempt VAT rate shall be 0")
+			c.require("BR-E-08", sameAmount(vat.TaxableAmount, expected), "exempt taxable amount shall equal the sum of the exempt lines")
+			c.require("BR-E-09", vat.TaxAmount == 0, "exempt VAT amount shall be 0")
+			c.require("BR-E-10", exempted, "exempt VAT shall have an exemption reason")
+		case VatCategoryExport:
+			c.require("BR-G-02", hasVatID(d.Seller), "export invoices shall contain the seller VAT identifier")
+			c.require("BR-G-05", vat.Rate == 0, "export VAT rate shall be 0")
+			c.require("BR-G-08", sameAmount(vat.TaxableAmount, expected), "export taxable amount shall equal the sum of the export lines")
+			c.require("BR-G-09", vat.TaxAmount == 0, "export VAT amount shall be 0")
+			c.require("BR-G-10", exempted, "export VAT shall have an exemption reason")
+		}
+	}
+
+	for _, line := range d.Lines {
+		if line.VatCategory == VatCategoryReverseCharge {
+			c.require("BR-AE-03", hasVatID(d.Buyer), fmt.Sprintf("line %s is reverse charged but the buyer has no VAT identifier", line.ID))
+		}
+	}
+
+	c.require("BR-CO-09", d.Seller.VatID == "" || vat.Country(d.Seller.VatID) != "",
+		"seller VAT identifier shall have an ISO 3166-1 country prefix")
+}
+
+type checker struct {
+	violations []Violation
+}
+
+func (c *checker) require(rule string, ok bool, message string) {
+	if !ok {
+		c.violations = append(c.violations, Violation{Rule: rule, Message: message})
+	}
+}
+
+func sameAmount(a, b float64) bool {
+	return math.Abs(a-b) < amountTolerance
+}
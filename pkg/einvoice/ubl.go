@@ -0,0 +1,313 @@
+package einvoice
+
+import (
+	"encoding/xml"
+)
+
+const (
+	nsUBLInvoice    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	nsUBLCreditNote = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	nsCac           = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	nsCbc           = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+
+	PeppolCustomizationID = "urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+	PeppolProfileID       = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+
+	taxSchemeVat  = "VAT"
+	dateFormatISO = "2006-01-02"
+)
+
+// UBLDocument is a UBL 2.1 Invoice or CreditNote following Peppol BIS Billing
+// 3.0. Elements that only exist in one of both documents are left empty in the
+// other one.
+type UBLDocument struct {
+	XMLName  xml.Name
+	Xmlns    string `xml:"xmlns,attr"`
+	XmlnsCac string `xml:"xmlns:cac,attr"`
+	XmlnsCbc string `xml:"xmlns:cbc,attr"`
+
+	CustomizationID    string           `xml:"cbc:CustomizationID"`
+	ProfileID          string           `xml:"cbc:ProfileID"`
+	ID                 string           `xml:"cbc:ID"`
+	IssueDate          string           `xml:"cbc:IssueDate"`
+	DueDate            string           `xml:"cbc:DueDate,omitempty"`
+	InvoiceTypeCode    string           `xml:"cbc:InvoiceTypeCode,omitempty"`
+	CreditNoteTypeCode string           `xml:"cbc:CreditNoteTypeCode,omitempty"`
+	Notes              []string         `xml:"cbc:Note"`
+	Currency           string           `xml:"cbc:DocumentCurrencyCode"`
+	BuyerReference     string           `xml:"cbc:BuyerReference,omitempty"`
+	BillingReference   *ublBillingRef   `xml:"cac:BillingReference,omitempty"`
+	Supplier           ublParty         `xml:"cac:AccountingSupplierParty>cac:Party"`
+	Customer           ublParty         `xml:"cac:AccountingCustomerParty>cac:Party"`
+	PaymentMeans       *ublPaymentMeans `xml:"cac:PaymentMeans,omitempty"`
+	PaymentTerms       *ublPaymentTerms `xml:"cac:PaymentTerms,omitempty"`
+	TaxTotal           ublTaxTotal      `xml:"cac:TaxTotal"`
+	MonetaryTotal      ublMonetaryTotal `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines       []ublLine        `xml:"cac:InvoiceLine"`
+	CreditNoteLines    []ublLine        `xml:"cac:CreditNoteLine"`
+}
+
+type ublBillingRef struct {
+	ID string `xml:"cac:InvoiceDocumentReference>cbc:ID"`
+}
+
+type ublParty struct {
+	Endpoint    *ublIdentifier `xml:"cbc:EndpointID,omitempty"`
+	Name        string         `xml:"cac:PartyName>cbc:Name,omitempty"`
+	Address     ublAddress     `xml:"cac:PostalAddress"`
+	TaxScheme   *ublTaxScheme  `xml:"cac:PartyTaxScheme,omitempty"`
+	LegalEntity string         `xml:"cac:PartyLegalEntity>cbc:RegistrationName"`
+	Contact     *ublContact    `xml:"cac:Contact,omitempty"`
+}
+
+type ublIdentifier struct {
+	SchemeID string `xml:"schemeID,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type ublAddress struct {
+	Street           string `xml:"cbc:StreetName,omitempty"`
+	AdditionalStreet string `xml:"cbc:AdditionalStreetName,omitempty"`
+	City             string `xml:"cbc:CityName,omitempty"`
+	PostCode         string `xml:"cbc:PostalZone,omitempty"`
+	Country          string `xml:"cac:Country>cbc:IdentificationCode"`
+}
+
+type ublTaxScheme struct {
+	CompanyID string `xml:"cbc:CompanyID"`
+	TaxScheme string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type ublContact struct {
+	Name      string `xml:"cbc:Name,omitempty"`
+	Telephone string `xml:"cbc:Telephone,omitempty"`
+	Email     string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type ublPaymentMeans struct {
+	Code      string      `xml:"cbc:PaymentMeansCode"`
+	DueDate   string      `xml:"cbc:PaymentDueDate,omitempty"`
+	PaymentID string      `xml:"cbc:PaymentID,omitempty"`
+	Account   *ublAccount `xml:"cac:PayeeFinancialAccount,omitempty"`
+}
+
+type ublPaymentTerms struct {
+	Note string `xml:"cbc:Note"`
+}
+
+type ublAccount struct {
+	ID     string `xml:"cbc:ID"`
+	Name   string `xml:"cbc:Name,omitempty"`
+	Branch string `xml:"cac:FinancialInstitutionBranch>cbc:ID,omitempty"`
+}
+
+type ublAmount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount ublAmount        `xml:"cbc:TaxAmount"`
+	Subtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	Category      ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxCategory struct {
+	ID              string `xml:"cbc:ID"`
+	Percent         string `xml:"cbc:Percent,omitempty"`
+	ExemptionCode   string `xml:"cbc:TaxExemptionReasonCode,omitempty"`
+	ExemptionReason string `xml:"cbc:TaxExemptionReason,omitempty"`
+	TaxScheme       string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtension ublAmount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusive  ublAmount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusive  ublAmount  `xml:"cbc:TaxInclusiveAmount"`
+	Prepaid       *ublAmount `xml:"cbc:PrepaidAmount,omitempty"`
+	Payable       ublAmount  `xml:"cbc:PayableAmount"`
+}
+
+type ublLine struct {
+	ID               string       `xml:"cbc:ID"`
+	InvoicedQuantity *ublQuantity `xml:"cbc:InvoicedQuantity,omitempty"`
+	CreditedQuantity *ublQuantity `xml:"cbc:CreditedQuantity,omitempty"`
+	LineExtension    ublAmount    `xml:"cbc:LineExtensionAmount"`
+	Item             ublItem      `xml:"cac:Item"`
+	Price            ublAmount    `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+type ublQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublItem struct {
+	Name     string         `xml:"cbc:Name"`
+	Category ublTaxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
+
+// ToUBL maps the document to a UBL Invoice, or a CreditNote when the type
+// code is 381.
+//
+//nolint:funlen //mapping is easier to follow in a single place
+func (d *Document) ToUBL() *UBLDocument {
+	creditNote := d.TypeCode == TypeCodeCreditNote
+	amount := func(v float64) ublAmount {
+		return ublAmount{CurrencyID: d.Currency, Value: formatAmount(v)}
+	}
+
+	doc := &UBLDocument{
+		XmlnsCac:        nsCac,
+		XmlnsCbc:        nsCbc,
+		CustomizationID: PeppolCustomizationID,
+		ProfileID:       PeppolProfileID,
+		ID:              d.Number,
+		IssueDate:       d.IssueDate.Format(dateFormatISO),
+		Notes:           d.Notes,
+		Currency:        d.Currency,
+		BuyerReference:  d.BuyerReference,
+		Supplier:        d.Seller.toUBL(),
+		Customer:        d.Buyer.toUBL(),
+		TaxTotal:        ublTaxTotal{TaxAmount: amount(d.Totals.TaxTotal)},
+		MonetaryTotal: ublMonetaryTotal{
+			LineExtension: amount(d.Totals.LineTotal),
+			TaxExclusive:  amount(d.Totals.TaxExclusive),
+			TaxInclusive:  amount(d.Totals.TaxInclusive),
+			Payable:       amount(d.Totals.Payable),
+		},
+	}
+
+	dueDate := ""
+	if !d.DueDate.IsZero() {
+		dueDate = d.DueDate.Format(dateFormatISO)
+	}
+
+	if creditNote {
+		doc.XMLName = xml.Name{Local: "CreditNote"}
+		doc.Xmlns = nsUBLCreditNote
+		doc.CreditNoteTypeCode = d.TypeCode
+	} else {
+		doc.XMLName = xml.Name{Local: "Invoice"}
+		doc.Xmlns = nsUBLInvoice
+		doc.InvoiceTypeCode = d.TypeCode
+		doc.DueDate = dueDate
+	}
+
+	if d.PrecedingInvoice != "" {
+		doc.BillingReference = &ublBillingRef{ID: d.PrecedingInvoice}
+	}
+
+	if d.Payment.MeansCode != "" {
+		doc.PaymentMeans = &ublPaymentMeans{
+			Code:      d.Payment.MeansCode,
+			PaymentID: d.Payment.RemittanceInfo,
+		}
+
+		if creditNote {
+			doc.PaymentMeans.DueDate = dueDate
+		}
+
+		if d.Payment.IBAN != "" {
+			doc.PaymentMeans.Account = &ublAccount{
+				ID:     d.Payment.IBAN,
+				Name:   d.Payment.AccountName,
+				Branch: d.Payment.BIC,
+			}
+		}
+	}
+
+	if d.PaymentTerms != "" {
+		doc.PaymentTerms = &ublPaymentTerms{Note: d.PaymentTerms}
+	}
+
+	if d.Totals.Prepaid != 0 {
+		prepaid := amount(d.Totals.Prepaid)
+		doc.MonetaryTotal.Prepaid = &prepaid
+	}
+
+	for _, vat := range d.VatBreakdown {
+		doc.TaxTotal.Subtotals = append(doc.TaxTotal.Subtotals, ublTaxSubtotal{
+			TaxableAmount: amount(vat.TaxableAmount),
+			TaxAmount:     amount(vat.TaxAmount),
+			Category: ublTaxCategory{
+				ID:              vat.Category,
+				Percent:         vatRate(vat.Category, vat.Rate),
+				ExemptionCode:   vat.ExemptionCode,
+				ExemptionReason: vat.ExemptionReason,
+				TaxScheme:       taxSchemeVat,
+			},
+		})
+	}
+
+	for _, line := range d.Lines {
+		ubl := ublLine{
+			ID:            line.ID,
+			LineExtension: amount(line.NetAmount),
+			Item: ublItem{
+				Name: line.Name,
+				Category: ublTaxCategory{
+					ID:        line.VatCategory,
+					Percent:   vatRate(line.VatCategory, line.VatRate),
+					TaxScheme: taxSchemeVat,
+				},
+			},
+			Price: ublAmount{CurrencyID: d.Currency, Value: formatPrice(line.Price)},
+		}
+
+		quantity := &ublQuantity{UnitCode: line.UnitCode, Value: formatPercent(line.Quantity)}
+
+		if creditNote {
+			ubl.CreditedQuantity = quantity
+			doc.CreditNoteLines = append(doc.CreditNoteLines, ubl)
+		} else {
+			ubl.InvoicedQuantity = quantity
+			doc.InvoiceLines = append(doc.InvoiceLines, ubl)
+		}
+	}
+
+	return doc
+}
+
+// MarshalUBL serializes the document to UBL 2.1 XML.
+func (d *Document) MarshalUBL() ([]byte, error) {
+	out, err := xml.MarshalIndent(d.ToUBL(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+func (p *Party) toUBL() ublParty {
+	party := ublParty{
+		Name:        p.Name,
+		LegalEntity: p.Name,
+		Address: ublAddress{
+			Street:           p.Address.Line1,
+			AdditionalStreet: p.Address.Line2,
+			City:             p.Address.City,
+			PostCode:         p.Address.PostCode,
+			Country:          p.Address.CountryCode,
+		},
+	}
+
+	if p.EndpointID != "" {
+		party.Endpoint = &ublIdentifier{SchemeID: p.EndpointScheme, Value: p.EndpointID}
+	}
+
+	if p.VatID != "" {
+		party.TaxScheme = &ublTaxScheme{CompanyID: p.VatID, TaxScheme: taxSchemeVat}
+	}
+
+	if p.Contact != "" || p.Phone != "" || p.Email != "" {
+		party.Contact = &ublContact{Name: p.Contact, Telephone: p.Phone, Email: p.Email}
+	}
+
+	return party
+}
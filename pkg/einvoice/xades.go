@@ -0,0 +1,252 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	nsDsig  = "http://www.w3.org/2000/09/xmldsig#"
+	nsXades = "http://uri.etsi.org/01903/v1.3.2#"
+
+	algC14N       = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	algRSASHA256  = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algSHA1       = "http://www.w3.org/2000/09/xmldsig#sha1"
+	algSHA256     = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algEnveloped  = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	typeSignedPrp = "http://uri.etsi.org/01903#SignedProperties"
+
+	// FacturaePolicyURL identifies the Facturae signature policy v3.1 and
+	// FacturaePolicyHash is the SHA-1 digest of the policy document.
+	FacturaePolicyURL         = "http://www.facturae.es/politica_de_firma_formato_facturae/politica_de_firma_formato_facturae_v3_1.pdf"
+	FacturaePolicyHash        = "Ohixl6upD6av8N7pEvDABhEL6hM="
+	facturaePolicyDescription = "Política de Firma FacturaE v3.1"
+
+	signatureIDBytes = 8
+)
+
+var ErrUnsupportedKey = errors.New("einvoice: only RSA keys can sign documents")
+
+// Signer holds the key and certificate used to sign documents.
+type Signer struct {
+	key         *rsa.PrivateKey
+	certificate *x509.Certificate
+	// Role is the signer role stated in the signature, "emisor" by default.
+	Role string
+}
+
+// ParsePKCS12 loads a signer from a PKCS#12 (.p12/.pfx) file content.
+func ParsePKCS12(data []byte, password string) (*Signer, error) {
+	key, certificate, _, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("einvoice: reading certificate: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	return &Signer{key: rsaKey, certificate: certificate, Role: "emisor"}, nil
+}
+
+// Certificate returns the certificate of the signer.
+func (s *Signer) Certificate() *x509.Certificate {
+	return s.certificate
+}
+
+// SignXAdES adds an enveloped XAdES-EPES signature following the Facturae
+// signature policy as the last child of the root element of doc.
+//
+//nolint:funlen //the signature is assembled step by step as the norm describes it
+func (s *Signer) SignXAdES(doc []byte, signingTime time.Time) ([]byte, error) {
+	root, namespaces, err := rootElement(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	closing := "</" + root + ">"
+
+	end := bytes.LastIndex(doc, []byte(closing))
+	if end < 0 {
+		return nil, ErrNoRootElement
+	}
+
+	dsPrefix := ""
+
+	for prefix, uri := range namespaces {
+		if uri == nsDsig && prefix != "" {
+			dsPrefix = prefix
+		}
+	}
+
+	if dsPrefix == "" {
+		return nil, fmt.Errorf("einvoice: root element shall declare the %s namespace", nsDsig)
+	}
+
+	ds := func(name string) string { return dsPrefix + ":" + name }
+
+	inherited := copyNamespaces(namespaces)
+	inherited["xades"] = nsXades
+
+	id, err := signatureID()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := struct{ signature, signedInfo, signedProps, keyInfo, reference, value, object string }{
+		signature:   "Signature" + id,
+		signedInfo:  "Signature" + id + "-SignedInfo",
+		signedProps: "Signature" + id + "-SignedProperties",
+		keyInfo:     "Certificate" + id,
+		reference:   "Reference-" + id,
+		value:       "SignatureValue" + id,
+		object:      "Signature" + id + "-Object",
+	}
+
+	digest := func(fragment []byte) (string, error) {
+		canonical, err := canonicalize(fragment, inherited)
+		if err != nil {
+			return "", err
+		}
+
+		sum := sha256.Sum256(canonical)
+
+		return base64.StdEncoding.EncodeToString(sum[:]), nil
+	}
+
+	// The enveloped-signature transform removes the signature before hashing,
+	// so the document digest is the digest of the unsigned document.
+	canonicalDoc, err := canonicalize(doc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	docSum := sha256.Sum256(canonicalDoc)
+	certSum := sha256.Sum256(s.certificate.Raw)
+
+	signedProps := `<xades:SignedProperties Id="` + ids.signedProps + `">` +
+		`<xades:SignedSignatureProperties>` +
+		`<xades:SigningTime>` + signingTime.Format(time.RFC3339) + `</xades:SigningTime>` +
+		`<xades:SigningCertificate><xades:Cert><xades:CertDigest>` +
+		`<` + ds("DigestMethod") + ` Algorithm="` + algSHA256 + `"></` + ds("DigestMethod") + `>` +
+		`<` + ds("DigestValue") + `>` + base64.StdEncoding.EncodeToString(certSum[:]) + `</` + ds("DigestValue") + `>` +
+		`</xades:CertDigest><xades:IssuerSerial>` +
+		`<` + ds("X509IssuerName") + `>` + EscapeXML(s.certificate.Issuer.String()) + `</` + ds("X509IssuerName") + `>` +
+		`<` + ds("X509SerialNumber") + `>` + s.certificate.SerialNumber.String() + `</` + ds("X509SerialNumber") + `>` +
+		`</xades:IssuerSerial></xades:Cert></xades:SigningCertificate>` +
+		`<xades:SignaturePolicyIdentifier><xades:SignaturePolicyId>` +
+		`<xades:SigPolicyId><xades:Identifier>` + FacturaePolicyURL + `</xades:Identifier>` +
+		`<xades:Description>` + facturaePolicyDescription + `</xades:Description></xades:SigPolicyId>` +
+		`<xades:SigPolicyHash>` +
+		`<` + ds("DigestMethod") + ` Algorithm="` + algSHA1 + `"></` + ds("DigestMethod") + `>` +
+		`<` + ds("DigestValue") + `>` + FacturaePolicyHash + `</` + ds("DigestValue") + `>` +
+		`</xades:SigPolicyHash></xades:SignaturePolicyId></xades:SignaturePolicyIdentifier>` +
+		`<xades:SignerRole><xades:ClaimedRoles><xades:ClaimedRole>` + EscapeXML(s.Role) +
+		`</xades:ClaimedRole></xades:ClaimedRoles></xades:SignerRole>` +
+		`</xades:SignedSignatureProperties>` +
+		`<xades:SignedDataObjectProperties><xades:DataObjectFormat ObjectReference="#` + ids.reference + `">` +
+		`<xades:Description>Factura electrónica</xades:Description>` +
+		`<xades:ObjectIdentifier><xades:Identifier Qualifier="OIDAsURN">urn:oid:1.2.840.10003.5.109.10</xades:Identifier>` +
+		`</xades:ObjectIdentifier><xades:MimeType>text/xml</xades:MimeType>` +
+		`</xades:DataObjectFormat></xades:SignedDataObjectProperties>` +
+		`</xades:SignedProperties>`
+
+	publicKey := s.key.PublicKey
+	keyInfo := `<` + ds("KeyInfo") + ` Id="` + ids.keyInfo + `">` +
+		`<` + ds("X509Data") + `><` + ds("X509Certificate") + `>` + base64.StdEncoding.EncodeToString(s.certificate.Raw) +
+		`</` + ds("X509Certificate") + `></` + ds("X509Data") + `>` +
+		`<` + ds("KeyValue") + `><` + ds("RSAKeyValue") + `>` +
+		`<` + ds("Modulus") + `>` + base64.StdEncoding.EncodeToString(publicKey.N.Bytes()) + `</` + ds("Modulus") + `>` +
+		`<` + ds("Exponent") + `>` + base64.StdEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()) +
+		`</` + ds("Exponent") + `>` +
+		`</` + ds("RSAKeyValue") + `></` + ds("KeyValue") + `>` +
+		`</` + ds("KeyInfo") + `>`
+
+	propsDigest, err := digest([]byte(signedProps))
+	if err != nil {
+		return nil, err
+	}
+
+	keyDigest, err := digest([]byte(keyInfo))
+	if err != nil {
+		return nil, err
+	}
+
+	reference := func(attrs, transforms, value string) string {
+		return `<` + ds("Reference") + attrs + `>` + transforms +
+			`<` + ds("DigestMethod") + ` Algorithm="` + algSHA256 + `"></` + ds("DigestMethod") + `>` +
+			`<` + ds("DigestValue") + `>` + value + `</` + ds("DigestValue") + `>` +
+			`</` + ds("Reference") + `>`
+	}
+
+	signedInfo := `<` + ds("SignedInfo") + ` Id="` + ids.signedInfo + `">` +
+		`<` + ds("CanonicalizationMethod") + ` Algorithm="` + algC14N + `"></` + ds("CanonicalizationMethod") + `>` +
+		`<` + ds("SignatureMethod") + ` Algorithm="` + algRSASHA256 + `"></` + ds("SignatureMethod") + `>` +
+		reference(` Type="`+typeSignedPrp+`" URI="#`+ids.signedProps+`"`, "", propsDigest) +
+		reference(` URI="#`+ids.keyInfo+`"`, "", keyDigest) +
+		reference(` Id="`+ids.reference+`" URI=""`,
+			`<`+ds("Transforms")+`><`+ds("Transform")+` Algorithm="`+algEnveloped+`"></`+ds("Transform")+`></`+ds("Transforms")+`>`,
+			base64.StdEncoding.EncodeToString(docSum[:])) +
+		`</` + ds("SignedInfo") + `>`
+
+	canonicalInfo, err := canonicalize([]byte(signedInfo), inherited)
+	if err != nil {
+		return nil, err
+	}
+
+	infoSum := sha256.Sum256(canonicalInfo)
+
+	value, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, infoSum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	signature := `<` + ds("Signature") + ` xmlns:xades="` + nsXades + `" Id="` + ids.signature + `">` +
+		signedInfo +
+		`<` + ds("SignatureValue") + ` Id="` + ids.value + `">` + base64.StdEncoding.EncodeToString(value) +
+		`</` + ds("SignatureValue") + `>` +
+		keyInfo +
+		`<` + ds("Object") + ` Id="` + ids.object + `">` +
+		`<xades:QualifyingProperties Target="#` + ids.signature + `">` + signedProps + `</xades:QualifyingProperties>` +
+		`</` + ds("Object") + `>` +
+		`</` + ds("Signature") + `>`
+
+	out := make([]byte, 0, len(doc)+len(signature))
+	out = append(out, doc[:end]...)
+	out = append(out, signature...)
+	out = append(out, doc[end:]...)
+
+	return out, nil
+}
+
+func signatureID() (string, error) {
+	buf := make([]byte, signatureIDBytes)
+
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", buf), nil
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// EscapeXML escapes the characters of s that are markup in XML text and
+// attribute values.
+func EscapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
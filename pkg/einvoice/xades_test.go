@@ -0,0 +1,268 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// newTestPKCS12 returns a PKCS#12 file holding key and a self-signed
+// certificate for it, protected by password.
+func newTestPKCS12(t *testing.T, key crypto.Signer, password string) []byte {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(4711),
+		Subject:      pkix.Name{CommonName: "Ana García", SerialNumber: "IDCES-12345678Z"},
+		NotBefore:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := pkcs12.Modern.Encode(key, certificate, nil, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ParsePKCS12(newTestPKCS12(t, key, "secret"), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+// newTestFacturae returns the unsigned Facturae of a domestic invoice.
+func newTestFacturae(t *testing.T) []byte {
+	t.Helper()
+
+	invoice := newTestInvoice(21, 15)
+	invoice.AddItem(model.Item{Description: "Consulting & training <remote>", Quantity: 2, Rate: 100})
+
+	doc, err := NewFacturae(invoice, FacturaeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := doc.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return out
+}
+
+// element returns the element of doc starting with start, which shall be
+// unique, up to its end tag.
+func element(doc []byte, start, name string) ([]byte, error) {
+	from := bytes.Index(doc, []byte(start))
+	if from < 0 {
+		return nil, fmt.Errorf("%s not found", start)
+	}
+
+	end := bytes.Index(doc[from:], []byte("</"+name+">"))
+	if end < 0 {
+		return nil, fmt.Errorf("%s is not closed", name)
+	}
+
+	return doc[from : from+end+len("</"+name+">")], nil
+}
+
+// verifyXAdES checks the references and the signature value of a signed
+// document the way a validator does, from the canonical bytes.
+func verifyXAdES(signed []byte, certificate *x509.Certificate) error {
+	_, namespaces, err := rootElement(signed)
+	if err != nil {
+		return err
+	}
+
+	namespaces["xades"] = nsXades
+
+	signature, err := element(signed, "<ds:Signature ", "ds:Signature")
+	if err != nil {
+		return err
+	}
+
+	signedInfo, err := element(signature, "<ds:SignedInfo", "ds:SignedInfo")
+	if err != nil {
+		return err
+	}
+
+	var parsed struct {
+		SignedInfo struct {
+			References []struct {
+				URI    string `xml:"URI,attr"`
+				Digest string `xml:"DigestValue"`
+			} `xml:"Reference"`
+		} `xml:"SignedInfo"`
+		Value   string `xml:"SignatureValue"`
+		KeyInfo struct {
+			Certificate string `xml:"X509Data>X509Certificate"`
+		} `xml:"KeyInfo"`
+	}
+
+	if err := xml.Unmarshal(signature, &parsed); err != nil {
+		return err
+	}
+
+	if parsed.KeyInfo.Certificate != base64.StdEncoding.EncodeToString(certificate.Raw) {
+		return errors.New("KeyInfo does not hold the signing certificate")
+	}
+
+	if len(parsed.SignedInfo.References) != 3 {
+		return fmt.Errorf("got %d references, want the signed properties, the key info and the document", len(parsed.SignedInfo.References))
+	}
+
+	for _, reference := range parsed.SignedInfo.References {
+		var canonical []byte
+
+		switch {
+		case reference.URI == "":
+			canonical, err = canonicalize(bytes.Replace(signed, signature, nil, 1), nil)
+		case strings.HasPrefix(reference.URI, "#"):
+			var referenced []byte
+
+			id := ` Id="` + reference.URI[1:] + `"`
+			from := bytes.LastIndexByte(signature[:bytes.Index(signature, []byte(id))], '<')
+			name := string(signature[from+1 : from+bytes.IndexByte(signature[from:], ' ')])
+
+			referenced, err = element(signature[from:], "<"+name, name)
+			if err == nil {
+				canonical, err = canonicalize(referenced, namespaces)
+			}
+		default:
+			err = fmt.Errorf("unexpected reference %s", reference.URI)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if sum := sha256.Sum256(canonical); base64.StdEncoding.EncodeToString(sum[:]) != reference.Digest {
+			return fmt.Errorf("digest of reference %q does not match", reference.URI)
+		}
+	}
+
+	canonicalInfo, err := canonicalize(signedInfo, namespaces)
+	if err != nil {
+		return err
+	}
+
+	value, err := base64.StdEncoding.DecodeString(parsed.Value)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(canonicalInfo)
+
+	return rsa.VerifyPKCS1v15(certificate.PublicKey.(*rsa.PublicKey), crypto.SHA256, sum[:], value)
+}
+
+func TestSignXAdES(t *testing.T) {
+	signer := newTestSigner(t)
+	doc := newTestFacturae(t)
+	signingTime := time.Date(2025, 3, 14, 10, 30, 0, 0, time.UTC)
+
+	signed, err := signer.SignXAdES(doc, signingTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyXAdES(signed, signer.Certificate()); err != nil {
+		t.Fatalf("signature does not verify: %v\n%s", err, signed)
+	}
+
+	if !bytes.HasSuffix(bytes.TrimSpace(signed), []byte("</ds:Signature></fe:Facturae>")) {
+		t.Error("signature is not the last child of the root element")
+	}
+
+	for _, want := range []string{
+		"<xades:SigningTime>2025-03-14T10:30:00Z</xades:SigningTime>",
+		"<xades:Identifier>" + FacturaePolicyURL + "</xades:Identifier>",
+		"<ds:DigestValue>" + FacturaePolicyHash + "</ds:DigestValue>",
+		"<xades:ClaimedRole>emisor</xades:ClaimedRole>",
+		"<ds:X509SerialNumber>4711</ds:X509SerialNumber>",
+	} {
+		if !bytes.Contains(signed, []byte(want)) {
+			t.Errorf("signature has no %s", want)
+		}
+	}
+
+	tampered := bytes.Replace(signed, []byte("<InvoiceTotal>"), []byte("<InvoiceTotal>1"), 1)
+	if err := verifyXAdES(tampered, signer.Certificate()); err == nil {
+		t.Error("tampered document verifies")
+	}
+
+	second, err := signer.SignXAdES(doc, signingTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(signed, second) {
+		t.Error("two signatures share their identifiers")
+	}
+}
+
+func TestSignXAdESRequiresTheDsigNamespace(t *testing.T) {
+	if _, err := newTestSigner(t).SignXAdES([]byte(`<fe:Facturae xmlns:fe="urn:fe"></fe:Facturae>`), time.Now()); err == nil {
+		t.Error("signed a document without the xmldsig namespace")
+	}
+}
+
+func TestParsePKCS12(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParsePKCS12(newTestPKCS12(t, rsaKey, "secret"), "wrong"); err == nil {
+		t.Error("parsed a certificate with a wrong password")
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParsePKCS12(newTestPKCS12(t, ecKey, "secret"), "secret"); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("got %v, want ErrUnsupportedKey", err)
+	}
+}
@@ -0,0 +1,240 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// newTestDocument returns the document of an invoice with two VAT rates and
+// a withholding, the case the golden files cover.
+func newTestDocument(t *testing.T) *Document {
+	t.Helper()
+
+	invoice := newTestInvoice(21, 15)
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 2, Rate: 100})
+	invoice.AddItem(model.Item{Description: "Books", Quantity: 1, Rate: 50, Vat: 4})
+
+	doc, err := FromInvoice(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the output, run the tests with -update to refresh it:\n%s", path, got)
+	}
+}
+
+// amount parses a decimal amount of the XML, failing the test when it is not one.
+func amount(t *testing.T, s string) float64 {
+	t.Helper()
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		t.Fatalf("amount %q: %v", s, err)
+	}
+
+	return v
+}
+
+func TestMarshalUBL(t *testing.T) {
+	out, err := newTestDocument(t).MarshalUBL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "invoice.ubl.xml", out)
+
+	var parsed struct {
+		XMLName  xml.Name
+		TaxTotal struct {
+			TaxAmount   string `xml:"TaxAmount"`
+			TaxSubtotal []struct {
+				TaxableAmount string `xml:"TaxableAmount"`
+				TaxAmount     string `xml:"TaxAmount"`
+				Category      string `xml:"TaxCategory>ID"`
+				Percent       string `xml:"TaxCategory>Percent"`
+			} `xml:"TaxSubtotal"`
+		} `xml:"TaxTotal"`
+		Totals struct {
+			LineExtension string `xml:"LineExtensionAmount"`
+			TaxInclusive  string `xml:"TaxInclusiveAmount"`
+			Prepaid       string `xml:"PrepaidAmount"`
+			Payable       string `xml:"PayableAmount"`
+		} `xml:"LegalMonetaryTotal"`
+		Lines []struct {
+			Amount string `xml:"LineExtensionAmount"`
+		} `xml:"InvoiceLine"`
+	}
+
+	if err := xml.Unmarshal(out, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.XMLName.Space != "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2" || parsed.XMLName.Local != "Invoice" {
+		t.Errorf("got root %v, want a UBL Invoice", parsed.XMLName)
+	}
+
+	var vat, lines float64
+	for _, sub := range parsed.TaxTotal.TaxSubtotal {
+		vat += amount(t, sub.TaxAmount)
+	}
+
+	for _, line := range parsed.Lines {
+		lines += amount(t, line.Amount)
+	}
+
+	if len(parsed.TaxTotal.TaxSubtotal) != 2 || math.Abs(vat-amount(t, parsed.TaxTotal.TaxAmount)) > 0.001 {
+		t.Errorf("got subtotals %+v, want two adding up to %s", parsed.TaxTotal.TaxSubtotal, parsed.TaxTotal.TaxAmount)
+	}
+
+	if math.Abs(lines-amount(t, parsed.Totals.LineExtension)) > 0.001 {
+		t.Errorf("lines add up to %v, want %s", lines, parsed.Totals.LineExtension)
+	}
+
+	payable := amount(t, parsed.Totals.TaxInclusive) - amount(t, parsed.Totals.Prepaid)
+	if math.Abs(payable-amount(t, parsed.Totals.Payable)) > 0.001 {
+		t.Errorf("got payable %s, want %v", parsed.Totals.Payable, payable)
+	}
+}
+
+func TestMarshalCII(t *testing.T) {
+	out, err := newTestDocument(t).MarshalCII(ProfileEN16931)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "invoice.cii.xml", out)
+
+	var parsed struct {
+		XMLName   xml.Name
+		Guideline string `xml:"ExchangedDocumentContext>GuidelineSpecifiedDocumentContextParameter>ID"`
+		Trade     struct {
+			Lines      []struct{} `xml:"IncludedSupplyChainTradeLineItem"`
+			Settlement struct {
+				Taxes []struct {
+					Calculated string `xml:"CalculatedAmount"`
+					Basis      string `xml:"BasisAmount"`
+					Category   string `xml:"CategoryCode"`
+					Rate       string `xml:"RateApplicablePercent"`
+				} `xml:"ApplicableTradeTax"`
+				Summation struct {
+					TaxBasis string `xml:"TaxBasisTotalAmount"`
+					TaxTotal string `xml:"TaxTotalAmount"`
+					Grand    string `xml:"GrandTotalAmount"`
+					Prepaid  string `xml:"TotalPrepaidAmount"`
+					Due      string `xml:"DuePayableAmount"`
+				} `xml:"SpecifiedTradeSettlementHeaderMonetarySummation"`
+			} `xml:"ApplicableHeaderTradeSettlement"`
+		} `xml:"SupplyChainTradeTransaction"`
+	}
+
+	if err := xml.Unmarshal(out, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.XMLName.Local != "CrossIndustryInvoice" || parsed.Guideline != ProfileEN16931.GuidelineID() {
+		t.Errorf("got root %v and guideline %q, want an EN 16931 CrossIndustryInvoice", parsed.XMLName, parsed.Guideline)
+	}
+
+	settlement := parsed.Trade.Settlement
+	if len(parsed.Trade.Lines) != 2 || len(settlement.Taxes) != 2 {
+		t.Fatalf("got %d lines and %d taxes, want 2 of each", len(parsed.Trade.Lines), len(settlement.Taxes))
+	}
+
+	var vat, basis float64
+	for _, tax := range settlement.Taxes {
+		vat += amount(t, tax.Calculated)
+		basis += amount(t, tax.Basis)
+	}
+
+	summation := settlement.Summation
+	if math.Abs(vat-amount(t, summation.TaxTotal)) > 0.001 || math.Abs(basis-amount(t, summation.TaxBasis)) > 0.001 {
+		t.Errorf("taxes add up to %v on %v, want %s on %s", vat, basis, summation.TaxTotal, summation.TaxBasis)
+	}
+
+	due := amount(t, summation.Grand) - amount(t, summation.Prepaid)
+	if math.Abs(due-amount(t, summation.Due)) > 0.001 {
+		t.Errorf("got due %s, want %v", summation.Due, due)
+	}
+}
+
+// TestMarshalCIISchema validates the CII of every profile, of a credit note and
+// of an exempt invoice with xmllint against the Factur-X schema in testdata.
+func TestMarshalCIISchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not found")
+	}
+
+	creditNote := newTestInvoice(21, 0)
+	creditNote.Type = model.TypeCreditNote
+	creditNote.Corrects = "F25-000"
+	creditNote.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: 100})
+
+	exempt := newTestInvoice(0, 0)
+	exempt.From.Phone = "+34 600 000 000"
+	exempt.Payment.Swift = ""
+	exempt.AddItem(model.Item{Description: "Training", Quantity: 1, Rate: 300})
+
+	docs := map[string]*Document{"invoice": newTestDocument(t)}
+
+	for name, invoice := range map[string]*model.Invoice{"credit note": creditNote, "exempt": exempt} {
+		doc, err := FromInvoice(invoice)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		docs[name] = doc
+	}
+
+	schema := filepath.Join("testdata", "facturx", "FACTUR-X_EN16931.xsd")
+
+	for name, doc := range docs {
+		for _, profile := range GetSupportedProfiles() {
+			t.Run(name+"/"+string(profile), func(t *testing.T) {
+				out, err := doc.MarshalCII(profile)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				path := filepath.Join(t.TempDir(), "invoice.xml")
+				if err := os.WriteFile(path, out, 0o600); err != nil {
+					t.Fatal(err)
+				}
+
+				if msg, err := exec.Command(xmllint, "--noout", "--nonet", "--schema", schema, path).CombinedOutput(); err != nil {
+					t.Errorf("%v: %s\n%s", err, msg, out)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Package exchange reads the euro foreign exchange reference rates published
+// by the European Central Bank.
+package exchange
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// Sources of exchange rates.
+const (
+	SourceECB    = "ECB"
+	SourceManual = "manual"
+)
+
+var ErrNoRates = errors.New("exchange: no exchange rates found")
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB reads the reference rates of an ECB eurofxref file, daily or
+// historical.
+func ParseECB(data []byte) ([]model.ExchangeRate, error) {
+	var envelope ecbEnvelope
+
+	err := xml.Unmarshal(data, &envelope)
+	if err != nil {
+		return nil, fmt.Errorf("exchange: %w", err)
+	}
+
+	var rates []model.ExchangeRate
+
+	for _, day := range envelope.Days {
+		date, err := time.ParseInLocation(time.DateOnly, day.Time, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("exchange: %w", err)
+		}
+
+		for _, r := range day.Rates {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(r.Rate), 64)
+			if err != nil || rate <= 0 {
+				return nil, fmt.Errorf("exchange: rate %s of %s on %s not valid", r.Rate, r.Currency, day.Time)
+			}
+
+			rates = append(rates, model.ExchangeRate{
+				Currency: strings.ToUpper(r.Currency),
+				Date:     date,
+				Rate:     rate,
+				Source:   SourceECB,
+			})
+		}
+	}
+
+	if len(rates) == 0 {
+		return nil, ErrNoRates
+	}
+
+	return rates, nil
+}
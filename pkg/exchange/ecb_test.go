@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseECB(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2025-03-14">
+			<Cube currency="USD" rate="1.0879"/>
+			<Cube currency="GBP" rate="0.84"/>
+		</Cube>
+		<Cube time="2025-03-13">
+			<Cube currency="usd" rate="1.0833"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	rates, err := ParseECB([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rates) != 3 {
+		t.Fatalf("got %+v, want 3 rates", rates)
+	}
+
+	last := rates[2]
+	if last.Currency != "USD" || last.Rate != 1.0833 || last.Date.Day() != 13 || last.Source != SourceECB {
+		t.Errorf("got %+v", last)
+	}
+}
+
+func TestParseECBErrors(t *testing.T) {
+	if _, err := ParseECB([]byte(`<Envelope><Cube></Cube></Envelope>`)); !errors.Is(err, ErrNoRates) {
+		t.Errorf("got %v, want ErrNoRates", err)
+	}
+
+	if _, err := ParseECB([]byte(`<Envelope><Cube><Cube time="2025-03-14"><Cube currency="USD" rate="0"/></Cube></Cube></Envelope>`)); err == nil {
+		t.Error("got no error for a rate of 0")
+	}
+}
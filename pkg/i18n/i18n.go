@@ -70,10 +70,15 @@ func (t *translator) GetLanguage() Language {
 func (t *translator) loadTranslations() {
 	t.translations[English] = map[string]string{
 		// PDF Header
-		"invoice":      "Invoice",
-		"invoice_caps": "INVOICE",
-		"date":         "Date",
-		"due":          "Due",
+		"invoice":          "Invoice",
+		"invoice_caps":     "INVOICE",
+		"credit_note_caps": "CREDIT NOTE",
+		"corrects":         "Corrects",
+		"date":             "Date",
+		"due":              "Due",
+
+		// Verifactu
+		"verifactu_legend": "Invoice verifiable at the AEAT website",
 
 		// PDF Sections
 		"from": "From",
@@ -85,6 +90,8 @@ func (t *translator) loadTranslations() {
 		"rate":        "Rate",
 		"amount":      "Amount",
 
+		"prices_include_vat": "Prices include VAT, amounts shown net of VAT",
+
 		// Payment Section
 		"payment_info": "Payment Info",
 		"holder":       "Holder",
@@ -92,10 +99,15 @@ func (t *translator) loadTranslations() {
 		"swift":        "Swift",
 
 		// Totals
-		"subtotal": "Subtotal",
-		"vat":      "VAT",
-		"irpf":     "IRPF",
-		"total":    "Total",
+		"subtotal":        "Subtotal",
+		"tax_vat":         "VAT",
+		"tax_withholding": "Withholding",
+		"tax_surcharge":   "Surcharge",
+		"tax_sales_tax":   "Sales tax",
+		"total":           "Total",
+		"paid_on":         "Paid on",
+		"amount_due":      "Amount due",
+		"vat_in":          "VAT in",
 
 		// Status
 		"draft": "DRAFT",
@@ -108,10 +120,15 @@ func (t *translator) loadTranslations() {
 
 	t.translations[Spanish] = map[string]string{
 		// PDF Header
-		"invoice":      "Factura",
-		"invoice_caps": "FACTURA",
-		"date":         "Fecha",
-		"due":          "Vence",
+		"invoice":          "Factura",
+		"invoice_caps":     "FACTURA",
+		"credit_note_caps": "RECTIFICATIVA",
+		"corrects":         "Rectifica",
+		"date":             "Fecha",
+		"due":              "Vence",
+
+		// Verifactu
+		"verifactu_legend": "Factura verificable en la sede electrónica de la AEAT",
 
 		// PDF Sections
 		"from": "De",
@@ -123,6 +140,8 @@ func (t *translator) loadTranslations() {
 		"rate":        "Precio",
 		"amount":      "Importe",
 
+		"prices_include_vat": "Precios con IVA incluido, importes sin IVA",
+
 		// Payment Section
 		"payment_info": "Información de Pago",
 		"holder":       "Titular",
@@ -130,10 +149,15 @@ func (t *translator) loadTranslations() {
 		"swift":        "Swift",
 
 		// Totals
-		"subtotal": "Subtotal",
-		"vat":      "IVA",
-		"irpf":     "IRPF",
-		"total":    "Total",
+		"subtotal":        "Subtotal",
+		"tax_vat":         "IVA",
+		"tax_withholding": "IRPF",
+		"tax_surcharge":   "Rec. equiv.",
+		"tax_sales_tax":   "Imp. ventas",
+		"total":           "Total",
+		"paid_on":         "Pagada el",
+		"amount_due":      "Pendiente",
+		"vat_in":          "IVA en",
 
 		// Status
 		"draft": "BORRADOR",
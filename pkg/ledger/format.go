@@ -0,0 +1,174 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Journal formats.
+const (
+	FormatBeancount = "beancount"
+	FormatHledger   = "hledger"
+	FormatCSV       = "csv"
+)
+
+var ErrFormat = errors.New("ledger: unknown format, use beancount, hledger or csv")
+
+// Write formats the entries of a journal.
+func Write(format string, entries []Entry) ([]byte, error) {
+	switch format {
+	case FormatBeancount:
+		return Beancount(entries), nil
+	case FormatHledger, "ledger":
+		return Hledger(entries), nil
+	case FormatCSV:
+		return CSV(entries)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrFormat, format)
+}
+
+// Extension returns the usual file extension of a format.
+func Extension(format string) string {
+	switch format {
+	case FormatBeancount:
+		return ".beancount"
+	case FormatCSV:
+		return ".csv"
+	}
+
+	return ".journal"
+}
+
+// Beancount writes the entries as Beancount transactions, opening every
+// account on the date of the first entry. Invoice IDs are written as links
+// and payment references as metadata.
+func Beancount(entries []Entry) []byte {
+	var b bytes.Buffer
+
+	if len(entries) > 0 {
+		opened := entries[0].Date.Format(time.DateOnly)
+
+		for _, account := range accounts(entries) {
+			fmt.Fprintf(&b, "%s open %s\n", opened, account)
+		}
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n%s * %s %s", e.Date.Format(time.DateOnly), strconv.Quote(e.Payee), strconv.Quote(e.Narration))
+
+		for _, link := range e.Links {
+			fmt.Fprintf(&b, " ^%s", beancountLink(link))
+		}
+
+		b.WriteString("\n")
+
+		if e.Reference != "" {
+			fmt.Fprintf(&b, "  reference: %s\n", strconv.Quote(e.Reference))
+		}
+
+		for _, p := range e.Postings {
+			fmt.Fprintf(&b, "  %-40s %12.2f %s\n", p.Account, p.Amount, e.Currency)
+		}
+	}
+
+	return b.Bytes()
+}
+
+// Hledger writes the entries as hledger journal transactions, which ledger
+// reads too. Invoice IDs and payment references are written as tags.
+func Hledger(entries []Entry) []byte {
+	var b bytes.Buffer
+
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		tags := make([]string, 0, len(e.Links)+1)
+		for _, link := range e.Links {
+			tags = append(tags, "invoice: "+link)
+		}
+
+		if e.Reference != "" {
+			tags = append(tags, "reference: "+strings.ReplaceAll(e.Reference, ",", " "))
+		}
+
+		fmt.Fprintf(&b, "%s * %s | %s  ; %s\n", e.Date.Format(time.DateOnly), e.Payee, e.Narration, strings.Join(tags, ", "))
+
+		for _, p := range e.Postings {
+			fmt.Fprintf(&b, "    %-40s %12.2f %s\n", p.Account, p.Amount, e.Currency)
+		}
+	}
+
+	return b.Bytes()
+}
+
+// CSV writes the entries as a generic journal, a row for each posting with
+// its debit or credit.
+func CSV(entries []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+
+	err := w.Write([]string{"date", "entry", "kind", "invoice", "payee", "description", "account", "debit", "credit", "currency", "reference"})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range entries {
+		for _, p := range e.Postings {
+			debit, credit := "", ""
+			if p.Amount > 0 {
+				debit = strconv.FormatFloat(p.Amount, 'f', 2, 64)
+			} else {
+				credit = strconv.FormatFloat(-p.Amount, 'f', 2, 64)
+			}
+
+			err = w.Write([]string{
+				e.Date.Format(time.DateOnly), strconv.Itoa(i + 1), e.Kind, strings.Join(e.Links, " "), e.Payee, e.Narration,
+				p.Account, debit, credit, e.Currency, e.Reference,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// accounts returns the accounts used by the entries, sorted.
+func accounts(entries []Entry) []string {
+	var names []string
+
+	for _, e := range entries {
+		for _, p := range e.Postings {
+			names = append(names, p.Account)
+		}
+	}
+
+	slices.Sort(names)
+
+	return slices.Compact(names)
+}
+
+// beancountLink replaces the characters not allowed in Beancount links.
+func beancountLink(id string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_/.", r) {
+			return r
+		}
+
+		return '_'
+	}, id)
+}
@@ -0,0 +1,59 @@
+package ledger
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// golden compares got with the file name of testdata, rewriting it with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the output, run the tests with -update to refresh it:\n%s", path, got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	entries := Journal(newTestInvoices(), DefaultAccounts(), time.Time{}, time.Time{})
+
+	for _, format := range []string{FormatBeancount, FormatHledger, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			out, err := Write(format, entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden(t, "journal"+Extension(format), out)
+		})
+	}
+
+	if _, err := Write("gnucash", entries); !errors.Is(err, ErrFormat) {
+		t.Errorf("got %v, want ErrFormat", err)
+	}
+}
+
+func TestBeancountLink(t *testing.T) {
+	if got := beancountLink("F25/001 bis#2"); got != "F25/001_bis_2" {
+		t.Errorf("got %s, want F25/001_bis_2", got)
+	}
+}
@@ -0,0 +1,218 @@
+// Package ledger turns invoices and their payments into double-entry journal
+// entries for plain-text accounting.
+package ledger
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// Kinds of entries.
+const (
+	KindInvoice    = "invoice"
+	KindCreditNote = "credit_note"
+	KindPayment    = "payment"
+)
+
+// ClientPlaceholder is replaced by the ID of the client in the names of the
+// accounts, so every client gets its own receivable account.
+const ClientPlaceholder = "{client}"
+
+// Accounts maps the amounts of the invoices to accounts. Receivable and
+// Credit may hold ClientPlaceholder.
+type Accounts struct {
+	Receivable  string
+	Revenue     string
+	Vat         string
+	Surcharge   string
+	SalesTax    string
+	Withholding string
+	Bank        string
+	Cash        string
+	// Credit holds the overpayments kept as credit of the clients.
+	Credit string
+}
+
+// DefaultAccounts returns the accounts used when none is configured.
+func DefaultAccounts() Accounts {
+	return Accounts{
+		Receivable:  "Assets:Receivables:" + ClientPlaceholder,
+		Revenue:     "Income:Sales",
+		Vat:         "Liabilities:VAT",
+		Surcharge:   "Liabilities:VAT:Surcharge",
+		SalesTax:    "Liabilities:SalesTax",
+		Withholding: "Assets:Withholding",
+		Bank:        "Assets:Bank",
+		Cash:        "Assets:Cash",
+		Credit:      "Liabilities:ClientCredit:" + ClientPlaceholder,
+	}
+}
+
+// Posting is an amount booked to an account, positive for debits.
+type Posting struct {
+	Account string
+	Amount  float64
+}
+
+// Entry is a balanced journal entry. Links hold the IDs of the invoices it
+// comes from.
+type Entry struct {
+	Date      time.Time
+	Kind      string
+	Payee     string
+	Narration string
+	Currency  string
+	Reference string
+	Links     []string
+	Postings  []Posting
+}
+
+// Journal returns the entries of the issued invoices, credit notes and
+// payments dated between from and to, both included and ignored when zero,
+// oldest first. Payments are booked against the receivable of the invoice up
+// to its balance, the rest to the credit of the client; payments with the
+// credit method draw from that credit.
+func Journal(invoices []*model.Invoice, accounts Accounts, from, to time.Time) []Entry {
+	credited := map[string]float64{}
+
+	for _, invoice := range invoices {
+		if invoice.IsCreditNote() {
+			credited[invoice.Corrects] += invoice.Totals().Total
+		}
+	}
+
+	var entries []Entry
+
+	for _, invoice := range invoices {
+		entries = append(entries, invoiceEntry(invoice, accounts))
+
+		balance := invoice.Totals().Total - credited[invoice.ID]
+
+		for _, payment := range invoice.Payments {
+			entries = append(entries, paymentEntry(invoice, payment, balance, accounts))
+			balance -= payment.Amount
+		}
+	}
+
+	entries = slices.DeleteFunc(entries, func(e Entry) bool {
+		return (!from.IsZero() && model.Day(e.Date).Before(model.Day(from))) || (!to.IsZero() && model.Day(e.Date).After(model.Day(to)))
+	})
+
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return cmp.Or(model.Day(a.Date).Compare(model.Day(b.Date)), strings.Compare(a.Links[0], b.Links[0]))
+	})
+
+	return entries
+}
+
+func invoiceEntry(invoice *model.Invoice, accounts Accounts) Entry {
+	totals := invoice.Totals()
+	entry := Entry{
+		Date:      invoice.Date,
+		Kind:      KindInvoice,
+		Payee:     invoice.To.Name,
+		Narration: "Invoice " + invoice.ID,
+		Currency:  invoice.Currency,
+		Links:     []string{invoice.ID},
+	}
+
+	sign := 1.
+
+	if invoice.IsCreditNote() {
+		sign = -1
+		entry.Kind = KindCreditNote
+		entry.Narration = "Credit note " + invoice.ID + " of " + invoice.Corrects
+		entry.Links = append(entry.Links, invoice.Corrects)
+	}
+
+	entry.add(accountOf(accounts.Receivable, invoice), sign*totals.Total)
+	entry.add(accounts.Revenue, -sign*totals.Subtotal)
+
+	for _, tax := range totals.Taxes {
+		switch tax.Kind {
+		case model.TaxKindVat:
+			entry.add(accounts.Vat, -sign*tax.Amount)
+		case model.TaxKindSurcharge:
+			entry.add(accounts.Surcharge, -sign*tax.Amount)
+		case model.TaxKindSalesTax:
+			entry.add(accounts.SalesTax, -sign*tax.Amount)
+		case model.TaxKindWithholding:
+			entry.add(accounts.Withholding, -sign*tax.Amount)
+		}
+	}
+
+	return entry
+}
+
+func paymentEntry(invoice *model.Invoice, payment model.InvoicePayment, balance float64, accounts Accounts) Entry {
+	entry := Entry{
+		Date:      payment.Date,
+		Kind:      KindPayment,
+		Payee:     invoice.To.Name,
+		Narration: "Payment of " + invoice.ID,
+		Currency:  invoice.Currency,
+		Reference: payment.Reference,
+		Links:     []string{invoice.ID},
+	}
+
+	receivable := model.Round(min(payment.Amount, max(balance, 0)))
+
+	switch payment.Method {
+	case model.PaymentMethodCredit:
+		entry.add(accountOf(accounts.Credit, invoice), payment.Amount)
+	case model.PaymentMethodCash:
+		entry.add(accounts.Cash, payment.Amount)
+	default:
+		entry.add(accounts.Bank, payment.Amount)
+	}
+
+	entry.add(accountOf(accounts.Receivable, invoice), -receivable)
+	entry.add(accountOf(accounts.Credit, invoice), -(payment.Amount - receivable))
+
+	return entry
+}
+
+// add books an amount to an account, merging it with an earlier posting to
+// the same account. Zero amounts are left out.
+func (e *Entry) add(account string, amount float64) {
+	amount = model.Round(amount)
+	if amount == 0 {
+		return
+	}
+
+	i := slices.IndexFunc(e.Postings, func(p Posting) bool { return p.Account == account })
+	if i < 0 {
+		e.Postings = append(e.Postings, Posting{Account: account, Amount: amount})
+
+		return
+	}
+
+	e.Postings[i].Amount = model.Round(e.Postings[i].Amount + amount)
+}
+
+// accountOf returns an account with the client of an invoice in place of
+// ClientPlaceholder, capitalised as account names require.
+func accountOf(account string, invoice *model.Invoice) string {
+	if !strings.Contains(account, ClientPlaceholder) {
+		return account
+	}
+
+	client := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			return r
+		}
+
+		return '-'
+	}, invoice.To.ID)
+
+	if runes := []rune(client); len(runes) > 0 {
+		client = string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+
+	return strings.ReplaceAll(account, ClientPlaceholder, cmp.Or(client, "Unknown"))
+}
@@ -0,0 +1,177 @@
+package ledger
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2025, month, day, 10, 0, 0, 0, time.Local)
+}
+
+func newTestInvoice(id string, month time.Month, client string, rate float64) *model.Invoice {
+	invoice := model.NewInvoice(id, 30*24*time.Hour, "EUR", "", "")
+	invoice.Status = model.StatusIssued
+	invoice.Date = date(month, 1)
+	invoice.To = model.Client{ID: client, Name: client + " S.L."}
+	invoice.SetTaxes(21, 0, map[string]string{})
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: rate})
+
+	return invoice
+}
+
+// newTestInvoices returns an invoice with IRPF withheld, paid in excess, one
+// with the equivalence surcharge paid from that credit and a credit note.
+func newTestInvoices() []*model.Invoice {
+	withheld := newTestInvoice("F25-001", time.March, "acme corp", 1000)
+	withheld.SetTaxes(21, 15, map[string]string{})
+	withheld.Payments = []model.InvoicePayment{
+		{Date: date(time.March, 20), Amount: 1000, Method: model.PaymentMethodTransfer, Reference: "TR-1, 2"},
+		{Date: date(time.March, 25), Amount: 100, Method: model.PaymentMethodCash},
+	}
+
+	surcharge := newTestInvoice("F25-002", time.April, "shop", 100)
+	surcharge.Tax.Taxes = append(surcharge.Tax.Taxes, model.NewTax(model.TaxKindSurcharge, 5.2))
+	surcharge.Payments = []model.InvoicePayment{
+		{Date: date(time.April, 2), Amount: 40, Method: model.PaymentMethodCredit},
+		{Date: date(time.April, 30), Amount: 86.2, Method: model.PaymentMethodTransfer},
+	}
+
+	corrected := newTestInvoice("F25-003", time.April, "acme corp", 200)
+	credit := newTestInvoice("F25-004", time.May, "acme corp", 50)
+	credit.Type = model.TypeCreditNote
+	credit.Corrects = corrected.ID
+	corrected.Payments = []model.InvoicePayment{{Date: date(time.May, 10), Amount: 242, Method: model.PaymentMethodTransfer}}
+
+	return []*model.Invoice{credit, corrected, surcharge, withheld}
+}
+
+func TestJournalBalances(t *testing.T) {
+	entries := Journal(newTestInvoices(), DefaultAccounts(), time.Time{}, time.Time{})
+
+	if len(entries) != 9 {
+		t.Fatalf("got %d entries, want 4 invoices and 5 payments", len(entries))
+	}
+
+	for i, e := range entries {
+		if i > 0 && e.Date.Before(entries[i-1].Date) {
+			t.Errorf("entry %s of %v is after a later one", e.Narration, e.Date)
+		}
+
+		sum := 0.
+		for _, p := range e.Postings {
+			sum += p.Amount
+		}
+
+		if model.Round(sum) != 0 {
+			t.Errorf("%s does not balance, its postings add up to %v: %+v", e.Narration, sum, e.Postings)
+		}
+	}
+}
+
+func TestJournalPostings(t *testing.T) {
+	entries := Journal(newTestInvoices(), DefaultAccounts(), time.Time{}, time.Time{})
+
+	tests := []struct {
+		narration string
+		kind      string
+		want      []Posting
+	}{
+		{
+			narration: "Invoice F25-001",
+			kind:      KindInvoice,
+			want: []Posting{
+				{"Assets:Receivables:Acme-corp", 1060}, {"Income:Sales", -1000}, {"Liabilities:VAT", -210}, {"Assets:Withholding", 150},
+			},
+		},
+		{
+			narration: "Payment of F25-001",
+			kind:      KindPayment,
+			want:      []Posting{{"Assets:Bank", 1000}, {"Assets:Receivables:Acme-corp", -1000}},
+		},
+		{
+			narration: "Payment of F25-001",
+			kind:      KindPayment,
+			want:      []Posting{{"Assets:Cash", 100}, {"Assets:Receivables:Acme-corp", -60}, {"Liabilities:ClientCredit:Acme-corp", -40}},
+		},
+		{
+			narration: "Invoice F25-002",
+			kind:      KindInvoice,
+			want: []Posting{
+				{"Assets:Receivables:Shop", 126.2}, {"Income:Sales", -100}, {"Liabilities:VAT", -21}, {"Liabilities:VAT:Surcharge", -5.2},
+			},
+		},
+		{
+			narration: "Payment of F25-002",
+			kind:      KindPayment,
+			want:      []Posting{{"Liabilities:ClientCredit:Shop", 40}, {"Assets:Receivables:Shop", -40}},
+		},
+		{
+			narration: "Credit note F25-004 of F25-003",
+			kind:      KindCreditNote,
+			want:      []Posting{{"Assets:Receivables:Acme-corp", -60.5}, {"Income:Sales", 50}, {"Liabilities:VAT", 10.5}},
+		},
+		{
+			// The credit note leaves 181.50 owed, the rest is credit.
+			narration: "Payment of F25-003",
+			kind:      KindPayment,
+			want:      []Posting{{"Assets:Bank", 242}, {"Assets:Receivables:Acme-corp", -181.5}, {"Liabilities:ClientCredit:Acme-corp", -60.5}},
+		},
+	}
+
+	seen := map[string]int{}
+
+	for _, tt := range tests {
+		n := seen[tt.narration]
+		seen[tt.narration]++
+
+		var found *Entry
+
+		for i := range entries {
+			if entries[i].Narration != tt.narration {
+				continue
+			}
+
+			if n == 0 {
+				found = &entries[i]
+
+				break
+			}
+
+			n--
+		}
+
+		if found == nil {
+			t.Errorf("no entry %s", tt.narration)
+
+			continue
+		}
+
+		if found.Kind != tt.kind || !slices.Equal(found.Postings, tt.want) {
+			t.Errorf("got %s %s %+v, want %s %+v", found.Kind, tt.narration, found.Postings, tt.kind, tt.want)
+		}
+	}
+}
+
+func TestJournalPeriod(t *testing.T) {
+	entries := Journal(newTestInvoices(), DefaultAccounts(), date(time.March, 25), date(time.April, 2))
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Date.Format(time.DateOnly)+" "+e.Narration)
+	}
+
+	want := []string{
+		"2025-03-25 Payment of F25-001",
+		"2025-04-01 Invoice F25-002",
+		"2025-04-01 Invoice F25-003",
+		"2025-04-02 Payment of F25-002",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
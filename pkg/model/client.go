@@ -1,9 +1,47 @@
 package model
 
+import "time"
+
 type Client struct {
 	ID       string `json:"id" yaml:"id"`
 	Name     string `json:"name" yaml:"name"`
 	VatID    string `json:"vat_id" yaml:"vat_id"`
 	Address1 string `json:"address1" yaml:"address1"`
 	Address2 string `json:"address2" yaml:"address2"`
+
+	// Dir3 is only set for Spanish public administrations, which receive
+	// invoices through FACe.
+	Dir3 *Dir3 `json:"dir3,omitempty" yaml:"dir3,omitempty"`
+
+	// Vies is the last check of the VAT number in VIES, nil if it was never
+	// checked.
+	Vies *ViesCheck `json:"vies,omitempty" yaml:"vies,omitempty"`
+
+	// Credit is the amount overpaid by the client, which can pay later
+	// invoices with the credit payment method.
+	Credit float64 `json:"credit,omitempty" yaml:"credit,omitempty"`
+}
+
+// IntraCommunity reports whether the last VIES check found the VAT number
+// active for intra-community trade, which makes reverse charge apply.
+func (c *Client) IntraCommunity() bool {
+	return c.Vies != nil && c.Vies.Valid
+}
+
+// ViesCheck is the answer of VIES, the EU VAT Information Exchange System,
+// about a VAT number. ConsultationNumber proves the check was made and is only
+// given when the request carries the VAT number of the requester.
+type ViesCheck struct {
+	Valid              bool      `json:"valid" yaml:"valid"`
+	CheckedAt          time.Time `json:"checked_at" yaml:"checked_at"`
+	ConsultationNumber string    `json:"consultation_number,omitempty" yaml:"consultation_number,omitempty"`
+	Name               string    `json:"name,omitempty" yaml:"name,omitempty"`
+	Address            string    `json:"address,omitempty" yaml:"address,omitempty"`
+}
+
+// Dir3 holds the DIR3 codes of the administrative centres receiving an invoice.
+type Dir3 struct {
+	AccountingOffice string `json:"accounting_office" yaml:"accounting_office"`
+	ManagementBody   string `json:"management_body" yaml:"management_body"`
+	ProcessingUnit   string `json:"processing_unit" yaml:"processing_unit"`
 }
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 var currencySymbols = map[string]string{
 	"USD": "$",
 	"EUR": "€",
@@ -16,3 +18,66 @@ var currencySymbols = map[string]string{
 func GetCurrencySymbol(code string) string {
 	return currencySymbols[code]
 }
+
+// ExchangeBase is the currency exchange rates are quoted against, as in the
+// euro foreign exchange reference rates of the ECB.
+const ExchangeBase = "EUR"
+
+// ExchangeRate is the value of one euro in a currency on a day.
+type ExchangeRate struct {
+	Currency string    `json:"currency" yaml:"currency"`
+	Date     time.Time `json:"date" yaml:"date"`
+	Rate     float64   `json:"rate" yaml:"rate"`
+	// Source is "ECB" for reference rates and "manual" for rates entered by
+	// hand.
+	Source string `json:"source" yaml:"source"`
+}
+
+// InvoiceExchange is the exchange rate of an invoice in a foreign currency,
+// taken when it is issued. Rate is the value of one unit of the currency of
+// the invoice in the home currency, To, on Date, the day the rate was
+// published.
+type InvoiceExchange struct {
+	From string    `json:"from" yaml:"from"`
+	To   string    `json:"to" yaml:"to"`
+	Rate float64   `json:"rate" yaml:"rate"`
+	Date time.Time `json:"date" yaml:"date"`
+}
+
+// InHome returns a copy of the invoice with its amounts in the home currency
+// of its exchange rate, or the invoice itself when it has none. As required
+// for VAT, the taxable amount of each item is converted and the taxes are
+// computed on the converted amounts.
+func (i *Invoice) InHome() *Invoice {
+	x := i.Exchange
+	if x == nil {
+		return i
+	}
+
+	home := *i
+	home.Currency = x.To
+	home.Items = make([]*Item, 0, len(i.Items))
+	home.Tax.Taxes = make([]Tax, 0, len(i.Tax.Taxes))
+	home.Payments = make([]InvoicePayment, 0, len(i.Payments))
+
+	for _, item := range i.Items {
+		home.Items = append(home.Items, &Item{
+			Description: item.Description,
+			Quantity:    1,
+			Vat:         item.Vat,
+			Rate:        Round(Round(item.GetAmount()) * x.Rate),
+		})
+	}
+
+	for _, tax := range i.Tax.Taxes {
+		tax.Base = Round(tax.Base * x.Rate)
+		home.Tax.Taxes = append(home.Tax.Taxes, tax)
+	}
+
+	for _, payment := range i.Payments {
+		payment.Amount = Round(payment.Amount * x.Rate)
+		home.Payments = append(home.Payments, payment)
+	}
+
+	return &home
+}
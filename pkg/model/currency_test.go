@@ -0,0 +1,49 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInHome(t *testing.T) {
+	invoice := NewInvoice("F25-001", 0, "USD", "", "")
+	invoice.SetTaxes(21, 15, map[string]string{})
+	invoice.AddItem(Item{Description: "Consulting", Quantity: 3, Rate: 33.33})
+	invoice.AddItem(Item{Description: "Hosting", Quantity: 1, Rate: 10.01})
+	invoice.Tax.Taxes = append(invoice.Tax.Taxes, Tax{Kind: TaxKindVat, Rate: 10, Base: 10.01, Sign: 1})
+	invoice.Payments = []InvoicePayment{{Date: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), Amount: 100, Method: "transfer"}}
+
+	if invoice.InHome() != invoice {
+		t.Fatal("got a copy of an invoice without an exchange rate")
+	}
+
+	invoice.Exchange = &InvoiceExchange{From: "USD", To: "EUR", Rate: 0.925926}
+	home := invoice.InHome()
+
+	if home.Currency != "EUR" || invoice.Currency != "USD" {
+		t.Errorf("got currencies %s and %s, want the copy in EUR and the invoice in USD", home.Currency, invoice.Currency)
+	}
+
+	// 99.99 * 0.925926 = 92.582... and 10.01 * 0.925926 = 9.268...
+	if len(home.Items) != 2 || home.Items[0].GetAmount() != 92.58 || home.Items[1].GetAmount() != 9.27 {
+		t.Errorf("got items %+v, want the amounts 92.58 and 9.27", home.Items)
+	}
+
+	if invoice.Items[0].Rate != 33.33 || invoice.Items[0].Quantity != 3 {
+		t.Errorf("got item %+v, want the item of the invoice unchanged", invoice.Items[0])
+	}
+
+	totals := home.Totals()
+	if totals.Subtotal != 101.85 || totals.Vat != 22.32 || totals.Retention != 15.28 {
+		t.Errorf("got subtotal %v, VAT %v, retention %v; want the taxes on the converted base", totals.Subtotal, totals.Vat, totals.Retention)
+	}
+
+	last := home.Tax.Taxes[len(home.Tax.Taxes)-1]
+	if last.Base != 9.27 || invoice.Tax.Taxes[len(invoice.Tax.Taxes)-1].Base != 10.01 {
+		t.Errorf("got base %v, want 9.27 and the one of the invoice unchanged", last.Base)
+	}
+
+	if home.Payments[0].Amount != 92.59 || invoice.Payments[0].Amount != 100 {
+		t.Errorf("got payment %v, want 92.59 and the one of the invoice unchanged", home.Payments[0].Amount)
+	}
+}
@@ -8,4 +8,18 @@ type Freelancer struct {
 	VatID    string `json:"vat_id" yaml:"vat_id"`
 	Address1 string `json:"address1" yaml:"address1"`
 	Address2 string `json:"address2" yaml:"address2"`
+
+	// Address is the structured form of the address, needed by payment slips
+	// such as the Swiss QR-bill.
+	Address *PostalAddress `json:"address,omitempty" yaml:"address,omitempty"`
+}
+
+// PostalAddress is an address split into its parts. Country is the ISO 3166-1
+// alpha-2 code.
+type PostalAddress struct {
+	Street         string `json:"street" yaml:"street"`
+	BuildingNumber string `json:"building_number" yaml:"building_number"`
+	PostCode       string `json:"post_code" yaml:"post_code"`
+	Town           string `json:"town" yaml:"town"`
+	Country        string `json:"country" yaml:"country"`
 }
@@ -8,18 +8,52 @@ const (
 	DefaultDueSpan = 30
 )
 
+const (
+	TypeInvoice    = "invoice"
+	TypeCreditNote = "credit_note"
+)
+
+const (
+	StatusCreated       = "CREATED"
+	StatusIssued        = "ISSUED"
+	StatusPartiallyPaid = "PARTIALLY_PAID"
+	StatusPaid          = "PAID"
+	StatusCancelled     = "CANCELLED"
+)
+
 type Item struct {
 	Description string  `json:"description" yaml:"description"`
 	Quantity    int     `json:"quantity" yaml:"quantity"`
 	Vat         float64 `json:"vat" yaml:"vat"`
 	Rate        float64 `json:"rate" yaml:"rate"`
+	// Gross is the unit price including VAT of items priced gross, in which
+	// case Rate is the net unit price derived from it.
+	Gross float64 `json:"gross,omitempty" yaml:"gross,omitempty"`
 }
 
+// GetAmount returns the net amount of the item. The net amount of items priced
+// gross is derived from the gross amount of the whole line, rounded to cents,
+// so it does not carry the rounding of the unit price.
 func (i *Item) GetAmount() float64 {
+	if i.Gross != 0 {
+		return Round(float64(i.Quantity) * i.Gross / (1 + i.Vat/100)) //nolint:mnd //static percentage calculation
+	}
+
 	return float64(i.Quantity) * i.Rate
 }
 
+// IsGross reports whether the item is priced including VAT.
+func (i *Item) IsGross() bool {
+	return i.Gross != 0
+}
+
+// GetVat returns the VAT of the item, the gross amount of the line less its
+// net amount for items priced gross.
 func (i *Item) GetVat() float64 {
+	if i.Gross != 0 {
+		return Round(float64(i.Quantity)*i.Gross) - i.GetAmount()
+	}
+
 	return i.GetAmount() * i.Vat / 100 //nolint:mnd //static percentage calculation
 }
 
@@ -51,24 +85,17 @@ type Payment struct {
 	Swift  string `json:"swift" yaml:"swift"`
 }
 
-type TaxInfo struct {
-	Vat       float64 `json:"vat" yaml:"vat"`
-	Retention float64 `json:"retention" yaml:"retention"`
-}
-
-func (t TaxInfo) VatAmount(amount float64) float64 {
-	return amount * t.Vat / 100 //nolint:mnd //calculating percentage
-}
-
-func (t TaxInfo) RetentionAmount(amount float64) float64 {
-	return amount * t.Retention / 100 //nolint:mnd //calculating percentage
-}
-
 type Invoice struct {
 	ID     string `json:"id" yaml:"id"`
 	Status string `json:"status" yaml:"status"`
 	Logo   string `json:"logo" yaml:"logo"`
 
+	// Type is empty or TypeInvoice for invoices and TypeCreditNote for credit
+	// notes, which reference the invoice they correct in Corrects.
+	Type      string `json:"type,omitempty" yaml:"type,omitempty"`
+	Corrects  string `json:"corrects,omitempty" yaml:"corrects,omitempty"`
+	Reference string `json:"reference,omitempty" yaml:"reference,omitempty"`
+
 	From Freelancer `json:"from" yaml:"from"`
 	To   Client     `json:"to" yaml:"to"`
 
@@ -80,10 +107,18 @@ type Invoice struct {
 	Tax      TaxInfo `json:"tax" yaml:"tax"`
 	Discount float64 `json:"discount" yaml:"discount"`
 	Currency string  `json:"currency" yaml:"currency"`
+	// Exchange is set when an invoice in a foreign currency is issued.
+	Exchange *InvoiceExchange `json:"exchange,omitempty" yaml:"exchange,omitempty"`
 
 	Payment Payment `json:"payment" yaml:"payment"`
+	// Payments are the payments received, which move the status of the invoice
+	// to partially paid or paid.
+	Payments []InvoicePayment `json:"payments,omitempty" yaml:"payments,omitempty"`
 
 	Notes Notes `json:"notes" yaml:"notes"`
+
+	// Verifactu is set when the invoice is issued with Verifactu enabled.
+	Verifactu *InvoiceVerifactu `json:"verifactu,omitempty" yaml:"verifactu,omitempty"`
 }
 
 func NewInvoice(id string, due time.Duration, currency, note, noDueNote string) *Invoice {
@@ -94,38 +129,89 @@ func NewInvoice(id string, due time.Duration, currency, note, noDueNote string)
 
 	return &Invoice{
 		ID:       id,
-		Status:   "CREATED",
+		Status:   StatusCreated,
 		Date:     time.Now(),
 		Due:      due,
 		Items:    []*Item{},
-		Tax:      TaxInfo{},
+		Tax:      TaxInfo{Taxes: []Tax{}},
 		Discount: 0,
 		Currency: currency,
 		Notes:    notes,
 	}
 }
 
+// SetTaxes replaces the taxes of the invoice by its VAT and withholding rates
+// and sets their notes.
 func (i *Invoice) SetTaxes(vat, retention float64, configNotes map[string]string) {
-	i.Tax = TaxInfo{
-		Vat:       vat,
-		Retention: retention,
-	}
+	i.Tax = TaxInfo{Taxes: legacyTaxes(vat, retention)}
 
-	i.Tax.Vat = vat
 	if vat == 0 {
 		i.Notes.Vat0 = configNotes["vat_0"]
 	}
 
-	i.Tax.Retention = retention
 	if retention != 0 {
 		i.Notes.RetentionNot0 = configNotes["retention_not_0"]
 	}
 }
 
+// AddTax adds a tax such as the equivalence surcharge to the invoice.
+func (i *Invoice) AddTax(tax Tax) {
+	i.Tax.Taxes = append(i.Tax.Taxes, tax)
+}
+
+// IsIssued reports whether the invoice has been issued, after which it can not
+// be modified.
+func (i *Invoice) IsIssued() bool {
+	return i.IsBooked() || i.Status == StatusCancelled
+}
+
+// IsBooked reports whether the invoice has been issued and not cancelled,
+// whether it is paid or not, so it counts for taxes and revenue.
+func (i *Invoice) IsBooked() bool {
+	return i.Status == StatusIssued || i.Status == StatusPartiallyPaid || i.Status == StatusPaid
+}
+
+// IncludesVat reports whether any price of the invoice includes VAT.
+func (i *Invoice) IncludesVat() bool {
+	if i.Tax.Inclusive {
+		return true
+	}
+
+	for _, item := range i.Items {
+		if item.IsGross() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsCreditNote reports whether the invoice is a credit note.
+func (i *Invoice) IsCreditNote() bool {
+	return i.Type == TypeCreditNote
+}
+
+// AddItem adds an item to the invoice. Items take the VAT rate of the invoice
+// when they have none, and the rate of items added to invoices priced
+// including VAT is taken as gross.
 func (i *Invoice) AddItem(item Item) {
 	if item.Vat == 0 {
-		item.Vat = i.Tax.Vat
+		item.Vat = i.Tax.VatRate()
+	}
+
+	if i.Tax.Inclusive && item.Gross == 0 {
+		item.Gross = item.Rate
+	}
+
+	if item.Gross != 0 {
+		item.Rate = Round(item.Gross / (1 + item.Vat/100)) //nolint:mnd //static percentage calculation
 	}
 
 	i.Items = append(i.Items, &item)
 }
+
+// Day returns the calendar day of a time as midnight UTC, so times of the same
+// day compare equal whatever their location.
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
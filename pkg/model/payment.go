@@ -0,0 +1,71 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Payment methods of recorded payments. PaymentMethodCredit pays from the
+// credit left to the client by earlier overpayments.
+const (
+	PaymentMethodTransfer    = "transfer"
+	PaymentMethodDirectDebit = "direct_debit"
+	PaymentMethodCard        = "card"
+	PaymentMethodCash        = "cash"
+	PaymentMethodCredit      = "credit"
+	PaymentMethodOther       = "other"
+)
+
+// PaymentMethods lists the accepted payment methods.
+var PaymentMethods = []string{
+	PaymentMethodTransfer,
+	PaymentMethodDirectDebit,
+	PaymentMethodCard,
+	PaymentMethodCash,
+	PaymentMethodCredit,
+	PaymentMethodOther,
+}
+
+// InvoicePayment is a payment received for an invoice. Amount is the whole
+// amount received, including any part of it kept as client credit.
+type InvoicePayment struct {
+	Date      time.Time `json:"date" yaml:"date"`
+	Amount    float64   `json:"amount" yaml:"amount"`
+	Method    string    `json:"method" yaml:"method"`
+	Reference string    `json:"reference,omitempty" yaml:"reference,omitempty"`
+}
+
+// IsPaymentMethod reports whether method is one of PaymentMethods.
+func IsPaymentMethod(method string) bool {
+	return slices.Contains(PaymentMethods, method)
+}
+
+// Paid returns the sum of the payments of the invoice.
+func (i *Invoice) Paid() float64 {
+	paid := 0.
+	for _, payment := range i.Payments {
+		paid += payment.Amount
+	}
+
+	return Round(paid)
+}
+
+// Balance returns the total of the invoice less its payments. It is negative
+// when the client paid more than the total.
+func (i *Invoice) Balance() float64 {
+	return Round(i.Totals().Total - i.Paid())
+}
+
+// PaidOn returns the date of the last payment of the invoice, the zero time
+// when it has none.
+func (i *Invoice) PaidOn() time.Time {
+	var last time.Time
+
+	for _, payment := range i.Payments {
+		if payment.Date.After(last) {
+			last = payment.Date
+		}
+	}
+
+	return last
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+// Kinds of tax an invoice can carry.
+const (
+	TaxKindVat = "vat"
+	// TaxKindWithholding is an income tax withheld by the client, such as the
+	// Spanish IRPF.
+	TaxKindWithholding = "withholding"
+	// TaxKindSurcharge is the Spanish equivalence surcharge (recargo de
+	// equivalencia) charged to retailers on top of VAT.
+	TaxKindSurcharge = "surcharge"
+	// TaxKindSalesTax is a local sales tax, such as a US state sales tax.
+	TaxKindSalesTax = "sales_tax"
+)
+
+// Tax is one of the taxes of an invoice.
+type Tax struct {
+	Kind string  `json:"kind" yaml:"kind"`
+	Rate float64 `json:"rate" yaml:"rate"`
+	// Base is the taxable amount when the tax only applies to part of the
+	// invoice, 0 for the subtotal.
+	Base float64 `json:"base,omitempty" yaml:"base,omitempty"`
+	// Sign is 1 for taxes added to the total and -1 for taxes withheld from
+	// it.
+	Sign int `json:"sign" yaml:"sign"`
+}
+
+// NewTax returns a tax of a kind with its usual sign: withholdings are
+// subtracted from the total and every other tax is added.
+func NewTax(kind string, rate float64) Tax {
+	sign := 1
+	if kind == TaxKindWithholding {
+		sign = -1
+	}
+
+	return Tax{Kind: kind, Rate: rate, Sign: sign}
+}
+
+// TaxAmount is a tax with the amount it adds to the invoice total, negative
+// for withholdings.
+type TaxAmount struct {
+	Tax
+
+	Base   float64
+	Amount float64
+}
+
+// Compute returns the tax on subtotal, or on its own base when set.
+func (t Tax) Compute(subtotal float64) TaxAmount {
+	base := subtotal
+	if t.Base != 0 {
+		base = t.Base
+	}
+
+	sign := float64(t.Sign)
+	if sign == 0 {
+		sign = float64(NewTax(t.Kind, 0).Sign)
+	}
+
+	return TaxAmount{
+		Tax:    t,
+		Base:   base,
+		Amount: sign * Round(base*t.Rate/100), //nolint:mnd //calculating percentage
+	}
+}
+
+type TaxInfo struct {
+	Taxes []Tax `json:"taxes" yaml:"taxes"`
+
+	// Treatment is the VAT treatment picked by the tax rules, such as
+	// "reverse_charge", and Country the country whose VAT is charged. Both
+	// are empty when the VAT rate was set by hand.
+	Treatment string `json:"treatment,omitempty" yaml:"treatment,omitempty"`
+	Country   string `json:"country,omitempty" yaml:"country,omitempty"`
+	// Supply is the kind of supply the rules were applied to, such as
+	// "goods", empty for services.
+	Supply string `json:"supply,omitempty" yaml:"supply,omitempty"`
+
+	// Inclusive is set when the prices of the items include VAT.
+	Inclusive bool `json:"inclusive,omitempty" yaml:"inclusive,omitempty"`
+}
+
+// UnmarshalJSON maps invoices stored with a single "vat" and "retention" rate
+// onto the list of taxes.
+func (t *TaxInfo) UnmarshalJSON(data []byte) error {
+	type plain TaxInfo
+
+	stored := struct {
+		*plain
+
+		Vat       float64 `json:"vat"`
+		Retention float64 `json:"retention"`
+	}{plain: (*plain)(t)}
+
+	err := json.Unmarshal(data, &stored)
+	if err != nil {
+		return err
+	}
+
+	if t.Taxes == nil {
+		t.Taxes = legacyTaxes(stored.Vat, stored.Retention)
+	}
+
+	return nil
+}
+
+// VatRate returns the VAT rate of the invoice, 0 when it has no VAT.
+func (t TaxInfo) VatRate() float64 {
+	return t.Rate(TaxKindVat)
+}
+
+// WithholdingRate returns the rate withheld by the client, 0 when none.
+func (t TaxInfo) WithholdingRate() float64 {
+	return t.Rate(TaxKindWithholding)
+}
+
+// Rate returns the rate of the first tax of a kind, 0 when there is none.
+func (t TaxInfo) Rate(kind string) float64 {
+	for _, tax := range t.Taxes {
+		if tax.Kind == kind {
+			return tax.Rate
+		}
+	}
+
+	return 0
+}
+
+// legacyTaxes are the taxes of the single VAT and retention rates, the VAT
+// always listed even at 0% as it was printed.
+func legacyTaxes(vat, retention float64) []Tax {
+	taxes := []Tax{NewTax(TaxKindVat, vat)}
+	if retention != 0 {
+		taxes = append(taxes, NewTax(TaxKindWithholding, retention))
+	}
+
+	return taxes
+}
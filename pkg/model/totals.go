@@ -0,0 +1,129 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	centsFactor = 100
+)
+
+// Totals holds the rounded monetary totals of an invoice. Vat and Retention
+// are the sums of the VAT and withholding taxes, the latter as a positive
+// amount, and Taxes lists every tax in the order of the invoice.
+type Totals struct {
+	Subtotal  float64
+	Vat       float64
+	Retention float64
+	Total     float64
+	Taxes     []TaxAmount
+}
+
+// Of returns the sum of the taxes of a kind, negative for withholdings.
+func (t Totals) Of(kind string) float64 {
+	sum := 0.
+
+	for _, tax := range t.Taxes {
+		if tax.Kind == kind {
+			sum += tax.Amount
+		}
+	}
+
+	return Round(sum)
+}
+
+// Round rounds an amount to cents.
+func Round(amount float64) float64 {
+	return math.Round(amount*centsFactor) / centsFactor
+}
+
+// Subtotal returns the sum of the item amounts, rounded to cents.
+func (i *Invoice) Subtotal() float64 {
+	subtotal := 0.
+
+	for _, item := range i.Items {
+		subtotal += Round(item.GetAmount())
+	}
+
+	return Round(subtotal)
+}
+
+// Totals computes the invoice totals. Every document that shows amounts should
+// rely on it so the figures match across formats.
+func (i *Invoice) Totals() Totals {
+	totals := Totals{
+		Subtotal: i.Subtotal(),
+		Taxes:    make([]TaxAmount, 0, len(i.Tax.Taxes)),
+	}
+
+	total := totals.Subtotal
+
+	for _, tax := range i.Tax.Taxes {
+		amounts := []TaxAmount{tax.Compute(totals.Subtotal)}
+		if tax.Kind == TaxKindVat && tax.Base == 0 {
+			amounts = i.vatByRate(tax)
+		}
+
+		for _, amount := range amounts {
+			totals.Taxes = append(totals.Taxes, amount)
+			total += amount.Amount
+		}
+	}
+
+	totals.Vat = totals.Of(TaxKindVat)
+	// subtracting from 0 keeps invoices without withholding at 0, not -0
+	totals.Retention = 0 - totals.Of(TaxKindWithholding)
+	totals.Total = Round(total)
+
+	return totals
+}
+
+// vatByRate computes the VAT of the items grouped by their rate, in the order
+// the rates first appear. The VAT of items priced gross is their gross amount
+// less their net amount, so the gross amounts entered are kept exactly; the
+// VAT of the other items is computed on the sum of their net amounts. An
+// invoice without items has the VAT of the invoice, at 0.
+func (i *Invoice) vatByRate(vat Tax) []TaxAmount {
+	if len(i.Items) == 0 {
+		return []TaxAmount{vat.Compute(0)}
+	}
+
+	var amounts []TaxAmount
+
+	type group struct{ base, net, inclusive float64 }
+
+	groups := map[float64]*group{}
+
+	for _, item := range i.Items {
+		g, ok := groups[item.Vat]
+		if !ok {
+			g = &group{}
+			groups[item.Vat] = g
+
+			amounts = append(amounts, TaxAmount{Tax: Tax{Kind: vat.Kind, Rate: item.Vat, Sign: vat.Sign}})
+		}
+
+		amount := Round(item.GetAmount())
+		g.base += amount
+
+		if item.IsGross() {
+			g.inclusive += Round(float64(item.Quantity)*item.Gross) - amount
+		} else {
+			g.net += amount
+		}
+	}
+
+	for n := range amounts {
+		g := groups[amounts[n].Rate]
+		amounts[n].Base = Round(g.base)
+		amounts[n].Amount = Round(Round(g.net*amounts[n].Rate/100) + g.inclusive) //nolint:mnd //calculating percentage
+	}
+
+	return amounts
+}
+
+// DueDate returns the payment due date of the invoice.
+func (i *Invoice) DueDate() time.Time {
+	return i.Date.Add(i.Due)
+}
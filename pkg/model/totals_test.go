@@ -0,0 +1,114 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func newTestInvoice(vat float64, inclusive bool) *Invoice {
+	invoice := NewInvoice("F25-001", 0, "EUR", "", "")
+	invoice.SetTaxes(vat, 0, map[string]string{})
+	invoice.Tax.Inclusive = inclusive
+
+	return invoice
+}
+
+func TestTotalsKeepsGrossAmounts(t *testing.T) {
+	invoice := newTestInvoice(21, false)
+	for range 3 {
+		invoice.AddItem(Item{Description: "Coffee", Quantity: 1, Gross: 1})
+	}
+
+	totals := invoice.Totals()
+
+	if totals.Subtotal != 2.49 || totals.Vat != 0.51 || totals.Total != 3 {
+		t.Errorf("got subtotal %v, VAT %v, total %v; want 2.49, 0.51, 3", totals.Subtotal, totals.Vat, totals.Total)
+	}
+}
+
+func TestTotalsHonoursItemVat(t *testing.T) {
+	invoice := newTestInvoice(21, false)
+	invoice.AddItem(Item{Description: "Books", Quantity: 1, Vat: 4, Gross: 104})
+
+	totals := invoice.Totals()
+
+	if totals.Subtotal != 100 || totals.Vat != 4 || totals.Total != 104 {
+		t.Errorf("got subtotal %v, VAT %v, total %v; want 100, 4, 104", totals.Subtotal, totals.Vat, totals.Total)
+	}
+}
+
+func TestTotalsGroupsVatByRate(t *testing.T) {
+	invoice := newTestInvoice(21, false)
+	invoice.AddItem(Item{Description: "Consulting", Quantity: 2, Rate: 100})
+	invoice.AddItem(Item{Description: "Books", Quantity: 1, Rate: 50, Vat: 4})
+	invoice.AddItem(Item{Description: "Hosting", Quantity: 1, Rate: 10.05})
+
+	totals := invoice.Totals()
+
+	want := []TaxAmount{
+		{Tax: Tax{Kind: TaxKindVat, Rate: 21, Sign: 1}, Base: 210.05, Amount: 44.11},
+		{Tax: Tax{Kind: TaxKindVat, Rate: 4, Sign: 1}, Base: 50, Amount: 2},
+	}
+
+	if len(totals.Taxes) != len(want) {
+		t.Fatalf("got %d taxes, want %d: %+v", len(totals.Taxes), len(want), totals.Taxes)
+	}
+
+	for n, tax := range totals.Taxes {
+		if tax != want[n] {
+			t.Errorf("tax %d: got %+v, want %+v", n, tax, want[n])
+		}
+	}
+
+	if totals.Vat != 46.11 || totals.Total != 306.16 {
+		t.Errorf("got VAT %v, total %v; want 46.11, 306.16", totals.Vat, totals.Total)
+	}
+}
+
+func TestTotalsInclusiveInvoice(t *testing.T) {
+	invoice := newTestInvoice(21, true)
+	invoice.AddItem(Item{Description: "Design", Quantity: 1, Rate: 1210})
+	invoice.AddItem(Item{Description: "Print", Quantity: 3, Rate: 9.99})
+
+	totals := invoice.Totals()
+
+	if totals.Total != 1239.97 {
+		t.Errorf("got total %v, want 1239.97", totals.Total)
+	}
+
+	if totals.Subtotal+totals.Vat != totals.Total {
+		t.Errorf("subtotal %v and VAT %v do not add up to %v", totals.Subtotal, totals.Vat, totals.Total)
+	}
+}
+
+func TestTotalsRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention float64
+		want      float64
+	}{
+		{"none", 0, 0},
+		{"15%", 15, 150},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := NewInvoice("F25-001", 0, "EUR", "", "")
+			invoice.SetTaxes(21, tt.retention, map[string]string{})
+			invoice.AddItem(Item{Description: "Work", Quantity: 1, Rate: 1000})
+
+			got := invoice.Totals().Retention
+			if got != tt.want || math.Signbit(got) {
+				t.Errorf("got retention %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotalsWithoutItems(t *testing.T) {
+	totals := newTestInvoice(21, false).Totals()
+
+	if len(totals.Taxes) != 1 || totals.Taxes[0].Rate != 21 || totals.Total != 0 {
+		t.Errorf("got %+v, want a single 21%% VAT of 0", totals)
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	RecordRegistration = "alta"
+	RecordCancellation = "anulacion"
+
+	RecordPending  = "pending"
+	RecordAccepted = "accepted"
+	RecordRejected = "rejected"
+)
+
+// VerifactuRecord is an entry of the chain of invoice records required by the
+// Spanish Verifactu regulation. Every record stores the hash of the previous
+// one, so records are never modified once created except for their
+// submission status.
+type VerifactuRecord struct {
+	Sequence int    `json:"sequence" yaml:"sequence"`
+	Kind     string `json:"kind" yaml:"kind"`
+
+	IssuerID    string    `json:"issuer_id" yaml:"issuer_id"`
+	InvoiceID   string    `json:"invoice_id" yaml:"invoice_id"`
+	InvoiceDate time.Time `json:"invoice_date" yaml:"invoice_date"`
+	InvoiceType string    `json:"invoice_type,omitempty" yaml:"invoice_type,omitempty"`
+	TaxTotal    float64   `json:"tax_total" yaml:"tax_total"`
+	Total       float64   `json:"total" yaml:"total"`
+
+	Previous    *VerifactuRecordRef `json:"previous,omitempty" yaml:"previous,omitempty"`
+	GeneratedAt time.Time           `json:"generated_at" yaml:"generated_at"`
+	Hash        string              `json:"hash" yaml:"hash"`
+
+	Status      string     `json:"status" yaml:"status"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty" yaml:"submitted_at,omitempty"`
+	Error       string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// VerifactuRecordRef identifies the record a new one is chained to.
+type VerifactuRecordRef struct {
+	IssuerID    string    `json:"issuer_id" yaml:"issuer_id"`
+	InvoiceID   string    `json:"invoice_id" yaml:"invoice_id"`
+	InvoiceDate time.Time `json:"invoice_date" yaml:"invoice_date"`
+	Hash        string    `json:"hash" yaml:"hash"`
+}
+
+// Ref returns the reference used to chain the next record to this one.
+func (r *VerifactuRecord) Ref() *VerifactuRecordRef {
+	return &VerifactuRecordRef{
+		IssuerID:    r.IssuerID,
+		InvoiceID:   r.InvoiceID,
+		InvoiceDate: r.InvoiceDate,
+		Hash:        r.Hash,
+	}
+}
+
+// InvoiceVerifactu is stored on issued invoices to print the verification QR
+// code.
+type InvoiceVerifactu struct {
+	Hash string `json:"hash" yaml:"hash"`
+	QR   string `json:"qr" yaml:"qr"`
+}
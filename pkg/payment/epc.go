@@ -0,0 +1,71 @@
+// Package payment builds the payment codes printed on invoices.
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+const (
+	epcServiceTag     = "BCD"
+	epcVersion        = "002"
+	epcCharsetUTF8    = "1"
+	epcIdentification = "SCT"
+	epcCurrency       = "EUR"
+
+	epcMaxName       = 70
+	epcMaxRemittance = 140
+	epcMinAmount     = 0.01
+	epcMaxAmount     = 999999999.99
+)
+
+var (
+	ErrNoBeneficiary = errors.New("payment: the payment holder and IBAN are required")
+	ErrAmount        = errors.New("payment: amount out of range")
+)
+
+// EPCQR returns the content of an EPC069-12 QR code ("GiroCode") requesting a
+// SEPA credit transfer of amount euros to the account of payment, with
+// reference as unstructured remittance information.
+func EPCQR(payment model.Payment, amount float64, reference string) (string, error) {
+	iban := strings.ToUpper(strings.ReplaceAll(payment.Iban, " ", ""))
+	name := strings.TrimSpace(payment.Holder)
+
+	if iban == "" || name == "" {
+		return "", ErrNoBeneficiary
+	}
+
+	amount = model.Round(amount)
+	if amount < epcMinAmount || amount > epcMaxAmount {
+		return "", fmt.Errorf("%w: %.2f", ErrAmount, amount)
+	}
+
+	lines := []string{
+		epcServiceTag,
+		epcVersion,
+		epcCharsetUTF8,
+		epcIdentification,
+		strings.ToUpper(strings.ReplaceAll(payment.Swift, " ", "")),
+		truncate(name, epcMaxName),
+		iban,
+		epcCurrency + strconv.FormatFloat(amount, 'f', 2, 64),
+		"", // purpose
+		"", // structured creditor reference, exclusive with the remittance text
+		truncate(reference, epcMaxRemittance),
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func truncate(s string, size int) string {
+	runes := []rune(s)
+	if len(runes) > size {
+		return string(runes[:size])
+	}
+
+	return s
+}
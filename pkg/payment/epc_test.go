@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func TestEPCQR(t *testing.T) {
+	p := model.Payment{Holder: " Ana García ", Iban: "ES91 2100 0418 4502 0005 1332", Swift: "caix esbb xxx"}
+
+	got, err := EPCQR(p, 1210.004, "F25-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"BCD",
+		"002",
+		"1",
+		"SCT",
+		"CAIXESBBXXX",
+		"Ana García",
+		"ES9121000418450200051332",
+		"EUR1210.00",
+		"",
+		"",
+		"F25-001",
+	}, "\n")
+
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEPCQRTruncates(t *testing.T) {
+	p := model.Payment{Holder: strings.Repeat("ñ", 80), Iban: "DE89370400440532013000"}
+
+	got, err := EPCQR(p, 1, strings.Repeat("r", 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(got, "\n")
+	if len(lines) != 11 || lines[4] != "" {
+		t.Fatalf("got %q, want 11 lines and no BIC", lines)
+	}
+
+	if name := []rune(lines[5]); len(name) != 70 {
+		t.Errorf("got a name of %d characters, want 70", len(name))
+	}
+
+	if len(lines[10]) != 140 {
+		t.Errorf("got a remittance of %d characters, want 140", len(lines[10]))
+	}
+}
+
+func TestEPCQRErrors(t *testing.T) {
+	p := model.Payment{Holder: "Ana", Iban: "DE89370400440532013000"}
+
+	for _, amount := range []float64{0, 0.004, -1, 1e9} {
+		if _, err := EPCQR(p, amount, ""); !errors.Is(err, ErrAmount) {
+			t.Errorf("%v: got %v, want ErrAmount", amount, err)
+		}
+	}
+
+	if _, err := EPCQR(model.Payment{Iban: p.Iban}, 1, ""); !errors.Is(err, ErrNoBeneficiary) {
+		t.Errorf("got %v, want ErrNoBeneficiary", err)
+	}
+}
@@ -0,0 +1,126 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+var (
+	ErrInvalidIBAN = errors.New("payment: invalid IBAN")
+	ErrInvalidBIC  = errors.New("payment: invalid BIC")
+
+	bicPattern = regexp.MustCompile(`^[A-Z]{4}([A-Z]{2})[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+// ibanLengths holds the IBAN length of every country in the SWIFT IBAN
+// registry.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22, "BH": 22, "BI": 27,
+	"BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22, "DJ": 27, "DK": 18, "DO": 28,
+	"EE": 20, "EG": 29, "ES": 24, "FI": 18, "FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23,
+	"GL": 18, "GR": 27, "GT": 28, "HN": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26,
+	"IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20, "LV": 21,
+	"LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20, "MR": 27, "MT": 31, "MU": 30, "NI": 28,
+	"NL": 18, "NO": 15, "OM": 23, "PK": 24, "PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22,
+	"RU": 33, "SA": 24, "SC": 31, "SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25,
+	"SV": 28, "TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20, "YE": 30,
+}
+
+// Validate checks the IBAN and BIC of the payment info. Both are optional, as
+// some invoices are not paid by transfer.
+func Validate(p model.Payment) error {
+	if p.Iban != "" {
+		err := ValidateIBAN(p.Iban)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.Swift != "" {
+		return ValidateBIC(p.Swift)
+	}
+
+	return nil
+}
+
+// Compact upper-cases s and drops everything but letters and digits, as in
+// IBANs, BICs and references written in groups.
+func Compact(s string) string {
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return -1
+		}
+
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// ValidateIBAN checks the length of an IBAN for its country and its mod-97
+// checksum (ISO 13616). Spaces are ignored.
+func ValidateIBAN(iban string) error {
+	iban = Compact(iban)
+
+	if len(iban) < 4 { //nolint:mnd //country code and check digits
+		return fmt.Errorf("%w: %q is too short", ErrInvalidIBAN, iban)
+	}
+
+	length, ok := ibanLengths[iban[:2]]
+	if !ok {
+		return fmt.Errorf("%w: unknown country %q", ErrInvalidIBAN, iban[:2])
+	}
+
+	if len(iban) != length {
+		return fmt.Errorf("%w: %s IBANs have %d characters, %s has %d", ErrInvalidIBAN, iban[:2], length, iban, len(iban))
+	}
+
+	remainder := 0
+
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % ibanMod //nolint:mnd //decimal digit
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % ibanMod //nolint:mnd //letters count from 10
+		default:
+			return fmt.Errorf("%w: unexpected character %q in %s", ErrInvalidIBAN, r, iban)
+		}
+	}
+
+	if remainder != 1 {
+		return fmt.Errorf("%w: wrong checksum in %s", ErrInvalidIBAN, FormatIBAN(iban))
+	}
+
+	return nil
+}
+
+// ValidateBIC checks the structure of a BIC (ISO 9362): bank code, a known
+// country code, location and an optional branch code.
+func ValidateBIC(bic string) error {
+	bic = Compact(bic)
+
+	match := bicPattern.FindStringSubmatch(bic)
+	if match == nil {
+		return fmt.Errorf("%w: %q shall have 8 or 11 letters and digits", ErrInvalidBIC, bic)
+	}
+
+	if _, ok := ibanLengths[match[1]]; !ok && !knownBICCountry(match[1]) {
+		return fmt.Errorf("%w: unknown country %q in %s", ErrInvalidBIC, match[1], bic)
+	}
+
+	return nil
+}
+
+// FormatIBAN returns the IBAN in groups of four characters, its print format.
+func FormatIBAN(iban string) string {
+	return blocks(Compact(iban), 4, false) //nolint:mnd //IBAN print format
+}
+
+// knownBICCountry covers the countries with banks but without IBAN.
+func knownBICCountry(code string) bool {
+	return strings.Contains(" AR AU CA CN HK ID IN JP KR MX MY NZ PH SG TH TW US VN ZA ", " "+code+" ")
+}
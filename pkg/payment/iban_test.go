@@ -0,0 +1,54 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	for _, iban := range []string{
+		"ES91 2100 0418 4502 0005 1332",
+		"DE89370400440532013000",
+		"gb29 nwbk 6016 1331 9268 19",
+		"CH93-0076-2011-6238-5295-7",
+	} {
+		if err := ValidateIBAN(iban); err != nil {
+			t.Errorf("%s: %v", iban, err)
+		}
+	}
+
+	for _, iban := range []string{
+		"ES91 2100 0418 4502 0005 1333",
+		"ES91 2100 0418 4502 0005 133",
+		"QQ91 2100 0418 4502 0005 1332",
+		"ES9",
+	} {
+		if err := ValidateIBAN(iban); !errors.Is(err, ErrInvalidIBAN) {
+			t.Errorf("%s: got %v, want ErrInvalidIBAN", iban, err)
+		}
+	}
+}
+
+func TestValidateBIC(t *testing.T) {
+	for _, bic := range []string{"CAIXESBBXXX", "DEUTDEFF", "BOFAUS3N"} {
+		if err := ValidateBIC(bic); err != nil {
+			t.Errorf("%s: %v", bic, err)
+		}
+	}
+
+	for _, bic := range []string{"CAIXESB", "CAIXQQBBXXX"} {
+		if err := ValidateBIC(bic); !errors.Is(err, ErrInvalidBIC) {
+			t.Errorf("%s: got %v, want ErrInvalidBIC", bic, err)
+		}
+	}
+}
+
+func TestCompactAndFormatIBAN(t *testing.T) {
+	if got := Compact(" es91-2100.0418 "); got != "ES9121000418" {
+		t.Errorf("got %q, want ES9121000418", got)
+	}
+
+	if got := FormatIBAN("ES9121000418450200051332"); got != "ES91 2100 0418 4502 0005 1332" {
+		t.Errorf("got %q", got)
+	}
+}
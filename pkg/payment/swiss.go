@@ -0,0 +1,345 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+const (
+	ReferenceQR       = "QRR"
+	ReferenceCreditor = "SCOR"
+	ReferenceNone     = "NON"
+
+	swissQRType      = "SPC"
+	swissQRVersion   = "0200"
+	swissQRCoding    = "1"
+	swissQRTrailer   = "EPD"
+	addressStructure = "S"
+
+	qrReferenceLength = 27
+	creditorRefMax    = 21
+	qrIIDStart        = 4
+	qrIIDEnd          = 9
+	qrIIDMin          = 30000
+	qrIIDMax          = 31999
+	swissIBANLength   = 21
+	ibanMod           = 97
+	ibanCheckBase     = 98
+
+	maxNameLength     = 70
+	maxStreetLength   = 70
+	maxBuildingLength = 16
+	maxPostCodeLength = 16
+	maxTownLength     = 35
+	maxMessageLength  = 140
+)
+
+var (
+	ErrSwissAccount   = errors.New("payment: the QR-bill needs a Swiss or Liechtenstein IBAN or QR-IBAN")
+	ErrSwissCurrency  = errors.New("payment: the QR-bill only supports CHF and EUR")
+	ErrSwissCreditor  = errors.New("payment: the QR-bill needs the structured freelancer address")
+	ErrSwissReference = errors.New("payment: a QR-IBAN needs an invoice ID with digits as QR reference")
+
+	streetLine = regexp.MustCompile(`^(.*?)\s+(\d+\s?[A-Za-z]?)$`)
+	townLine   = regexp.MustCompile(`^(?:([A-Z]{2})-)?(\d{4,5})\s+(.+)$`)
+
+	// mod10Table is the table of the recursive modulo 10 check digit of QR
+	// references.
+	mod10Table = [10]int{0, 9, 4, 6, 8, 2, 7, 1, 3, 5}
+)
+
+// Address is a structured address of a QR-bill party.
+type Address struct {
+	Name           string
+	Street         string
+	BuildingNumber string
+	PostCode       string
+	Town           string
+	Country        string
+}
+
+// Lines returns the address as printed on the payment slip.
+func (a *Address) Lines() []string {
+	lines := []string{a.Name}
+
+	if street := strings.TrimSpace(a.Street + " " + a.BuildingNumber); street != "" {
+		lines = append(lines, street)
+	}
+
+	town := strings.TrimSpace(a.PostCode + " " + a.Town)
+	if a.Country != "CH" && a.Country != "LI" {
+		town = a.Country + "-" + town
+	}
+
+	return append(lines, town)
+}
+
+// SwissQRBill holds the data of a Swiss QR-bill payment part, as defined by
+// the SIX Swiss Implementation Guidelines for the QR-bill v2.3.
+type SwissQRBill struct {
+	Account       string
+	Creditor      Address
+	Amount        float64
+	Currency      string
+	Debtor        *Address
+	ReferenceType string
+	Reference     string
+	Message       string
+}
+
+// NewSwissQRBill builds the QR-bill of an invoice for amount, the balance
+// still owed on it. Invoices paid to a QR-IBAN
+// get a QR reference and the other ones a creditor reference (ISO 11649), both
+// derived from the invoice ID. The debtor is left blank when the client
+// address can not be split into its parts.
+func NewSwissQRBill(invoice *model.Invoice, amount float64) (*SwissQRBill, error) {
+	iban := Compact(invoice.Payment.Iban)
+	if !IsSwissAccount(iban) {
+		return nil, ErrSwissAccount
+	}
+
+	if invoice.Currency != "CHF" && invoice.Currency != "EUR" {
+		return nil, fmt.Errorf("%w: %s", ErrSwissCurrency, invoice.Currency)
+	}
+
+	from := invoice.From
+	if from.Address == nil || from.Address.Town == "" || from.Address.PostCode == "" || from.Address.Country == "" {
+		return nil, ErrSwissCreditor
+	}
+
+	amount = model.Round(amount)
+	if amount < epcMinAmount || amount > epcMaxAmount {
+		return nil, fmt.Errorf("%w: %.2f", ErrAmount, amount)
+	}
+
+	name := from.Company
+	if name == "" {
+		name = from.Name
+	}
+
+	bill := &SwissQRBill{
+		Account: iban,
+		Creditor: Address{
+			Name:           name,
+			Street:         from.Address.Street,
+			BuildingNumber: from.Address.BuildingNumber,
+			PostCode:       from.Address.PostCode,
+			Town:           from.Address.Town,
+			Country:        strings.ToUpper(from.Address.Country),
+		},
+		Amount:        amount,
+		Currency:      invoice.Currency,
+		Debtor:        clientAddress(&invoice.To),
+		ReferenceType: ReferenceCreditor,
+		Reference:     CreditorReference(invoice.ID),
+		Message:       truncate(invoice.ID, maxMessageLength),
+	}
+
+	if IsQRIBAN(iban) {
+		ref, err := QRReference(invoice.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		bill.ReferenceType = ReferenceQR
+		bill.Reference = ref
+	}
+
+	if bill.Reference == "" {
+		bill.ReferenceType = ReferenceNone
+	}
+
+	return bill, nil
+}
+
+// Payload returns the content of the Swiss QR code.
+func (b *SwissQRBill) Payload() string {
+	lines := []string{swissQRType, swissQRVersion, swissQRCoding, b.Account}
+	lines = append(lines, addressFields(&b.Creditor)...)
+	lines = append(lines, make([]string, 7)...) //nolint:mnd //ultimate creditor, reserved for future use
+	lines = append(lines, strconv.FormatFloat(b.Amount, 'f', 2, 64), b.Currency)
+
+	if b.Debtor != nil {
+		lines = append(lines, addressFields(b.Debtor)...)
+	} else {
+		lines = append(lines, make([]string, 7)...) //nolint:mnd //no debtor
+	}
+
+	lines = append(lines, b.ReferenceType, b.Reference, b.Message, swissQRTrailer)
+
+	return strings.Join(lines, "\n")
+}
+
+// FormattedAccount returns the IBAN in blocks of four characters.
+func (b *SwissQRBill) FormattedAccount() string {
+	return FormatIBAN(b.Account)
+}
+
+// FormattedReference returns the reference as printed on the payment slip.
+func (b *SwissQRBill) FormattedReference() string {
+	if b.ReferenceType == ReferenceQR {
+		return blocks(b.Reference, 5, true) //nolint:mnd //QR reference print format
+	}
+
+	return blocks(b.Reference, 4, false) //nolint:mnd //creditor reference print format
+}
+
+// IsSwissAccount reports whether an IBAN is Swiss or from Liechtenstein, the
+// only accounts the QR-bill can be paid to.
+func IsSwissAccount(iban string) bool {
+	iban = Compact(iban)
+
+	return len(iban) == swissIBANLength && (strings.HasPrefix(iban, "CH") || strings.HasPrefix(iban, "LI"))
+}
+
+// IsQRIBAN reports whether an IBAN is a QR-IBAN, whose institution ID is in
+// the 30000-31999 range.
+func IsQRIBAN(iban string) bool {
+	iban = Compact(iban)
+	if len(iban) != swissIBANLength {
+		return false
+	}
+
+	iid, err := strconv.Atoi(iban[qrIIDStart:qrIIDEnd])
+
+	return err == nil && iid >= qrIIDMin && iid <= qrIIDMax
+}
+
+// QRReference returns the 27 digit QR reference made of the digits of id and
+// a recursive modulo 10 check digit.
+func QRReference(id string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, id)
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" || len(digits) >= qrReferenceLength {
+		return "", fmt.Errorf("%w: %s", ErrSwissReference, id)
+	}
+
+	digits = strings.Repeat("0", qrReferenceLength-1-len(digits)) + digits
+
+	carry := 0
+	for _, r := range digits {
+		carry = mod10Table[(carry+int(r-'0'))%10]
+	}
+
+	return digits + strconv.Itoa((10-carry)%10), nil //nolint:mnd //modulo 10
+}
+
+// CreditorReference returns the ISO 11649 creditor reference ("RF") made of
+// the letters and digits of id, or an empty string when id has none.
+func CreditorReference(id string) string {
+	ref := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'A' && r <= 'Z':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+
+		return -1
+	}, id)
+
+	if ref == "" {
+		return ""
+	}
+
+	if len(ref) > creditorRefMax {
+		ref = ref[len(ref)-creditorRefMax:]
+	}
+
+	numeric := &strings.Builder{}
+
+	for _, r := range ref + "RF00" {
+		if r >= 'A' && r <= 'Z' {
+			numeric.WriteString(strconv.Itoa(int(r-'A') + 10)) //nolint:mnd //letters count from 10
+		} else {
+			numeric.WriteRune(r)
+		}
+	}
+
+	n, _ := new(big.Int).SetString(numeric.String(), 10) //nolint:mnd //decimal
+	check := ibanCheckBase - new(big.Int).Mod(n, big.NewInt(ibanMod)).Int64()
+
+	return fmt.Sprintf("RF%02d%s", check, ref)
+}
+
+// clientAddress splits the address lines of a client such as "Bahnhofstrasse
+// 1" and "8001 Zürich" or "CH-8001 Zürich". The country comes from the
+// prefix of the town line or of the VAT number.
+func clientAddress(client *model.Client) *Address {
+	town := townLine.FindStringSubmatch(strings.TrimSpace(client.Address2))
+	if town == nil || client.Name == "" {
+		return nil
+	}
+
+	country := town[1]
+	if country == "" {
+		country = strings.ToUpper(strings.TrimSpace(client.VatID))
+		if len(country) < 2 || country[0] < 'A' || country[0] > 'Z' || country[1] < 'A' || country[1] > 'Z' {
+			return nil
+		}
+
+		country = country[:2]
+	}
+
+	address := &Address{
+		Name:     truncate(client.Name, maxNameLength),
+		Street:   truncate(strings.TrimSpace(client.Address1), maxStreetLength),
+		PostCode: truncate(town[2], maxPostCodeLength),
+		Town:     truncate(town[3], maxTownLength),
+		Country:  country,
+	}
+
+	if street := streetLine.FindStringSubmatch(address.Street); street != nil {
+		address.Street = street[1]
+		address.BuildingNumber = truncate(street[2], maxBuildingLength)
+	}
+
+	return address
+}
+
+func addressFields(a *Address) []string {
+	return []string{
+		addressStructure,
+		truncate(a.Name, maxNameLength),
+		truncate(a.Street, maxStreetLength),
+		truncate(a.BuildingNumber, maxBuildingLength),
+		truncate(a.PostCode, maxPostCodeLength),
+		truncate(a.Town, maxTownLength),
+		a.Country,
+	}
+}
+
+// blocks groups s in blocks of size characters, starting from the end when
+// fromEnd is set.
+func blocks(s string, size int, fromEnd bool) string {
+	var parts []string
+
+	if fromEnd {
+		first := len(s) % size
+		if first > 0 {
+			parts = append(parts, s[:first])
+		}
+
+		s = s[first:]
+	}
+
+	for len(s) > size {
+		parts = append(parts, s[:size])
+		s = s[size:]
+	}
+
+	return strings.Join(append(parts, s), " ")
+}
@@ -0,0 +1,137 @@
+package payment
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func newSwissInvoice(iban string) *model.Invoice {
+	invoice := model.NewInvoice("F25-0042", 0, "CHF", "", "")
+	invoice.From = model.Freelancer{
+		Name: "Ana García",
+		Address: &model.PostalAddress{
+			Street: "Musterstrasse", BuildingNumber: "1", PostCode: "8000", Town: "Zürich", Country: "ch",
+		},
+	}
+	invoice.To = model.Client{Name: "Acme AG", Address1: "Bahnhofstrasse 12a", Address2: "CH-3000 Bern"}
+	invoice.Payment = model.Payment{Iban: iban}
+
+	return invoice
+}
+
+func TestQRReference(t *testing.T) {
+	// The example of the SIX Swiss Implementation Guidelines
+	ref, err := QRReference("21000000000313947143000901")
+	if err != nil || ref != "210000000003139471430009017" {
+		t.Errorf("got %q, %v; want 210000000003139471430009017", ref, err)
+	}
+
+	ref, err = QRReference("F25-0042")
+	if err != nil || len(ref) != qrReferenceLength || ref != "000000000000000000002500428" {
+		t.Errorf("got %q, %v; want the padded digits of the ID and check digit 8", ref, err)
+	}
+
+	for _, id := range []string{"DRAFT", "0000", strings.Repeat("1", 27)} {
+		if _, err := QRReference(id); !errors.Is(err, ErrSwissReference) {
+			t.Errorf("%s: got %v, want ErrSwissReference", id, err)
+		}
+	}
+}
+
+func TestCreditorReference(t *testing.T) {
+	tests := map[string]string{
+		// The example of ISO 11649
+		"539007547034": "RF18539007547034",
+		"f25-001":      "RF22F25001",
+		"---":          "",
+	}
+
+	for id, want := range tests {
+		if got := CreditorReference(id); got != want {
+			t.Errorf("CreditorReference(%q) = %q, want %q", id, got, want)
+		}
+	}
+
+	if got := CreditorReference("INV-2025-000000000000000042"); len(got) != 4+creditorRefMax {
+		t.Errorf("got %q, want the reference cut to %d characters", got, creditorRefMax)
+	}
+}
+
+func TestNewSwissQRBillReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		iban    string
+		id      string
+		refType string
+		err     error
+	}{
+		{"QR-IBAN with a QR reference", "CH44 3199 9123 0008 8901 2", "F25-0042", ReferenceQR, nil},
+		{"IBAN with a creditor reference", "CH93 0076 2011 6238 5295 7", "F25-0042", ReferenceCreditor, nil},
+		{"QR-IBAN without digits", "CH44 3199 9123 0008 8901 2", "DRAFT", "", ErrSwissReference},
+		{"IBAN not Swiss", "DE89 3704 0044 0532 0130 00", "F25-0042", "", ErrSwissAccount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := newSwissInvoice(tt.iban)
+			invoice.ID = tt.id
+
+			bill, err := NewSwissQRBill(invoice, 10)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+
+			if err == nil && bill.ReferenceType != tt.refType {
+				t.Errorf("got reference %s %s, want a %s", bill.ReferenceType, bill.Reference, tt.refType)
+			}
+		})
+	}
+}
+
+func TestSwissQRBillPayload(t *testing.T) {
+	bill, err := NewSwissQRBill(newSwissInvoice("CH44 3199 9123 0008 8901 2"), 1949.755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"SPC", "0200", "1", "CH4431999123000889012",
+		"S", "Ana García", "Musterstrasse", "1", "8000", "Zürich", "CH",
+		"", "", "", "", "", "", "",
+		"1949.76", "CHF",
+		"S", "Acme AG", "Bahnhofstrasse", "12a", "3000", "Bern", "CH",
+		"QRR", "000000000000000000002500428", "F25-0042",
+		"EPD",
+	}, "\n")
+
+	if got := bill.Payload(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if got := bill.FormattedReference(); got != "00 00000 00000 00000 00025 00428" {
+		t.Errorf("got formatted reference %q", got)
+	}
+}
+
+func TestNewSwissQRBillErrors(t *testing.T) {
+	euros := newSwissInvoice("CH93 0076 2011 6238 5295 7")
+	euros.Currency = "USD"
+
+	if _, err := NewSwissQRBill(euros, 10); !errors.Is(err, ErrSwissCurrency) {
+		t.Errorf("got %v, want ErrSwissCurrency", err)
+	}
+
+	noAddress := newSwissInvoice("CH93 0076 2011 6238 5295 7")
+	noAddress.From.Address = nil
+
+	if _, err := NewSwissQRBill(noAddress, 10); !errors.Is(err, ErrSwissCreditor) {
+		t.Errorf("got %v, want ErrSwissCreditor", err)
+	}
+
+	if _, err := NewSwissQRBill(newSwissInvoice("CH93 0076 2011 6238 5295 7"), 0); !errors.Is(err, ErrAmount) {
+		t.Errorf("got %v, want ErrAmount", err)
+	}
+}
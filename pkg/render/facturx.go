@@ -0,0 +1,136 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/einvoice"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+const (
+	FacturXFileName = "factur-x.xml"
+	FacturXProducer = "invoiceling"
+)
+
+// PdfRenderer is a renderer producing a PDF document.
+type PdfRenderer interface {
+	Render(invoice *model.Invoice, draft bool) error
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// FacturX turns the PDF of another renderer into a Factur-X / ZUGFeRD hybrid
+// invoice: a PDF/A-3 document with the CII XML of the invoice embedded.
+type FacturX struct {
+	pdf     PdfRenderer
+	profile einvoice.Profile
+	xml     []byte
+	opts    pdfA3Options
+}
+
+func NewFacturXRender(pdf PdfRenderer, profile einvoice.Profile) *FacturX {
+	return &FacturX{
+		pdf:     pdf,
+		profile: profile,
+	}
+}
+
+func (f *FacturX) Render(invoice *model.Invoice, draft bool) error {
+	doc, err := einvoice.FromInvoice(invoice)
+	if err != nil {
+		return err
+	}
+
+	err = doc.Validate()
+	if err != nil {
+		return err
+	}
+
+	f.xml, err = doc.MarshalCII(f.profile)
+	if err != nil {
+		return err
+	}
+
+	f.opts = pdfA3Options{
+		Title:    "Invoice " + invoice.ID,
+		Author:   doc.Seller.Name,
+		Subject:  "Invoice " + invoice.ID + " for " + doc.Buyer.Name,
+		Producer: FacturXProducer,
+		Date:     time.Now().Truncate(time.Second),
+		Attachment: pdfAttachment{
+			Name:         FacturXFileName,
+			Description:  "Factur-X invoice",
+			MimeType:     "text/xml",
+			Relationship: f.profile.AFRelationship(),
+			Data:         f.xml,
+		},
+		ExtraXMP: facturXMP(f.profile),
+	}
+
+	return f.pdf.Render(invoice, draft)
+}
+
+// WriteTo writes the PDF/A-3 document with the embedded invoice XML to w.
+func (f *FacturX) WriteTo(w io.Writer) (int64, error) {
+	buf := bytes.Buffer{}
+
+	_, err := f.pdf.WriteTo(&buf)
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := convertToPDFA3(buf.Bytes(), f.opts)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(out)
+
+	return int64(n), err
+}
+
+// XML returns the CII XML embedded in the last rendered document.
+func (f *FacturX) XML() []byte {
+	return f.xml
+}
+
+// facturXMP declares the Factur-X XMP properties and their PDF/A extension schema.
+func facturXMP(profile einvoice.Profile) string {
+	property := func(name, description string) string {
+		return `<rdf:li rdf:parseType="Resource">
+<pdfaProperty:name>` + name + `</pdfaProperty:name>
+<pdfaProperty:valueType>Text</pdfaProperty:valueType>
+<pdfaProperty:category>external</pdfaProperty:category>
+<pdfaProperty:description>` + description + `</pdfaProperty:description>
+</rdf:li>
+`
+	}
+
+	return `<rdf:Description rdf:about="" xmlns:fx="urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#">
+<fx:DocumentType>INVOICE</fx:DocumentType>
+<fx:DocumentFileName>` + FacturXFileName + `</fx:DocumentFileName>
+<fx:Version>1.0</fx:Version>
+<fx:ConformanceLevel>` + profile.ConformanceLevel() + `</fx:ConformanceLevel>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/"
+ xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#" xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">
+<pdfaExtension:schemas>
+<rdf:Bag>
+<rdf:li rdf:parseType="Resource">
+<pdfaSchema:schema>Factur-X PDFA Extension Schema</pdfaSchema:schema>
+<pdfaSchema:namespaceURI>urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#</pdfaSchema:namespaceURI>
+<pdfaSchema:prefix>fx</pdfaSchema:prefix>
+<pdfaSchema:property>
+<rdf:Seq>
+` + property("DocumentFileName", "The name of the embedded XML document") +
+		property("DocumentType", "The type of the hybrid document in capital letters, e.g. INVOICE or ORDER") +
+		property("Version", "The actual version of the standard applying to the embedded XML document") +
+		property("ConformanceLevel", "The conformance level of the embedded XML document") + `</rdf:Seq>
+</pdfaSchema:property>
+</rdf:li>
+</rdf:Bag>
+</pdfaExtension:schemas>
+</rdf:Description>
+`
+}
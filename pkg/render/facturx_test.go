@@ -0,0 +1,260 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Inmovilizame/invoiceling/pkg/einvoice"
+)
+
+var (
+	reXrefSection = regexp.MustCompile(`^(\d+) (\d+)\s*$`)
+	reXrefEntry   = regexp.MustCompile(`^(\d{10}) (\d{5}) ([nf])\s*$`)
+	reTrailerPrev = regexp.MustCompile(`/Prev\s+(\d+)`)
+	reReference   = regexp.MustCompile(`(\d+) 0 R`)
+	reLength      = regexp.MustCompile(`/Length\s+(\d+)`)
+)
+
+// parsedPdf is a PDF read back through its cross reference tables.
+type parsedPdf struct {
+	data     []byte
+	offsets  map[int]int
+	trailers []string
+}
+
+// parsePdf follows the startxref and /Prev chain of a PDF, failing the test
+// when a cross reference table or an entry of it does not point where it should.
+func parsePdf(t *testing.T, data []byte) *parsedPdf {
+	t.Helper()
+
+	m := reStartXref.FindSubmatch(data)
+	if m == nil {
+		t.Fatal("startxref not found at the end of the file")
+	}
+
+	p := &parsedPdf{data: data, offsets: map[int]int{}}
+	pos, _ := strconv.Atoi(string(m[1])) //nolint:errcheck //regexp guarantees digits
+
+	for {
+		if pos >= len(data) || !bytes.HasPrefix(data[pos:], []byte("xref")) {
+			t.Fatalf("no xref table at offset %d", pos)
+		}
+
+		end := bytes.Index(data[pos:], []byte("trailer"))
+		if end < 0 {
+			t.Fatalf("no trailer after the xref table at %d", pos)
+		}
+
+		p.readXref(t, string(data[pos+len("xref"):pos+end]))
+
+		trailer, _, _ := strings.Cut(string(data[pos+end:]), "startxref")
+		p.trailers = append(p.trailers, trailer)
+
+		prev := reTrailerPrev.FindStringSubmatch(trailer)
+		if prev == nil {
+			break
+		}
+
+		pos, _ = strconv.Atoi(prev[1]) //nolint:errcheck //regexp guarantees digits
+	}
+
+	for num, offset := range p.offsets {
+		if header := fmt.Sprintf("%d 0 obj", num); !bytes.HasPrefix(data[offset:], []byte(header)) {
+			t.Errorf("xref entry of object %d points to %q", num, data[offset:min(offset+20, len(data))])
+		}
+	}
+
+	return p
+}
+
+// readXref records the offsets of a table, keeping those of the later updates.
+func (p *parsedPdf) readXref(t *testing.T, table string) {
+	t.Helper()
+
+	lines := bytes.Split(bytes.TrimSpace([]byte(table)), []byte("\n"))
+	for i := 0; i < len(lines); {
+		section := reXrefSection.FindSubmatch(lines[i])
+		if section == nil {
+			t.Fatalf("bad xref subsection %q", lines[i])
+		}
+
+		first, _ := strconv.Atoi(string(section[1])) //nolint:errcheck //regexp guarantees digits
+		count, _ := strconv.Atoi(string(section[2])) //nolint:errcheck //regexp guarantees digits
+		i++
+
+		for n := range count {
+			if i >= len(lines) {
+				t.Fatalf("xref subsection %d %d is cut short", first, count)
+			}
+
+			entry := reXrefEntry.FindSubmatch(lines[i])
+			if entry == nil {
+				t.Fatalf("bad xref entry %q", lines[i])
+			}
+
+			i++
+
+			if _, newer := p.offsets[first+n]; newer || string(entry[3]) == "f" {
+				continue
+			}
+
+			p.offsets[first+n], _ = strconv.Atoi(string(entry[1])) //nolint:errcheck //regexp guarantees digits
+		}
+	}
+}
+
+// object returns the dictionary of an object and its stream, if it has one.
+func (p *parsedPdf) object(t *testing.T, num int) (string, []byte) {
+	t.Helper()
+
+	offset, ok := p.offsets[num]
+	if !ok {
+		t.Fatalf("object %d is not in the xref tables", num)
+	}
+
+	body := p.data[offset:]
+	body = body[:bytes.Index(body, []byte("endobj"))]
+
+	start := bytes.Index(body, []byte("stream\n"))
+	if start < 0 {
+		return string(body), nil
+	}
+
+	dict := string(body[:start])
+
+	length := reLength.FindStringSubmatch(dict)
+	if length == nil {
+		t.Fatalf("stream of object %d has no /Length", num)
+	}
+
+	n, _ := strconv.Atoi(length[1]) //nolint:errcheck //regexp guarantees digits
+
+	return dict, body[start+len("stream\n") : start+len("stream\n")+n]
+}
+
+// ref returns the object referenced after key in a dictionary.
+func ref(t *testing.T, dict, key string) int {
+	t.Helper()
+
+	m := regexp.MustCompile(regexp.QuoteMeta(key) + `\s*\[?\s*(\d+) 0 R`).FindStringSubmatch(dict)
+	if m == nil {
+		t.Fatalf("%s not found in %s", key, dict)
+	}
+
+	num, _ := strconv.Atoi(m[1]) //nolint:errcheck //regexp guarantees digits
+
+	return num
+}
+
+func TestFacturXPdfA3(t *testing.T) {
+	for _, profile := range einvoice.GetSupportedProfiles() {
+		t.Run(string(profile), func(t *testing.T) {
+			f := NewFacturXRender(newTestRender(t), profile)
+			if err := f.Render(newTestInvoice(), false); err != nil {
+				t.Fatal(err)
+			}
+
+			out := bytes.Buffer{}
+			if _, err := f.WriteTo(&out); err != nil {
+				t.Fatal(err)
+			}
+
+			pdf := parsePdf(t, out.Bytes())
+			if len(pdf.trailers) != 2 {
+				t.Fatalf("got %d xref sections, want the original and the PDF/A update", len(pdf.trailers))
+			}
+
+			trailer := pdf.trailers[0]
+			for _, key := range []string{"/Size", "/Root", "/Info", "/ID", "/Prev"} {
+				if !strings.Contains(trailer, key) {
+					t.Errorf("trailer has no %s:\n%s", key, trailer)
+				}
+			}
+
+			catalog, _ := pdf.object(t, ref(t, trailer, "/Root"))
+
+			spec, _ := pdf.object(t, ref(t, catalog, "/AF"))
+			if want := "/AFRelationship /" + profile.AFRelationship(); !strings.Contains(spec, want) {
+				t.Errorf("file spec %s has no %s", spec, want)
+			}
+
+			file, data := pdf.object(t, ref(t, spec, "/EF << /F"))
+			if !strings.Contains(file, "/Type /EmbeddedFile /Subtype /text#2Fxml") {
+				t.Errorf("got embedded file %s, want a text/xml EmbeddedFile", file)
+			}
+
+			if !bytes.Equal(data, f.XML()) {
+				t.Error("embedded file is not the CII XML of the invoice")
+			}
+
+			intent, _ := pdf.object(t, ref(t, catalog, "/OutputIntents"))
+			if !strings.Contains(intent, "/S /GTS_PDFA1") {
+				t.Errorf("got output intent %s, want a PDF/A one", intent)
+			}
+
+			_, icc := pdf.object(t, ref(t, intent, "/DestOutputProfile"))
+			if len(icc) < 40 || string(icc[36:40]) != "acsp" {
+				t.Error("destination output profile is not an ICC profile")
+			}
+
+			meta, xmp := pdf.object(t, ref(t, catalog, "/Metadata"))
+			if !strings.Contains(meta, "/Type /Metadata /Subtype /XML") {
+				t.Errorf("got metadata %s, want an XML Metadata stream", meta)
+			}
+
+			checkXMP(t, xmp, profile)
+
+			for _, m := range reReference.FindAllStringSubmatch(catalog, -1) {
+				num, _ := strconv.Atoi(m[1]) //nolint:errcheck //regexp guarantees digits
+				pdf.object(t, num)
+			}
+		})
+	}
+}
+
+// checkXMP checks the XMP metadata declares PDF/A-3B and the Factur-X profile.
+func checkXMP(t *testing.T, xmp []byte, profile einvoice.Profile) {
+	t.Helper()
+
+	values := map[string]string{}
+	decoder := xml.NewDecoder(bytes.NewReader(xmp))
+
+	var name xml.Name
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			name = token.Name
+		case xml.CharData:
+			if text := string(bytes.TrimSpace(token)); text != "" {
+				values[name.Space+" "+name.Local] = text
+			}
+		}
+	}
+
+	fx := "urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#"
+	want := map[string]string{
+		"http://www.aiim.org/pdfa/ns/id/ part":        "3",
+		"http://www.aiim.org/pdfa/ns/id/ conformance": "B",
+		fx + " DocumentType":                          "INVOICE",
+		fx + " DocumentFileName":                      FacturXFileName,
+		fx + " ConformanceLevel":                      profile.ConformanceLevel(),
+		"http://purl.org/dc/elements/1.1/ format":     "application/pdf",
+	}
+
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("XMP %s is %q, want %q", key, values[key], value)
+		}
+	}
+}
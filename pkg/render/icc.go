@@ -0,0 +1,110 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+const (
+	iccHeaderSize   = 128
+	iccTagEntrySize = 12
+	iccFixedOne     = 65536
+	iccGamma        = 0x0233 // 2.2 as u8Fixed8Number
+	iccDescFiller   = 67
+	iccAlignment    = 4
+	iccVersion      = 0x02100000
+)
+
+// sRGBProfile builds a small ICC v2 display profile approximating sRGB with a
+// 2.2 gamma curve. PDF/A requires an output intent with an embedded profile,
+// and generating it keeps a binary asset with its own license out of the repo.
+func sRGBProfile() []byte {
+	xyz := func(x, y, z float64) []byte {
+		return iccTag("XYZ ", s15Fixed16(x), s15Fixed16(y), s15Fixed16(z))
+	}
+
+	curve := iccTag("curv", uint32(1), uint16(iccGamma), uint16(0))
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", iccDesc("sRGB IEC61966-2.1")},
+		{"cprt", iccTag("text", []byte("No copyright, use freely\x00"))},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},             //nolint:mnd //D50 white point
+		{"rXYZ", xyz(0.4360747, 0.2225045, 0.0139322)}, //nolint:mnd //sRGB red primary adapted to D50
+		{"gXYZ", xyz(0.3850649, 0.7168786, 0.0971045)}, //nolint:mnd //sRGB green primary adapted to D50
+		{"bXYZ", xyz(0.1430804, 0.0606169, 0.7141733)}, //nolint:mnd //sRGB blue primary adapted to D50
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	table := bytes.Buffer{}
+	data := bytes.Buffer{}
+	offset := iccHeaderSize + iccAlignment + len(tags)*iccTagEntrySize
+
+	writeBE(&table, uint32(len(tags)))
+
+	for _, tag := range tags {
+		table.WriteString(tag.sig)
+		writeBE(&table, uint32(offset+data.Len()), uint32(len(tag.data)))
+
+		data.Write(tag.data)
+
+		for data.Len()%iccAlignment != 0 {
+			data.WriteByte(0)
+		}
+	}
+
+	header := bytes.Buffer{}
+	size := iccHeaderSize + table.Len() + data.Len()
+
+	writeBE(&header, uint32(size), uint32(0), uint32(iccVersion))
+	header.WriteString("mntrRGB XYZ ")
+	writeBE(&header, [6]uint16{2024, 1, 1}) //nolint:mnd //fixed creation date keeps the profile stable
+	header.WriteString("acsp")
+	header.Write(make([]byte, 28))                                            //nolint:mnd //platform, flags, device and attributes
+	writeBE(&header, s15Fixed16(0.9642), s15Fixed16(1.0), s15Fixed16(0.8249)) //nolint:mnd //D50 illuminant
+	header.Write(make([]byte, iccHeaderSize-header.Len()))
+
+	return append(append(header.Bytes(), table.Bytes()...), data.Bytes()...)
+}
+
+func iccTag(sig string, values ...any) []byte {
+	buf := bytes.Buffer{}
+	buf.WriteString(sig)
+	writeBE(&buf, uint32(0))
+	writeBE(&buf, values...)
+
+	return buf.Bytes()
+}
+
+func iccDesc(text string) []byte {
+	ascii := append([]byte(text), 0)
+
+	buf := bytes.Buffer{}
+	buf.WriteString("desc")
+	writeBE(&buf, uint32(0), uint32(len(ascii)))
+	buf.Write(ascii)
+	writeBE(&buf, uint32(0), uint32(0), uint16(0), uint8(0))
+	buf.Write(make([]byte, iccDescFiller))
+
+	return buf.Bytes()
+}
+
+func s15Fixed16(v float64) int32 {
+	return int32(math.Round(v * iccFixedOne))
+}
+
+func writeBE(buf *bytes.Buffer, values ...any) {
+	for _, v := range values {
+		if b, ok := v.([]byte); ok {
+			buf.Write(b)
+			continue
+		}
+
+		_ = binary.Write(buf, binary.BigEndian, v) //nolint:errcheck //bytes.Buffer writes do not fail
+	}
+}
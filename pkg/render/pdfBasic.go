@@ -4,14 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"io"
 	"os"
 	"strconv"
-	"time"
 
 	"github.com/Inmovilizame/invoiceling/assets"
 	"github.com/Inmovilizame/invoiceling/pkg/i18n"
 	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/payment"
+	"github.com/Inmovilizame/invoiceling/pkg/verifactu"
 	"github.com/signintech/gopdf"
+	"github.com/skip2/go-qrcode"
 )
 
 type DateFormat string
@@ -23,6 +26,7 @@ const (
 )
 
 const (
+	FontSizeSmall        = 8
 	FontSizeNormal       = 10
 	FontSizeSubtleNormal = 12
 	FontSizeSubtleTotal  = 14
@@ -40,6 +44,9 @@ const (
 	HeaderInfoValue     = 100
 	HeaderLogoSize      = 100
 	HeaderMinHeight     = 160
+	HeaderQRStartX      = 240
+	HeaderQRSize        = 85 // 30 mm, the minimum size allowed for the Verifactu QR code
+	HeaderQRLegendWidth = 130
 )
 
 const (
@@ -56,6 +63,11 @@ const (
 	ItemAmountWidth = 80
 )
 
+const (
+	QRPixels      = 256
+	PaymentQRSize = 3 * LineHeight
+)
+
 const (
 	DraftText            = "DRAFT"
 	DraftAlpha           = 0.65
@@ -64,12 +76,20 @@ const (
 )
 
 type PdfBasic struct {
-	debug      bool
-	lastYPos   float64
-	translator i18n.Translator
+	debug         bool
+	paymentQR     bool
+	swissBill     bool
+	paymentStatus bool
+	exchangeVat   bool
+	balance       func(*model.Invoice) float64
+	lastYPos      float64
+	totalsYPos    float64
+	translator    i18n.Translator
 	gopdf.GoPdf
 }
 
+// NewPdfBasicRender returns a renderer ready to render a single invoice. The
+// renderer keeps page state, so a new one is needed for every document.
 func NewPdfBasicRender(translator i18n.Translator) (*PdfBasic, error) {
 	interFont, err := assets.FS.ReadFile("fonts/Inter.ttf")
 	if err != nil {
@@ -103,8 +123,39 @@ func NewPdfBasicRender(translator i18n.Translator) (*PdfBasic, error) {
 	return &pb, nil
 }
 
+// SetBalance sets how the amount still owed on an invoice is computed, for
+// the payment codes and the amount due. Without it only the payments of the
+// invoice are subtracted from its total.
+func (p *PdfBasic) SetBalance(balance func(*model.Invoice) float64) {
+	p.balance = balance
+}
+
+// SetPaymentQR enables the EPC QR code ("GiroCode") next to the payment info
+// of invoices in euros.
+func (p *PdfBasic) SetPaymentQR(enabled bool) {
+	p.paymentQR = enabled
+}
+
+// SetPaymentStatus enables the date of payment of paid invoices, or the amount
+// still due of partially paid ones, below the totals.
+func (p *PdfBasic) SetPaymentStatus(enabled bool) {
+	p.paymentStatus = enabled
+}
+
+// SetExchangeVat enables the VAT in the home currency, with the exchange rate,
+// below the totals of invoices in a foreign currency.
+func (p *PdfBasic) SetExchangeVat(enabled bool) {
+	p.exchangeVat = enabled
+}
+
+// SetSwissQRBill enables the Swiss QR-bill payment part on invoices paid to a
+// Swiss or Liechtenstein account.
+func (p *PdfBasic) SetSwissQRBill(enabled bool) {
+	p.swissBill = enabled
+}
+
 func (p *PdfBasic) Render(invoice *model.Invoice, draft bool) error {
-	err := p.header(invoice.Logo, invoice.ID, invoice.Date, invoice.Due)
+	err := p.header(invoice)
 	if err != nil {
 		return err
 	}
@@ -124,15 +175,42 @@ func (p *PdfBasic) Render(invoice *model.Invoice, draft bool) error {
 	p.Line(Margin, p.GetY(), gopdf.PageSizeA4.W-Margin, p.GetY())
 	p.Br(LineHeight)
 
-	err = p.items(invoice.Items, invoice.Tax, invoice.Currency, invoice.Payment)
+	err = p.items(invoice.Items, invoice.IncludesVat(), invoice.Totals(), invoice.Currency, invoice.Payment, p.giroCode(invoice))
 	if err != nil {
 		return err
 	}
 
+	if p.exchangeVat {
+		err = p.homeVat(invoice)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.paymentStatus {
+		err = p.paid(invoice)
+		if err != nil {
+			return err
+		}
+	}
+
 	p.SetY(p.lastYPos)
 	p.Br(LineHeight)
 
-	err = p.notes(invoice.Notes)
+	bill, err := p.swissQRBillOf(invoice)
+	if err != nil {
+		return err
+	}
+
+	notesBottom := gopdf.PageSizeA4.H
+	billTop := gopdf.PageSizeA4.H - SwissBillHeight - LineHeight
+	sharePage := bill != nil && p.GetY()+notesHeight(invoice.Notes) <= billTop
+
+	if sharePage {
+		notesBottom = billTop + Margin
+	}
+
+	err = p.notes(invoice.Notes, notesBottom)
 	if err != nil {
 		return err
 	}
@@ -144,24 +222,47 @@ func (p *PdfBasic) Render(invoice *model.Invoice, draft bool) error {
 		}
 	}
 
+	if bill != nil {
+		if !sharePage {
+			p.AddPage()
+		}
+
+		return p.swissQRBill(bill)
+	}
+
 	return nil
 }
 
-func (p *PdfBasic) SaveTo(filename string) error {
-	return p.WritePdf(filename)
+// WriteTo writes the rendered PDF document to w.
+func (p *PdfBasic) WriteTo(w io.Writer) (int64, error) {
+	return p.GoPdf.WriteTo(w)
 }
 
-func (p *PdfBasic) header(logo, id string, date time.Time, due time.Duration) error {
-	err := p.headingTitle()
+func (p *PdfBasic) header(invoice *model.Invoice) error {
+	logo, date, due := invoice.Logo, invoice.Date, invoice.Due
+
+	title := p.translator.T("invoice_caps")
+	if invoice.IsCreditNote() {
+		title = p.translator.T("credit_note_caps")
+	}
+
+	err := p.headingTitle(title)
 	if err != nil {
 		return err
 	}
 
-	err = p.headingInfoLine(p.translator.T("invoice"), id)
+	err = p.headingInfoLine(p.translator.T("invoice"), invoice.ID)
 	if err != nil {
 		return err
 	}
 
+	if invoice.Corrects != "" {
+		err = p.headingInfoLine(p.translator.T("corrects"), invoice.Corrects)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = p.headingInfoLine(p.translator.T("date"), date.Format(string(DFYMD)))
 	if err != nil {
 		return err
@@ -181,6 +282,16 @@ func (p *PdfBasic) header(logo, id string, date time.Time, due time.Duration) er
 	p.SetX(HeaderInfoStartX)
 	p.SetY(Margin)
 
+	if invoice.Verifactu != nil {
+		err = p.verifactuQR(invoice.Verifactu.QR)
+		if err != nil {
+			return err
+		}
+
+		p.SetX(HeaderInfoStartX)
+		p.SetY(Margin)
+	}
+
 	if logo != "" {
 		startX := p.GetX()
 		startY := p.GetY()
@@ -205,6 +316,94 @@ func (p *PdfBasic) header(logo, id string, date time.Time, due time.Duration) er
 	return nil
 }
 
+// verifactuQR draws the Verifactu QR code of an issued invoice between the
+// heading and the logo, with the legends required by Orden HAC/1177/2024.
+func (p *PdfBasic) verifactuQR(url string) error {
+	legendX := HeaderQRStartX - float64(HeaderQRLegendWidth-HeaderQRSize)/2 //nolint:mnd //centered on the code
+
+	p.setBoldNormalText()
+	p.SetXY(legendX, Margin)
+
+	err := p.CellWithOption(&gopdf.Rect{W: HeaderQRLegendWidth, H: LineHeight}, verifactu.Legend, p.getCellOptions(gopdf.Center))
+	if err != nil {
+		return err
+	}
+
+	err = p.qrCode(url, HeaderQRStartX, Margin+LineHeight, HeaderQRSize)
+	if err != nil {
+		return err
+	}
+
+	p.setSmallText()
+	p.SetXY(legendX, Margin+LineHeight+HeaderQRSize)
+
+	err = p.MultiCellWithOption(&gopdf.Rect{W: HeaderQRLegendWidth, H: LineHeight}, p.translator.T("verifactu_legend"),
+		p.getCellOptions(gopdf.Center))
+	if err != nil {
+		return err
+	}
+
+	if p.GetY() > p.lastYPos {
+		p.lastYPos = p.GetY()
+	}
+
+	return nil
+}
+
+// giroCode returns the content of the EPC QR code of an invoice for its
+// balance, or an empty string when it is disabled, the invoice is paid or it
+// can not be used for the invoice. Why it can not
+// be used is printed to stderr, as the PDF itself may be written to stdout.
+func (p *PdfBasic) giroCode(invoice *model.Invoice) string {
+	balance := p.balanceOf(invoice)
+	if !p.paymentQR || invoice.Currency != "EUR" || invoice.IsCreditNote() || balance <= 0 {
+		return ""
+	}
+
+	code, err := payment.EPCQR(invoice.Payment, balance, invoice.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Skipping payment QR code of invoice %s. %v\n", invoice.ID, err)
+		return ""
+	}
+
+	return code
+}
+
+func (p *PdfBasic) balanceOf(invoice *model.Invoice) float64 {
+	if p.balance == nil {
+		return invoice.Balance()
+	}
+
+	return p.balance(invoice)
+}
+
+// swissQRBillOf returns the QR-bill of an invoice for its balance, or nil when
+// it is disabled, the invoice is paid or it is not paid to a Swiss account. An
+// invoice that should carry the QR-bill but can not is an error, so it is not
+// sent without it.
+func (p *PdfBasic) swissQRBillOf(invoice *model.Invoice) (*payment.SwissQRBill, error) {
+	balance := p.balanceOf(invoice)
+	if !p.swissBill || invoice.IsCreditNote() || balance <= 0 || !payment.IsSwissAccount(invoice.Payment.Iban) {
+		return nil, nil
+	}
+
+	bill, err := payment.NewSwissQRBill(invoice, balance)
+	if err != nil {
+		return nil, fmt.Errorf("QR-bill of invoice %s: %w", invoice.ID, err)
+	}
+
+	return bill, nil
+}
+
+func (p *PdfBasic) qrCode(content string, x, y, size float64) error {
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return err
+	}
+
+	return p.ImageFrom(qr.Image(QRPixels), x, y, &gopdf.Rect{W: size, H: size})
+}
+
 func (p *PdfBasic) sendingInfo(from *model.Freelancer, client *model.Client) error {
 	startY := p.GetY()
 
@@ -224,7 +423,14 @@ func (p *PdfBasic) sendingInfo(from *model.Freelancer, client *model.Client) err
 }
 
 //nolint:funlen //TODO fix func length
-func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string, payment model.Payment) error {
+func (p *PdfBasic) items(
+	items []*model.Item,
+	inclusive bool,
+	totals model.Totals,
+	currency string,
+	paymentInfo model.Payment,
+	giroCode string,
+) error {
 	currSymbol := model.GetCurrencySymbol(currency)
 
 	p.setSubtleNormalText()
@@ -236,11 +442,7 @@ func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string
 
 	p.setNormalText()
 
-	subtotal := 0.
-
 	for _, item := range items {
-		subtotal += item.GetAmount()
-
 		err := p.itemTableRow(
 			item.Description,
 			strconv.Itoa(item.Quantity),
@@ -252,7 +454,14 @@ func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string
 		}
 	}
 
-	total := subtotal + tax.VatAmount(subtotal) - tax.RetentionAmount(subtotal)
+	if inclusive {
+		p.setSubtleNormalText()
+
+		err = p.itemTableRow(p.translator.T("prices_include_vat"), "", "", "")
+		if err != nil {
+			return err
+		}
+	}
 
 	p.Br(LineHeight)
 	startY := p.GetY()
@@ -281,11 +490,18 @@ func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string
 		return err
 	}
 
+	if giroCode != "" {
+		err = p.qrCode(giroCode, Margin+ItemDescWidth-PaymentQRSize, p.GetY(), PaymentQRSize)
+		if err != nil {
+			return err
+		}
+	}
+
 	p.Br(5)            //nolint:mnd //static value
 	p.SetX(Margin + 5) //nolint:mnd //static value
 	p.setNormalText()
 
-	err = p.CellWithOption(&gopdf.Rect{W: ItemQtyWidth}, p.translator.T("holder_label")+payment.Holder, p.getCellOptions(gopdf.Left))
+	err = p.CellWithOption(&gopdf.Rect{W: ItemQtyWidth}, p.translator.T("holder_label")+paymentInfo.Holder, p.getCellOptions(gopdf.Left))
 	if err != nil {
 		return err
 	}
@@ -293,7 +509,7 @@ func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string
 	p.Br(FromToLineHeight)
 	p.SetX(Margin + 5) //nolint:mnd //static value
 
-	err = p.CellWithOption(&gopdf.Rect{W: ItemQtyWidth}, p.translator.T("iban_label")+payment.Iban, p.getCellOptions(gopdf.Left))
+	err = p.CellWithOption(&gopdf.Rect{W: ItemQtyWidth}, p.translator.T("iban_label")+payment.FormatIBAN(paymentInfo.Iban), p.getCellOptions(gopdf.Left))
 	if err != nil {
 		return err
 	}
@@ -301,7 +517,7 @@ func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string
 	p.Br(FromToLineHeight)
 	p.SetX(Margin + 5) //nolint:mnd //static value
 
-	err = p.CellWithOption(&gopdf.Rect{W: ItemQtyWidth}, p.translator.T("swift_label")+payment.Swift, p.getCellOptions(gopdf.Left))
+	err = p.CellWithOption(&gopdf.Rect{W: ItemQtyWidth}, p.translator.T("swift_label")+paymentInfo.Swift, p.getCellOptions(gopdf.Left))
 	if err != nil {
 		return err
 	}
@@ -322,39 +538,36 @@ func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string
 		"",
 		p.translator.T("subtotal"),
 		"",
-		strconv.FormatFloat(subtotal, 'f', 2, 64)+currSymbol)
+		strconv.FormatFloat(totals.Subtotal, 'f', 2, 64)+currSymbol)
 	if err != nil {
 		return err
 	}
 
+	// Zero rated VAT and withholdings are explained by the notes, in the
+	// order of the notes
 	mark := "*"
-	vatLabel := p.translator.T("vat")
-	retentionLabel := p.translator.T("irpf")
 
-	if tax.Vat == 0 {
-		vatLabel += mark
-		mark += "*"
-	}
+	for _, tax := range totals.Taxes {
+		label := p.translator.T("tax_" + tax.Kind)
 
-	if tax.Retention != 0 {
-		retentionLabel += mark
-	}
+		switch {
+		case tax.Kind == model.TaxKindVat && tax.Rate == 0:
+			label += mark
+			mark += "*"
+		case tax.Kind == model.TaxKindWithholding && tax.Rate != 0:
+			label += mark
+		}
 
-	err = p.itemTableRow(
-		"",
-		vatLabel,
-		strconv.FormatFloat(tax.Vat, 'f', 0, 64)+"%",
-		strconv.FormatFloat(tax.VatAmount(subtotal), 'f', 2, 64)+currSymbol)
-	if err != nil {
-		return err
-	}
+		rate := strconv.FormatFloat(tax.Rate, 'f', -1, 64) + "%"
+		if tax.Amount < 0 {
+			rate = "-" + rate
+		}
 
-	if tax.Retention != 0 {
 		err = p.itemTableRow(
 			"",
-			retentionLabel,
-			"-"+strconv.FormatFloat(tax.Retention, 'f', 0, 64)+"%",
-			strconv.FormatFloat(-tax.RetentionAmount(subtotal), 'f', 2, 64)+currSymbol)
+			label,
+			rate,
+			strconv.FormatFloat(tax.Amount, 'f', 2, 64)+currSymbol)
 		if err != nil {
 			return err
 		}
@@ -366,11 +579,13 @@ func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string
 		"",
 		p.translator.T("total"),
 		"",
-		strconv.FormatFloat(total, 'f', 2, 64)+currSymbol)
+		strconv.FormatFloat(totals.Total, 'f', 2, 64)+currSymbol)
 	if err != nil {
 		return err
 	}
 
+	p.totalsYPos = p.GetY()
+
 	if p.GetY() > p.lastYPos {
 		p.lastYPos = p.GetY()
 	}
@@ -378,12 +593,72 @@ func (p *PdfBasic) items(items []*model.Item, tax model.TaxInfo, currency string
 	return nil
 }
 
-func (p *PdfBasic) notes(notes model.Notes) error {
+// paid writes the date of the last payment of a paid invoice, or the amount
+// still due after the payments of a partially paid one, below the totals.
+func (p *PdfBasic) paid(invoice *model.Invoice) error {
+	var label, value string
+
+	switch invoice.Status {
+	case model.StatusPaid:
+		label = p.translator.T("paid_on")
+		value = invoice.PaidOn().Format(string(DFYMD))
+	case model.StatusPartiallyPaid:
+		label = p.translator.T("amount_due")
+		value = strconv.FormatFloat(p.balanceOf(invoice), 'f', 2, 64) + model.GetCurrencySymbol(invoice.Currency)
+	default:
+		return nil
+	}
+
+	p.SetY(p.totalsYPos)
+	p.setSubtleNormalText()
+
+	err := p.itemTableRow("", label, "", value)
+	if err != nil {
+		return err
+	}
+
+	if p.GetY() > p.lastYPos {
+		p.lastYPos = p.GetY()
+	}
+
+	return nil
+}
+
+// homeVat writes the VAT in the home currency of an invoice issued in a
+// foreign currency, with the exchange rate used, below the totals.
+func (p *PdfBasic) homeVat(invoice *model.Invoice) error {
+	x := invoice.Exchange
+	if x == nil {
+		return nil
+	}
+
+	rate := fmt.Sprintf("1 %s = %s %s (%s)", x.From, strconv.FormatFloat(x.Rate, 'f', -1, 64), x.To, x.Date.Format(string(DFYMD)))
+	vat := strconv.FormatFloat(invoice.InHome().Totals().Vat, 'f', 2, 64) + model.GetCurrencySymbol(x.To)
+
+	p.SetY(p.totalsYPos)
+	p.setSubtleNormalText()
+
+	err := p.itemTableRow(rate, p.translator.T("vat_in")+" "+x.To, "", vat)
+	if err != nil {
+		return err
+	}
+
+	p.totalsYPos = p.GetY()
+
+	if p.GetY() > p.lastYPos {
+		p.lastYPos = p.GetY()
+	}
+
+	return nil
+}
+
+// notes writes the notes so they end a margin above bottom.
+func (p *PdfBasic) notes(notes model.Notes, bottom float64) error {
 	notesSlice := notes.ToSlice()
 	mark := ""
 
 	p.setNormalText()
-	p.SetY(float64(842 - Margin - len(notesSlice)*20))
+	p.SetY(bottom - Margin - notesHeight(notes))
 
 	for _, line := range notesSlice {
 		err := p.MultiCell(
@@ -402,6 +677,10 @@ func (p *PdfBasic) notes(notes model.Notes) error {
 	return nil
 }
 
+func notesHeight(notes model.Notes) float64 {
+	return float64(len(notes.ToSlice()) * 20) //nolint:mnd //static value
+}
+
 func (p *PdfBasic) draftOverlay() error {
 	p.setDraftText()
 
@@ -450,12 +729,12 @@ func (p *PdfBasic) itemTableRow(desc, qty, rate, total string) error {
 	return nil
 }
 
-func (p *PdfBasic) headingTitle() error {
+func (p *PdfBasic) headingTitle(title string) error {
 	p.setTitleText()
 
 	err := p.CellWithOption(
 		&gopdf.Rect{W: HeaderInfoWidth},
-		p.translator.T("invoice_caps"),
+		title,
 		p.getCellOptions(gopdf.Center),
 	)
 	if err != nil {
@@ -660,6 +939,24 @@ func (p *PdfBasic) setNormalText() {
 	}
 }
 
+func (p *PdfBasic) setBoldNormalText() {
+	p.SetTextColor(colorBlack())
+
+	err := p.SetFont("Inter-Bold", "", FontSizeNormal)
+	if err != nil {
+		fmt.Println("Error Loading font: 'Inter-Bold'")
+	}
+}
+
+func (p *PdfBasic) setSmallText() {
+	p.SetTextColor(colorBlack())
+
+	err := p.SetFont("Inter", "", FontSizeSmall)
+	if err != nil {
+		fmt.Println("Error Loading font: 'Inter'")
+	}
+}
+
 func (p *PdfBasic) setSubtleNormalText() {
 	p.SetTextColor(colorLavender())
 
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Inmovilizame/invoiceling/pkg/i18n"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func newTestRender(t *testing.T) *PdfBasic {
+	t.Helper()
+
+	r, err := NewPdfBasicRender(i18n.NewTranslator())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+// newTestInvoice returns an issued invoice of 121 EUR paid by transfer.
+func newTestInvoice() *model.Invoice {
+	invoice := model.NewInvoice("F25-001", 0, "EUR", "", "")
+	invoice.Status = model.StatusIssued
+	invoice.From = model.Freelancer{Name: "Ana García", VatID: "ES12345678Z"}
+	invoice.To = model.Client{ID: "acme", Name: "Acme", VatID: "ESB12345674"}
+	invoice.Payment = model.Payment{Holder: "Ana García", Iban: "ES91 2100 0418 4502 0005 1332", Swift: "CAIXESBBXXX"}
+	invoice.SetTaxes(21, 0, map[string]string{})
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: 100})
+
+	return invoice
+}
+
+func TestGiroCode(t *testing.T) {
+	partial := newTestInvoice()
+	partial.Payments = []model.InvoicePayment{{Amount: 21}}
+
+	dollars := newTestInvoice()
+	dollars.Currency = "USD"
+
+	creditNote := newTestInvoice()
+	creditNote.Type = model.TypeCreditNote
+
+	tests := []struct {
+		name    string
+		invoice *model.Invoice
+		balance func(*model.Invoice) float64
+		want    string
+	}{
+		{"balance after payments", partial, nil, "EUR100.00"},
+		{"balance from the service", newTestInvoice(), func(*model.Invoice) float64 { return 21 }, "EUR21.00"},
+		{"fully credited", newTestInvoice(), func(*model.Invoice) float64 { return 0 }, ""},
+		{"not in euros", dollars, nil, ""},
+		{"credit note", creditNote, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRender(t)
+			r.SetPaymentQR(true)
+			r.SetBalance(tt.balance)
+
+			got := r.giroCode(tt.invoice)
+			if tt.want == "" {
+				if got != "" {
+					t.Errorf("got %q, want no code", got)
+				}
+
+				return
+			}
+
+			if lines := strings.Split(got, "\n"); len(lines) != 11 || lines[7] != tt.want {
+				t.Errorf("got %q, want the amount %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := newTestRender(t).giroCode(newTestInvoice()); got != "" {
+		t.Errorf("got %q with the code disabled", got)
+	}
+}
@@ -0,0 +1,337 @@
+package render
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec //PDF file identifiers and checksums are defined as MD5
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/Inmovilizame/invoiceling/pkg/einvoice"
+)
+
+var (
+	ErrPdfStructure = errors.New("pdfa: unexpected PDF structure")
+
+	reStartXref = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	reSize      = regexp.MustCompile(`/Size\s+(\d+)`)
+	reRoot      = regexp.MustCompile(`/Root\s+(\d+)\s+0\s+R`)
+	reObject    = regexp.MustCompile(`(?s)(?:^|\n)(\d+) 0 obj\s*(<<.*?)\s*endobj`)
+)
+
+// pdfAttachment is a file embedded in a PDF/A-3 document as an associated file.
+type pdfAttachment struct {
+	Name         string
+	Description  string
+	MimeType     string
+	Relationship string
+	Data         []byte
+}
+
+// pdfA3Options holds the document information written in the Info dictionary
+// and the XMP metadata, which PDF/A requires to be consistent.
+type pdfA3Options struct {
+	Title      string
+	Author     string
+	Subject    string
+	Producer   string
+	Date       time.Time
+	Attachment pdfAttachment
+	// ExtraXMP is added to the rdf:RDF element of the XMP metadata.
+	ExtraXMP string
+}
+
+// convertToPDFA3 appends an incremental update to a PDF written by gopdf that
+// adds what PDF/A-3b needs: XMP metadata, an sRGB output intent, a file
+// identifier, explicit CID to GID maps and the embedded attachment.
+func convertToPDFA3(pdf []byte, opts pdfA3Options) ([]byte, error) {
+	prevXref, size, root, err := parseTrailer(pdf)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := parseObjects(pdf)
+
+	catalog, ok := objects[root]
+	if !ok {
+		return nil, fmt.Errorf("%w: catalog %d not found", ErrPdfStructure, root)
+	}
+
+	u := newPdfUpdate(pdf, size)
+
+	for num, dict := range objects {
+		if strings.Contains(dict, "/CIDFontType2") && !strings.Contains(dict, "/CIDToGIDMap") {
+			u.replace(num, insertEntries(dict, "/CIDToGIDMap /Identity"))
+		}
+	}
+
+	att := opts.Attachment
+	sum := md5.Sum(att.Data) //nolint:gosec //checksum required by the PDF specification
+	date := pdfDate(opts.Date)
+
+	fileObj := u.add(fmt.Sprintf(
+		"<< /Type /EmbeddedFile /Subtype /%s /Params << /Size %d /ModDate %s /CheckSum <%s> >> /Length %d >>",
+		pdfName(att.MimeType), len(att.Data), date, hex.EncodeToString(sum[:]), len(att.Data),
+	), att.Data)
+
+	specObj := u.add(fmt.Sprintf(
+		"<< /Type /Filespec /F %s /UF %s /Desc %s /AFRelationship /%s /EF << /F %d 0 R /UF %d 0 R >> >>",
+		pdfString(att.Name), pdfString(att.Name), pdfString(att.Description), att.Relationship, fileObj, fileObj,
+	), nil)
+
+	xmp := xmpMetadata(opts)
+	metaObj := u.add(fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>", len(xmp)), xmp)
+
+	icc := sRGBProfile()
+	iccObj := u.add(fmt.Sprintf("<< /N 3 /Length %d >>", len(icc)), icc)
+	intentObj := u.add(fmt.Sprintf(
+		"<< /Type /OutputIntent /S /GTS_PDFA1 /OutputConditionIdentifier (sRGB IEC61966-2.1) /Info (sRGB IEC61966-2.1) /DestOutputProfile %d 0 R >>",
+		iccObj,
+	), nil)
+
+	infoObj := u.add(fmt.Sprintf(
+		"<< /Title %s /Author %s /Subject %s /Producer %s /Creator %s /CreationDate %s /ModDate %s >>",
+		pdfString(opts.Title), pdfString(opts.Author), pdfString(opts.Subject),
+		pdfString(opts.Producer), pdfString(opts.Producer), date, date,
+	), nil)
+
+	u.replace(root, insertEntries(catalog, fmt.Sprintf(
+		"/Metadata %d 0 R /OutputIntents [%d 0 R] /AF [%d 0 R] "+
+			"/Names << /EmbeddedFiles << /Names [%s %d 0 R] >> >> /PageMode /UseAttachments",
+		metaObj, intentObj, specObj, pdfString(att.Name), specObj,
+	)))
+
+	return u.finish(root, infoObj, prevXref), nil
+}
+
+func parseTrailer(pdf []byte) (prevXref, size, root int, err error) {
+	m := reStartXref.FindSubmatch(pdf)
+	if m == nil {
+		return 0, 0, 0, fmt.Errorf("%w: startxref not found", ErrPdfStructure)
+	}
+
+	prevXref, _ = strconv.Atoi(string(m[1])) //nolint:errcheck //regexp guarantees digits
+
+	trailerPos := bytes.LastIndex(pdf, []byte("trailer"))
+	if trailerPos < 0 {
+		return 0, 0, 0, fmt.Errorf("%w: trailer not found", ErrPdfStructure)
+	}
+
+	trailer := pdf[trailerPos:]
+
+	sizeMatch := reSize.FindSubmatch(trailer)
+	rootMatch := reRoot.FindSubmatch(trailer)
+
+	if sizeMatch == nil || rootMatch == nil {
+		return 0, 0, 0, fmt.Errorf("%w: incomplete trailer", ErrPdfStructure)
+	}
+
+	size, _ = strconv.Atoi(string(sizeMatch[1])) //nolint:errcheck //regexp guarantees digits
+	root, _ = strconv.Atoi(string(rootMatch[1])) //nolint:errcheck //regexp guarantees digits
+
+	return prevXref, size, root, nil
+}
+
+// parseObjects returns the dictionary of every object without a stream.
+func parseObjects(pdf []byte) map[int]string {
+	objects := map[int]string{}
+
+	for _, m := range reObject.FindAllSubmatch(pdf, -1) {
+		body := string(m[2])
+		if strings.Contains(body, "stream") {
+			continue
+		}
+
+		num, _ := strconv.Atoi(string(m[1])) //nolint:errcheck //regexp guarantees digits
+		objects[num] = body
+	}
+
+	return objects
+}
+
+// insertEntries adds entries at the end of a dictionary.
+func insertEntries(dict, entries string) string {
+	end := strings.LastIndex(dict, ">>")
+	if end < 0 {
+		return dict
+	}
+
+	return dict[:end] + entries + "\n" + dict[end:]
+}
+
+// pdfUpdate collects the objects of an incremental update.
+type pdfUpdate struct {
+	base    []byte
+	body    bytes.Buffer
+	offsets map[int]int
+	next    int
+}
+
+func newPdfUpdate(base []byte, size int) *pdfUpdate {
+	u := &pdfUpdate{
+		base:    base,
+		offsets: map[int]int{},
+		next:    size,
+	}
+
+	if !bytes.HasSuffix(base, []byte("\n")) {
+		u.body.WriteByte('\n')
+	}
+
+	return u
+}
+
+func (u *pdfUpdate) add(dict string, stream []byte) int {
+	num := u.next
+	u.next++
+
+	u.write(num, dict, stream)
+
+	return num
+}
+
+func (u *pdfUpdate) replace(num int, dict string) {
+	u.write(num, dict, nil)
+}
+
+func (u *pdfUpdate) write(num int, dict string, stream []byte) {
+	u.offsets[num] = len(u.base) + u.body.Len()
+
+	fmt.Fprintf(&u.body, "%d 0 obj\n%s\n", num, dict)
+
+	if stream != nil {
+		u.body.WriteString("stream\n")
+		u.body.Write(stream)
+		u.body.WriteString("\nendstream\n")
+	}
+
+	u.body.WriteString("endobj\n")
+}
+
+// finish writes the cross reference section and trailer of the update.
+func (u *pdfUpdate) finish(root, info, prevXref int) []byte {
+	nums := make([]int, 0, len(u.offsets))
+	for num := range u.offsets {
+		nums = append(nums, num)
+	}
+
+	slices.Sort(nums)
+
+	xrefPos := len(u.base) + u.body.Len()
+	u.body.WriteString("xref\n")
+
+	for start := 0; start < len(nums); {
+		end := start + 1
+		for end < len(nums) && nums[end] == nums[end-1]+1 {
+			end++
+		}
+
+		fmt.Fprintf(&u.body, "%d %d\n", nums[start], end-start)
+
+		for _, num := range nums[start:end] {
+			fmt.Fprintf(&u.body, "%010d 00000 n \n", u.offsets[num])
+		}
+
+		start = end
+	}
+
+	id := md5.Sum(u.base) //nolint:gosec //file identifier, not a security feature
+	fileID := hex.EncodeToString(id[:])
+
+	fmt.Fprintf(&u.body,
+		"trailer\n<<\n/Size %d\n/Root %d 0 R\n/Info %d 0 R\n/Prev %d\n/ID [<%s> <%s>]\n>>\nstartxref\n%d\n%%%%EOF\n",
+		u.next, root, info, prevXref, fileID, fileID, xrefPos,
+	)
+
+	return append(slices.Clone(u.base), u.body.Bytes()...)
+}
+
+// pdfString encodes text as a PDF string, using UTF-16BE for non ASCII text.
+func pdfString(s string) string {
+	ascii := true
+
+	for _, r := range s {
+		if r > '~' || r < ' ' {
+			ascii = false
+			break
+		}
+	}
+
+	if ascii {
+		return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s) + ")"
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString("<FEFF")
+
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&buf, "%04X", u)
+	}
+
+	buf.WriteString(">")
+
+	return buf.String()
+}
+
+// pdfName escapes a value to be used as a PDF name, as in /text#2Fxml.
+func pdfName(s string) string {
+	b := strings.Builder{}
+
+	for _, c := range []byte(s) {
+		if c < '!' || c > '~' || strings.IndexByte("#/()<>[]{}%", c) >= 0 {
+			fmt.Fprintf(&b, "#%02X", c)
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	return fmt.Sprintf("(D:%s%s%02d'%02d')", t.Format("20060102150405"), sign, offset/3600, offset%3600/60) //nolint:mnd //seconds per hour and minute
+}
+
+func xmpMetadata(opts pdfA3Options) []byte {
+	date := opts.Date.Format(time.RFC3339)
+
+	return []byte(`<?xpacket begin="` + "\uFEFF" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">
+<pdfaid:part>3</pdfaid:part>
+<pdfaid:conformance>B</pdfaid:conformance>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:format>application/pdf</dc:format>
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">` + einvoice.EscapeXML(opts.Title) + `</rdf:li></rdf:Alt></dc:title>
+<dc:creator><rdf:Seq><rdf:li>` + einvoice.EscapeXML(opts.Author) + `</rdf:li></rdf:Seq></dc:creator>
+<dc:description><rdf:Alt><rdf:li xml:lang="x-default">` + einvoice.EscapeXML(opts.Subject) + `</rdf:li></rdf:Alt></dc:description>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/">
+<pdf:Producer>` + einvoice.EscapeXML(opts.Producer) + `</pdf:Producer>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/">
+<xmp:CreatorTool>` + einvoice.EscapeXML(opts.Producer) + `</xmp:CreatorTool>
+<xmp:CreateDate>` + date + `</xmp:CreateDate>
+<xmp:ModifyDate>` + date + `</xmp:ModifyDate>
+</rdf:Description>
+` + opts.ExtraXMP + `</rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`)
+}
@@ -0,0 +1,301 @@
+package render
+
+import (
+	"strconv"
+
+	"github.com/Inmovilizame/invoiceling/pkg/payment"
+	"github.com/signintech/gopdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// Dimensions of the QR-bill payment part in points, from the millimetres of
+// the SIX Swiss Implementation Guidelines for the QR-bill.
+const (
+	mm = 72 / 25.4
+
+	SwissBillHeight     = 105 * mm
+	SwissReceiptWidth   = 62 * mm
+	SwissBillMargin     = 5 * mm
+	SwissQRStartX       = SwissReceiptWidth + SwissBillMargin
+	SwissQRStartY       = 17 * mm
+	SwissQRSize         = 46 * mm
+	SwissCrossSize      = 7 * mm
+	SwissCrossBorder    = 0.5 * mm
+	SwissAmountStartY   = 68 * mm
+	SwissAcceptanceY    = 82 * mm
+	SwissInfoStartX     = 118 * mm
+	SwissReceiptInfoW   = 52 * mm
+	SwissCurrencyWidth  = 15 * mm
+	SwissReceiptBlankW  = 52 * mm
+	SwissReceiptBlankH  = 20 * mm
+	SwissPaymentBlankW  = 65 * mm
+	SwissPaymentBlankH  = 25 * mm
+	SwissBlankCornerLen = 3 * mm
+
+	FontSizeSwissTitle          = 11
+	FontSizeSwissHeading        = 8
+	FontSizeSwissValue          = 10
+	FontSizeSwissReceiptHeading = 6
+	FontSizeSwissReceiptValue   = 8
+)
+
+// The QR-bill may only be printed in German, French, Italian or English.
+const (
+	swissReceipt          = "Receipt"
+	swissPaymentPart      = "Payment part"
+	swissAccount          = "Account / Payable to"
+	swissReference        = "Reference"
+	swissInformation      = "Additional information"
+	swissPayableBy        = "Payable by"
+	swissPayableByBlank   = "Payable by (name/address)"
+	swissCurrency         = "Currency"
+	swissAmount           = "Amount"
+	swissAcceptancePoint  = "Acceptance point"
+	swissCrossArmWidth    = 6. / 32
+	swissCrossArmLength   = 20. / 32
+	swissLineSpacingRatio = 1.1
+)
+
+// swissQRBill draws the QR-bill payment part and receipt in the bottom 105 mm
+// of the current page.
+func (p *PdfBasic) swissQRBill(bill *payment.SwissQRBill) error {
+	top := gopdf.PageSizeA4.H - SwissBillHeight
+
+	p.SetStrokeColor(colorBlack())
+	p.SetLineWidth(0.5) //nolint:mnd //thin separation line
+	p.SetLineType("dashed")
+	p.Line(0, top, gopdf.PageSizeA4.W, top)
+	p.Line(SwissReceiptWidth, top, SwissReceiptWidth, gopdf.PageSizeA4.H)
+	p.SetLineType("solid")
+
+	err := p.swissReceipt(bill, top)
+	if err != nil {
+		return err
+	}
+
+	return p.swissPaymentPart(bill, top)
+}
+
+func (p *PdfBasic) swissReceipt(bill *payment.SwissQRBill, top float64) error {
+	x := SwissBillMargin
+	lh := FontSizeSwissReceiptValue * swissLineSpacingRatio
+
+	err := p.swissText(x, top+SwissBillMargin, "Inter-Bold", FontSizeSwissTitle, swissReceipt)
+	if err != nil {
+		return err
+	}
+
+	y := top + SwissQRStartY - lh
+
+	sections := [][]string{
+		append([]string{swissAccount, bill.FormattedAccount()}, bill.Creditor.Lines()...),
+	}
+
+	if bill.ReferenceType != payment.ReferenceNone {
+		sections = append(sections, []string{swissReference, bill.FormattedReference()})
+	}
+
+	if bill.Debtor != nil {
+		sections = append(sections, append([]string{swissPayableBy}, bill.Debtor.Lines()...))
+	}
+
+	y, err = p.swissSections(x, y, FontSizeSwissReceiptHeading, FontSizeSwissReceiptValue, sections)
+	if err != nil {
+		return err
+	}
+
+	if bill.Debtor == nil {
+		err = p.swissText(x, y, "Inter-Bold", FontSizeSwissReceiptHeading, swissPayableByBlank)
+		if err != nil {
+			return err
+		}
+
+		p.swissBlankField(x, y+lh, SwissReceiptBlankW, SwissReceiptBlankH)
+	}
+
+	err = p.swissAmount(x, top+SwissAmountStartY, FontSizeSwissReceiptHeading, FontSizeSwissReceiptValue, bill)
+	if err != nil {
+		return err
+	}
+
+	err = p.SetFont("Inter-Bold", "", FontSizeSwissReceiptHeading)
+	if err != nil {
+		return err
+	}
+
+	p.SetXY(x, top+SwissAcceptanceY)
+
+	return p.CellWithOption(
+		&gopdf.Rect{W: SwissReceiptInfoW, H: lh},
+		swissAcceptancePoint,
+		gopdf.CellOption{Align: gopdf.Right},
+	)
+}
+
+func (p *PdfBasic) swissPaymentPart(bill *payment.SwissQRBill, top float64) error {
+	err := p.swissText(SwissQRStartX, top+SwissBillMargin, "Inter-Bold", FontSizeSwissTitle, swissPaymentPart)
+	if err != nil {
+		return err
+	}
+
+	qr, err := qrcode.New(bill.Payload(), qrcode.Medium)
+	if err != nil {
+		return err
+	}
+
+	qr.DisableBorder = true
+
+	err = p.ImageFrom(qr.Image(QRPixels), SwissQRStartX, top+SwissQRStartY, &gopdf.Rect{W: SwissQRSize, H: SwissQRSize})
+	if err != nil {
+		return err
+	}
+
+	p.swissCross(SwissQRStartX+(SwissQRSize-SwissCrossSize)/2, top+SwissQRStartY+(SwissQRSize-SwissCrossSize)/2) //nolint:mnd //centered
+
+	err = p.swissAmount(SwissQRStartX, top+SwissAmountStartY, FontSizeSwissHeading, FontSizeSwissValue, bill)
+	if err != nil {
+		return err
+	}
+
+	sections := [][]string{
+		append([]string{swissAccount, bill.FormattedAccount()}, bill.Creditor.Lines()...),
+	}
+
+	if bill.ReferenceType != payment.ReferenceNone {
+		sections = append(sections, []string{swissReference, bill.FormattedReference()})
+	}
+
+	if bill.Message != "" {
+		sections = append(sections, []string{swissInformation, bill.Message})
+	}
+
+	if bill.Debtor != nil {
+		sections = append(sections, append([]string{swissPayableBy}, bill.Debtor.Lines()...))
+	}
+
+	y, err := p.swissSections(SwissInfoStartX, top+SwissBillMargin, FontSizeSwissHeading, FontSizeSwissValue, sections)
+	if err != nil {
+		return err
+	}
+
+	if bill.Debtor == nil {
+		err = p.swissText(SwissInfoStartX, y, "Inter-Bold", FontSizeSwissHeading, swissPayableByBlank)
+		if err != nil {
+			return err
+		}
+
+		p.swissBlankField(SwissInfoStartX, y+FontSizeSwissValue*swissLineSpacingRatio, SwissPaymentBlankW, SwissPaymentBlankH)
+	}
+
+	return nil
+}
+
+// swissSections writes headed blocks of lines from y and returns the position
+// after the last one.
+func (p *PdfBasic) swissSections(x, y, headingSize, valueSize float64, sections [][]string) (float64, error) {
+	lh := valueSize * swissLineSpacingRatio
+
+	for _, section := range sections {
+		err := p.swissText(x, y, "Inter-Bold", headingSize, section[0])
+		if err != nil {
+			return 0, err
+		}
+
+		y += lh
+
+		for _, line := range section[1:] {
+			err = p.swissText(x, y, "Inter", valueSize, line)
+			if err != nil {
+				return 0, err
+			}
+
+			y += lh
+		}
+
+		y += lh / 2 //nolint:mnd //space between sections
+	}
+
+	return y, nil
+}
+
+func (p *PdfBasic) swissAmount(x, y, headingSize, valueSize float64, bill *payment.SwissQRBill) error {
+	lh := valueSize * swissLineSpacingRatio
+
+	err := p.swissText(x, y, "Inter-Bold", headingSize, swissCurrency)
+	if err != nil {
+		return err
+	}
+
+	err = p.swissText(x+SwissCurrencyWidth, y, "Inter-Bold", headingSize, swissAmount)
+	if err != nil {
+		return err
+	}
+
+	err = p.swissText(x, y+lh, "Inter", valueSize, bill.Currency)
+	if err != nil {
+		return err
+	}
+
+	return p.swissText(x+SwissCurrencyWidth, y+lh, "Inter", valueSize, swissFormatAmount(bill.Amount))
+}
+
+func (p *PdfBasic) swissText(x, y float64, font string, size float64, text string) error {
+	p.SetTextColor(0, 0, 0)
+
+	err := p.SetFont(font, "", size)
+	if err != nil {
+		return err
+	}
+
+	p.SetXY(x, y)
+
+	return p.Cell(nil, text)
+}
+
+// swissCross draws the Swiss cross placed on top of the QR code.
+func (p *PdfBasic) swissCross(x, y float64) {
+	inner := SwissCrossSize - 2*SwissCrossBorder //nolint:mnd //both sides
+	arm := inner * swissCrossArmWidth
+	length := inner * swissCrossArmLength
+
+	p.SetFillColor(255, 255, 255) //nolint:mnd //white
+	p.RectFromUpperLeftWithStyle(x, y, SwissCrossSize, SwissCrossSize, "F")
+	p.SetFillColor(0, 0, 0)
+	p.RectFromUpperLeftWithStyle(x+SwissCrossBorder, y+SwissCrossBorder, inner, inner, "F")
+
+	cx, cy := x+SwissCrossSize/2, y+SwissCrossSize/2 //nolint:mnd //center
+
+	p.SetFillColor(255, 255, 255)                                         //nolint:mnd //white
+	p.RectFromUpperLeftWithStyle(cx-arm/2, cy-length/2, arm, length, "F") //nolint:mnd //centered
+	p.RectFromUpperLeftWithStyle(cx-length/2, cy-arm/2, length, arm, "F") //nolint:mnd //centered
+}
+
+// swissBlankField draws the corner marks of a field left blank for the debtor
+// to fill in.
+func (p *PdfBasic) swissBlankField(x, y, w, h float64) {
+	c := SwissBlankCornerLen
+
+	p.SetStrokeColor(0, 0, 0)
+	p.SetLineWidth(0.75) //nolint:mnd //corner marks width
+	p.Line(x, y, x+c, y)
+	p.Line(x, y, x, y+c)
+	p.Line(x+w-c, y, x+w, y)
+	p.Line(x+w, y, x+w, y+c)
+	p.Line(x, y+h, x+c, y+h)
+	p.Line(x, y+h-c, x, y+h)
+	p.Line(x+w-c, y+h, x+w, y+h)
+	p.Line(x+w, y+h-c, x+w, y+h)
+}
+
+// swissFormatAmount formats amounts with a space as thousands separator, as
+// the QR-bill requires.
+func swissFormatAmount(amount float64) string {
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	integer, decimals := s[:len(s)-3], s[len(s)-3:]
+
+	for i := len(integer) - 3; i > 0; i -= 3 {
+		integer = integer[:i] + " " + integer[i:]
+	}
+
+	return integer + decimals
+}
@@ -0,0 +1,123 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Widths of the fields of the AEAT record designs.
+const (
+	amountWidth  = 17
+	percentWidth = 5
+	nifWidth     = 9
+	nameWidth    = 80
+	auxReserved  = 70
+	auxVersion   = "0100"
+	auxTrailer   = 213
+	centsFactor  = 100
+)
+
+// Kinds of return in the AEAT files.
+const (
+	declarationPay      = "I"
+	declarationRefund   = "D"
+	declarationNegative = "N"
+)
+
+// Taxpayer identifies who files a return.
+type Taxpayer struct {
+	// NIF is the Spanish tax id, without the ES prefix of the VAT number.
+	NIF   string
+	Name  string
+	Phone string
+}
+
+// File returns the return as a fixed-width file following the record design
+// of the AEAT for importing it in the online form: the header and trailer of
+// the model, the reserved AUX record and the first page, with the boxes in
+// the order of the form. Boxes not filled by invoiceling are zero, so the
+// file should be checked in the online form before filing.
+func (r *Return) File(taxpayer Taxpayer) []byte {
+	var b strings.Builder
+
+	model := r.Model + "0"
+	period := strconv.Itoa(r.Year) + r.Period
+
+	fmt.Fprintf(&b, "<T%s%s0000>", model, period)
+	b.WriteString("<AUX>")
+	b.WriteString(strings.Repeat(" ", auxReserved))
+	b.WriteString(auxVersion)
+	b.WriteString(strings.Repeat(" ", 4+nifWidth+auxTrailer)) //nolint:mnd //reserved positions
+	b.WriteString("</AUX>")
+
+	fmt.Fprintf(&b, "<T%s01000>", r.Model)
+	b.WriteString(" ") // not a complementary page
+	b.WriteString(r.declarationType())
+	b.WriteString(alpha(taxpayer.NIF, nifWidth))
+	b.WriteString(alpha(taxpayer.Name, nameWidth))
+	b.WriteString(period)
+
+	for _, def := range r.layout {
+		if def.Percent {
+			b.WriteString(number(r.Value(def.Number), percentWidth))
+		} else {
+			b.WriteString(number(r.Value(def.Number), amountWidth))
+		}
+	}
+
+	fmt.Fprintf(&b, "</T%s01000>", r.Model)
+	fmt.Fprintf(&b, "</T%s%s0000>", model, period)
+
+	return latin1(b.String())
+}
+
+func (r *Return) declarationType() string {
+	switch result := r.Result(); {
+	case result > 0:
+		return declarationPay
+	case result < 0 && r.Model == "303":
+		return declarationRefund
+	default:
+		return declarationNegative
+	}
+}
+
+// alpha left aligns an uppercase text in a field, cutting it when longer.
+func alpha(s string, width int) string {
+	runes := []rune(strings.ToUpper(s))
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
+
+// latin1 encodes a file in ISO-8859-1, the charset of the AEAT files, so every
+// character takes a single position. Characters out of it become '?'.
+func latin1(s string) []byte {
+	out := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		if r > unicode.MaxLatin1 {
+			r = '?'
+		}
+
+		out = append(out, byte(r))
+	}
+
+	return out
+}
+
+// number writes an amount in cents right aligned and zero padded, negative
+// amounts starting with N as required by the record designs.
+func number(value float64, width int) string {
+	cents := int64(math.Round(math.Abs(value) * centsFactor))
+	if value < 0 {
+		return "N" + fmt.Sprintf("%0*d", width-1, cents)
+	}
+
+	return fmt.Sprintf("%0*d", width, cents)
+}
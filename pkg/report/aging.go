@@ -0,0 +1,139 @@
+package report
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+const hoursInDay = 24
+
+// AgingBuckets are the labels of the aging buckets: not due yet, and the days
+// past the due date.
+var AgingBuckets = []string{"Current", "1-30", "31-60", "61-90", "90+"}
+
+// agingLimits are the last day late of each bucket but the last one.
+var agingLimits = []int{0, 30, 60, 90}
+
+// Receivable is an invoice with an amount still owed.
+type Receivable struct {
+	Invoice     *model.Invoice
+	Outstanding float64
+	// DaysLate is the number of days past the due date, 0 or negative when
+	// the invoice is not due yet.
+	DaysLate int
+}
+
+// Overdue reports whether the receivable is past its due date.
+func (r Receivable) Overdue() bool {
+	return r.DaysLate > 0
+}
+
+// Bucket returns the index of the aging bucket of the receivable.
+func (r Receivable) Bucket() int {
+	for i, limit := range agingLimits {
+		if r.DaysLate <= limit {
+			return i
+		}
+	}
+
+	return len(agingLimits)
+}
+
+// Receivables returns the issued invoices with an amount still owed on a
+// date, oldest due date first. Payments and credit notes reduce the amount
+// owed on the invoice they settle or correct.
+func Receivables(invoices []*model.Invoice, now time.Time) []Receivable {
+	credited := map[string]float64{}
+
+	for _, invoice := range invoices {
+		if invoice.IsCreditNote() {
+			credited[invoice.Corrects] += invoice.Totals().Total
+		}
+	}
+
+	today := day(now)
+	receivables := make([]Receivable, 0)
+
+	for _, invoice := range invoices {
+		if invoice.IsCreditNote() {
+			continue
+		}
+
+		outstanding := model.Round(invoice.Totals().Total - credited[invoice.ID] - invoice.Paid())
+		if outstanding <= 0 {
+			continue
+		}
+
+		receivables = append(receivables, Receivable{
+			Invoice:     invoice,
+			Outstanding: outstanding,
+			DaysLate:    int(math.Round(today.Sub(day(invoice.DueDate())).Hours() / hoursInDay)),
+		})
+	}
+
+	slices.SortFunc(receivables, func(a, b Receivable) int {
+		return a.Invoice.DueDate().Compare(b.Invoice.DueDate())
+	})
+
+	return receivables
+}
+
+// ClientAging is the amount owed by a client in each aging bucket.
+type ClientAging struct {
+	VatID   string
+	Name    string
+	Buckets []float64
+	Total   float64
+}
+
+// Aging groups receivables by the VAT number of the client, the clients owing
+// more first.
+func Aging(receivables []Receivable) []*ClientAging {
+	byClient := map[string]*ClientAging{}
+
+	for _, r := range receivables {
+		key := vat.Normalize(r.Invoice.To.VatID)
+		if key == "" {
+			key = r.Invoice.To.ID
+		}
+
+		client, ok := byClient[key]
+		if !ok {
+			client = &ClientAging{
+				VatID:   r.Invoice.To.VatID,
+				Name:    r.Invoice.To.Name,
+				Buckets: make([]float64, len(AgingBuckets)),
+			}
+			byClient[key] = client
+		}
+
+		client.Buckets[r.Bucket()] += r.Outstanding
+		client.Total += r.Outstanding
+	}
+
+	clients := make([]*ClientAging, 0, len(byClient))
+	for _, client := range byClient {
+		clients = append(clients, client)
+	}
+
+	slices.SortFunc(clients, func(a, b *ClientAging) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return clients
+}
+
+// day truncates a time to the start of its day in its location.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
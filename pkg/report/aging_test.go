@@ -0,0 +1,38 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func TestReceivablesAndAging(t *testing.T) {
+	late := newTestInvoice("F25-001", time.January, "ESB12345674", 21, 0, 1000)
+	late.Payments = []model.InvoicePayment{{Date: late.Date, Amount: 210}}
+
+	credited := newTestInvoice("F25-002", time.January, "ESB12345674", 21, 0, 100)
+	partial := creditNote(credited, "F25-003")
+
+	current := newTestInvoice("F25-004", time.March, "DE136695976", 0, 0, 500)
+
+	now := time.Date(2025, 3, 15, 18, 0, 0, 0, time.Local)
+	receivables := Receivables([]*model.Invoice{current, late, credited, partial}, now)
+
+	if len(receivables) != 2 {
+		t.Fatalf("got %+v, want the late and current invoices", receivables)
+	}
+
+	if r := receivables[0]; r.Invoice.ID != "F25-001" || r.Outstanding != 1000 || r.DaysLate != 43 || r.Bucket() != 2 {
+		t.Errorf("got %s owing %v, %d days late in bucket %d", r.Invoice.ID, r.Outstanding, r.DaysLate, r.Bucket())
+	}
+
+	if r := receivables[1]; r.Overdue() || r.Bucket() != 0 {
+		t.Errorf("got %+v, want it current", r)
+	}
+
+	clients := Aging(receivables)
+	if len(clients) != 2 || clients[0].Total != 1000 || clients[0].Buckets[2] != 1000 || clients[1].Buckets[0] != 500 {
+		t.Errorf("got %+v, %+v", clients[0], clients[1])
+	}
+}
@@ -0,0 +1,188 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+// Threshold347 is the yearly amount, taxes included, above which the
+// operations with a client are declared in the 347.
+const Threshold347 = 3005.06
+
+var spanishPostCode = regexp.MustCompile(`\b(0[1-9]|[1-4][0-9]|5[0-2])[0-9]{3}\b`)
+
+// ClientYear sums the invoices and credit notes of a client in a year.
+type ClientYear struct {
+	VatID string
+	Name  string
+	// NIF is the Spanish tax id of the client, empty for foreign clients.
+	NIF string
+	// Province is the province code of the postal code of Spanish clients.
+	Province string
+
+	Invoices int
+	Net      float64
+	Taxes    float64
+	Withheld float64
+	Total    float64
+
+	// Quarters holds the amount invoiced in each quarter, taxes included and
+	// withholdings not subtracted.
+	Quarters [quartersInYear]float64
+	// Quarters347 holds the part of Quarters declared in the 347: supplies
+	// to Spanish clients not subject to withholding, which the client
+	// declares itself, nor declared in the 349.
+	Quarters347 [quartersInYear]float64
+}
+
+// Amount347 returns the yearly amount of the operations declared in the 347.
+func (c *ClientYear) Amount347() float64 {
+	sum := 0.
+	for _, amount := range c.Quarters347 {
+		sum += amount
+	}
+
+	return model.Round(sum)
+}
+
+// Declared reports whether the client crosses the 347 threshold.
+func (c *ClientYear) Declared() bool {
+	return c.Amount347() > Threshold347
+}
+
+// Summary groups the invoices and credit notes dated in a year by the VAT
+// number of the client, sorted by client name.
+func Summary(invoices []*model.Invoice, year int) []*ClientYear {
+	byClient := map[string]*ClientYear{}
+
+	for _, invoice := range invoices {
+		if invoice.Date.Year() != year {
+			continue
+		}
+
+		key := vat.Normalize(invoice.To.VatID)
+		if key == "" {
+			key = invoice.To.ID
+		}
+
+		client, ok := byClient[key]
+		if !ok {
+			client = &ClientYear{VatID: invoice.To.VatID, Name: invoice.To.Name}
+			client.NIF, client.Province = spanishClient(&invoice.To)
+			byClient[key] = client
+		}
+
+		s := sign(invoice)
+		totals := invoice.Totals()
+		gross := s * (totals.Total + totals.Retention)
+		quarter := QuarterOf(invoice.Date).Number - 1
+
+		client.Invoices++
+		client.Net += s * totals.Subtotal
+		client.Taxes += gross - s*totals.Subtotal
+		client.Withheld += s * totals.Retention
+		client.Total += s * totals.Total
+		client.Quarters[quarter] += gross
+
+		if declared347(client, invoice, totals) {
+			client.Quarters347[quarter] += gross
+		}
+	}
+
+	clients := make([]*ClientYear, 0, len(byClient))
+	for _, client := range byClient {
+		clients = append(clients, client)
+	}
+
+	slices.SortFunc(clients, func(a, b *ClientYear) int {
+		return strings.Compare(strings.ToUpper(a.Name), strings.ToUpper(b.Name))
+	})
+
+	return clients
+}
+
+func declared347(client *ClientYear, invoice *model.Invoice, totals model.Totals) bool {
+	if client.NIF == "" || totals.Retention != 0 {
+		return false
+	}
+
+	t := treatment(invoice)
+
+	return t == tax.TreatmentDomestic || t == tax.TreatmentExempt
+}
+
+// spanishClient returns the Spanish tax id of a client, with or without the ES
+// prefix, and the province code of its postal code.
+func spanishClient(client *model.Client) (nif, province string) {
+	id := vat.Normalize(client.VatID)
+	if !strings.HasPrefix(id, "ES") {
+		id = "ES" + id
+	}
+
+	if vat.Validate(id) != nil {
+		return "", ""
+	}
+
+	for _, line := range []string{client.Address2, client.Address1} {
+		if postCode := spanishPostCode.FindString(line); postCode != "" {
+			province = postCode[:2]
+
+			break
+		}
+	}
+
+	return strings.TrimPrefix(id, "ES"), province
+}
+
+// SummaryCSV writes the client summary as CSV, one row per client.
+func SummaryCSV(clients []*ClientYear) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+
+	header := []string{"vat_id", "name", "invoices", "net", "taxes", "withheld", "total", "q1", "q2", "q3", "q4", "amount_347", "declared_347"}
+
+	err := w.Write(header)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range clients {
+		row := []string{
+			c.VatID,
+			c.Name,
+			strconv.Itoa(c.Invoices),
+			formatCSV(c.Net),
+			formatCSV(c.Taxes),
+			formatCSV(c.Withheld),
+			formatCSV(c.Total),
+		}
+
+		for _, amount := range c.Quarters {
+			row = append(row, formatCSV(amount))
+		}
+
+		row = append(row, formatCSV(c.Amount347()), strconv.FormatBool(c.Declared()))
+
+		err = w.Write(row)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+func formatCSV(amount float64) string {
+	return strconv.FormatFloat(model.Round(amount), 'f', 2, 64)
+}
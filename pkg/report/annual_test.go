@@ -0,0 +1,170 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func TestModel390(t *testing.T) {
+	services := newTestInvoice("F25-001", time.January, "ESB12345674", 21, 15, 1000)
+	shop := newTestInvoice("F25-005", time.April, "ESB12345674", 21, 0, 200)
+	shop.Tax.Taxes = append(shop.Tax.Taxes, model.NewTax(model.TaxKindSurcharge, 5.2))
+
+	last := newTestInvoice("F24-100", time.December, "ESB12345674", 21, 0, 9999)
+	last.Date = last.Date.AddDate(-1, 0, 0)
+
+	invoices := []*model.Invoice{
+		last,
+		services,
+		newTestInvoice("F25-002", time.February, "ESB12345674", 4, 0, 100),
+		creditNote(services, "F25-003"),
+		newTestInvoice("F25-004", time.March, "DE136695976", 0, 0, 500),
+		shop,
+		newTestInvoice("F25-006", time.June, "ESB12345674", 10, 0, 300),
+	}
+
+	r, err := Model390(invoices, 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first quarter pays 4 and the second 21% of 200, 5.2% of 200 and
+	// 10% of 300.
+	want := map[string]float64{
+		"01": 100, "02": 4, "03": 300, "04": 30, "05": 1200, "06": 252, "11": 200, "12": 10.4,
+		box390CorrectedBase: -1000, box390CorrectedVat: -210,
+		box390Accrued: 86.4, box390Deductible: 0, box390Result: 86.4, box390Paid: 86.4,
+		box390Domestic: 600, box390NotSubject: 500, box390Volume: 1100,
+	}
+
+	for box, value := range want {
+		if got := r.Value(box); got != value {
+			t.Errorf("box %s: got %v, want %v", box, got, value)
+		}
+	}
+
+	if r.Period != periodYear || r.Result() != 86.4 {
+		t.Errorf("got result %v for period %s, want box 65, 86.4, for 0A", r.Result(), r.Period)
+	}
+
+	if _, err := Model390([]*model.Invoice{newTestInvoice("F25-001", time.May, "ESB12345674", 7, 0, 100)}, 2025); err == nil {
+		t.Error("got no error for a 7% rate")
+	}
+}
+
+// newTestClients returns the invoices of a year to a Spanish client over the
+// 347 threshold, one at it, a professional who withholds IRPF and a German
+// business.
+func newTestClients() []*model.Invoice {
+	over := func(id string, month time.Month, rate float64) *model.Invoice {
+		invoice := newTestInvoice(id, month, "ESB12345674", 21, 0, rate)
+		invoice.To.Name = "Acme"
+		invoice.To.Address2 = "28013 Madrid"
+
+		return invoice
+	}
+
+	refunded := over("F25-002", time.May, 2000)
+	refund := creditNote(refunded, "F25-004")
+	refund.Date = time.Date(2025, time.August, 1, 10, 0, 0, 0, time.Local)
+
+	// 2,483.52 plus 21% is 3,005.06.
+	at := newTestInvoice("F25-005", time.June, "ES87654321X", 21, 0, 2483.52)
+	at.To.Name = "Bea"
+
+	professional := newTestInvoice("F25-006", time.March, "ES00000000T", 21, 15, 5000)
+	professional.To.Name = "Carlos"
+
+	german := newTestInvoice("F25-007", time.March, "DE136695976", 0, 0, 5000)
+	german.To.Name = "Dresden GmbH"
+
+	return []*model.Invoice{
+		over("F25-001", time.February, 2000),
+		refunded,
+		over("F25-003", time.September, 500),
+		refund,
+		at,
+		professional,
+		german,
+	}
+}
+
+func TestSummary(t *testing.T) {
+	clients := Summary(newTestClients(), 2025)
+	if len(clients) != 4 {
+		t.Fatalf("got %d clients, want 4", len(clients))
+	}
+
+	acme, bea, carlos, german := clients[0], clients[1], clients[2], clients[3]
+
+	if acme.NIF != "B12345674" || acme.Province != "28" || acme.Invoices != 4 || acme.Net != 2500 || acme.Total != 3025 {
+		t.Errorf("got %+v, want 4 invoices to B12345674 of Madrid adding up to 3,025", acme)
+	}
+
+	if acme.Quarters347 != [4]float64{2420, 2420, -1815, 0} || acme.Amount347() != 3025 || !acme.Declared() {
+		t.Errorf("got quarters %v and amount %v, want them declared by quarter with the credit note", acme.Quarters347, acme.Amount347())
+	}
+
+	if bea.Amount347() != Threshold347 || bea.Declared() {
+		t.Errorf("got amount %v declared %v, want the threshold itself left out", bea.Amount347(), bea.Declared())
+	}
+
+	if carlos.Quarters[0] != 6050 || carlos.Withheld != 750 || carlos.Amount347() != 0 || carlos.Declared() {
+		t.Errorf("got %+v, want the invoice with IRPF withheld out of the 347", carlos)
+	}
+
+	if german.NIF != "" || german.Quarters[0] != 5000 || german.Declared() {
+		t.Errorf("got %+v, want the foreign client out of the 347", german)
+	}
+}
+
+func TestFile347(t *testing.T) {
+	file := string(File347(Summary(newTestClients(), 2025), 2025, Taxpayer{NIF: "12345678Z", Name: "Ana García", Phone: "600 111 222"}))
+
+	records := strings.Split(strings.TrimSuffix(file, "\r\n"), "\r\n")
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want the declarant and Acme", len(records))
+	}
+
+	for _, record := range records {
+		if len(record) != recordWidth347 {
+			t.Errorf("got a record of %d characters, want %d", len(record), recordWidth347)
+		}
+	}
+
+	declarant, acme := records[0], records[1]
+
+	// The name is uppercase in ISO-8859-1 and the phone keeps only digits.
+	if want := "13472025" + "12345678Z" + "ANA GARC\xcdA"; !strings.HasPrefix(declarant, want) || declarant[57:67] != "T600111222" {
+		t.Errorf("got declarant %q, want %q and the phone", declarant[:67], want)
+	}
+
+	// Positions 136 to 160 hold the number of clients and their total.
+	if got := declarant[135:160]; got != "000000001 000000000302500" {
+		t.Errorf("got count and total %q, want one client and 3,025.00", got)
+	}
+
+	fields := []struct {
+		name       string
+		start, end int
+		want       string
+	}{
+		{"NIF", 17, 26, "B12345674"},
+		{"province", 76, 78, "28"},
+		{"key", 81, 82, operationSales},
+		{"amount", 82, 98, " 000000000302500"},
+		{"first quarter", 135, 151, " 000000000242000"},
+		{"second quarter", 167, 183, " 000000000242000"},
+		{"third quarter", 199, 215, "N000000000181500"},
+		{"fourth quarter", 231, 247, " 000000000000000"},
+	}
+
+	for _, f := range fields {
+		if got := acme[f.start:f.end]; got != f.want {
+			t.Errorf("%s: got %q, want %q", f.name, got, f.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+)
+
+// rateRow is a row of a VAT form, with the boxes of the taxable base, the rate
+// and the tax of one rate. Forms without a box for the rate leave pct empty.
+type rateRow struct {
+	rate              float64
+	base, pct, amount string
+}
+
+// form tells the boxes of a VAT form where each kind of supply is reported.
+// Empty boxes are not reported in the form.
+type form struct {
+	vat       []rateRow
+	surcharge []rateRow
+
+	correctedBase      string
+	correctedVat       string
+	correctedSurBase   string
+	correctedSurcharge string
+
+	intraEUServices string
+	intraEUGoods    string
+	exportGoods     string
+	notSubject      string
+	oss             string
+	// domestic and exempt are the volume of domestic and exempt supplies.
+	domestic string
+	exempt   string
+
+	// accrued is the total VAT accrued.
+	accrued string
+}
+
+// accrue adds the invoices dated in a period to the boxes of the form.
+func (f form) accrue(invoices []*model.Invoice, contains func(time.Time) bool) (map[string]float64, error) {
+	values := map[string]float64{}
+
+	for _, invoice := range invoices {
+		if !contains(invoice.Date) {
+			continue
+		}
+
+		err := f.add(values, invoice)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", invoice.ID, err)
+		}
+	}
+
+	accrued := 0.
+
+	for _, row := range f.vat {
+		accrued += values[row.amount]
+	}
+
+	for _, row := range f.surcharge {
+		accrued += values[row.amount]
+	}
+
+	values[f.accrued] = accrued + values[f.correctedVat] + values[f.correctedSurcharge]
+	delete(values, "")
+
+	return values, nil
+}
+
+func (f form) add(values map[string]float64, invoice *model.Invoice) error {
+	s := sign(invoice)
+	totals := invoice.Totals()
+
+	switch treatment(invoice) {
+	case tax.TreatmentDomestic:
+		values[f.domestic] += s * totals.Subtotal
+	case tax.TreatmentReverseCharge:
+		values[f.intraEUServices] += s * totals.Subtotal
+
+		return nil
+	case tax.TreatmentIntraEUGoods:
+		values[f.intraEUGoods] += s * totals.Subtotal
+
+		return nil
+	case tax.TreatmentExport:
+		if invoice.Tax.Supply == tax.SupplyGoods {
+			values[f.exportGoods] += s * totals.Subtotal
+		} else {
+			values[f.notSubject] += s * totals.Subtotal
+		}
+
+		return nil
+	case tax.TreatmentOSS:
+		values[f.oss] += s * totals.Subtotal
+
+		return nil
+	default:
+		values[f.exempt] += s * totals.Subtotal
+
+		return nil
+	}
+
+	for _, amount := range totals.Taxes {
+		var rows []rateRow
+
+		switch amount.Kind {
+		case model.TaxKindVat:
+			rows = f.vat
+
+			if invoice.IsCreditNote() {
+				values[f.correctedBase] -= amount.Base
+				values[f.correctedVat] -= amount.Amount
+
+				continue
+			}
+		case model.TaxKindSurcharge:
+			rows = f.surcharge
+
+			if invoice.IsCreditNote() {
+				values[f.correctedSurBase] -= amount.Base
+				values[f.correctedSurcharge] -= amount.Amount
+
+				continue
+			}
+		default:
+			continue
+		}
+
+		row, ok := findRow(rows, amount.Rate)
+		if !ok {
+			return fmt.Errorf("%w: %s %g%%", ErrRate, amount.Kind, amount.Rate)
+		}
+
+		values[row.base] += amount.Base
+		values[row.amount] += amount.Amount
+
+		if row.pct != "" {
+			values[row.pct] = amount.Rate
+		}
+	}
+
+	return nil
+}
+
+func findRow(rows []rateRow, rate float64) (rateRow, bool) {
+	for _, row := range rows {
+		if row.rate == rate {
+			return row, true
+		}
+	}
+
+	return rateRow{}, false
+}
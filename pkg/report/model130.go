@@ -0,0 +1,125 @@
+package report
+
+import (
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// prepaymentRate is the share of the net income paid in advance every
+// quarter in the 130 form.
+const prepaymentRate = 20
+
+// Boxes of the 130 form, section I (economic activities) and III (total).
+const (
+	box130Income     = "01"
+	box130Expenses   = "02"
+	box130Net        = "03"
+	box130Share      = "04"
+	box130Paid       = "05"
+	box130Withheld   = "06"
+	box130Activities = "07"
+	box130Sum        = "12"
+	box130Reduction  = "13"
+	box130Difference = "14"
+	box130Negative   = "15"
+	box130Mortgage   = "16"
+	box130Subtotal   = "17"
+	box130Complement = "18"
+	box130Result     = "19"
+)
+
+var layout130 = []boxDef{
+	{Number: box130Income, Label: "Income of the year", Always: true},
+	{Number: box130Expenses, Label: "Deductible expenses of the year", Always: true},
+	{Number: box130Net, Label: "Net income", Always: true},
+	{Number: box130Share, Label: "20% of the net income", Always: true},
+	{Number: box130Paid, Label: "Prepayments of previous quarters", Always: true},
+	{Number: box130Withheld, Label: "Withholdings of the year", Always: true},
+	{Number: box130Activities, Label: "Result of the activities", Always: true},
+	{Number: box130Sum, Label: "Sum of results", Always: true},
+	{Number: box130Reduction, Label: "Reduction for low income"},
+	{Number: box130Difference, Label: "Difference", Always: true},
+	{Number: box130Negative, Label: "Negative results of previous quarters"},
+	{Number: box130Mortgage, Label: "Deduction for the main residence"},
+	{Number: box130Subtotal, Label: "Subtotal", Always: true},
+	{Number: box130Complement, Label: "Result of the complemented return"},
+	{Number: box130Result, Label: "Result of the return", Always: true},
+}
+
+// Model130 fills the quarterly income tax prepayment (Modelo 130) with the
+// invoices and credit notes dated from the start of the year to the end of
+// a quarter. expenses are the deductible expenses of the same span, which are
+// not tracked. paid are the prepayments of the previous quarters; when nil
+// they are computed from the invoices as if there had been no expenses.
+func Model130(invoices []*model.Invoice, q Quarter, expenses float64, paid *float64) *Return {
+	previous := 0.
+
+	if paid != nil {
+		previous = *paid
+	} else {
+		for number := 1; number < q.Number; number++ {
+			result := activities130(invoices, Quarter{Year: q.Year, Number: number}, previous)
+			if result > 0 {
+				previous += result
+			}
+		}
+	}
+
+	income, withheld := yearToDate(invoices, q)
+	net := income - expenses
+	share := prepayment(net)
+	result := share - previous - withheld
+
+	values := map[string]float64{
+		box130Income:     income,
+		box130Expenses:   expenses,
+		box130Net:        net,
+		box130Share:      share,
+		box130Paid:       previous,
+		box130Withheld:   withheld,
+		box130Activities: result,
+		box130Sum:        result,
+		box130Difference: result,
+		box130Subtotal:   result,
+		box130Result:     result,
+	}
+
+	return newReturn("130", q.Year, q.Period(), layout130, values)
+}
+
+// activities130 returns box 07 of a quarter without expenses.
+func activities130(invoices []*model.Invoice, q Quarter, paid float64) float64 {
+	income, withheld := yearToDate(invoices, q)
+
+	return prepayment(income) - paid - withheld
+}
+
+// prepayment returns box 04, the share of a positive net income paid in
+// advance.
+func prepayment(net float64) float64 {
+	if net <= 0 {
+		return 0
+	}
+
+	return model.Round(net * prepaymentRate / fullPercent)
+}
+
+// yearToDate returns the net income and the withholdings of the invoices dated
+// from the start of the year to the end of a quarter.
+func yearToDate(invoices []*model.Invoice, q Quarter) (income, withheld float64) {
+	start := Quarter{Year: q.Year, Number: 1}.Start()
+	end := q.End()
+
+	for _, invoice := range invoices {
+		if invoice.Date.Before(start) || !invoice.Date.Before(end) {
+			continue
+		}
+
+		s := sign(invoice)
+		totals := invoice.Totals()
+
+		income += s * totals.Subtotal
+		withheld += s * totals.Retention
+	}
+
+	return model.Round(income), model.Round(withheld)
+}
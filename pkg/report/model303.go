@@ -0,0 +1,130 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// vatRows are the rows of the 303 with the VAT accrued under the general
+// regime.
+var vatRows = []rateRow{
+	{0, "150", "151", "152"},
+	{2, "165", "166", "167"},
+	{4, "01", "02", "03"},
+	{5, "153", "154", "155"},
+	{10, "04", "05", "06"},
+	{21, "07", "08", "09"},
+}
+
+// surchargeRows are the rows of the 303 with the equivalence surcharge.
+var surchargeRows = []rateRow{
+	{0.26, "168", "169", "170"},
+	{0.5, "16", "17", "18"},
+	{0.62, "156", "157", "158"},
+	{1.4, "19", "20", "21"},
+	{5.2, "22", "23", "24"},
+}
+
+// Boxes of the 303 form filled from the invoices.
+const (
+	box303CorrectedBase      = "14"
+	box303CorrectedVat       = "15"
+	box303CorrectedSurcharge = "26"
+	box303CorrectedSurBase   = "25"
+	box303Accrued            = "27"
+	box303Deductible         = "45"
+	box303GeneralResult      = "46"
+	box303IntraEU            = "59"
+	box303Exports            = "60"
+	box303NotSubject         = "120"
+	box303OSS                = "123"
+	box303Sum                = "64"
+	box303StateShare         = "65"
+	box303StateResult        = "66"
+	box303Result             = "69"
+	box303Final              = "71"
+)
+
+var layout303 = buildLayout303()
+
+func buildLayout303() []boxDef {
+	layout := make([]boxDef, 0)
+
+	for _, row := range vatRows {
+		layout = append(layout,
+			boxDef{Number: row.base, Label: fmt.Sprintf("Taxable base %g%%", row.rate)},
+			boxDef{Number: row.pct, Label: fmt.Sprintf("VAT rate %g%%", row.rate), Percent: true},
+			boxDef{Number: row.amount, Label: fmt.Sprintf("VAT %g%%", row.rate)},
+		)
+	}
+
+	layout = append(layout,
+		boxDef{Number: box303CorrectedBase, Label: "Corrected taxable base"},
+		boxDef{Number: box303CorrectedVat, Label: "Corrected VAT"},
+	)
+
+	for _, row := range surchargeRows {
+		layout = append(layout,
+			boxDef{Number: row.base, Label: fmt.Sprintf("Surcharge base %g%%", row.rate)},
+			boxDef{Number: row.pct, Label: fmt.Sprintf("Surcharge rate %g%%", row.rate), Percent: true},
+			boxDef{Number: row.amount, Label: fmt.Sprintf("Surcharge %g%%", row.rate)},
+		)
+	}
+
+	return append(layout,
+		boxDef{Number: box303CorrectedSurBase, Label: "Corrected surcharge base"},
+		boxDef{Number: box303CorrectedSurcharge, Label: "Corrected surcharge"},
+		boxDef{Number: box303Accrued, Label: "Total VAT accrued", Always: true},
+		boxDef{Number: box303Deductible, Label: "Total VAT deductible", Always: true},
+		boxDef{Number: box303GeneralResult, Label: "Result of the general regime", Always: true},
+		boxDef{Number: box303IntraEU, Label: "Intra-Community supplies of goods and services"},
+		boxDef{Number: box303Exports, Label: "Exports"},
+		boxDef{Number: box303NotSubject, Label: "Not subject by place of supply rules"},
+		boxDef{Number: box303OSS, Label: "Not subject, declared in the One-Stop-Shop"},
+		boxDef{Number: box303Sum, Label: "Sum of results", Always: true},
+		boxDef{Number: box303StateShare, Label: "Share of the State", Percent: true, Always: true},
+		boxDef{Number: box303StateResult, Label: "Result attributable to the State", Always: true},
+		boxDef{Number: box303Result, Label: "Result", Always: true},
+		boxDef{Number: box303Final, Label: "Result of the return", Always: true},
+	)
+}
+
+// form303 places the invoices in the boxes of the 303.
+var form303 = form{
+	vat:                vatRows,
+	surcharge:          surchargeRows,
+	correctedBase:      box303CorrectedBase,
+	correctedVat:       box303CorrectedVat,
+	correctedSurBase:   box303CorrectedSurBase,
+	correctedSurcharge: box303CorrectedSurcharge,
+	intraEUServices:    box303IntraEU,
+	intraEUGoods:       box303IntraEU,
+	exportGoods:        box303Exports,
+	notSubject:         box303NotSubject,
+	oss:                box303OSS,
+	accrued:            box303Accrued,
+}
+
+// Model303 fills the quarterly VAT return (Modelo 303) with the invoices and
+// credit notes dated in a quarter. Only the VAT accrued is known, expenses are
+// not tracked so nothing is deducted; credit notes are reported as
+// corrections of the bases and VAT.
+func Model303(invoices []*model.Invoice, q Quarter) (*Return, error) {
+	values, err := form303.accrue(invoices, q.Contains)
+	if err != nil {
+		return nil, err
+	}
+
+	accrued := values[box303Accrued]
+
+	values[box303Deductible] = 0
+	values[box303GeneralResult] = accrued
+	values[box303Sum] = accrued
+	values[box303StateShare] = fullPercent
+	values[box303StateResult] = accrued
+	values[box303Result] = accrued
+	values[box303Final] = accrued
+
+	return newReturn("303", q.Year, q.Period(), layout303, values), nil
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Fields of the 347 records.
+const (
+	recordWidth347   = 500
+	countWidth347    = 9
+	nameWidth347     = 40
+	phoneWidth347    = 9
+	idWidth347       = 13
+	operationSales   = "B"
+	mediumTelematic  = "T"
+	sheetDeclared    = "D"
+	unsignedWidth347 = 15
+)
+
+// File347 returns the declaration of operations with third parties (Modelo
+// 347) of a year in the AEAT text format: a record of the declarant followed
+// by a record for each client over the threshold, with the sales of each
+// quarter under operation key B.
+func File347(clients []*ClientYear, year int, taxpayer Taxpayer) []byte {
+	declared := make([]*ClientYear, 0, len(clients))
+	total := 0.
+
+	for _, client := range clients {
+		if client.Declared() {
+			declared = append(declared, client)
+			total += client.Amount347()
+		}
+	}
+
+	prefix := "347" + strconv.Itoa(year) + alpha(taxpayer.NIF, nifWidth)
+
+	var b strings.Builder
+
+	declarant := "1" + prefix +
+		alpha(taxpayer.Name, nameWidth347) +
+		mediumTelematic +
+		alpha(digitsOnly(taxpayer.Phone), phoneWidth347) +
+		alpha(taxpayer.Name, nameWidth347) +
+		fmt.Sprintf("347%0*d", idWidth347-3, 0) + //nolint:mnd //model prefix
+		"  " + // neither complementary nor substitutive
+		strings.Repeat("0", idWidth347) +
+		fmt.Sprintf("%0*d", countWidth347, len(declared)) +
+		signed(total) +
+		strings.Repeat("0", countWidth347) +
+		signed(0)
+
+	b.WriteString(record(declarant))
+
+	for _, client := range declared {
+		line := "2" + prefix +
+			alpha(client.NIF, nifWidth) +
+			strings.Repeat(" ", nifWidth) + // legal representative
+			alpha(client.Name, nameWidth347) +
+			sheetDeclared +
+			alpha(client.Province, 2) + //nolint:mnd //province code
+			strings.Repeat(" ", 3) + //nolint:mnd //country code and blank of residents
+			operationSales +
+			signed(client.Amount347()) +
+			"  " + // neither insurance nor business premises rental
+			strings.Repeat("0", unsignedWidth347) + // cash
+			signed(0) + // real estate transfers
+			strings.Repeat("0", 4) //nolint:mnd //year of the cash amounts
+
+		for _, amount := range client.Quarters347 {
+			line += signed(amount) + signed(0)
+		}
+
+		b.WriteString(record(line))
+	}
+
+	return latin1(b.String())
+}
+
+// record pads a record to its width and ends the line.
+func record(line string) string {
+	runes := []rune(line)
+	if len(runes) < recordWidth347 {
+		return line + strings.Repeat(" ", recordWidth347-len(runes)) + "\r\n"
+	}
+
+	return string(runes[:recordWidth347]) + "\r\n"
+}
+
+// signed writes an amount as the sign, blank or N, followed by the cents.
+func signed(amount float64) string {
+	s := " "
+	if amount < 0 {
+		s = "N"
+	}
+
+	return s + fmt.Sprintf("%0*d", unsignedWidth347, int64(math.Round(math.Abs(amount)*centsFactor)))
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+
+		return r
+	}, s)
+}
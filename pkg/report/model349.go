@@ -0,0 +1,240 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+// Operation keys of the 349.
+const (
+	Key349Goods    = "E"
+	Key349Services = "S"
+)
+
+// Fields of the 349 records.
+const (
+	baseWidth349     = 13
+	countryWidth349  = 2
+	operatorWidth349 = 15
+	amountWidth349   = 15
+	blankDeclared349 = 58
+)
+
+// Operation349 is the total of the intra-Community operations with a client
+// under an operation key.
+type Operation349 struct {
+	// VatID is the VAT number of the client with its country prefix.
+	VatID  string
+	Name   string
+	Key    string
+	Amount float64
+}
+
+// Rectification349 corrects the operations declared in an earlier period,
+// after a credit note.
+type Rectification349 struct {
+	Operation349
+
+	Year   int
+	Period string
+	// Previous is the amount declared before, Amount the corrected one.
+	Previous float64
+}
+
+// Statement349 is the recapitulative statement of intra-Community operations
+// (Modelo 349) of a quarter.
+type Statement349 struct {
+	Quarter        Quarter
+	Operations     []Operation349
+	Rectifications []Rectification349
+}
+
+// Total returns the sum of the operations of the period.
+func (s *Statement349) Total() float64 {
+	total := 0.
+	for _, op := range s.Operations {
+		total += op.Amount
+	}
+
+	return model.Round(total)
+}
+
+// RectifiedTotal returns the sum of the corrected amounts.
+func (s *Statement349) RectifiedTotal() float64 {
+	total := 0.
+	for _, r := range s.Rectifications {
+		total += r.Amount
+	}
+
+	return model.Round(total)
+}
+
+// Model349 builds the recapitulative statement of a quarter from the invoices
+// of the freelancer. An invoice is declared when it charges no VAT to a client
+// whose VAT number, checked with vat.Validate, belongs to another EU member
+// state: supplies of goods under key E and services under key S. Credit notes
+// of invoices of an earlier period are declared as rectifications of that
+// period; invoices holds every issued invoice so their amounts can be found.
+func Model349(invoices []*model.Invoice, q Quarter) *Statement349 {
+	byID := make(map[string]*model.Invoice, len(invoices))
+	for _, invoice := range invoices {
+		byID[invoice.ID] = invoice
+	}
+
+	type rectification struct {
+		op     Operation349
+		period Quarter
+	}
+
+	operations := map[Operation349]float64{}
+	rectified := map[rectification]float64{}
+
+	for _, invoice := range invoices {
+		if !q.Contains(invoice.Date) {
+			continue
+		}
+
+		op, ok := operation349(invoice)
+		if !ok {
+			continue
+		}
+
+		amount := invoice.Totals().Subtotal
+		corrected := byID[invoice.Corrects]
+
+		if !invoice.IsCreditNote() || corrected == nil || q.Contains(corrected.Date) {
+			operations[op] += sign(invoice) * amount
+
+			continue
+		}
+
+		rectified[rectification{op: op, period: QuarterOf(corrected.Date)}] -= amount
+	}
+
+	s := &Statement349{Quarter: q}
+
+	for op, amount := range operations {
+		op.Amount = model.Round(amount)
+		s.Operations = append(s.Operations, op)
+	}
+
+	for r, change := range rectified {
+		previous := 0.
+
+		for _, invoice := range invoices {
+			op, ok := operation349(invoice)
+			if ok && op == r.op && r.period.Contains(invoice.Date) {
+				previous += sign(invoice) * invoice.Totals().Subtotal
+			}
+		}
+
+		rectification := Rectification349{
+			Operation349: r.op,
+			Year:         r.period.Year,
+			Period:       r.period.Period(),
+			Previous:     model.Round(previous),
+		}
+		rectification.Amount = model.Round(previous + change)
+
+		s.Rectifications = append(s.Rectifications, rectification)
+	}
+
+	slices.SortFunc(s.Operations, func(a, b Operation349) int {
+		return strings.Compare(a.VatID+a.Key, b.VatID+b.Key)
+	})
+	slices.SortFunc(s.Rectifications, func(a, b Rectification349) int {
+		return strings.Compare(a.VatID+a.Key+a.Period, b.VatID+b.Key+b.Period)
+	})
+
+	return s
+}
+
+// operation349 returns the client and key under which an invoice is declared,
+// without amount, or false when it is not an intra-Community operation.
+func operation349(invoice *model.Invoice) (Operation349, bool) {
+	vatID := vat.Normalize(invoice.To.VatID)
+	country := vat.Country(vatID)
+	seller := vat.Country(invoice.From.VatID)
+
+	if vat.Validate(vatID) != nil || !tax.IsEU(country) || country == seller || invoice.Tax.VatRate() != 0 {
+		return Operation349{}, false
+	}
+
+	key := Key349Services
+
+	switch treatment(invoice) {
+	case tax.TreatmentIntraEUGoods:
+		key = Key349Goods
+	case tax.TreatmentReverseCharge:
+		if invoice.Tax.Supply == tax.SupplyGoods {
+			key = Key349Goods
+		}
+	default:
+		return Operation349{}, false
+	}
+
+	return Operation349{VatID: vatID, Name: invoice.To.Name, Key: key}, true
+}
+
+// File returns the statement in the AEAT text format: a record of the
+// declarant followed by a record for each operation and rectification.
+func (s *Statement349) File(taxpayer Taxpayer) []byte {
+	prefix := "349" + strconv.Itoa(s.Quarter.Year) + alpha(taxpayer.NIF, nifWidth)
+
+	var b strings.Builder
+
+	declarant := "1" + prefix +
+		alpha(taxpayer.Name, nameWidth347) +
+		mediumTelematic +
+		alpha(digitsOnly(taxpayer.Phone), phoneWidth347) +
+		alpha(taxpayer.Name, nameWidth347) +
+		fmt.Sprintf("349%0*d", idWidth347-3, 0) + //nolint:mnd //model prefix
+		"  " + // neither complementary nor substitutive
+		strings.Repeat("0", idWidth347) +
+		s.Quarter.Period() +
+		fmt.Sprintf("%0*d", countWidth347, len(s.Operations)) +
+		cents(s.Total(), amountWidth349) +
+		fmt.Sprintf("%0*d", countWidth347, len(s.Rectifications)) +
+		cents(s.RectifiedTotal(), amountWidth349)
+
+	b.WriteString(record(declarant))
+
+	for _, op := range s.Operations {
+		b.WriteString(record(operator349(prefix, op) + cents(op.Amount, baseWidth349)))
+	}
+
+	for _, r := range s.Rectifications {
+		line := operator349(prefix, r.Operation349) +
+			strings.Repeat(" ", baseWidth349) +
+			strings.Repeat(" ", 17+nameWidth347) + //nolint:mnd //substitute operator of triangular operations
+			strconv.Itoa(r.Year) + r.Period +
+			cents(r.Amount, baseWidth349) +
+			cents(r.Previous, baseWidth349)
+
+		b.WriteString(record(line))
+	}
+
+	return latin1(b.String())
+}
+
+// operator349 returns the start of the record of an operation, up to the key.
+func operator349(prefix string, op Operation349) string {
+	return "2" + prefix +
+		strings.Repeat(" ", blankDeclared349) +
+		op.VatID[:countryWidth349] +
+		alpha(op.VatID[countryWidth349:], operatorWidth349) +
+		alpha(op.Name, nameWidth347) +
+		op.Key
+}
+
+// cents writes a positive amount in cents, zero padded.
+func cents(amount float64, width int) string {
+	return fmt.Sprintf("%0*d", width, int64(math.Round(math.Abs(amount)*centsFactor)))
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+)
+
+func newTestStatement() *Statement349 {
+	goods := newTestInvoice("F25-004", time.May, "FR40303265045", 0, 0, 2000)
+	goods.Tax.Supply = tax.SupplyGoods
+
+	february := newTestInvoice("F25-001", time.February, "DE136695976", 0, 0, 800)
+	refund := newTestInvoice("F25-006", time.June, "DE136695976", 0, 0, 300)
+	refund.Type = model.TypeCreditNote
+	refund.Corrects = february.ID
+
+	invoices := []*model.Invoice{
+		february,
+		newTestInvoice("F25-002", time.April, "DE136695976", 0, 0, 1000),
+		newTestInvoice("F25-003", time.April, "DE136695976", 21, 0, 9999),
+		goods,
+		newTestInvoice("F25-005", time.June, "DE136695976", 0, 0, 500),
+		refund,
+		newTestInvoice("F25-007", time.June, "ESB12345674", 21, 0, 9999),
+		newTestInvoice("F25-008", time.June, "DE136695975", 0, 0, 9999),
+		newTestInvoice("F25-009", time.July, "DE136695976", 0, 0, 9999),
+	}
+
+	for _, invoice := range invoices {
+		invoice.To.Name = "Client " + invoice.To.VatID[:2]
+	}
+
+	return Model349(invoices, Quarter{Year: 2025, Number: 2})
+}
+
+func TestModel349(t *testing.T) {
+	s := newTestStatement()
+
+	// Invoices charging VAT, to Spanish clients, with invalid VAT numbers or
+	// out of the quarter are left out.
+	want := []Operation349{
+		{VatID: "DE136695976", Name: "Client DE", Key: Key349Services, Amount: 1500},
+		{VatID: "FR40303265045", Name: "Client FR", Key: Key349Goods, Amount: 2000},
+	}
+
+	if len(s.Operations) != len(want) {
+		t.Fatalf("got operations %+v, want %+v", s.Operations, want)
+	}
+
+	for i, op := range s.Operations {
+		if op != want[i] {
+			t.Errorf("got operation %+v, want %+v", op, want[i])
+		}
+	}
+
+	// The credit note of February corrects the first quarter.
+	if len(s.Rectifications) != 1 {
+		t.Fatalf("got rectifications %+v, want one of 1T", s.Rectifications)
+	}
+
+	r := s.Rectifications[0]
+	if r.VatID != "DE136695976" || r.Key != Key349Services || r.Year != 2025 || r.Period != "1T" || r.Previous != 800 || r.Amount != 500 {
+		t.Errorf("got rectification %+v, want 1T of DE136695976 from 800 to 500", r)
+	}
+
+	if s.Total() != 3500 || s.RectifiedTotal() != 500 {
+		t.Errorf("got totals %v and %v, want 3,500 and 500", s.Total(), s.RectifiedTotal())
+	}
+}
+
+func TestStatement349File(t *testing.T) {
+	file := string(newTestStatement().File(Taxpayer{NIF: "12345678Z", Name: "Ana", Phone: "600111222"}))
+
+	records := strings.Split(strings.TrimSuffix(file, "\r\n"), "\r\n")
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want the declarant, two operations and a rectification", len(records))
+	}
+
+	for _, record := range records {
+		if len(record) != recordWidth347 {
+			t.Errorf("got a record of %d characters, want %d", len(record), recordWidth347)
+		}
+	}
+
+	// Positions 136 to 185 hold the period, the operations and the
+	// rectifications with their totals.
+	if got := records[0][135:185]; got != "2T"+"000000002"+"000000000350000"+"000000001"+"000000000050000" {
+		t.Errorf("got declarant totals %q", got)
+	}
+
+	fields := []struct {
+		record     int
+		name       string
+		start, end int
+		want       string
+	}{
+		{1, "country", 75, 77, "DE"},
+		{1, "VAT number", 77, 92, "136695976      "},
+		{1, "key", 132, 133, Key349Services},
+		{1, "amount", 133, 146, "0000000150000"},
+		{2, "country", 75, 77, "FR"},
+		{2, "key", 132, 133, Key349Goods},
+		{2, "amount", 133, 146, "0000000200000"},
+		{3, "key", 132, 133, Key349Services},
+		{3, "period", 203, 209, "20251T"},
+		{3, "corrected amount", 209, 222, "0000000050000"},
+		{3, "previous amount", 222, 235, "0000000080000"},
+	}
+
+	for _, f := range fields {
+		if got := records[f.record][f.start:f.end]; got != f.want {
+			t.Errorf("record %d %s: got %q, want %q", f.record, f.name, got, f.want)
+		}
+	}
+}
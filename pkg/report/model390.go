@@ -0,0 +1,134 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// periodYear is the period code of annual returns.
+const periodYear = "0A"
+
+// Boxes of the 390 form filled from the invoices.
+const (
+	box390CorrectedBase      = "31"
+	box390CorrectedVat       = "32"
+	box390CorrectedSurBase   = "33"
+	box390CorrectedSurcharge = "34"
+	box390Accrued            = "47"
+	box390Deductible         = "64"
+	box390Result             = "65"
+	box390Paid               = "95"
+	box390Domestic           = "99"
+	box390IntraEUGoods       = "103"
+	box390Exports            = "104"
+	box390Exempt             = "105"
+	box390NotSubject         = "110"
+	box390Volume             = "108"
+)
+
+var vatRows390 = []rateRow{
+	{4, "01", "", "02"},
+	{10, "03", "", "04"},
+	{21, "05", "", "06"},
+	{5, "500", "", "501"},
+}
+
+var surchargeRows390 = []rateRow{
+	{0.5, "07", "", "08"},
+	{1.4, "09", "", "10"},
+	{5.2, "11", "", "12"},
+}
+
+// form390 places the invoices in the boxes of the 390. Supplies not subject to
+// Spanish VAT, including the ones declared in the One-Stop-Shop, share a box.
+var form390 = form{
+	vat:                vatRows390,
+	surcharge:          surchargeRows390,
+	correctedBase:      box390CorrectedBase,
+	correctedVat:       box390CorrectedVat,
+	correctedSurBase:   box390CorrectedSurBase,
+	correctedSurcharge: box390CorrectedSurcharge,
+	intraEUServices:    box390NotSubject,
+	intraEUGoods:       box390IntraEUGoods,
+	exportGoods:        box390Exports,
+	notSubject:         box390NotSubject,
+	oss:                box390NotSubject,
+	domestic:           box390Domestic,
+	exempt:             box390Exempt,
+	accrued:            box390Accrued,
+}
+
+var layout390 = buildLayout390()
+
+func buildLayout390() []boxDef {
+	layout := make([]boxDef, 0)
+
+	for _, row := range vatRows390 {
+		layout = append(layout,
+			boxDef{Number: row.base, Label: fmt.Sprintf("Taxable base %g%%", row.rate)},
+			boxDef{Number: row.amount, Label: fmt.Sprintf("VAT %g%%", row.rate)},
+		)
+	}
+
+	for _, row := range surchargeRows390 {
+		layout = append(layout,
+			boxDef{Number: row.base, Label: fmt.Sprintf("Surcharge base %g%%", row.rate)},
+			boxDef{Number: row.amount, Label: fmt.Sprintf("Surcharge %g%%", row.rate)},
+		)
+	}
+
+	return append(layout,
+		boxDef{Number: box390CorrectedBase, Label: "Corrected taxable base"},
+		boxDef{Number: box390CorrectedVat, Label: "Corrected VAT"},
+		boxDef{Number: box390CorrectedSurBase, Label: "Corrected surcharge base"},
+		boxDef{Number: box390CorrectedSurcharge, Label: "Corrected surcharge"},
+		boxDef{Number: box390Accrued, Label: "Total VAT accrued", Always: true},
+		boxDef{Number: box390Deductible, Label: "Total VAT deductible", Always: true},
+		boxDef{Number: box390Result, Label: "Result of the general regime", Always: true},
+		boxDef{Number: box390Paid, Label: "Paid in the returns of the year", Always: true},
+		boxDef{Number: box390Domestic, Label: "Supplies under the general regime"},
+		boxDef{Number: box390IntraEUGoods, Label: "Exempt intra-Community supplies of goods"},
+		boxDef{Number: box390Exports, Label: "Exports"},
+		boxDef{Number: box390Exempt, Label: "Exempt supplies without deduction"},
+		boxDef{Number: box390NotSubject, Label: "Not subject or reverse charge supplies"},
+		boxDef{Number: box390Volume, Label: "Total volume of supplies", Always: true},
+	)
+}
+
+// Model390 fills the annual VAT summary (Modelo 390) with the invoices and
+// credit notes dated in a year. Box 95 sums the positive results of the 303 of
+// each quarter. Its result is box 65, the VAT accrued less the deductible.
+func Model390(invoices []*model.Invoice, year int) (*Return, error) {
+	values, err := form390.accrue(invoices, func(date time.Time) bool { return date.Year() == year })
+	if err != nil {
+		return nil, err
+	}
+
+	paid := 0.
+
+	for number := 1; number <= quartersInYear; number++ {
+		var quarterly *Return
+
+		quarterly, err = Model303(invoices, Quarter{Year: year, Number: number})
+		if err != nil {
+			return nil, err
+		}
+
+		if result := quarterly.Result(); result > 0 {
+			paid += result
+		}
+	}
+
+	values[box390Deductible] = 0
+	values[box390Result] = values[box390Accrued]
+	values[box390Paid] = paid
+	values[box390Volume] = values[box390Domestic] + values[box390IntraEUGoods] + values[box390Exports] +
+		values[box390Exempt] + values[box390NotSubject]
+
+	r := newReturn("390", year, periodYear, layout390, values)
+	r.result = box390Result
+
+	return r, nil
+}
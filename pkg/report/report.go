@@ -0,0 +1,201 @@
+// Package report aggregates issued invoices into the Spanish tax returns filed
+// by freelancers, giving the amount of each box of the official forms.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+const (
+	monthsInQuarter = 3
+	quartersInYear  = 4
+	fullPercent     = 100
+)
+
+var (
+	ErrQuarter = errors.New("report: quarter must look like 2025Q3")
+	ErrRate    = errors.New("report: no box for rate")
+)
+
+var quarterPattern = regexp.MustCompile(`^(\d{4})Q([1-4])$`)
+
+// Quarter is a calendar quarter, Number going from 1 to 4.
+type Quarter struct {
+	Year   int
+	Number int
+}
+
+// ParseQuarter parses a quarter written as 2025Q3.
+func ParseQuarter(s string) (Quarter, error) {
+	match := quarterPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if match == nil {
+		return Quarter{}, fmt.Errorf("%w: %s", ErrQuarter, s)
+	}
+
+	year, _ := strconv.Atoi(match[1])   //nolint:errcheck //matched as digits
+	number, _ := strconv.Atoi(match[2]) //nolint:errcheck //matched as digits
+
+	return Quarter{Year: year, Number: number}, nil
+}
+
+// QuarterOf returns the quarter of a date.
+func QuarterOf(t time.Time) Quarter {
+	return Quarter{Year: t.Year(), Number: (int(t.Month())-1)/monthsInQuarter + 1}
+}
+
+// LastQuarter returns the last quarter finished before a date, the one being
+// filed.
+func LastQuarter(now time.Time) Quarter {
+	return QuarterOf(now).Previous()
+}
+
+// Previous returns the quarter before q.
+func (q Quarter) Previous() Quarter {
+	if q.Number == 1 {
+		return Quarter{Year: q.Year - 1, Number: quartersInYear}
+	}
+
+	return Quarter{Year: q.Year, Number: q.Number - 1}
+}
+
+// Start returns the first instant of the quarter.
+func (q Quarter) Start() time.Time {
+	return time.Date(q.Year, time.Month((q.Number-1)*monthsInQuarter+1), 1, 0, 0, 0, 0, time.Local)
+}
+
+// End returns the first instant after the quarter.
+func (q Quarter) End() time.Time {
+	return q.Start().AddDate(0, monthsInQuarter, 0)
+}
+
+// Contains reports whether a date falls in the quarter.
+func (q Quarter) Contains(t time.Time) bool {
+	return !t.Before(q.Start()) && t.Before(q.End())
+}
+
+// Period returns the period code of the AEAT forms, such as 3T.
+func (q Quarter) Period() string {
+	return strconv.Itoa(q.Number) + "T"
+}
+
+func (q Quarter) String() string {
+	return fmt.Sprintf("%dQ%d", q.Year, q.Number)
+}
+
+// Box is a box of a tax form.
+type Box struct {
+	Number string
+	Label  string
+	Value  float64
+	// Percent is set for boxes holding a rate instead of an amount.
+	Percent bool
+}
+
+// Return is a filled tax form.
+type Return struct {
+	Model string
+	Year  int
+	// Period is the period code of the AEAT forms, such as 3T for the third
+	// quarter or 0A for the whole year.
+	Period string
+	Boxes  []Box
+	// layout lists every box of the page in the order of the AEAT record
+	// design, including the ones left empty.
+	layout []boxDef
+	// result is the box of the result, the last one of the layout when empty.
+	result string
+}
+
+// Value returns the value of a box, 0 when the box is empty.
+func (r *Return) Value(number string) float64 {
+	for _, box := range r.Boxes {
+		if box.Number == number {
+			return box.Value
+		}
+	}
+
+	return 0
+}
+
+// Result returns the amount to pay, negative when it is to be refunded or
+// compensated.
+func (r *Return) Result() float64 {
+	if r.result != "" {
+		return r.Value(r.result)
+	}
+
+	if len(r.layout) == 0 {
+		return 0
+	}
+
+	return r.Value(r.layout[len(r.layout)-1].Number)
+}
+
+type boxDef struct {
+	Number string
+	Label  string
+	// Percent is set for boxes holding a rate.
+	Percent bool
+	// Always is set for boxes printed even when empty, such as totals.
+	Always bool
+}
+
+// newReturn lays out the values of a form, keeping the empty boxes out but the
+// ones marked as always shown.
+func newReturn(form string, year int, period string, layout []boxDef, values map[string]float64) *Return {
+	r := &Return{Model: form, Year: year, Period: period, layout: layout}
+
+	for _, def := range layout {
+		value, ok := values[def.Number]
+		if !ok && !def.Always {
+			continue
+		}
+
+		r.Boxes = append(r.Boxes, Box{Number: def.Number, Label: def.Label, Value: model.Round(value), Percent: def.Percent})
+	}
+
+	return r
+}
+
+// sign returns -1 for credit notes, which reduce the amounts of the period.
+func sign(invoice *model.Invoice) float64 {
+	if invoice.IsCreditNote() {
+		return -1
+	}
+
+	return 1
+}
+
+// treatment returns the VAT treatment of an invoice. Invoices whose VAT was
+// set by hand are domestic when they charge VAT, and otherwise classified
+// with the tax rules from the countries of the parties.
+func treatment(invoice *model.Invoice) string {
+	if invoice.Tax.Treatment != "" {
+		return invoice.Tax.Treatment
+	}
+
+	if invoice.Tax.VatRate() != 0 {
+		return tax.TreatmentDomestic
+	}
+
+	decision, err := tax.Decide(tax.Scenario{
+		SellerCountry: vat.Country(invoice.From.VatID),
+		BuyerCountry:  vat.Country(invoice.To.VatID),
+		Business:      vat.Validate(invoice.To.VatID) == nil,
+		Supply:        invoice.Tax.Supply,
+	})
+	if err != nil {
+		return tax.TreatmentExempt
+	}
+
+	return decision.Treatment
+}
@@ -0,0 +1,140 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// newTestInvoice returns an issued invoice from a Spanish freelancer dated
+// in 2025, due in 30 days, with an item of rate at the VAT percent.
+func newTestInvoice(id string, month time.Month, buyer string, vat, retention, rate float64) *model.Invoice {
+	invoice := model.NewInvoice(id, 30*24*time.Hour, "EUR", "", "")
+	invoice.Status = model.StatusIssued
+	invoice.Date = time.Date(2025, month, 1, 10, 0, 0, 0, time.Local)
+	invoice.From = model.Freelancer{Name: "Ana", VatID: "ES12345678Z"}
+	invoice.To = model.Client{ID: buyer, Name: buyer, VatID: buyer}
+	invoice.SetTaxes(vat, retention, map[string]string{})
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: rate})
+
+	return invoice
+}
+
+func creditNote(invoice *model.Invoice, id string) *model.Invoice {
+	credit := *invoice
+	credit.ID = id
+	credit.Type = model.TypeCreditNote
+	credit.Corrects = invoice.ID
+
+	return &credit
+}
+
+func TestParseQuarter(t *testing.T) {
+	q, err := ParseQuarter(" 2025q3 ")
+	if err != nil || q != (Quarter{Year: 2025, Number: 3}) {
+		t.Fatalf("got %v, %v", q, err)
+	}
+
+	if q.Period() != "3T" || !q.Contains(time.Date(2025, 9, 30, 23, 59, 0, 0, time.Local)) || q.Contains(q.End()) {
+		t.Errorf("got period %s from %v to %v", q.Period(), q.Start(), q.End())
+	}
+
+	if LastQuarter(time.Date(2025, 1, 20, 0, 0, 0, 0, time.Local)) != (Quarter{Year: 2024, Number: 4}) {
+		t.Error("the quarter filed in January is not the fourth of the year before")
+	}
+
+	if _, err := ParseQuarter("2025Q5"); err == nil {
+		t.Error("got no error for 2025Q5")
+	}
+}
+
+func TestModel303(t *testing.T) {
+	services := newTestInvoice("F25-001", time.January, "ESB12345674", 21, 15, 1000)
+	books := newTestInvoice("F25-002", time.February, "ESB12345674", 4, 0, 100)
+
+	invoices := []*model.Invoice{
+		services,
+		books,
+		creditNote(services, "F25-003"),
+		newTestInvoice("F25-004", time.March, "DE136695976", 0, 0, 500),
+		newTestInvoice("F25-005", time.April, "ESB12345674", 21, 0, 9999),
+	}
+
+	r, err := Model303(invoices, Quarter{Year: 2025, Number: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{
+		"01": 100, "03": 4,
+		"07": 1000, "08": 21, "09": 210,
+		box303CorrectedBase: -1000, box303CorrectedVat: -210,
+		box303IntraEU: 500,
+		box303Accrued: 4,
+		box303Final:   4,
+	}
+
+	for box, value := range want {
+		if got := r.Value(box); got != value {
+			t.Errorf("box %s: got %v, want %v", box, got, value)
+		}
+	}
+
+	if r.Result() != 4 || r.Period != "1T" {
+		t.Errorf("got result %v for period %s, want 4 for 1T", r.Result(), r.Period)
+	}
+}
+
+func TestModel303UnknownRate(t *testing.T) {
+	invoice := newTestInvoice("F25-001", time.January, "ESB12345674", 7, 0, 100)
+
+	if _, err := Model303([]*model.Invoice{invoice}, Quarter{Year: 2025, Number: 1}); err == nil {
+		t.Error("got no error for a 7% rate")
+	}
+}
+
+func TestModel130(t *testing.T) {
+	last := newTestInvoice("F24-100", time.December, "ESB12345674", 21, 15, 9999)
+	last.Date = last.Date.AddDate(-1, 0, 0)
+	goods := newTestInvoice("F25-002", time.February, "ESB12345674", 21, 0, 500)
+	returned := creditNote(goods, "F25-004")
+	returned.Date = returned.Date.AddDate(0, 3, 0)
+
+	invoices := []*model.Invoice{
+		last,
+		newTestInvoice("F25-001", time.January, "ESB12345674", 21, 15, 1000),
+		goods,
+		newTestInvoice("F25-003", time.April, "ESB12345674", 21, 15, 2000),
+		returned,
+		newTestInvoice("F25-005", time.July, "ESB12345674", 21, 15, 9999),
+	}
+
+	// The first quarter pays 20% of 1,500 less the 150 withheld.
+	q2 := Quarter{Year: 2025, Number: 2}
+	r := Model130(invoices, q2, 400, nil)
+
+	want := map[string]float64{
+		box130Income: 3000, box130Expenses: 400, box130Net: 2600, box130Share: 520,
+		box130Paid: 150, box130Withheld: 450, box130Activities: -80, box130Result: -80,
+	}
+
+	for box, value := range want {
+		if got := r.Value(box); got != value {
+			t.Errorf("box %s: got %v, want %v", box, got, value)
+		}
+	}
+
+	if r.Result() != -80 || r.Period != "2T" {
+		t.Errorf("got result %v for period %s, want -80 for 2T", r.Result(), r.Period)
+	}
+
+	paid := 200.
+	if r := Model130(invoices, q2, 400, &paid); r.Value(box130Paid) != 200 || r.Result() != -130 {
+		t.Errorf("got paid %v and result %v, want the prepayments given, 200, and -130", r.Value(box130Paid), r.Result())
+	}
+
+	if r := Model130(invoices, q2, 5000, nil); r.Value(box130Net) != -2000 || r.Value(box130Share) != 0 {
+		t.Errorf("got net %v and share %v, want no prepayment of a loss", r.Value(box130Net), r.Value(box130Share))
+	}
+}
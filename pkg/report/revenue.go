@@ -0,0 +1,305 @@
+package report
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+// Groupings of the revenue report.
+const (
+	GroupMonth    = "month"
+	GroupQuarter  = "quarter"
+	GroupClient   = "client"
+	GroupCurrency = "currency"
+)
+
+const monthsInYear = 12
+
+var ErrGroup = errors.New("report: unknown grouping, use month, quarter, client or currency")
+
+// RevenueRow holds the revenue of a group. Gross is the amount invoiced with
+// taxes and before withholdings, and PreviousGross the gross of the same group
+// the year before. Paid is the number of paid invoices and DaysToPay the
+// average number of days from their date to their last payment.
+type RevenueRow struct {
+	Key           string  `json:"key"`
+	Name          string  `json:"name,omitempty"`
+	Currency      string  `json:"currency"`
+	Invoices      int     `json:"invoices"`
+	Net           float64 `json:"net"`
+	Vat           float64 `json:"vat"`
+	Retention     float64 `json:"retention"`
+	Gross         float64 `json:"gross"`
+	PreviousGross float64 `json:"previous_gross"`
+	Paid          int     `json:"paid"`
+	DaysToPay     float64 `json:"days_to_pay"`
+
+	paidIn float64
+}
+
+// Growth returns the change of the gross over the year before in percent,
+// false when there was no revenue the year before.
+func (r RevenueRow) Growth() (float64, bool) {
+	if r.PreviousGross == 0 {
+		return 0, false
+	}
+
+	return model.Round((r.Gross - r.PreviousGross) / math.Abs(r.PreviousGross) * fullPercent), true
+}
+
+func (r *RevenueRow) add(invoice *model.Invoice) {
+	s := sign(invoice)
+	totals := invoice.Totals()
+
+	r.Invoices++
+	r.Net = model.Round(r.Net + s*totals.Subtotal)
+	r.Vat = model.Round(r.Vat + s*totals.Vat)
+	r.Retention = model.Round(r.Retention + s*totals.Retention)
+	r.Gross = model.Round(r.Gross + s*(totals.Total+totals.Retention))
+
+	if invoice.Status == model.StatusPaid {
+		r.Paid++
+		r.paidIn += day(invoice.PaidOn()).Sub(day(invoice.Date)).Hours() / hoursInDay
+	}
+}
+
+// Revenue is the revenue of a year by group, with the clients invoiced the
+// most.
+type Revenue struct {
+	Year       int          `json:"year"`
+	GroupBy    string       `json:"group_by"`
+	Rows       []RevenueRow `json:"rows"`
+	Totals     []RevenueRow `json:"totals"`
+	TopClients []RevenueRow `json:"top_clients"`
+}
+
+// NewRevenue sums the invoices and credit notes of a year and the year before
+// by group. Amounts in different currencies are never added up: each group
+// has a row for every currency. Months and quarters without revenue are
+// listed so the rows can be charted.
+func NewRevenue(invoices []*model.Invoice, year int, groupBy string, top int) (*Revenue, error) {
+	keyOf, err := groupKey(groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	type rowKey struct{ key, currency string }
+
+	rows := map[rowKey]*RevenueRow{}
+	totals := map[rowKey]*RevenueRow{}
+	clients := map[rowKey]*RevenueRow{}
+
+	row := func(rows map[rowKey]*RevenueRow, key, name, currency string) *RevenueRow {
+		r, ok := rows[rowKey{key, currency}]
+		if !ok {
+			r = &RevenueRow{Key: key, Name: name, Currency: currency}
+			rows[rowKey{key, currency}] = r
+		}
+
+		return r
+	}
+
+	for _, invoice := range invoices {
+		key, name := keyOf(invoice)
+		client := clientKey(invoice)
+
+		switch invoice.Date.Year() {
+		case year:
+			row(rows, key, name, invoice.Currency).add(invoice)
+			row(clients, client, invoice.To.Name, invoice.Currency).add(invoice)
+
+			row(totals, "total", "", invoice.Currency).add(invoice)
+		case year - 1:
+			previous := invoice.Totals()
+			gross := sign(invoice) * (previous.Total + previous.Retention)
+
+			row(rows, key, name, invoice.Currency).PreviousGross += gross
+			row(clients, client, invoice.To.Name, invoice.Currency).PreviousGross += gross
+
+			row(totals, "total", "", invoice.Currency).PreviousGross += gross
+		}
+	}
+
+	for total := range totals {
+		for _, key := range periods(groupBy) {
+			row(rows, key, "", total.currency)
+		}
+	}
+
+	r := &Revenue{Year: year, GroupBy: groupBy}
+	r.Rows = sortedRows(rows, func(a, b RevenueRow) int {
+		if groupBy == GroupClient {
+			if c := cmp.Compare(b.Gross, a.Gross); c != 0 {
+				return c
+			}
+		}
+
+		return cmp.Or(strings.Compare(a.Key, b.Key), strings.Compare(a.Currency, b.Currency))
+	})
+	r.Totals = sortedRows(totals, func(a, b RevenueRow) int { return strings.Compare(a.Currency, b.Currency) })
+
+	r.TopClients = sortedRows(clients, func(a, b RevenueRow) int {
+		return cmp.Or(cmp.Compare(b.Gross, a.Gross), strings.Compare(a.Name, b.Name))
+	})
+	r.TopClients = slices.DeleteFunc(r.TopClients, func(row RevenueRow) bool { return row.Invoices == 0 })
+
+	if top > 0 && len(r.TopClients) > top {
+		r.TopClients = r.TopClients[:top]
+	}
+
+	return r, nil
+}
+
+// sortedRows returns the rows of a map in order, with the gross of the year
+// before rounded and the average days to payment.
+func sortedRows[K comparable](rows map[K]*RevenueRow, compare func(a, b RevenueRow) int) []RevenueRow {
+	sorted := make([]RevenueRow, 0, len(rows))
+
+	for _, row := range rows {
+		row.PreviousGross = model.Round(row.PreviousGross)
+
+		if row.Paid > 0 {
+			row.DaysToPay = math.Round(row.paidIn / float64(row.Paid))
+		}
+
+		sorted = append(sorted, *row)
+	}
+
+	slices.SortFunc(sorted, compare)
+
+	return sorted
+}
+
+// groupKey returns the function giving the group of an invoice, with the name
+// shown for it when the key is not readable.
+func groupKey(groupBy string) (func(*model.Invoice) (key, name string), error) {
+	switch groupBy {
+	case GroupMonth, "":
+		return func(i *model.Invoice) (string, string) { return fmt.Sprintf("%02d", int(i.Date.Month())), "" }, nil
+	case GroupQuarter:
+		return func(i *model.Invoice) (string, string) { return "Q" + strconv.Itoa(QuarterOf(i.Date).Number), "" }, nil
+	case GroupClient:
+		return func(i *model.Invoice) (string, string) { return clientKey(i), i.To.Name }, nil
+	case GroupCurrency:
+		return func(i *model.Invoice) (string, string) { return invoicedIn(i), "" }, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrGroup, groupBy)
+}
+
+// periods returns the keys of every month or quarter of a year, none for the
+// other groupings.
+func periods(groupBy string) []string {
+	var keys []string
+
+	switch groupBy {
+	case GroupMonth, "":
+		for month := 1; month <= monthsInYear; month++ {
+			keys = append(keys, fmt.Sprintf("%02d", month))
+		}
+	case GroupQuarter:
+		for number := 1; number <= quartersInYear; number++ {
+			keys = append(keys, "Q"+strconv.Itoa(number))
+		}
+	}
+
+	return keys
+}
+
+// invoicedIn returns the currency an invoice was issued in, also when its
+// amounts were converted to the home currency.
+func invoicedIn(invoice *model.Invoice) string {
+	if invoice.Exchange != nil {
+		return invoice.Exchange.From
+	}
+
+	return invoice.Currency
+}
+
+func clientKey(invoice *model.Invoice) string {
+	if key := vat.Normalize(invoice.To.VatID); key != "" {
+		return key
+	}
+
+	return invoice.To.ID
+}
+
+// CSV writes the rows of the report as CSV.
+func (r *Revenue) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+
+	err := w.Write([]string{r.GroupBy, "name", "currency", "invoices", "net", "vat", "retention", "gross", "previous_gross", "growth", "days_to_pay"})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range slices.Concat(r.Rows, r.Totals) {
+		growth := ""
+		if g, ok := row.Growth(); ok {
+			growth = strconv.FormatFloat(g, 'f', 2, 64)
+		}
+
+		days := ""
+		if row.Paid > 0 {
+			days = strconv.FormatFloat(row.DaysToPay, 'f', 0, 64)
+		}
+
+		err = w.Write([]string{
+			row.Key, row.Name, row.Currency, strconv.Itoa(row.Invoices),
+			formatCSV(row.Net), formatCSV(row.Vat), formatCSV(row.Retention), formatCSV(row.Gross),
+			formatCSV(row.PreviousGross), growth, days,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// JSON returns the report as indented JSON.
+func (r *Revenue) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Chart draws the gross of each row as a horizontal bar of up to width
+// characters.
+func (r *Revenue) Chart(width int) string {
+	most := 0.
+	for _, row := range r.Rows {
+		most = max(most, math.Abs(row.Gross))
+	}
+
+	var b strings.Builder
+
+	for _, row := range r.Rows {
+		label := row.Key
+		if row.Name != "" {
+			label = row.Name
+		}
+
+		bar := 0
+		if most > 0 {
+			bar = int(math.Round(math.Abs(row.Gross) / most * float64(width)))
+		}
+
+		fmt.Fprintf(&b, "%-20.20s %-3s |%s %.2f\n", label, row.Currency, strings.Repeat("#", bar), row.Gross)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func TestNewRevenue(t *testing.T) {
+	slow := newTestInvoice("F25-001", time.March, "ESB12345674", 21, 15, 1000)
+	slow.Status = model.StatusPaid
+	slow.Payments = []model.InvoicePayment{{Date: slow.Date.AddDate(0, 0, 10), Amount: 1060}}
+
+	prompt := newTestInvoice("F25-002", time.March, "ESB12345674", 21, 0, 100)
+	prompt.Status = model.StatusPaid
+	prompt.Payments = []model.InvoicePayment{{Date: prompt.Date.Add(time.Hour), Amount: 121}}
+
+	last := newTestInvoice("F24-001", time.March, "ESB12345674", 21, 0, 1000)
+	last.Date = last.Date.AddDate(-1, 0, 0)
+
+	r, err := NewRevenue([]*model.Invoice{slow, prompt, last}, 2025, GroupMonth, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Rows) != monthsInYear || r.Rows[2].Key != "03" {
+		t.Fatalf("got rows %+v, want every month", r.Rows)
+	}
+
+	total := r.Totals[0]
+	if total.Invoices != 2 || total.Net != 1100 || total.Retention != 150 || total.Gross != 1331 || total.PreviousGross != 1210 {
+		t.Errorf("got totals %+v", total)
+	}
+
+	if total.Paid != 2 || total.DaysToPay != 5 {
+		t.Errorf("got %d paid in %v days, want 2 in 5", total.Paid, total.DaysToPay)
+	}
+
+	if growth, ok := total.Growth(); !ok || growth != 10 {
+		t.Errorf("got growth %v, %v; want 10%%", growth, ok)
+	}
+
+	if len(r.TopClients) != 1 || r.TopClients[0].Key != "ESB12345674" {
+		t.Errorf("got top clients %+v", r.TopClients)
+	}
+
+	if _, err := NewRevenue(nil, 2025, "week", 0); err == nil {
+		t.Error("got no error for an unknown grouping")
+	}
+}
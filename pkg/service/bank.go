@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/bank"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// maxPaymentReference is the length of the references stored on payments
+// taken from the remittance text of transfers without a bank reference.
+const maxPaymentReference = 35
+
+// Bank records the payments found in bank statements.
+type Bank struct {
+	invoices *InvoiceService
+}
+
+func NewBankService(invoices *InvoiceService) *Bank {
+	return &Bank{
+		invoices: invoices,
+	}
+}
+
+// Reconcile matches the credits of a statement to the issued invoices with a
+// balance. Credits already recorded as a payment with the same reference,
+// date and amount are skipped, so a statement can be imported again.
+func (b *Bank) Reconcile(transactions []bank.Transaction) ([]bank.Match, []bank.Transaction) {
+	var open []bank.Open
+
+	recorded := map[string]bool{}
+
+	for _, invoice := range b.invoices.List(func(invoice *model.Invoice) bool { return invoice.IsBooked() }) {
+		for _, payment := range invoice.Payments {
+			recorded[paymentKey(payment)] = true
+		}
+
+		if invoice.IsCreditNote() || invoice.Status == model.StatusPaid {
+			continue
+		}
+
+		if balance := b.invoices.Balance(invoice); balance > 0 {
+			open = append(open, bank.Open{Invoice: invoice, Balance: balance})
+		}
+	}
+
+	// the oldest invoice is paid first when several match equally well
+	slices.SortStableFunc(open, func(a, b bank.Open) int {
+		return a.Invoice.DueDate().Compare(b.Invoice.DueDate())
+	})
+
+	transactions = slices.DeleteFunc(slices.Clone(transactions), func(t bank.Transaction) bool {
+		return recorded[paymentKey(paymentOf(t))]
+	})
+
+	return bank.Reconcile(transactions, open)
+}
+
+// Record records a confirmed match as a payment by transfer of the invoice,
+// returning the part of it kept as credit of the client.
+func (b *Bank) Record(match bank.Match) (*model.Invoice, float64, error) {
+	return b.invoices.Pay(match.Invoice.ID, paymentOf(match.Transaction))
+}
+
+// paymentOf returns the payment by transfer recorded for a credit. Its
+// reference is the bank reference, or the start of the remittance text.
+func paymentOf(t bank.Transaction) model.InvoicePayment {
+	reference := t.Reference
+	if remittance := []rune(t.Remittance); reference == "" {
+		reference = string(remittance[:min(len(remittance), maxPaymentReference)])
+	}
+
+	return model.InvoicePayment{
+		Date:      t.Date,
+		Amount:    model.Round(t.Amount),
+		Method:    model.PaymentMethodTransfer,
+		Reference: reference,
+	}
+}
+
+// paymentKey identifies a payment by its reference, day and amount.
+func paymentKey(p model.InvoicePayment) string {
+	return fmt.Sprintf("%s|%s|%.2f", p.Reference, p.Date.Format(time.DateOnly), p.Amount)
+}
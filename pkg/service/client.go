@@ -1,30 +1,38 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"regexp"
-	"strings"
+	"fmt"
+	"time"
 
 	"github.com/Inmovilizame/invoiceling/internal/repository"
 	"github.com/Inmovilizame/invoiceling/pkg/model"
-)
-
-const (
-	countryCodeLength = 2
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+	"github.com/Inmovilizame/invoiceling/pkg/vies"
 )
 
 var (
-	ErrNotValidVatFormat = errors.New("vat: format not valid")
-	ErrCountryNotFound   = errors.New("vat: country not found")
+	ErrNotValidVatFormat = vat.ErrFormat
+	ErrCountryNotFound   = vat.ErrUnknownCountry
+	ErrClientNotFound    = errors.New("client not found")
+	ErrVatNotChecked     = errors.New("VAT number not checked in VIES")
+	ErrNoVatChecker      = errors.New("VIES checks are disabled, set vies.enabled")
 )
 
 type Client struct {
-	repo ClientRepo
+	repo    ClientRepo
+	checker VatChecker
+	maxAge  time.Duration
 }
 
-func NewClientService(repo ClientRepo) *Client {
+// NewClientService builds the client service. checker is nil when VIES checks
+// are disabled, and VIES results newer than maxAge are reused by Verify.
+func NewClientService(repo ClientRepo, checker VatChecker, maxAge time.Duration) *Client {
 	return &Client{
-		repo: repo,
+		repo:    repo,
+		checker: checker,
+		maxAge:  maxAge,
 	}
 }
 
@@ -32,17 +40,17 @@ func (cs *Client) List(filter repository.Filter[*model.Client]) []*model.Client
 	return cs.repo.List(filter)
 }
 
-func (cs *Client) Create(id, name, vatID, address1, address2 string) error {
+// Create stores a new client. dir3 is nil unless the client is a Spanish public
+// administration. EU VAT numbers are checked in VIES when a checker is set; a
+// failed check still stores the client and returns ErrVatNotChecked.
+func (cs *Client) Create(ctx context.Context, id, name, vatID, address1, address2 string, dir3 *model.Dir3) error {
 	err := ValidateNumberFormat(vatID)
 	if err != nil {
 		return err
 	}
 
 	if id == "" {
-		b := strings.Builder{}
-		b.WriteString("client")
-		b.WriteString("-")
-		b.WriteString(vatID)
+		id = "client-" + vat.Normalize(vatID)
 	}
 
 	client := &model.Client{
@@ -51,9 +59,66 @@ func (cs *Client) Create(id, name, vatID, address1, address2 string) error {
 		VatID:    vatID,
 		Address1: address1,
 		Address2: address2,
+		Dir3:     dir3,
+	}
+
+	var checkErr error
+
+	if cs.checker != nil && vies.IsMember(vatID) {
+		client.Vies, checkErr = cs.check(ctx, vatID)
+	}
+
+	err = cs.repo.Create(client)
+	if err != nil {
+		return err
+	}
+
+	return checkErr
+}
+
+// Verify checks the VAT number of a client in VIES and stores the result. The
+// last result is reused while newer than the configured maximum age, unless
+// force is set. When VIES can not be reached the client is returned with its
+// last result along with ErrVatNotChecked.
+func (cs *Client) Verify(ctx context.Context, id string, force bool) (*model.Client, error) {
+	client := cs.repo.Read(id)
+	if client == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, id)
+	}
+
+	if cs.checker == nil {
+		return nil, ErrNoVatChecker
+	}
+
+	if !vies.IsMember(client.VatID) {
+		return nil, fmt.Errorf("%w: %s", vies.ErrNotMember, client.VatID)
+	}
+
+	if !force && client.Vies != nil && time.Since(client.Vies.CheckedAt) < cs.maxAge {
+		return client, nil
 	}
 
-	return cs.repo.Create(client)
+	check, err := cs.check(ctx, client.VatID)
+	if err != nil {
+		return client, err
+	}
+
+	client.Vies = check
+
+	if cs.repo.Update(client) == nil {
+		return nil, fmt.Errorf("client %s: VIES result not stored", id)
+	}
+
+	return client, nil
+}
+
+func (cs *Client) check(ctx context.Context, vatID string) (*model.ViesCheck, error) {
+	check, err := cs.checker.Check(ctx, vatID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVatNotChecked, err)
+	}
+
+	return check, nil
 }
 
 func (cs *Client) Read(id string) *model.Client {
@@ -68,54 +133,8 @@ func (cs *Client) Delete(invoiceID string) error {
 	return cs.repo.Delete(invoiceID)
 }
 
-// ValidateNumberFormat validates a VAT number by its format.
+// ValidateNumberFormat validates a VAT number by its format and check digits.
+// Errors are *vat.Error values naming the rule that failed.
 func ValidateNumberFormat(n string) error {
-	n = strings.ToUpper(n)
-	if len(n) <= countryCodeLength {
-		return ErrNotValidVatFormat
-	}
-
-	patterns := map[string]string{
-		"AT": `U[A-Z0-9]{8}`,
-		"BE": `(0[0-9]{9}|[0-9]{10})`,
-		"BG": `[0-9]{9,10}`,
-		"CH": `(?:E(?:-| )[0-9]{3}(?:\.| )[0-9]{3}(?:\.| )[0-9]{3}( MWST)?|E[0-9]{9}(?:MWST)?)`,
-		"CY": `[0-9]{8}[A-Z]`,
-		"CZ": `[0-9]{8,10}`,
-		"DE": `[0-9]{9}`,
-		"DK": `[0-9]{8}`,
-		"EE": `[0-9]{9}`,
-		"EL": `[0-9]{9}`,
-		"ES": `[A-Z][0-9]{7}[A-Z]|[0-9]{8}[A-Z]|[A-Z][0-9]{8}`,
-		"FI": `[0-9]{8}`,
-		"FR": `([A-Z]{2}|[0-9]{2})[0-9]{9}`,
-		"GB": `[0-9]{9}|[0-9]{12}|(GD|HA)[0-9]{3}`,
-		"HR": `[0-9]{11}`,
-		"HU": `[0-9]{8}`,
-		"IE": `[A-Z0-9]{7}[A-Z]|[A-Z0-9]{7}[A-W][A-I]`,
-		"IT": `[0-9]{11}`,
-		"LT": `([0-9]{9}|[0-9]{12})`,
-		"LU": `[0-9]{8}`,
-		"LV": `[0-9]{11}`,
-		"MT": `[0-9]{8}`,
-		"NL": `[0-9]{9}B[0-9]{2}`,
-		"PL": `[0-9]{10}`,
-		"PT": `[0-9]{9}`,
-		"RO": `[0-9]{2,10}`,
-		"SE": `[0-9]{12}`,
-		"SI": `[0-9]{8}`,
-		"SK": `[0-9]{10}`,
-	}
-
-	pattern, ok := patterns[n[0:2]]
-	if !ok {
-		return ErrCountryNotFound
-	}
-
-	matched, err := regexp.MatchString(pattern, n[2:])
-	if !matched {
-		return ErrNotValidVatFormat
-	}
-
-	return err
+	return vat.Validate(n)
 }
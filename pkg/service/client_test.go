@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/vies"
+)
+
+// newTestVies serves the VIES stand-in, counting the checks made, and fails
+// every check while down is set.
+func newTestVies(t *testing.T, checks, down *atomic.Int32) *vies.Checker {
+	t.Helper()
+
+	mock := &vies.MockHandler{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+
+		if down.Load() != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]any{"errorWrappers": []map[string]string{{"error": "MS_UNAVAILABLE"}}}) //nolint:errcheck //test
+
+			return
+		}
+
+		mock.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	return vies.NewChecker(srv.URL, "ES12345678Z")
+}
+
+func TestVerifyReusesRecentChecks(t *testing.T) {
+	var checks, down atomic.Int32
+
+	repo := repository.NewFsClient(t.TempDir())
+	cs := NewClientService(repo, newTestVies(t, &checks, &down), time.Hour)
+	ctx := context.Background()
+
+	if err := cs.Create(ctx, "berlin", "Berlin GmbH", "DE136695976", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	client := cs.Read("berlin")
+	if checks.Load() != 1 || client.Vies == nil || !client.Vies.Valid || client.Vies.ConsultationNumber == "" {
+		t.Fatalf("got %d checks and %+v, want the client checked on creation", checks.Load(), client.Vies)
+	}
+
+	if _, err := cs.Verify(ctx, "berlin", false); err != nil || checks.Load() != 1 {
+		t.Errorf("got %d checks and %v, want the check of the creation reused", checks.Load(), err)
+	}
+
+	if _, err := cs.Verify(ctx, "berlin", true); err != nil || checks.Load() != 2 {
+		t.Errorf("got %d checks and %v, want a forced check", checks.Load(), err)
+	}
+
+	// A check older than vies.max_age is made again.
+	client.Vies.CheckedAt = time.Now().Add(-2 * time.Hour)
+	repo.Update(client)
+
+	client, err := cs.Verify(ctx, "berlin", false)
+	if err != nil || checks.Load() != 3 || time.Since(client.Vies.CheckedAt) > time.Minute {
+		t.Errorf("got %d checks and %v, want an expired check made again", checks.Load(), err)
+	}
+
+	stored := cs.Read("berlin").Vies
+	if stored == nil || !stored.CheckedAt.Equal(client.Vies.CheckedAt) {
+		t.Errorf("got stored check %+v, want the new one", stored)
+	}
+
+	// Without VIES the last result is kept.
+	down.Store(1)
+
+	client, err = cs.Verify(ctx, "berlin", true)
+	if !errors.Is(err, ErrVatNotChecked) || !errors.Is(err, vies.ErrCheck) || client.Vies == nil || !client.Vies.Valid {
+		t.Errorf("got %v and %+v, want ErrVatNotChecked with the last result", err, client.Vies)
+	}
+}
+
+func TestCreateWithoutVies(t *testing.T) {
+	var checks, down atomic.Int32
+
+	down.Store(1)
+
+	cs := NewClientService(repository.NewFsClient(t.TempDir()), newTestVies(t, &checks, &down), time.Hour)
+
+	err := cs.Create(context.Background(), "", "Berlin GmbH", "DE136695976", "", "", nil)
+	if !errors.Is(err, ErrVatNotChecked) {
+		t.Errorf("got %v, want ErrVatNotChecked", err)
+	}
+
+	if client := cs.Read("client-DE136695976"); client == nil || client.Vies != nil {
+		t.Errorf("got %+v, want the client stored unchecked", client)
+	}
+
+	if _, err := cs.Verify(context.Background(), "madrid", false); !errors.Is(err, ErrClientNotFound) {
+		t.Errorf("got %v, want ErrClientNotFound", err)
+	}
+
+	cs.checker = nil
+	if _, err := cs.Verify(context.Background(), "client-DE136695976", false); !errors.Is(err, ErrNoVatChecker) {
+		t.Errorf("got %v, want ErrNoVatChecker", err)
+	}
+}
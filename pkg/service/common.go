@@ -1,6 +1,10 @@
 package service
 
 import (
+	"context"
+	"io"
+	"time"
+
 	"github.com/Inmovilizame/invoiceling/internal/repository"
 	"github.com/Inmovilizame/invoiceling/pkg/model"
 )
@@ -19,19 +23,51 @@ type InvoiceRepo interface {
 	Read(invoiceID string) *model.Invoice
 	Update(invoice *model.Invoice) *model.Invoice
 	Delete(invoiceID string) error
+	ModTime(invoiceID string) (time.Time, error)
+}
+
+type VerifactuRepo interface {
+	List(filter repository.Filter[*model.VerifactuRecord]) []*model.VerifactuRecord
+	Last() *model.VerifactuRecord
+	Create(record *model.VerifactuRecord) error
+	Update(record *model.VerifactuRecord) *model.VerifactuRecord
+}
+
+type ExchangeRepo interface {
+	List(filter repository.Filter[model.ExchangeRate]) []model.ExchangeRate
+	Find(currency string, date time.Time) *model.ExchangeRate
+	Save(rates []model.ExchangeRate) error
+}
+
+// VatChecker confirms whether a VAT number is active for intra-community trade,
+// such as the VIES client.
+type VatChecker interface {
+	Check(ctx context.Context, vatID string) (*model.ViesCheck, error)
 }
 
 type CfgRepo interface {
 	GetNotes() map[string]string
 	GetPdfOutputDir() string
+	GetPdfFilenamePattern() string
 	GetCurrency() string
+	GetHomeCurrency() string
+	GetExchangeShowVat() bool
 	GetIDFormat() string
 	GetLogo() string
 	GetFreelancer() model.Freelancer
 	GetPaymentInfo() model.Payment
+	GetVerifactuEnabled() bool
+	GetVerifactuQRURL() string
+	GetTaxRate() float64
+	GetTaxOSS() bool
+	GetTaxSupply() string
 }
 
 type RendererInterface interface {
 	Render(invoice *model.Invoice, draft bool) error
-	SaveTo(filename string) error
+	WriteTo(w io.Writer) (int64, error)
 }
+
+// RendererFactory builds a fresh renderer. Renderers keep page state, so each
+// document needs its own instance.
+type RendererFactory func() (RendererInterface, error)
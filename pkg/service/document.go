@@ -1,47 +1,219 @@
 package service
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/Inmovilizame/invoiceling/pkg/i18n"
 	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/payment"
+)
+
+const (
+	pdfMask = 0o644
+	dirMask = 0o755
+)
+
+var (
+	ErrNoRenderer    = errors.New("document: renderer not configured")
+	ErrPathCollision = errors.New("document: two invoices share a PDF path, add {id} to the filename pattern")
 )
 
 type Document struct {
 	debug     bool
 	draft     bool
 	outputDir string
-	renderer  RendererInterface
+	pattern   string
+	language  i18n.Language
+	renderers RendererFactory
+}
+
+// BatchOptions configures Document.RenderAll.
+type BatchOptions struct {
+	// Workers is the maximum number of invoices rendered at the same time.
+	Workers int
+	// Force renders invoices even when their PDF is up to date.
+	Force bool
+	// SourceModTime returns the modification time of the invoice source data.
+	SourceModTime func(invoiceID string) (time.Time, error)
+}
+
+// RenderResult reports the outcome of rendering a single invoice in a batch.
+type RenderResult struct {
+	InvoiceID string
+	Path      string
+	Skipped   bool
+	Err       error
 }
 
-func NewDocumentService(debug, draft bool, outputDir string) (*Document, error) {
+// NewDocumentService builds the document service, failing when pattern has a
+// token ExpandFilenamePattern does not know.
+func NewDocumentService(debug, draft bool, outputDir, pattern string, language i18n.Language) (*Document, error) {
+	err := ValidateFilenamePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Document{
 		debug:     debug,
 		draft:     draft,
 		outputDir: outputDir,
+		pattern:   pattern,
+		language:  language,
 	}, nil
 }
 
-func (d *Document) SetRenderer(r RendererInterface) {
-	d.renderer = r
+func (d *Document) SetRendererFactory(f RendererFactory) {
+	d.renderers = f
+}
+
+// OutputPath returns the default destination of the rendered invoice, built
+// from the configured filename pattern.
+func (d *Document) OutputPath(invoice *model.Invoice) string {
+	return filepath.Join(d.outputDir, ExpandFilenamePattern(d.pattern, invoice, d.draft, d.language))
 }
 
+// Render renders the invoice and stores it in its default destination.
 func (d *Document) Render(invoice *model.Invoice) error {
-	filename := invoice.ID + ".pdf"
-	if d.draft {
-		filename = invoice.ID + "_DRAFT.pdf"
+	return d.RenderToFile(invoice, d.OutputPath(invoice))
+}
+
+// RenderToFile renders the invoice and stores it in dest, creating any missing
+// parent directory.
+func (d *Document) RenderToFile(invoice *model.Invoice, dest string) error {
+	content, err := d.RenderBytes(invoice)
+	if err != nil {
+		return err
 	}
 
-	err := d.renderer.Render(invoice, d.draft)
+	err = os.MkdirAll(filepath.Dir(dest), dirMask)
 	if err != nil {
 		return err
 	}
 
-	dest := filepath.Join(d.outputDir, filename)
+	return os.WriteFile(dest, content, pdfMask)
+}
+
+// RenderBytes renders the invoice and returns the document content.
+func (d *Document) RenderBytes(invoice *model.Invoice) ([]byte, error) {
+	buf := bytes.Buffer{}
+
+	err := d.RenderTo(&buf, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderTo renders the invoice and writes the document to w.
+func (d *Document) RenderTo(w io.Writer, invoice *model.Invoice) error {
+	if d.renderers == nil {
+		return ErrNoRenderer
+	}
+
+	err := payment.Validate(invoice.Payment)
+	if err != nil {
+		return fmt.Errorf("invoice %s: %w", invoice.ID, err)
+	}
+
+	renderer, err := d.renderers()
+	if err != nil {
+		return err
+	}
 
-	err = d.renderer.SaveTo(dest)
+	err = renderer.Render(invoice, d.draft)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = renderer.WriteTo(w)
+
+	return err
+}
+
+// RenderAll renders the invoices to their default destination using a bounded
+// pool of workers. Results are returned in the same order as invoices. An
+// invoice whose path is the one of an earlier invoice of the batch is not
+// rendered and fails with ErrPathCollision.
+func (d *Document) RenderAll(invoices []*model.Invoice, opts BatchOptions) []RenderResult {
+	workers := max(opts.Workers, 1)
+	results := make([]RenderResult, len(invoices))
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range jobs {
+				results[idx] = d.renderOne(invoices[idx], opts)
+			}
+		}()
+	}
+
+	owners := make(map[string]string, len(invoices))
+
+	for idx, invoice := range invoices {
+		path := d.OutputPath(invoice)
+		if owner, ok := owners[path]; ok {
+			results[idx] = RenderResult{
+				InvoiceID: invoice.ID,
+				Path:      path,
+				Err:       fmt.Errorf("%w: %s is also the PDF of %s", ErrPathCollision, path, owner),
+			}
+
+			continue
+		}
+
+		owners[path] = invoice.ID
+		jobs <- idx
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func (d *Document) renderOne(invoice *model.Invoice, opts BatchOptions) RenderResult {
+	result := RenderResult{
+		InvoiceID: invoice.ID,
+		Path:      d.OutputPath(invoice),
+	}
+
+	if !opts.Force && d.isUpToDate(invoice.ID, result.Path, opts.SourceModTime) {
+		result.Skipped = true
+		return result
+	}
+
+	result.Err = d.RenderToFile(invoice, result.Path)
+
+	return result
+}
+
+func (d *Document) isUpToDate(invoiceID, dest string, sourceModTime func(string) (time.Time, error)) bool {
+	if sourceModTime == nil {
+		return false
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		return false
+	}
+
+	srcTime, err := sourceModTime(invoiceID)
+	if err != nil {
+		return false
+	}
+
+	return info.ModTime().After(srcTime)
 }
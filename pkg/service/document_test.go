@@ -0,0 +1,205 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+var errTestRender = errors.New("render failed")
+
+// testRenderer writes the ID of the invoice it renders, failing for the IDs
+// in fail. A renderer renders a single invoice, as the PDF renderers keep
+// page state. running counts the renderers rendering at the same time and
+// peak the most seen.
+type testRenderer struct {
+	fail          map[string]bool
+	running, peak *atomic.Int32
+	invoice       *model.Invoice
+}
+
+func (r *testRenderer) Render(invoice *model.Invoice, _ bool) error {
+	if r.invoice != nil {
+		return fmt.Errorf("renderer of %s reused for %s", r.invoice.ID, invoice.ID)
+	}
+
+	r.invoice = invoice
+
+	if r.running != nil {
+		running := r.running.Add(1)
+		defer r.running.Add(-1)
+
+		for {
+			peak := r.peak.Load()
+			if running <= peak || r.peak.CompareAndSwap(peak, running) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if r.fail[invoice.ID] {
+		return fmt.Errorf("%w: %s", errTestRender, invoice.ID)
+	}
+
+	return nil
+}
+
+func (r *testRenderer) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, r.invoice.ID)
+
+	return int64(n), err
+}
+
+// newTestDocument renders to a temporary directory with pattern, failing for
+// the IDs in fail.
+func newTestDocument(t *testing.T, pattern string, fail map[string]bool) *Document {
+	t.Helper()
+
+	return newTestDocumentCounting(t, pattern, fail, nil, nil)
+}
+
+// newTestDocumentCounting is newTestDocument counting the invoices rendered
+// at the same time.
+func newTestDocumentCounting(t *testing.T, pattern string, fail map[string]bool, running, peak *atomic.Int32) *Document {
+	t.Helper()
+
+	doc, err := NewDocumentService(false, false, t.TempDir(), pattern, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.SetRendererFactory(func() (RendererInterface, error) {
+		return &testRenderer{fail: fail, running: running, peak: peak}, nil
+	})
+
+	return doc
+}
+
+func newTestInvoices(n int) []*model.Invoice {
+	invoices := make([]*model.Invoice, 0, n)
+
+	for i := range n {
+		invoices = append(invoices, &model.Invoice{
+			ID:   fmt.Sprintf("F25-%03d", i+1),
+			Date: time.Date(2025, time.Month(i%12+1), 1, 0, 0, 0, 0, time.Local),
+			To:   model.Client{ID: "acme", Name: "Acme"},
+		})
+	}
+
+	return invoices
+}
+
+func TestRenderAllPathCollision(t *testing.T) {
+	doc := newTestDocument(t, "{year}/{client}", nil)
+	invoices := newTestInvoices(3)
+
+	results := doc.RenderAll(invoices, BatchOptions{Workers: 2})
+
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+
+	for _, r := range results[1:] {
+		if !errors.Is(r.Err, ErrPathCollision) || r.Path != results[0].Path {
+			t.Errorf("%s: got %v, want ErrPathCollision on %s", r.InvoiceID, r.Err, results[0].Path)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Clean(results[0].Path))
+	if err != nil || string(content) != "F25-001" {
+		t.Errorf("got %q, %v; want the PDF of the first invoice kept", content, err)
+	}
+}
+
+func TestRenderAll(t *testing.T) {
+	var running, peak atomic.Int32
+
+	fail := map[string]bool{"F25-003": true, "F25-010": true, "F25-017": true}
+	doc := newTestDocumentCounting(t, "{year}/{month}/{id}", fail, &running, &peak)
+	invoices := newTestInvoices(20)
+
+	results := doc.RenderAll(invoices, BatchOptions{Workers: 4})
+	if len(results) != len(invoices) {
+		t.Fatalf("got %d results, want %d", len(results), len(invoices))
+	}
+
+	if peak.Load() < 2 || peak.Load() > 4 {
+		t.Errorf("got %d invoices rendered at the same time, want up to the 4 workers", peak.Load())
+	}
+
+	for i, r := range results {
+		if r.InvoiceID != invoices[i].ID || r.Path != doc.OutputPath(invoices[i]) || r.Skipped {
+			t.Errorf("result %d: got %+v, want the one of %s in order", i, r, invoices[i].ID)
+		}
+
+		_, statErr := os.Stat(r.Path)
+
+		if fail[r.InvoiceID] {
+			if !errors.Is(r.Err, errTestRender) || statErr == nil {
+				t.Errorf("%s: got %v, want its error and no PDF", r.InvoiceID, r.Err)
+			}
+
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Clean(r.Path))
+		if r.Err != nil || err != nil || string(content) != r.InvoiceID {
+			t.Errorf("%s: got %v and %q, want its own PDF", r.InvoiceID, r.Err, content)
+		}
+	}
+}
+
+func TestRenderAllSkipsUpToDate(t *testing.T) {
+	doc := newTestDocument(t, "", nil)
+	invoices := newTestInvoices(3)
+
+	for _, r := range doc.RenderAll(invoices, BatchOptions{}) {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+	}
+
+	edited := time.Now().Add(time.Hour)
+	modTime := func(id string) (time.Time, error) {
+		switch id {
+		case "F25-002":
+			return edited, nil
+		case "F25-003":
+			return time.Time{}, errors.New("not found")
+		}
+
+		return time.Time{}, nil
+	}
+
+	results := doc.RenderAll(invoices, BatchOptions{Workers: 2, SourceModTime: modTime})
+	if !results[0].Skipped || results[1].Skipped || results[2].Skipped {
+		t.Errorf("got %+v, want only the unchanged invoice skipped", results)
+	}
+
+	results = doc.RenderAll(invoices, BatchOptions{Workers: 2, Force: true, SourceModTime: modTime})
+	if results[0].Skipped {
+		t.Error("got an invoice skipped with Force")
+	}
+}
+
+func TestRenderAllWithoutRenderer(t *testing.T) {
+	doc, err := NewDocumentService(false, false, t.TempDir(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range doc.RenderAll(newTestInvoices(2), BatchOptions{Workers: 8}) {
+		if !errors.Is(r.Err, ErrNoRenderer) {
+			t.Errorf("%s: got %v, want ErrNoRenderer", r.InvoiceID, r.Err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/exchange"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+const (
+	// maxRateAge is how old the last rate before a date can be. The ECB
+	// publishes no rates on weekends and TARGET holidays.
+	maxRateAge = 7 * hoursInDay * time.Hour
+	// rateFactor keeps six decimals of the rates of invoices.
+	rateFactor = 1e6
+)
+
+var (
+	ErrNoExchangeRate = errors.New("no exchange rate")
+	ErrExchangeRate   = errors.New("exchange rate not valid")
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Exchange keeps the exchange rates, quoted against the euro, and converts
+// between the currency of invoices and the home currency.
+type Exchange struct {
+	repo ExchangeRepo
+	home string
+}
+
+func NewExchangeService(repo ExchangeRepo, home string) *Exchange {
+	return &Exchange{
+		repo: repo,
+		home: strings.ToUpper(home),
+	}
+}
+
+// Home returns the home currency, the one of reports and taxes.
+func (e *Exchange) Home() string {
+	return e.home
+}
+
+// Import stores rates, replacing those of the same currency and day.
+func (e *Exchange) Import(rates []model.ExchangeRate) error {
+	return e.repo.Save(rates)
+}
+
+// Set stores the value of one euro in a currency on a day, entered by hand.
+func (e *Exchange) Set(currency string, date time.Time, rate float64) (*model.ExchangeRate, error) {
+	currency = strings.ToUpper(currency)
+
+	switch {
+	case !currencyCode.MatchString(currency) || currency == model.ExchangeBase:
+		return nil, fmt.Errorf("%w: currency %s", ErrExchangeRate, currency)
+	case rate <= 0:
+		return nil, fmt.Errorf("%w: %g", ErrExchangeRate, rate)
+	}
+
+	r := model.ExchangeRate{
+		Currency: currency,
+		Date:     time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local),
+		Rate:     rate,
+		Source:   exchange.SourceManual,
+	}
+
+	return &r, e.repo.Save([]model.ExchangeRate{r})
+}
+
+// List returns the rates of a currency, of every currency when empty, from a
+// date on.
+func (e *Exchange) List(currency string, from time.Time) []model.ExchangeRate {
+	return e.repo.List(func(r model.ExchangeRate) bool {
+		return (currency == "" || strings.EqualFold(r.Currency, currency)) && !r.Date.Before(from)
+	})
+}
+
+// Rate returns the exchange rate from a currency to the home currency on a
+// date, from the last rates published on or before it, nil for the home
+// currency or no currency.
+func (e *Exchange) Rate(from string, date time.Time) (*model.InvoiceExchange, error) {
+	from = strings.ToUpper(from)
+	if from == "" || from == e.home {
+		return nil, nil
+	}
+
+	fromRate, err := e.perEuro(from, date)
+	if err != nil {
+		return nil, err
+	}
+
+	homeRate, err := e.perEuro(e.home, date)
+	if err != nil {
+		return nil, err
+	}
+
+	published := fromRate.Date
+	if from == model.ExchangeBase {
+		published = homeRate.Date
+	}
+
+	return &model.InvoiceExchange{
+		From: from,
+		To:   e.home,
+		Rate: math.Round(homeRate.Rate/fromRate.Rate*rateFactor) / rateFactor,
+		Date: published,
+	}, nil
+}
+
+// perEuro returns the last rate of a currency on or before date, 1 for the
+// euro.
+func (e *Exchange) perEuro(currency string, date time.Time) (*model.ExchangeRate, error) {
+	if currency == model.ExchangeBase {
+		return &model.ExchangeRate{Currency: currency, Date: date, Rate: 1}, nil
+	}
+
+	rate := e.repo.Find(currency, date)
+	if rate == nil || date.Sub(rate.Date) > maxRateAge {
+		return nil, fmt.Errorf("%w: %s on %s, import the ECB rates or set one with exchange set",
+			ErrNoExchangeRate, currency, date.Format(time.DateOnly))
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// showVatCfg shows the VAT in the home currency on the PDF.
+type showVatCfg struct{ testCfg }
+
+func (showVatCfg) GetExchangeShowVat() bool { return true }
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+}
+
+// newTestExchange keeps the rates of Friday 2025-03-14 of a euro in USD, 1.08,
+// and in GBP, 0.84.
+func newTestExchange(t *testing.T, home string) *Exchange {
+	t.Helper()
+
+	e := NewExchangeService(repository.NewFsExchange(t.TempDir()), home)
+
+	err := e.Import([]model.ExchangeRate{
+		{Currency: "USD", Date: day(2025, 3, 14), Rate: 1.08, Source: "ECB"},
+		{Currency: "GBP", Date: day(2025, 3, 14), Rate: 0.84, Source: "ECB"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+func TestExchangeRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		home      string
+		from      string
+		date      time.Time
+		want      *model.InvoiceExchange
+		noneSaved bool
+	}{
+		{name: "home currency", home: "EUR", from: "eur", date: day(2025, 3, 16)},
+		{name: "no currency", home: "EUR", from: "", date: day(2025, 3, 16)},
+		{
+			name: "last rate before a weekend",
+			home: "EUR", from: "usd", date: day(2025, 3, 16),
+			want: &model.InvoiceExchange{From: "USD", To: "EUR", Rate: 0.925926, Date: day(2025, 3, 14)},
+		},
+		{
+			name: "cross rate through the euro",
+			home: "GBP", from: "USD", date: day(2025, 3, 14),
+			want: &model.InvoiceExchange{From: "USD", To: "GBP", Rate: 0.777778, Date: day(2025, 3, 14)},
+		},
+		{
+			name: "euro to another home currency",
+			home: "GBP", from: "EUR", date: day(2025, 3, 17),
+			want: &model.InvoiceExchange{From: "EUR", To: "GBP", Rate: 0.84, Date: day(2025, 3, 14)},
+		},
+		{name: "rate older than a week", home: "EUR", from: "USD", date: day(2025, 3, 22), noneSaved: true},
+		{name: "no rate before the date", home: "EUR", from: "USD", date: day(2025, 3, 13), noneSaved: true},
+		{name: "currency without rates", home: "EUR", from: "JPY", date: day(2025, 3, 14), noneSaved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestExchange(t, tt.home).Rate(tt.from, tt.date)
+			if tt.noneSaved {
+				if !errors.Is(err, ErrNoExchangeRate) {
+					t.Errorf("got %+v, %v; want ErrNoExchangeRate", got, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("got %+v, want no rate", got)
+				}
+
+				return
+			}
+
+			if got == nil || got.From != tt.want.From || got.To != tt.want.To || got.Rate != tt.want.Rate || !got.Date.Equal(tt.want.Date) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// createForeignInvoice creates an invoice to "acme" in currency dated date,
+// with an item of 100 at 21% VAT.
+func createForeignInvoice(t *testing.T, is *InvoiceService, id int, currency string, date time.Time) *model.Invoice {
+	t.Helper()
+
+	vat := 21.
+
+	invoice, err := is.Create(CreateOptions{
+		ID:       id,
+		Date:     date,
+		ClientID: "acme",
+		Vat:      &vat,
+		Items:    []model.Item{{Description: "Consulting", Quantity: 1, Rate: 100}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invoice.Currency = currency
+
+	return is.Update(invoice)
+}
+
+func TestIssueKeepsTheExchangeRate(t *testing.T) {
+	is := newTestInvoiceService(t)
+	is.exchange = newTestExchange(t, "EUR")
+
+	invoice := createForeignInvoice(t, is, 1, "USD", day(2025, 3, 16))
+
+	invoice, err := is.Issue(invoice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := model.InvoiceExchange{From: "USD", To: "EUR", Rate: 0.925926, Date: day(2025, 3, 14)}
+	if x := invoice.Exchange; x == nil || x.From != want.From || x.Rate != want.Rate || !x.Date.Equal(want.Date) {
+		t.Fatalf("got exchange %+v, want %+v", x, want)
+	}
+
+	// Later rates do not change the rate of an issued invoice.
+	if _, err := is.exchange.Set("USD", day(2025, 3, 16), 1.2); err != nil {
+		t.Fatal(err)
+	}
+
+	issued, err := NewReportService(is, testCfg{}).Issued(2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issued) != 1 {
+		t.Fatalf("got %d invoices, want 1", len(issued))
+	}
+
+	totals := issued[0].Totals()
+	if issued[0].Currency != "EUR" || totals.Subtotal != 92.59 || totals.Vat != 19.44 || totals.Total != 112.03 {
+		t.Errorf("got %s %+v, want EUR 92.59 + 19.44 VAT", issued[0].Currency, totals)
+	}
+}
+
+func TestIssueWithoutExchangeRate(t *testing.T) {
+	is := newTestInvoiceService(t)
+	is.exchange = newTestExchange(t, "EUR")
+
+	invoice := createForeignInvoice(t, is, 1, "USD", day(2024, 6, 3))
+
+	invoice, err := is.Issue(invoice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if invoice.Exchange != nil {
+		t.Errorf("got exchange %+v, want none", invoice.Exchange)
+	}
+
+	report := NewReportService(is, testCfg{})
+	if _, err := report.Issued(2024); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("got %v, want ErrNoExchangeRate until a rate is imported", err)
+	}
+
+	if err := is.exchange.Import([]model.ExchangeRate{{Currency: "USD", Date: day(2024, 5, 31), Rate: 1.25}}); err != nil {
+		t.Fatal(err)
+	}
+
+	issued, err := report.Issued(2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totals := issued[0].Totals(); issued[0].Currency != "EUR" || totals.Subtotal != 80 || totals.Vat != 16.8 {
+		t.Errorf("got %s %+v, want EUR 80 + 16.80 VAT with the rate of the invoice date", issued[0].Currency, totals)
+	}
+}
+
+func TestIssueRequiresARateToShowTheVat(t *testing.T) {
+	is := newTestInvoiceService(t)
+	is.exchange = newTestExchange(t, "EUR")
+	is.cfgRepo = showVatCfg{}
+
+	invoice := createForeignInvoice(t, is, 1, "USD", day(2024, 6, 3))
+	if _, err := is.Issue(invoice.ID); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("got %v, want ErrNoExchangeRate", err)
+	}
+
+	if is.Read(invoice.ID).Status != model.StatusCreated {
+		t.Error("invoice was issued without the rate its PDF shows")
+	}
+
+	for i, currency := range []string{"", "eur"} {
+		invoice := createForeignInvoice(t, is, 2+i, currency, day(2024, 6, 3))
+
+		invoice, err := is.Issue(invoice.ID)
+		if err != nil {
+			t.Fatalf("currency %q: %v", currency, err)
+		}
+
+		if invoice.Exchange != nil {
+			t.Errorf("currency %q: got exchange %+v, want none", currency, invoice.Exchange)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/einvoice"
+	"github.com/Inmovilizame/invoiceling/pkg/ledger"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/sheet"
+)
+
+const (
+	exportMask = 0o644
+)
+
+// Export serializes invoices to structured e-invoicing formats.
+type Export struct {
+	outputDir string
+	iRepo     InvoiceRepo
+	cRepo     ClientRepo
+}
+
+func NewExportService(outputDir string, iRepo InvoiceRepo, cRepo ClientRepo) *Export {
+	return &Export{
+		outputDir: outputDir,
+		iRepo:     iRepo,
+		cRepo:     cRepo,
+	}
+}
+
+// UBL returns the invoice as a UBL 2.1 Peppol BIS Billing 3.0 document together
+// with the business rules it breaks. The XML is returned even when there are
+// violations so callers can decide whether to keep it.
+func (e *Export) UBL(invoice *model.Invoice) ([]byte, []einvoice.Violation, error) {
+	doc, err := einvoice.FromInvoice(invoice)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, err := doc.MarshalUBL()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out, doc.CheckPeppol(), nil
+}
+
+// Facturae returns the invoice as a Facturae 3.2.2 document, signed with
+// XAdES-EPES when signer is not nil.
+func (e *Export) Facturae(invoice *model.Invoice, signer *einvoice.Signer) ([]byte, error) {
+	opts := einvoice.FacturaeOptions{}
+
+	if invoice.IsCreditNote() {
+		if corrected := e.iRepo.Read(invoice.Corrects); corrected != nil {
+			opts.CorrectedDate = corrected.Date
+		}
+	}
+
+	doc, err := einvoice.NewFacturae(invoice, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := doc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	if signer == nil {
+		return out, nil
+	}
+
+	return signer.SignXAdES(out, time.Now().Truncate(time.Second))
+}
+
+// Journal returns the journal entries of the issued invoices, credit notes and
+// payments dated between from and to, ignored when zero.
+func (e *Export) Journal(accounts ledger.Accounts, from, to time.Time) []ledger.Entry {
+	return ledger.Journal(e.iRepo.List(func(invoice *model.Invoice) bool { return invoice.IsBooked() }), accounts, from, to)
+}
+
+// Sheet returns the invoices, their lines or the clients as a sheet with the
+// columns named, every column when none is.
+func (e *Export) Sheet(kind string, columns []string) (*sheet.Sheet, error) {
+	switch kind {
+	case sheet.KindInvoices:
+		selected, err := sheet.Select(sheet.InvoiceColumns, columns)
+		if err != nil {
+			return nil, err
+		}
+
+		return sheet.New(kind, selected, e.iRepo.List(noFilter())), nil
+	case sheet.KindLines:
+		selected, err := sheet.Select(sheet.LineColumns, columns)
+		if err != nil {
+			return nil, err
+		}
+
+		return sheet.New(kind, selected, sheet.Lines(e.iRepo.List(noFilter()))), nil
+	case sheet.KindClients:
+		selected, err := sheet.Select(sheet.ClientColumns, columns)
+		if err != nil {
+			return nil, err
+		}
+
+		return sheet.New(kind, selected, e.cRepo.List(func(*model.Client) bool { return true })), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", sheet.ErrKind, kind)
+}
+
+// OutputPath is the default path of an exported invoice with the given suffix,
+// e.g. "_ubl.xml".
+func (e *Export) OutputPath(invoice *model.Invoice, suffix string) string {
+	return filepath.Join(e.outputDir, sanitizeFilename(invoice.ID)+suffix)
+}
+
+// Path is the default path of an exported file not tied to one invoice.
+func (e *Export) Path(filename string) string {
+	return filepath.Join(e.outputDir, sanitizeFilename(filename))
+}
+
+// WriteFile stores an exported document creating the parent directories.
+func (e *Export) WriteFile(path string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), dirMask)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, exportMask)
+}
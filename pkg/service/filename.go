@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/Inmovilizame/invoiceling/pkg/i18n"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+const (
+	// DefaultFilenamePattern keeps the historical flat layout: ID.pdf or ID_DRAFT.pdf.
+	DefaultFilenamePattern = "{id}{draft}"
+
+	monthsInQuarter = 3
+)
+
+var ErrFilenameToken = errors.New("unknown token in the PDF filename pattern")
+
+var (
+	filenameTokens  = []string{"{year}", "{quarter}", "{month}", "{id}", "{client_id}", "{client}", "{status}", "{lang}", "{draft}"}
+	reFilenameToken = regexp.MustCompile(`\{[^{}]*\}`)
+)
+
+// ValidateFilenamePattern reports the first token of pattern that
+// ExpandFilenamePattern does not know, which would be kept as is.
+func ValidateFilenamePattern(pattern string) error {
+	for _, token := range reFilenameToken.FindAllString(pattern, -1) {
+		if !slices.Contains(filenameTokens, token) {
+			return fmt.Errorf("%w: %s, use %s", ErrFilenameToken, token, strings.Join(filenameTokens, ", "))
+		}
+	}
+
+	return nil
+}
+
+// ExpandFilenamePattern builds a relative PDF path for the invoice from pattern.
+//
+// Supported tokens are {year}, {quarter}, {month}, {id}, {client_id}, {client}
+// (a slug of the client name), {status}, {lang} and {draft}. Slashes in the
+// pattern create subdirectories; every path segment is sanitized so it can be
+// used as a filename. The ".pdf" extension is appended when missing.
+func ExpandFilenamePattern(pattern string, invoice *model.Invoice, draft bool, lang i18n.Language) string {
+	if pattern == "" {
+		pattern = DefaultFilenamePattern
+	}
+
+	draftMark := ""
+	if draft {
+		draftMark = "_DRAFT"
+	}
+
+	replacer := strings.NewReplacer(
+		"{year}", invoice.Date.Format("2006"),
+		"{quarter}", fmt.Sprintf("Q%d", (int(invoice.Date.Month())-1)/monthsInQuarter+1),
+		"{month}", invoice.Date.Format("01"),
+		"{id}", tokenValue(invoice.ID),
+		"{client_id}", tokenValue(invoice.To.ID),
+		"{client}", Slug(invoice.To.Name),
+		"{status}", tokenValue(invoice.Status),
+		"{lang}", string(lang),
+		"{draft}", draftMark,
+	)
+
+	segments := strings.Split(pattern, "/")
+	clean := make([]string, 0, len(segments))
+
+	for _, segment := range segments {
+		segment = sanitizeFilename(replacer.Replace(segment))
+		if segment == "" {
+			continue
+		}
+
+		clean = append(clean, segment)
+	}
+
+	path := filepath.Join(clean...)
+	if path == "" {
+		path = sanitizeFilename(invoice.ID + draftMark)
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".pdf") {
+		path += ".pdf"
+	}
+
+	return path
+}
+
+// Slug reduces s to its ASCII letters and digits, dropping accents, spaces
+// and punctuation. "Acmé Corp, S.L." becomes "AcmeCorpSL".
+func Slug(s string) string {
+	b := strings.Builder{}
+
+	for _, r := range norm.NFD.String(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// tokenValue keeps path separators out of values expanded into a segment.
+func tokenValue(s string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(s)
+}
+
+// sanitizeFilename replaces characters that are not portable in filenames and
+// drops segments that would escape the output directory.
+func sanitizeFilename(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+
+		return r
+	}, s)
+
+	return strings.Trim(s, " .")
+}
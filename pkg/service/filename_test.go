@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/i18n"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+func TestExpandFilenamePattern(t *testing.T) {
+	invoice := &model.Invoice{
+		ID:     "F25-007",
+		Date:   time.Date(2025, 7, 31, 23, 0, 0, 0, time.Local),
+		Status: model.StatusIssued,
+		To:     model.Client{ID: "acme", Name: "Acmé Corp, S.L."},
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		draft   bool
+		want    string
+	}{
+		{"default", "", false, "F25-007.pdf"},
+		{"default draft", "", true, "F25-007_DRAFT.pdf"},
+		{
+			"every token",
+			"{year}/{quarter}/{month}/{id}_{client_id}_{client}_{status}_{lang}{draft}",
+			true,
+			filepath.Join("2025", "Q3", "07", "F25-007_acme_AcmeCorpSL_ISSUED_es_DRAFT.pdf"),
+		},
+		{"extension kept", "{id}.PDF", false, "F25-007.PDF"},
+		{"unknown token kept", "{id}_{total}", false, "F25-007_{total}.pdf"},
+		{"reserved characters", `{id} <a>:b"c|d?e*f\g`, false, "F25-007 _a__b_c_d_e_f_g.pdf"},
+		{"empty and dot segments", "/{year}//../{id}/.", false, filepath.Join("2025", "F25-007.pdf")},
+		{"nothing left", "../{draft}", false, "F25-007.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandFilenamePattern(tt.pattern, invoice, tt.draft, i18n.Language("es")); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandFilenamePatternSeparatorsInValues(t *testing.T) {
+	invoice := &model.Invoice{
+		ID:   "2025/007",
+		Date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local),
+		To:   model.Client{ID: `..\acme`, Name: "../Acme"},
+	}
+
+	got := ExpandFilenamePattern("{quarter}/{client_id}/{client}/{id}", invoice, false, "")
+	if want := filepath.Join("Q1", "-acme", "Acme", "2025-007.pdf"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if !filepath.IsLocal(got) {
+		t.Errorf("%s escapes the output directory", got)
+	}
+}
+
+func TestValidateFilenamePattern(t *testing.T) {
+	if err := ValidateFilenamePattern("{year}/{quarter}/{month}/{id}_{client_id}_{client}_{status}_{lang}{draft}"); err != nil {
+		t.Error(err)
+	}
+
+	for _, pattern := range []string{"{id}_{total}", "{year}/{ID}", "{id}{}"} {
+		if err := ValidateFilenamePattern(pattern); !errors.Is(err, ErrFilenameToken) {
+			t.Errorf("%s: got %v, want ErrFilenameToken", pattern, err)
+		}
+	}
+
+	if _, err := NewDocumentService(false, false, t.TempDir(), "{year}/{invoice}", ""); !errors.Is(err, ErrFilenameToken) {
+		t.Errorf("got %v, want the document service to reject an unknown token", err)
+	}
+}
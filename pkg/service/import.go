@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/sheet"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+var (
+	ErrImportColumn = errors.New("missing value")
+	ErrImportNumber = errors.New("invalid number")
+	ErrDuplicate    = errors.New("duplicate")
+	ErrNumberGap    = errors.New("invoice number leaves a gap")
+)
+
+var trailingNumber = regexp.MustCompile(`(\d+)\D*$`)
+
+// ImportResult is the outcome of importing a row, or the rows of an invoice.
+type ImportResult struct {
+	Line   int
+	ID     string
+	Action string
+	Err    error
+}
+
+// Import creates clients and invoice lines from the rows of CSV files, through
+// the same rules as the client and invoice commands.
+type Import struct {
+	clients  *Client
+	invoices *InvoiceService
+}
+
+func NewImportService(clients *Client, invoices *InvoiceService) *Import {
+	return &Import{
+		clients:  clients,
+		invoices: invoices,
+	}
+}
+
+// Clients creates a client for each row with columns id, name, vat_id,
+// address1 and address2; id is optional as in client create. VAT numbers are
+// checked with ValidateNumberFormat. With dryRun set nothing is stored and
+// the results tell what would be done.
+func (im *Import) Clients(ctx context.Context, records []sheet.Record, dryRun bool) []ImportResult {
+	existing := map[string]bool{}
+	for _, client := range im.clients.List(func(*model.Client) bool { return true }) {
+		existing[client.ID] = true
+	}
+
+	results := make([]ImportResult, 0, len(records))
+
+	for _, r := range records {
+		result := ImportResult{Line: r.Line, ID: r.Get("id")}
+		if result.ID == "" {
+			result.ID = "client-" + vat.Normalize(r.Get("vat_id"))
+		}
+
+		result.Err = required(r, "name", "vat_id", "address1")
+		if result.Err == nil {
+			result.Err = ValidateNumberFormat(r.Get("vat_id"))
+		}
+
+		if result.Err == nil && existing[result.ID] {
+			result.Err = fmt.Errorf("%w: client %s exists", ErrDuplicate, result.ID)
+		}
+
+		if result.Err != nil {
+			results = append(results, result)
+
+			continue
+		}
+
+		existing[result.ID] = true
+		result.Action = "create client"
+
+		if !dryRun {
+			result.Err = im.clients.Create(ctx, result.ID, r.Get("name"), r.Get("vat_id"), r.Get("address1"), r.Get("address2"), nil)
+			if errors.Is(result.Err, ErrVatNotChecked) {
+				result.Action += ", VAT number not checked in VIES"
+				result.Err = nil
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// importLine is a parsed row of the lines file.
+type importLine struct {
+	line int
+	item model.Item
+}
+
+// Lines adds the items of each row to the invoice in the invoice column, with
+// columns description, quantity (1 when empty), rate or gross, and vat (the
+// one of the invoice when empty). Items are added to drafts, unless a draft
+// already holds one of them; issued invoices can not be changed. Invoices not found are created for the client in the
+// client column, dated by the date column, YYYY-MM-DD, when given, and
+// numbered by the digits at the end of the invoice column in the year of
+// their date; retention and note are those of new invoices. A number already
+// taken is an error, so a file can not be imported twice, and so is a number
+// leaving a gap after the last invoice of its year. The rows of an invoice are
+// imported together or not at all. With dryRun set nothing is stored.
+func (im *Import) Lines(records []sheet.Record, dryRun bool, retention float64, note string) []ImportResult {
+	batch := lineBatch{
+		invoices:  map[string]*model.Invoice{},
+		clients:   map[string]bool{},
+		last:      map[int]int{},
+		dryRun:    dryRun,
+		retention: retention,
+		note:      note,
+	}
+
+	for _, invoice := range im.invoices.List(noFilter()) {
+		batch.add(invoice)
+	}
+
+	for _, client := range im.clients.List(func(*model.Client) bool { return true }) {
+		batch.clients[client.ID] = true
+	}
+
+	var order []string
+
+	groups := map[string][]sheet.Record{}
+
+	for _, r := range records {
+		id := r.Get("invoice")
+		if _, ok := groups[id]; !ok {
+			order = append(order, id)
+		}
+
+		groups[id] = append(groups[id], r)
+	}
+
+	results := make([]ImportResult, 0, len(order))
+
+	for _, id := range order {
+		rows := groups[id]
+		result := ImportResult{Line: rows[0].Line, ID: id}
+
+		lines, err := parseLines(rows)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+
+			continue
+		}
+
+		items := make([]model.Item, 0, len(lines))
+		for _, l := range lines {
+			items = append(items, l.item)
+		}
+
+		invoice := batch.invoices[id]
+
+		switch {
+		case id == "":
+			result.Err = fmt.Errorf("%w: invoice", ErrImportColumn)
+		case invoice != nil && invoice.IsIssued():
+			result.Err = fmt.Errorf("%w: %s", ErrInvoiceIssued, id)
+		case invoice != nil:
+			result.Action = fmt.Sprintf("add %d lines", len(items))
+			result.Err = importedLines(invoice, lines)
+
+			if result.Err == nil && !dryRun {
+				_, result.Err = im.invoices.AddItems(invoice, items)
+			}
+		default:
+			result.Action, result.Err = im.createInvoice(rows[0], items, &batch)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// lineBatch holds the invoices and clients known while importing lines, with
+// those created by the import.
+type lineBatch struct {
+	invoices map[string]*model.Invoice
+	clients  map[string]bool
+	// last is the highest invoice number of each year.
+	last      map[int]int
+	dryRun    bool
+	retention float64
+	note      string
+}
+
+func (b *lineBatch) add(invoice *model.Invoice) {
+	b.invoices[invoice.ID] = invoice
+	b.last[invoice.Date.Year()] = max(b.last[invoice.Date.Year()], invoiceNumber(invoice.ID))
+}
+
+// createInvoice creates a draft with the items of an imported invoice.
+func (im *Import) createInvoice(r sheet.Record, items []model.Item, b *lineBatch) (string, error) {
+	clientID := r.Get("client")
+
+	switch {
+	case clientID == "":
+		return "", fmt.Errorf("%w: client of a new invoice", ErrImportColumn)
+	case !b.clients[clientID]:
+		return "", fmt.Errorf("%w: %s", ErrClientNotFound, clientID)
+	}
+
+	date := time.Now()
+
+	if value := r.Get("date"); value != "" {
+		var err error
+
+		date, err = time.ParseInLocation(time.DateOnly, value, time.Local)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", r.Line, err)
+		}
+	}
+
+	// numbers follow the series of the year of the invoice, not the current one
+	year := date.Year()
+
+	number := invoiceNumber(r.Get("invoice"))
+	if number == 0 {
+		number = b.last[year] + 1
+	}
+
+	id := im.invoices.getFormattedID(number, date)
+
+	switch {
+	// a file imported again must not add its lines twice
+	case b.invoices[id] != nil:
+		return "", fmt.Errorf("%w: invoice %s exists", ErrDuplicate, id)
+	case number > b.last[year]+1:
+		return "", fmt.Errorf("%w: %s, the last invoice of %d is number %d", ErrNumberGap, id, year, b.last[year])
+	}
+
+	invoice := &model.Invoice{ID: id, Date: date}
+
+	if !b.dryRun {
+		var err error
+
+		// the draft is stored with its items at once, so a failure leaves nothing behind
+		invoice, err = im.invoices.Create(CreateOptions{
+			ID:        number,
+			Date:      date,
+			ClientID:  clientID,
+			DueDays:   model.DefaultDueSpan,
+			Note:      b.note,
+			Retention: b.retention,
+			Items:     items,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	b.add(invoice)
+
+	return fmt.Sprintf("create invoice %s with %d lines", id, len(items)), nil
+}
+
+// importedLines rejects lines a draft already holds, as they come from a file
+// imported before.
+func importedLines(invoice *model.Invoice, lines []importLine) error {
+	for _, l := range lines {
+		// the item as AddItem stores it, with the VAT and net rate it derives
+		probe := model.Invoice{Tax: invoice.Tax}
+		probe.AddItem(l.item)
+
+		if slices.ContainsFunc(invoice.Items, func(item *model.Item) bool { return *item == *probe.Items[0] }) {
+			return fmt.Errorf("line %d: %w: invoice %s already has %s", l.line, ErrDuplicate, invoice.ID, l.item.Description)
+		}
+	}
+
+	return nil
+}
+
+func parseLines(rows []sheet.Record) ([]importLine, error) {
+	lines := make([]importLine, 0, len(rows))
+
+	for _, r := range rows {
+		err := required(r, "description")
+		if err != nil {
+			return nil, err
+		}
+
+		item := model.Item{Description: r.Get("description"), Quantity: 1}
+
+		if value := r.Get("quantity"); value != "" {
+			item.Quantity, err = strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w: quantity %s", r.Line, ErrImportNumber, value)
+			}
+		}
+
+		for column, field := range map[string]*float64{"rate": &item.Rate, "gross": &item.Gross, "vat": &item.Vat} {
+			*field, err = number(r, column)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if (item.Rate == 0) == (item.Gross == 0) {
+			return nil, fmt.Errorf("line %d: %w: either rate or gross", r.Line, ErrImportColumn)
+		}
+
+		lines = append(lines, importLine{line: r.Line, item: item})
+	}
+
+	return lines, nil
+}
+
+// number reads a decimal column, with a decimal point or comma, 0 when empty.
+func number(r sheet.Record, column string) (float64, error) {
+	value := r.Get(column)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("line %d: %w: %s %s", r.Line, ErrImportNumber, column, value)
+	}
+
+	return n, nil
+}
+
+func required(r sheet.Record, columns ...string) error {
+	i := slices.IndexFunc(columns, func(column string) bool { return r.Get(column) == "" })
+	if i < 0 {
+		return nil
+	}
+
+	return fmt.Errorf("line %d: %w: %s", r.Line, ErrImportColumn, columns[i])
+}
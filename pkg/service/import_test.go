@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/sheet"
+)
+
+type testCfg struct{}
+
+func (testCfg) GetNotes() map[string]string   { return map[string]string{} }
+func (testCfg) GetPdfOutputDir() string       { return "" }
+func (testCfg) GetPdfFilenamePattern() string { return "" }
+func (testCfg) GetCurrency() string           { return "EUR" }
+func (testCfg) GetHomeCurrency() string       { return "EUR" }
+func (testCfg) GetExchangeShowVat() bool      { return false }
+func (testCfg) GetIDFormat() string           { return "F%s-%03d" }
+func (testCfg) GetLogo() string               { return "" }
+func (testCfg) GetPaymentInfo() model.Payment { return model.Payment{} }
+func (testCfg) GetVerifactuEnabled() bool     { return false }
+func (testCfg) GetVerifactuQRURL() string     { return "" }
+func (testCfg) GetTaxRate() float64           { return 21 }
+func (testCfg) GetTaxOSS() bool               { return false }
+func (testCfg) GetTaxSupply() string          { return "services" }
+func (testCfg) GetFreelancer() model.Freelancer {
+	return model.Freelancer{Name: "Ana", VatID: "ES12345678Z"}
+}
+
+// newTestInvoiceService stores invoices and clients in a temporary directory,
+// with a client "acme" and the invoice F24-005 dated 2024-03-01.
+func newTestInvoiceService(t *testing.T) *InvoiceService {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, sub := range []string{"invoice", "client", "verifactu", "exchange"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cRepo := repository.NewFsClient(filepath.Join(dir, "client"))
+	if err := cRepo.Create(&model.Client{ID: "acme", Name: "Acme", VatID: "ESB12345674"}); err != nil {
+		t.Fatal(err)
+	}
+
+	is := NewInvoiceService(
+		repository.NewFsInvoice(filepath.Join(dir, "invoice")),
+		cRepo,
+		repository.NewFsVerifactu(filepath.Join(dir, "verifactu")),
+		repository.NewFsExchange(filepath.Join(dir, "exchange")),
+		testCfg{},
+	)
+
+	_, err := is.Create(CreateOptions{ID: 5, Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), ClientID: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return is
+}
+
+// newTestImport imports into the invoices and clients of newTestInvoiceService.
+func newTestImport(t *testing.T) (*Import, *InvoiceService) {
+	t.Helper()
+
+	is := newTestInvoiceService(t)
+
+	return NewImportService(NewClientService(is.cRepo, nil, 0), is), is
+}
+
+func importLines(t *testing.T, im *Import, csv string, dryRun bool) []ImportResult {
+	t.Helper()
+
+	records, err := sheet.ReadCSV([]byte(csv), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return im.Lines(records, dryRun, 0, "")
+}
+
+func TestImportLinesNumbersByYearOfDate(t *testing.T) {
+	im, is := newTestImport(t)
+
+	results := importLines(t, im, `invoice,client,date,description,rate
+OLD-0001,acme,2023-05-10,Design,100
+OLD-0002,acme,2023-06-10,Hosting,10
+OLD-X,acme,2023-07-10,Support,50
+`, false)
+
+	for n, want := range []string{"F23-001", "F23-002", "F23-003"} {
+		if results[n].Err != nil {
+			t.Fatalf("line %d: %v", results[n].Line, results[n].Err)
+		}
+
+		invoice := is.iRepo.Read(want)
+		if invoice == nil || invoice.Date.Year() != 2023 || len(invoice.Items) != 1 {
+			t.Errorf("invoice %s not stored with its 2023 date and line: %+v", want, invoice)
+		}
+	}
+
+	if next := is.nextNumber(); next != 6 {
+		t.Errorf("got next number %d, want 6 after F24-005", next)
+	}
+}
+
+func TestImportLinesRejectsGapsAndDuplicates(t *testing.T) {
+	im, is := newTestImport(t)
+
+	results := importLines(t, im, `invoice,client,date,description,rate
+OLD-0042,acme,2023-05-10,Design,100
+OLD-0005,acme,2024-05-10,Design,100
+OLD-0007,acme,2024-05-10,Design,100
+`, false)
+
+	wants := []error{ErrNumberGap, ErrDuplicate, ErrNumberGap}
+	for n, want := range wants {
+		if !errors.Is(results[n].Err, want) {
+			t.Errorf("line %d: got %v, want %v", results[n].Line, results[n].Err, want)
+		}
+	}
+
+	if invoices := is.List(noFilter()); len(invoices) != 1 {
+		t.Errorf("got %d invoices, want only F24-005", len(invoices))
+	}
+}
+
+func TestImportLinesDryRun(t *testing.T) {
+	im, is := newTestImport(t)
+
+	results := importLines(t, im, `invoice,client,date,description,rate
+OLD-0006,acme,2024-05-10,Design,100
+OLD-0007,acme,2024-06-10,Design,100
+`, true)
+
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("line %d: %v", result.Line, result.Err)
+		}
+	}
+
+	if results[1].Action != "create invoice F24-007 with 1 lines" {
+		t.Errorf("got action %q", results[1].Action)
+	}
+
+	if invoices := is.List(noFilter()); len(invoices) != 1 {
+		t.Errorf("got %d invoices, want nothing stored", len(invoices))
+	}
+}
+
+func TestImportLinesIntoDraft(t *testing.T) {
+	im, is := newTestImport(t)
+
+	const file = `invoice,description,quantity,rate
+F24-005,Design,2,100
+F24-005,Hosting,1,10
+`
+
+	results := importLines(t, im, file, false)
+	if results[0].Err != nil || results[0].Action != "add 2 lines" {
+		t.Fatalf("got %+v, want 2 lines added", results[0])
+	}
+
+	results = importLines(t, im, file, false)
+	if !errors.Is(results[0].Err, ErrDuplicate) {
+		t.Errorf("got %v importing the file again, want ErrDuplicate", results[0].Err)
+	}
+
+	results = importLines(t, im, "invoice,description,rate\nF24-005,Support,50\n", false)
+	if results[0].Err != nil {
+		t.Errorf("got %v adding a new line", results[0].Err)
+	}
+
+	if items := is.Read("F24-005").Items; len(items) != 3 {
+		t.Errorf("got %d items, want the 3 lines imported once", len(items))
+	}
+}
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -9,52 +11,123 @@ import (
 	"github.com/Inmovilizame/invoiceling/internal/repository"
 
 	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/payment"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+	"github.com/Inmovilizame/invoiceling/pkg/verifactu"
 )
 
 const (
 	hoursInDay = 24
 )
 
+var (
+	ErrInvoiceNotFound  = errors.New("invoice not found")
+	ErrInvoiceIssued    = errors.New("invoice already issued")
+	ErrInvoiceNotIssued = errors.New("invoice not issued")
+)
+
 type InvoiceService struct {
-	iRepo   InvoiceRepo
-	cRepo   ClientRepo
-	cfgRepo CfgRepo
+	iRepo    InvoiceRepo
+	cRepo    ClientRepo
+	vRepo    VerifactuRepo
+	cfgRepo  CfgRepo
+	exchange *Exchange
 }
 
-func NewInvoiceService(iRepo InvoiceRepo, cRepo ClientRepo, cfgRepo CfgRepo) *InvoiceService {
+func NewInvoiceService(iRepo InvoiceRepo, cRepo ClientRepo, vRepo VerifactuRepo, xRepo ExchangeRepo, cfgRepo CfgRepo) *InvoiceService {
 	return &InvoiceService{
-		iRepo:   iRepo,
-		cRepo:   cRepo,
-		cfgRepo: cfgRepo,
+		iRepo:    iRepo,
+		cRepo:    cRepo,
+		vRepo:    vRepo,
+		cfgRepo:  cfgRepo,
+		exchange: NewExchangeService(xRepo, cfgRepo.GetHomeCurrency()),
 	}
 }
 
+// CreateOptions configures InvoiceService.Create.
+type CreateOptions struct {
+	// ID is the invoice number, the next one when 0.
+	ID int
+	// Date is the invoice date, today when zero. The ID takes its year.
+	Date     time.Time
+	ClientID string
+	DueDays  int
+	Note     string
+	// Reference is the buyer reference or purchase order number.
+	Reference string
+	// Supply is the kind of supply for the tax rules, the configured one when
+	// empty.
+	Supply string
+	// Vat overrides the VAT rate picked by the tax rules.
+	Vat       *float64
+	Retention float64
+	// Inclusive is set when the rates of the items include VAT.
+	Inclusive bool
+	// Taxes are added after VAT and withholding. An equivalence surcharge
+	// without rate gets the one of the VAT.
+	Taxes []model.Tax
+	// Items are added before the invoice is stored.
+	Items []model.Item
+}
+
 func (is *InvoiceService) List(filter repository.Filter[*model.Invoice]) []*model.Invoice {
 	return is.iRepo.List(filter)
 }
 
-func (is *InvoiceService) Create(
-	id int,
-	clientID string,
-	dueDays int,
-	note string,
-	vat,
-	retention float64,
-) (*model.Invoice, error) {
-	idString := is.getFormattedID(id)
+// Create creates an invoice for a client. The VAT rate, treatment and legal
+// note are picked by the tax rules for the kind of supply unless opts.Vat is
+// given.
+func (is *InvoiceService) Create(opts CreateOptions) (*model.Invoice, error) {
+	date := opts.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	idString := is.getFormattedID(opts.ID, date)
 	cfgNotes := is.cfgRepo.GetNotes()
 
-	due, err := time.ParseDuration(fmt.Sprintf("%dh", dueDays*hoursInDay))
+	due, err := time.ParseDuration(fmt.Sprintf("%dh", opts.DueDays*hoursInDay))
 	if err != nil {
 		return nil, err
 	}
 
-	invoice := model.NewInvoice(idString, due, is.cfgRepo.GetCurrency(), note, cfgNotes["no_due"])
+	invoice := model.NewInvoice(idString, due, is.cfgRepo.GetCurrency(), opts.Note, cfgNotes["no_due"])
+	invoice.Date = date
 	invoice.Logo = is.cfgRepo.GetLogo()
 	invoice.From = is.cfgRepo.GetFreelancer()
-	invoice.To = *is.cRepo.Read(clientID)
+	invoice.To = *is.cRepo.Read(opts.ClientID)
 	invoice.Payment = is.cfgRepo.GetPaymentInfo()
-	invoice.SetTaxes(vat, retention, cfgNotes)
+	invoice.Reference = opts.Reference
+
+	if opts.Vat != nil {
+		invoice.SetTaxes(*opts.Vat, opts.Retention, cfgNotes)
+		invoice.Tax.Supply = opts.Supply
+	} else {
+		err = is.applyTaxRules(invoice, opts.Supply, opts.Retention, cfgNotes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range opts.Taxes {
+		if t.Kind == model.TaxKindSurcharge && t.Rate == 0 {
+			rate, ok := tax.EquivalenceSurcharge(invoice.Tax.VatRate())
+			if !ok {
+				return nil, fmt.Errorf("no equivalence surcharge for a VAT rate of %g%%", invoice.Tax.VatRate())
+			}
+
+			t.Rate = rate
+		}
+
+		invoice.AddTax(t)
+	}
+
+	invoice.Tax.Inclusive = opts.Inclusive
+
+	for _, item := range opts.Items {
+		invoice.AddItem(item)
+	}
 
 	err = is.iRepo.Create(invoice)
 	if err != nil {
@@ -64,16 +137,225 @@ func (is *InvoiceService) Create(
 	return invoice, nil
 }
 
+// CreateCreditNote creates a credit note that fully rectifies an invoice. The
+// client, taxes and items are copied from it and can be edited afterwards.
+func (is *InvoiceService) CreateCreditNote(id int, invoiceID, note string) (*model.Invoice, error) {
+	original := is.iRepo.Read(invoiceID)
+	if original == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvoiceNotFound, invoiceID)
+	}
+
+	if original.IsCreditNote() {
+		return nil, fmt.Errorf("%s is already a credit note", invoiceID)
+	}
+
+	creditNote := model.NewInvoice(is.getFormattedID(id, time.Now()), 0, original.Currency, note, "")
+	creditNote.Type = model.TypeCreditNote
+	creditNote.Corrects = original.ID
+	creditNote.Reference = original.Reference
+	creditNote.Logo = is.cfgRepo.GetLogo()
+	creditNote.From = is.cfgRepo.GetFreelancer()
+	creditNote.To = original.To
+	creditNote.Payment = original.Payment
+	creditNote.Tax = original.Tax
+	creditNote.Tax.Taxes = slices.Clone(original.Tax.Taxes)
+	creditNote.Notes.Vat0 = original.Notes.Vat0
+	creditNote.Notes.RetentionNot0 = original.Notes.RetentionNot0
+
+	for _, item := range original.Items {
+		copied := *item
+		creditNote.Items = append(creditNote.Items, &copied)
+	}
+
+	err := is.iRepo.Create(creditNote)
+	if err != nil {
+		return nil, err
+	}
+
+	return creditNote, nil
+}
+
+// applyTaxRules sets the taxes of an invoice from the countries of the
+// freelancer and the client, and whether the client is a business.
+func (is *InvoiceService) applyTaxRules(invoice *model.Invoice, supply string, retention float64, notes map[string]string) error {
+	if supply == "" {
+		supply = is.cfgRepo.GetTaxSupply()
+	}
+
+	seller := vat.Country(invoice.From.VatID)
+	if seller == "" && invoice.From.Address != nil {
+		seller = strings.ToUpper(invoice.From.Address.Country)
+	}
+
+	decision, err := tax.Decide(tax.Scenario{
+		SellerCountry: seller,
+		BuyerCountry:  vat.Country(invoice.To.VatID),
+		Business:      isBusiness(&invoice.To),
+		Supply:        supply,
+		DomesticRate:  is.cfgRepo.GetTaxRate(),
+		OSS:           is.cfgRepo.GetTaxOSS(),
+	})
+	if err != nil {
+		return err
+	}
+
+	invoice.SetTaxes(decision.Rate, retention, notes)
+	invoice.Tax.Treatment = decision.Treatment
+	invoice.Tax.Country = decision.Country
+	invoice.Tax.Supply = supply
+	invoice.Notes.Vat0 = ""
+
+	if decision.Note != "" {
+		invoice.Notes.Vat0 = notes[decision.Note]
+		if invoice.Notes.Vat0 == "" {
+			invoice.Notes.Vat0 = tax.DefaultNotes[decision.Note]
+		}
+	}
+
+	return nil
+}
+
+// isBusiness reports whether a client has a valid VAT number, trusting the
+// last VIES check when there is one.
+func isBusiness(client *model.Client) bool {
+	if client.Vies != nil {
+		return client.Vies.Valid
+	}
+
+	return vat.Validate(client.VatID) == nil
+}
+
 func (is *InvoiceService) Read(id string) *model.Invoice {
 	return is.iRepo.Read(id)
 }
 
-func (is *InvoiceService) AddItems(invoice *model.Invoice, items []model.Item) *model.Invoice {
+func (is *InvoiceService) AddItems(invoice *model.Invoice, items []model.Item) (*model.Invoice, error) {
+	if invoice.IsIssued() {
+		return nil, fmt.Errorf("%w: %s", ErrInvoiceIssued, invoice.ID)
+	}
+
 	for _, item := range items {
 		invoice.AddItem(item)
 	}
 
-	return is.iRepo.Update(invoice)
+	return is.iRepo.Update(invoice), nil
+}
+
+// Issue marks an invoice as issued, after which it can not be modified, unless
+// the IBAN or BIC it is paid to is not valid. With
+// Verifactu enabled it also creates the registration record of the invoice and
+// stores the data of the QR code printed on it. Invoices in a foreign
+// currency keep the exchange rate of their date to the home currency; without
+// a rate for it they are only issued when their PDF does not show the VAT in
+// the home currency, and reports convert them with the rate of their date.
+// Issuing a credit note settles the invoice it corrects when it leaves nothing
+// owed.
+func (is *InvoiceService) Issue(invoiceID string) (*model.Invoice, error) {
+	invoice := is.iRepo.Read(invoiceID)
+	if invoice == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvoiceNotFound, invoiceID)
+	}
+
+	if invoice.IsIssued() {
+		return nil, fmt.Errorf("%w: %s", ErrInvoiceIssued, invoiceID)
+	}
+
+	err := payment.Validate(invoice.Payment)
+	if err != nil {
+		return nil, fmt.Errorf("invoice %s: %w", invoice.ID, err)
+	}
+
+	exchange, err := is.Exchange(invoice)
+	if err != nil && (!errors.Is(err, ErrNoExchangeRate) || is.cfgRepo.GetExchangeShowVat()) {
+		return nil, err
+	}
+
+	invoice.Exchange = exchange
+
+	if is.cfgRepo.GetVerifactuEnabled() {
+		record, err := verifactu.NewRegistration(invoice, is.vRepo.Last(), time.Now().Truncate(time.Second))
+		if err != nil {
+			return nil, err
+		}
+
+		err = is.vRepo.Create(record)
+		if err != nil {
+			return nil, err
+		}
+
+		invoice.Verifactu = &model.InvoiceVerifactu{
+			Hash: record.Hash,
+			QR:   verifactu.QRURL(is.cfgRepo.GetVerifactuQRURL(), record),
+		}
+	}
+
+	invoice.Status = model.StatusIssued
+	invoice = is.iRepo.Update(invoice)
+
+	if invoice.IsCreditNote() {
+		if corrected := is.iRepo.Read(invoice.Corrects); corrected != nil && corrected.IsBooked() {
+			is.settle(corrected)
+			is.iRepo.Update(corrected)
+		}
+	}
+
+	return invoice, nil
+}
+
+// Exchange returns the exchange rate of an invoice to the home currency: the
+// one it was issued with, the one of the invoice corrected by a credit note,
+// or the rate of its date. It is nil for invoices in the home currency or
+// without a currency.
+func (is *InvoiceService) Exchange(invoice *model.Invoice) (*model.InvoiceExchange, error) {
+	if invoice.Exchange != nil {
+		return invoice.Exchange, nil
+	}
+
+	if invoice.Currency == "" || strings.EqualFold(invoice.Currency, is.exchange.Home()) {
+		return nil, nil
+	}
+
+	if invoice.IsCreditNote() {
+		corrected := is.iRepo.List(func(i *model.Invoice) bool { return i.ID == invoice.Corrects })
+		if len(corrected) > 0 && corrected[0].Exchange != nil && corrected[0].Currency == invoice.Currency {
+			return corrected[0].Exchange, nil
+		}
+	}
+
+	return is.exchange.Rate(invoice.Currency, invoice.Date)
+}
+
+// Cancel marks an issued invoice as cancelled. Invoices registered in
+// Verifactu get a cancellation record chained after the last record.
+func (is *InvoiceService) Cancel(invoiceID string) (*model.Invoice, error) {
+	invoice := is.iRepo.Read(invoiceID)
+	if invoice == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvoiceNotFound, invoiceID)
+	}
+
+	if len(invoice.Payments) > 0 {
+		return nil, fmt.Errorf("%w: %s has payments, use a credit note", ErrInvoicePaid, invoiceID)
+	}
+
+	if invoice.Status != model.StatusIssued {
+		return nil, fmt.Errorf("%w: %s", ErrInvoiceNotIssued, invoiceID)
+	}
+
+	if invoice.Verifactu != nil {
+		record, err := verifactu.NewCancellation(invoice, is.vRepo.Last(), time.Now().Truncate(time.Second))
+		if err != nil {
+			return nil, err
+		}
+
+		err = is.vRepo.Create(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	invoice.Status = model.StatusCancelled
+
+	return is.iRepo.Update(invoice), nil
 }
 
 func (is *InvoiceService) Update(invoice *model.Invoice) *model.Invoice {
@@ -84,22 +366,50 @@ func (is *InvoiceService) Delete(invoiceID string) error {
 	return is.iRepo.Delete(invoiceID)
 }
 
-func (is *InvoiceService) getFormattedID(id int) string {
-	// TODO: Make a better solution. Maybe a repo based GetLastID
+// ModTime returns the last modification time of the stored invoice.
+func (is *InvoiceService) ModTime(invoiceID string) (time.Time, error) {
+	return is.iRepo.ModTime(invoiceID)
+}
+
+// getFormattedID formats an invoice number with invoice.id_format and the year
+// of date, the next number when id is 0.
+func (is *InvoiceService) getFormattedID(id int, date time.Time) string {
 	if id == 0 {
-		id = 1
-		invoices := is.iRepo.List(noFilter())
+		id = is.nextNumber()
+	}
 
-		if len(invoices) > 0 {
-			invoice := invoices[len(invoices)-1]
+	return fmt.Sprintf(is.cfgRepo.GetIDFormat(), date.Format("06"), id)
+}
+
+// nextNumber returns the number following the one of the latest invoice by
+// date, so invoices imported with an earlier date do not move it.
+func (is *InvoiceService) nextNumber() int {
+	var latest *model.Invoice
 
-			idParts := strings.Split(invoice.ID, "-")
-			id, _ = strconv.Atoi(idParts[len(idParts)-1]) //nolint:errcheck,errcheck //hack based on known format
-			id++
+	for _, invoice := range is.iRepo.List(noFilter()) {
+		if latest == nil || !invoice.Date.Before(latest.Date) {
+			latest = invoice
 		}
 	}
 
-	return fmt.Sprintf(is.cfgRepo.GetIDFormat(), time.Now().Format("06"), id)
+	if latest == nil {
+		return 1
+	}
+
+	return invoiceNumber(latest.ID) + 1
+}
+
+// invoiceNumber returns the digits at the end of an invoice ID, 0 when it has
+// none.
+func invoiceNumber(id string) int {
+	m := trailingNumber.FindStringSubmatch(id)
+	if m == nil {
+		return 0
+	}
+
+	number, _ := strconv.Atoi(m[1]) //nolint:errcheck //digits only
+
+	return number
 }
 
 func noFilter() repository.Filter[*model.Invoice] {
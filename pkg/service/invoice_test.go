@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/payment"
+)
+
+func TestIssueRejectsInvalidPaymentDetails(t *testing.T) {
+	is := newTestInvoiceService(t)
+
+	invoice, err := is.Create(CreateOptions{ID: 10, Date: time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC), ClientID: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invoice.Payment = model.Payment{Holder: "Ana", Iban: "ES91 2100 0418 4502 0005 1333"}
+	is.Update(invoice)
+
+	if _, err = is.Issue(invoice.ID); !errors.Is(err, payment.ErrInvalidIBAN) {
+		t.Errorf("got %v, want ErrInvalidIBAN", err)
+	}
+
+	if is.Read(invoice.ID).IsIssued() {
+		t.Error("invoice issued with an invalid IBAN")
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+var (
+	ErrInvoicePaid     = errors.New("invoice already paid")
+	ErrPaymentMethod   = errors.New("unknown payment method")
+	ErrPaymentAmount   = errors.New("payment amount must be positive")
+	ErrPaymentDate     = errors.New("payment date before the invoice date")
+	ErrNotEnoughCredit = errors.New("not enough client credit")
+)
+
+// Balance returns the amount still owed on an invoice: its total less the
+// issued credit notes correcting it and the payments received. It is negative
+// when the client paid more than owed.
+func (is *InvoiceService) Balance(invoice *model.Invoice) float64 {
+	balance := invoice.Balance()
+
+	for _, creditNote := range is.iRepo.List(func(i *model.Invoice) bool {
+		return i.IsCreditNote() && i.Corrects == invoice.ID && i.IsBooked()
+	}) {
+		balance -= creditNote.Totals().Total
+	}
+
+	return model.Round(balance)
+}
+
+// Pay records a payment of an issued invoice and moves it to partially paid or
+// paid. A zero amount pays the balance. The part of the payment over the
+// balance is kept as credit of the client and returned; payments with the
+// credit method draw from that credit instead and never exceed the balance.
+func (is *InvoiceService) Pay(invoiceID string, payment model.InvoicePayment) (*model.Invoice, float64, error) {
+	invoice := is.iRepo.Read(invoiceID)
+	if invoice == nil {
+		return nil, 0, fmt.Errorf("%w: %s", ErrInvoiceNotFound, invoiceID)
+	}
+
+	switch {
+	case !invoice.IsBooked() || invoice.IsCreditNote():
+		return nil, 0, fmt.Errorf("%w: %s", ErrInvoiceNotIssued, invoiceID)
+	case invoice.Status == model.StatusPaid:
+		return nil, 0, fmt.Errorf("%w: %s", ErrInvoicePaid, invoiceID)
+	case !model.IsPaymentMethod(payment.Method):
+		return nil, 0, fmt.Errorf("%w: %s", ErrPaymentMethod, payment.Method)
+	}
+
+	balance := is.Balance(invoice)
+	if payment.Amount == 0 {
+		payment.Amount = balance
+	}
+
+	if payment.Amount <= 0 {
+		return nil, 0, ErrPaymentAmount
+	}
+
+	if payment.Date.IsZero() {
+		payment.Date = time.Now()
+	}
+
+	if model.Day(payment.Date).Before(model.Day(invoice.Date)) {
+		return nil, 0, fmt.Errorf("%w: %s", ErrPaymentDate, invoice.Date.Format(time.DateOnly))
+	}
+
+	payment.Amount = model.Round(payment.Amount)
+	credit := 0.
+
+	switch {
+	case payment.Method == model.PaymentMethodCredit:
+		payment.Amount = min(payment.Amount, balance)
+		credit = -payment.Amount
+	case payment.Amount > balance:
+		credit = model.Round(payment.Amount - balance)
+	}
+
+	if credit != 0 {
+		err := is.addCredit(invoice.To.ID, credit)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	invoice.Payments = append(invoice.Payments, payment)
+	is.settle(invoice)
+
+	return is.iRepo.Update(invoice), max(credit, 0), nil
+}
+
+// addCredit adds an amount to the credit of a client, or takes it when
+// negative.
+func (is *InvoiceService) addCredit(clientID string, amount float64) error {
+	client := is.cRepo.Read(clientID)
+	if client == nil {
+		return fmt.Errorf("%w: %s", ErrClientNotFound, clientID)
+	}
+
+	if client.Credit+amount < 0 {
+		return fmt.Errorf("%w: %.2f available", ErrNotEnoughCredit, client.Credit)
+	}
+
+	client.Credit = model.Round(client.Credit + amount)
+	is.cRepo.Update(client)
+
+	return nil
+}
+
+// settle sets the status of an issued invoice with payments from its balance.
+// Invoices without payments stay issued even when fully credited.
+func (is *InvoiceService) settle(invoice *model.Invoice) {
+	switch {
+	case len(invoice.Payments) == 0:
+		invoice.Status = model.StatusIssued
+	case is.Balance(invoice) <= 0:
+		invoice.Status = model.StatusPaid
+	default:
+		invoice.Status = model.StatusPartiallyPaid
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+var madrid = time.FixedZone("CEST", 2*60*60)
+
+// issueTestInvoice issues an invoice to "acme" dated date, with an item of
+// 100 at 21% VAT.
+func issueTestInvoice(t *testing.T, is *InvoiceService, id int, date time.Time) *model.Invoice {
+	t.Helper()
+
+	vat := 21.
+
+	invoice, err := is.Create(CreateOptions{ID: id, Date: date, ClientID: "acme", Vat: &vat})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = is.AddItems(invoice, []model.Item{{Description: "Consulting", Quantity: 1, Rate: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invoice, err = is.Issue(invoice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return invoice
+}
+
+func TestPayOnTheInvoiceDate(t *testing.T) {
+	is := newTestInvoiceService(t)
+	invoice := issueTestInvoice(t, is, 10, time.Date(2025, 7, 10, 10, 0, 0, 0, madrid))
+
+	_, _, err := is.Pay(invoice.ID, model.InvoicePayment{
+		Date:   time.Date(2025, 7, 9, 23, 59, 0, 0, madrid),
+		Amount: 10,
+		Method: model.PaymentMethodTransfer,
+	})
+	if !errors.Is(err, ErrPaymentDate) {
+		t.Errorf("got %v paying the day before, want ErrPaymentDate", err)
+	}
+
+	// Dates given on the command line are parsed at local midnight
+	paid, _, err := is.Pay(invoice.ID, model.InvoicePayment{
+		Date:   time.Date(2025, 7, 10, 0, 0, 0, 0, madrid),
+		Method: model.PaymentMethodTransfer,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if paid.Status != model.StatusPaid || paid.Paid() != 121 {
+		t.Errorf("got status %s and %v paid, want paid 121", paid.Status, paid.Paid())
+	}
+}
+
+func TestPayPartiallyAndKeepCredit(t *testing.T) {
+	is := newTestInvoiceService(t)
+	first := issueTestInvoice(t, is, 10, time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC))
+	second := issueTestInvoice(t, is, 11, time.Date(2025, 7, 11, 0, 0, 0, 0, time.UTC))
+	transfer := func(amount float64) model.InvoicePayment {
+		return model.InvoicePayment{Date: time.Date(2025, 7, 20, 0, 0, 0, 0, time.UTC), Amount: amount, Method: model.PaymentMethodTransfer}
+	}
+
+	invoice, credit, err := is.Pay(first.ID, transfer(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if invoice.Status != model.StatusPartiallyPaid || is.Balance(invoice) != 21 || credit != 0 {
+		t.Errorf("got status %s, balance %v and credit %v; want partially paid, 21 and 0", invoice.Status, is.Balance(invoice), credit)
+	}
+
+	invoice, credit, err = is.Pay(first.ID, transfer(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if invoice.Status != model.StatusPaid || is.Balance(invoice) != -29 || credit != 29 {
+		t.Errorf("got status %s, balance %v and credit %v; want paid, -29 and 29", invoice.Status, is.Balance(invoice), credit)
+	}
+
+	if _, _, err = is.Pay(first.ID, transfer(1)); !errors.Is(err, ErrInvoicePaid) {
+		t.Errorf("got %v, want ErrInvoicePaid", err)
+	}
+
+	fromCredit := transfer(29)
+	fromCredit.Method = model.PaymentMethodCredit
+
+	invoice, _, err = is.Pay(second.ID, fromCredit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if invoice.Paid() != 29 || is.cRepo.Read("acme").Credit != 0 {
+		t.Errorf("got %v paid from credit, %v credit left; want 29 and 0", invoice.Paid(), is.cRepo.Read("acme").Credit)
+	}
+
+	if _, _, err = is.Pay(second.ID, fromCredit); !errors.Is(err, ErrNotEnoughCredit) {
+		t.Errorf("got %v, want ErrNotEnoughCredit", err)
+	}
+}
+
+func TestPayRejects(t *testing.T) {
+	is := newTestInvoiceService(t)
+	invoice := issueTestInvoice(t, is, 10, time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name      string
+		invoiceID string
+		payment   model.InvoicePayment
+		want      error
+	}{
+		{"draft", "F24-005", model.InvoicePayment{Method: model.PaymentMethodCash}, ErrInvoiceNotIssued},
+		{"method", invoice.ID, model.InvoicePayment{Method: "cheque"}, ErrPaymentMethod},
+		{"amount", invoice.ID, model.InvoicePayment{Method: model.PaymentMethodCash, Amount: -5}, ErrPaymentAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := is.Pay(tt.invoiceID, tt.payment); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBalanceSubtractsIssuedCreditNotes(t *testing.T) {
+	is := newTestInvoiceService(t)
+	invoice := issueTestInvoice(t, is, 10, time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC))
+
+	creditNote, err := is.CreateCreditNote(11, invoice.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if balance := is.Balance(invoice); balance != 121 {
+		t.Errorf("got balance %v with a draft credit note, want 121", balance)
+	}
+
+	if _, err = is.Issue(creditNote.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	invoice = is.Read(invoice.ID)
+	if balance := is.Balance(invoice); balance != 0 || invoice.Status != model.StatusIssued {
+		t.Errorf("got balance %v and status %s, want 0 and still issued without payments", balance, invoice.Status)
+	}
+}
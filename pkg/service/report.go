@@ -0,0 +1,170 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/report"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+// Report fills tax returns from the issued invoices.
+type Report struct {
+	invoices *InvoiceService
+	cfgRepo  CfgRepo
+}
+
+func NewReportService(invoices *InvoiceService, cfgRepo CfgRepo) *Report {
+	return &Report{
+		invoices: invoices,
+		cfgRepo:  cfgRepo,
+	}
+}
+
+// Issued returns the invoices and credit notes issued in a year, in the home
+// currency. Cancelled invoices are left out, they no longer have any tax
+// effect.
+func (r *Report) Issued(year int) ([]*model.Invoice, error) {
+	return r.booked(func(invoice *model.Invoice) bool { return invoice.Date.Year() == year })
+}
+
+// Drafts returns the invoices and credit notes dated from from until to that
+// are not issued yet. Reports leave them out, as they have no tax effect until
+// they are issued.
+func (r *Report) Drafts(from, to time.Time) []*model.Invoice {
+	return r.invoices.List(func(invoice *model.Invoice) bool {
+		return invoice.Status == model.StatusCreated && !invoice.Date.Before(from) && invoice.Date.Before(to)
+	})
+}
+
+// Model303 fills the quarterly VAT return of a quarter.
+func (r *Report) Model303(q report.Quarter) (*report.Return, error) {
+	issued, err := r.Issued(q.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	return report.Model303(issued, q)
+}
+
+// Model130 fills the quarterly income tax prepayment of a quarter. expenses are
+// the deductible expenses of the year up to the end of the quarter and paid
+// the prepayments of the previous quarters, computed when nil.
+func (r *Report) Model130(q report.Quarter, expenses float64, paid *float64) (*report.Return, error) {
+	issued, err := r.Issued(q.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	return report.Model130(issued, q, expenses, paid), nil
+}
+
+// Model390 fills the annual VAT summary of a year.
+func (r *Report) Model390(year int) (*report.Return, error) {
+	issued, err := r.Issued(year)
+	if err != nil {
+		return nil, err
+	}
+
+	return report.Model390(issued, year)
+}
+
+// Model349 builds the recapitulative statement of intra-Community operations
+// of a quarter. Every issued invoice is passed so credit notes can correct
+// earlier periods.
+func (r *Report) Model349(q report.Quarter) (*report.Statement349, error) {
+	issued, err := r.booked(func(*model.Invoice) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	return report.Model349(issued, q), nil
+}
+
+// File349 returns the 349 in the AEAT text format, filed by the freelancer.
+func (r *Report) File349(statement *report.Statement349) []byte {
+	return statement.File(r.taxpayer())
+}
+
+// Receivables returns the issued invoices with an amount still owed on a date,
+// in the home currency.
+func (r *Report) Receivables(now time.Time) ([]report.Receivable, error) {
+	booked, err := r.booked(func(*model.Invoice) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	return report.Receivables(booked, now), nil
+}
+
+// Revenue sums the invoices of a year and the year before by group, keeping
+// the top clients invoiced the most.
+func (r *Report) Revenue(year int, groupBy string, top int) (*report.Revenue, error) {
+	booked, err := r.booked(func(invoice *model.Invoice) bool {
+		return invoice.Date.Year() == year || invoice.Date.Year() == year-1
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report.NewRevenue(booked, year, groupBy, top)
+}
+
+// Summary sums the invoices of a year by client.
+func (r *Report) Summary(year int) ([]*report.ClientYear, error) {
+	issued, err := r.Issued(year)
+	if err != nil {
+		return nil, err
+	}
+
+	return report.Summary(issued, year), nil
+}
+
+// booked returns the issued invoices matching filter converted to the home
+// currency, with the rate they were issued with or, for invoices issued
+// before rates were kept, the rate of their date.
+func (r *Report) booked(filter func(*model.Invoice) bool) ([]*model.Invoice, error) {
+	invoices := r.invoices.List(func(invoice *model.Invoice) bool {
+		return invoice.IsBooked() && filter(invoice)
+	})
+
+	for i, invoice := range invoices {
+		exchange, err := r.invoices.Exchange(invoice)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", invoice.ID, err)
+		}
+
+		invoice.Exchange = exchange
+		invoices[i] = invoice.InHome()
+	}
+
+	return invoices, nil
+}
+
+// File returns a return as the AEAT fixed-width file, filed by the freelancer.
+func (r *Report) File(ret *report.Return) []byte {
+	return ret.File(r.taxpayer())
+}
+
+// File347 returns the 347 of a year in the AEAT text format, filed by the
+// freelancer.
+func (r *Report) File347(clients []*report.ClientYear, year int) []byte {
+	return report.File347(clients, year, r.taxpayer())
+}
+
+func (r *Report) taxpayer() report.Taxpayer {
+	freelancer := r.cfgRepo.GetFreelancer()
+
+	name := freelancer.Company
+	if name == "" {
+		name = freelancer.Name
+	}
+
+	return report.Taxpayer{
+		NIF:   strings.TrimPrefix(vat.Normalize(freelancer.VatID), "ES"),
+		Name:  name,
+		Phone: freelancer.Phone,
+	}
+}
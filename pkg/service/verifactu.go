@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/internal/repository"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/verifactu"
+)
+
+// Verifactu builds and submits the payloads of the Verifactu record chain.
+type Verifactu struct {
+	vRepo    VerifactuRepo
+	iRepo    InvoiceRepo
+	issuer   model.Freelancer
+	system   verifactu.System
+	endpoint string
+}
+
+func NewVerifactuService(
+	vRepo VerifactuRepo,
+	iRepo InvoiceRepo,
+	issuer model.Freelancer,
+	system verifactu.System,
+	endpoint string,
+) *Verifactu {
+	return &Verifactu{
+		vRepo:    vRepo,
+		iRepo:    iRepo,
+		issuer:   issuer,
+		system:   system,
+		endpoint: endpoint,
+	}
+}
+
+func (v *Verifactu) List(filter repository.Filter[*model.VerifactuRecord]) []*model.VerifactuRecord {
+	return v.vRepo.List(filter)
+}
+
+// Pending returns the records not accepted yet, in chain order.
+func (v *Verifactu) Pending() []*model.VerifactuRecord {
+	return v.vRepo.List(func(r *model.VerifactuRecord) bool {
+		return r.Status != model.RecordAccepted
+	})
+}
+
+// ForInvoice returns the records of an invoice, in chain order.
+func (v *Verifactu) ForInvoice(invoiceID string) []*model.VerifactuRecord {
+	return v.vRepo.List(func(r *model.VerifactuRecord) bool {
+		return r.InvoiceID == invoiceID
+	})
+}
+
+// Payload returns the SOAP submission of records.
+func (v *Verifactu) Payload(records []*model.VerifactuRecord) ([]byte, error) {
+	issuerID, err := verifactu.IssuerID(v.issuer.VatID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]verifactu.Entry, 0, len(records))
+
+	for _, record := range records {
+		entry := verifactu.Entry{Record: record}
+
+		if record.Kind == model.RecordRegistration {
+			entry.Invoice = v.iRepo.Read(record.InvoiceID)
+			if entry.Invoice == nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvoiceNotFound, record.InvoiceID)
+			}
+
+			if entry.Invoice.IsCreditNote() {
+				if corrected := v.iRepo.Read(entry.Invoice.Corrects); corrected != nil {
+					entry.CorrectedDate = corrected.Date
+				}
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	name := v.issuer.Company
+	if name == "" {
+		name = v.issuer.Name
+	}
+
+	// Without a configured producer the freelancer is its own producer
+	system := v.system
+	if system.ProducerID == "" {
+		system.ProducerName = name
+		system.ProducerID = issuerID
+	}
+
+	return verifactu.NewEnvelope(name, issuerID, system, entries).Marshal()
+}
+
+// Submit sends records to the configured endpoint and stores the status the
+// endpoint gives to each of them.
+func (v *Verifactu) Submit(ctx context.Context, records []*model.VerifactuRecord) (*verifactu.Response, error) {
+	payload, err := v.Payload(records)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := verifactu.Submit(ctx, v.endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	// Lines are answered in the order the records were sent
+	for i, line := range response.Lines {
+		if i >= len(records) {
+			break
+		}
+
+		record := records[i]
+		record.SubmittedAt = &now
+		record.Status = model.RecordRejected
+		record.Error = ""
+
+		if line.Accepted() {
+			record.Status = model.RecordAccepted
+		} else {
+			record.Error = line.ErrorCode + " " + line.Description
+		}
+
+		v.vRepo.Update(record)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,93 @@
+package sheet
+
+import (
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// Kinds of sheets.
+const (
+	KindInvoices = "invoices"
+	KindLines    = "lines"
+	KindClients  = "clients"
+)
+
+// Line is an item of an invoice, a row of the lines sheet.
+type Line struct {
+	Invoice *model.Invoice
+	Item    *model.Item
+	Number  int
+}
+
+// Lines returns the items of invoices in order.
+func Lines(invoices []*model.Invoice) []Line {
+	var lines []Line
+
+	for _, invoice := range invoices {
+		for i, item := range invoice.Items {
+			lines = append(lines, Line{Invoice: invoice, Item: item, Number: i + 1})
+		}
+	}
+
+	return lines
+}
+
+// InvoiceColumns are the columns of the invoices sheet. Amounts are those of
+// the invoice, positive for credit notes too.
+var InvoiceColumns = []Column[*model.Invoice]{
+	{"id", func(i *model.Invoice) any { return i.ID }},
+	{"type", func(i *model.Invoice) any { return documentType(i) }},
+	{"status", func(i *model.Invoice) any { return i.Status }},
+	{"date", func(i *model.Invoice) any { return i.Date }},
+	{"due_date", func(i *model.Invoice) any { return i.DueDate() }},
+	{"client_id", func(i *model.Invoice) any { return i.To.ID }},
+	{"client_name", func(i *model.Invoice) any { return i.To.Name }},
+	{"client_vat_id", func(i *model.Invoice) any { return i.To.VatID }},
+	{"reference", func(i *model.Invoice) any { return i.Reference }},
+	{"corrects", func(i *model.Invoice) any { return i.Corrects }},
+	{"currency", func(i *model.Invoice) any { return i.Currency }},
+	{"net", func(i *model.Invoice) any { return i.Totals().Subtotal }},
+	{"vat_rate", func(i *model.Invoice) any { return i.Tax.VatRate() }},
+	{"vat", func(i *model.Invoice) any { return i.Totals().Vat }},
+	{"surcharge", func(i *model.Invoice) any { return i.Totals().Of(model.TaxKindSurcharge) }},
+	{"withholding_rate", func(i *model.Invoice) any { return i.Tax.WithholdingRate() }},
+	{"withholding", func(i *model.Invoice) any { return i.Totals().Retention }},
+	{"total", func(i *model.Invoice) any { return i.Totals().Total }},
+	{"paid", func(i *model.Invoice) any { return i.Paid() }},
+	{"paid_on", func(i *model.Invoice) any { return i.PaidOn() }},
+	{"treatment", func(i *model.Invoice) any { return i.Tax.Treatment }},
+}
+
+// LineColumns are the columns of the lines sheet.
+var LineColumns = []Column[Line]{
+	{"invoice", func(l Line) any { return l.Invoice.ID }},
+	{"date", func(l Line) any { return l.Invoice.Date }},
+	{"client_id", func(l Line) any { return l.Invoice.To.ID }},
+	{"client_name", func(l Line) any { return l.Invoice.To.Name }},
+	{"line", func(l Line) any { return l.Number }},
+	{"description", func(l Line) any { return l.Item.Description }},
+	{"quantity", func(l Line) any { return l.Item.Quantity }},
+	{"rate", func(l Line) any { return l.Item.Rate }},
+	{"gross", func(l Line) any { return l.Item.Gross }},
+	{"vat", func(l Line) any { return l.Item.Vat }},
+	{"amount", func(l Line) any { return model.Round(l.Item.GetAmount()) }},
+	{"currency", func(l Line) any { return l.Invoice.Currency }},
+}
+
+// ClientColumns are the columns of the clients sheet, also read by the
+// import of clients.
+var ClientColumns = []Column[*model.Client]{
+	{"id", func(c *model.Client) any { return c.ID }},
+	{"name", func(c *model.Client) any { return c.Name }},
+	{"vat_id", func(c *model.Client) any { return c.VatID }},
+	{"address1", func(c *model.Client) any { return c.Address1 }},
+	{"address2", func(c *model.Client) any { return c.Address2 }},
+	{"credit", func(c *model.Client) any { return c.Credit }},
+}
+
+func documentType(i *model.Invoice) string {
+	if i.IsCreditNote() {
+		return model.TypeCreditNote
+	}
+
+	return model.TypeInvoice
+}
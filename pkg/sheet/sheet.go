@@ -0,0 +1,202 @@
+// Package sheet writes invoices and clients as spreadsheets, CSV or XLSX, and
+// reads the rows of CSV files to import them.
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrColumn = errors.New("sheet: unknown column")
+	ErrKind   = errors.New("sheet: unknown kind, use invoices, lines or clients")
+)
+
+// Column is a column of a sheet of records of type T.
+type Column[T any] struct {
+	Name string
+	// Value returns the value of the column for a record: a string, an int,
+	// a float64 or a time.Time.
+	Value func(T) any
+}
+
+// Sheet is a table with a header row.
+type Sheet struct {
+	Name   string
+	Header []string
+	Rows   [][]any
+}
+
+// Select returns the columns named, in that order, or every column when
+// names is empty.
+func Select[T any](columns []Column[T], names []string) ([]Column[T], error) {
+	if len(names) == 0 {
+		return columns, nil
+	}
+
+	selected := make([]Column[T], 0, len(names))
+
+	for _, name := range names {
+		i := slices.IndexFunc(columns, func(c Column[T]) bool { return strings.EqualFold(c.Name, strings.TrimSpace(name)) })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s, use %s", ErrColumn, name, strings.Join(Names(columns), ", "))
+		}
+
+		selected = append(selected, columns[i])
+	}
+
+	return selected, nil
+}
+
+// Names returns the names of columns.
+func Names[T any](columns []Column[T]) []string {
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+// New builds a sheet with a row for each record.
+func New[T any](name string, columns []Column[T], records []T) *Sheet {
+	s := &Sheet{Name: name, Header: Names(columns)}
+
+	for _, record := range records {
+		row := make([]any, 0, len(columns))
+		for _, c := range columns {
+			value := c.Value(record)
+			if f, ok := value.(float64); ok && f == 0 {
+				value = 0. // no -0.00 for amounts rounded to zero
+			}
+
+			row = append(row, value)
+		}
+
+		s.Rows = append(s.Rows, row)
+	}
+
+	return s
+}
+
+// CSV writes the sheet as CSV separated by delimiter, a comma when empty.
+// Amounts are written with two decimals and dates as YYYY-MM-DD.
+func (s *Sheet) CSV(delimiter string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	if delimiter != "" {
+		w.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	}
+
+	err := w.Write(s.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range s.Rows {
+		record := make([]string, 0, len(row))
+		for _, value := range row {
+			record = append(record, format(value))
+		}
+
+		err = w.Write(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+func format(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+
+		return v.Format(time.DateOnly)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// Record is a row of an imported CSV file, by the lower case names of the
+// header. Line is the line number of the row in the file.
+type Record struct {
+	Line   int
+	Fields map[string]string
+}
+
+// Get returns the trimmed value of a column, empty when missing.
+func (r Record) Get(name string) string {
+	return strings.TrimSpace(r.Fields[name])
+}
+
+// ReadCSV reads the rows of a CSV file with a header row. The delimiter is
+// guessed between comma and semicolon from the header when empty.
+func ReadCSV(data []byte, delimiter string) ([]Record, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+
+	switch {
+	case delimiter != "":
+		r.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	default:
+		header, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+			r.Comma = ';'
+		}
+	}
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := make([]string, 0, len(rows[0]))
+	for _, name := range rows[0] {
+		header = append(header, strings.ToLower(strings.TrimSpace(name)))
+	}
+
+	records := make([]Record, 0, len(rows)-1)
+
+	for i, row := range rows[1:] {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		record := Record{Line: i + 2, Fields: map[string]string{}} //nolint:mnd //header and 1-based lines
+		for j, value := range row {
+			if j < len(header) {
+				record.Fields[header[j]] = value
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
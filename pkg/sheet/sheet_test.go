@@ -0,0 +1,73 @@
+package sheet
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type row struct {
+	id     string
+	amount float64
+	date   time.Time
+}
+
+var columns = []Column[row]{
+	{Name: "id", Value: func(r row) any { return r.id }},
+	{Name: "amount", Value: func(r row) any { return r.amount }},
+	{Name: "date", Value: func(r row) any { return r.date }},
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "\xef\xbb\xbfInvoice; Client ;Rate\nF25-001;acme;1,5\n;;\nF25-002;globex\n"
+
+	records, err := ReadCSV([]byte(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("got %+v, want 2 records", records)
+	}
+
+	if records[0].Get("client") != "acme" || records[0].Get("rate") != "1,5" || records[0].Line != 2 {
+		t.Errorf("got %+v", records[0])
+	}
+
+	if records[1].Line != 4 || records[1].Get("rate") != "" {
+		t.Errorf("got %+v, want line 4 without rate", records[1])
+	}
+}
+
+func TestSelect(t *testing.T) {
+	selected, err := Select(columns, []string{"date", " ID"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := Names(selected); len(names) != 2 || names[0] != "date" || names[1] != "id" {
+		t.Errorf("got %v, want date and id", names)
+	}
+
+	if _, err := Select(columns, []string{"total"}); !errors.Is(err, ErrColumn) {
+		t.Errorf("got %v, want ErrColumn", err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	records := []row{
+		{id: "F25-001", amount: 1210, date: time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{id: "F25-002", amount: math.Copysign(0, -1)},
+	}
+
+	out, err := New("invoices", columns, records).CSV(";")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id;amount;date\nF25-001;1210.00;2025-03-14\nF25-002;0.00;\n"
+	if string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
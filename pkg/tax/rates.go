@@ -0,0 +1,37 @@
+package tax
+
+// standardRates are the standard VAT rates of the EU member states, by ISO
+// 3166-1 alpha-2 code, as published in the Taxes in Europe Database.
+var standardRates = map[string]float64{
+	"AT": 20, "BE": 21, "BG": 20, "CY": 19, "CZ": 21, "DE": 19, "DK": 25,
+	"EE": 24, "ES": 21, "FI": 25.5, "FR": 20, "GR": 24, "HR": 25, "HU": 27,
+	"IE": 23, "IT": 22, "LT": 21, "LU": 17, "LV": 21, "MT": 18, "NL": 21,
+	"PL": 23, "PT": 23, "RO": 21, "SE": 25, "SI": 22, "SK": 23,
+}
+
+// IsEU reports whether a country is an EU member state.
+func IsEU(country string) bool {
+	_, ok := standardRates[country]
+
+	return ok
+}
+
+// StandardRate returns the standard VAT rate of an EU member state, 0 for other
+// countries.
+func StandardRate(country string) float64 {
+	return standardRates[country]
+}
+
+// equivalenceSurcharges are the Spanish equivalence surcharge rates (art. 161
+// of Law 37/1992) of each VAT rate.
+var equivalenceSurcharges = map[float64]float64{
+	21: 5.2, 10: 1.4, 5: 0.62, 4: 0.5, 2: 0.26,
+}
+
+// EquivalenceSurcharge returns the Spanish equivalence surcharge rate charged
+// to retailers on top of a VAT rate.
+func EquivalenceSurcharge(vatRate float64) (float64, bool) {
+	rate, ok := equivalenceSurcharges[vatRate]
+
+	return rate, ok
+}
@@ -0,0 +1,121 @@
+// Package tax decides the VAT treatment of an invoice from the countries of
+// the freelancer and the client, whether the client is a business and the kind
+// of supply, following the place of supply rules of Council Directive
+// 2006/112/EC.
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Treatments of an invoice. Every treatment but TreatmentDomestic and
+// TreatmentOSS is zero rated and needs a legal note.
+const (
+	TreatmentDomestic      = "domestic"
+	TreatmentExempt        = "exempt"
+	TreatmentReverseCharge = "reverse_charge"
+	TreatmentIntraEUGoods  = "intra_eu_goods"
+	TreatmentOSS           = "oss"
+	TreatmentExport        = "export"
+)
+
+// Kinds of supply, which decide the place of supply for consumers.
+const (
+	// SupplyServices are services under the general rules (art. 44 and 45).
+	SupplyServices = "services"
+	// SupplyElectronic are electronically supplied, telecommunication and
+	// broadcasting services, taxed where the consumer lives (art. 58).
+	SupplyElectronic = "electronic"
+	// SupplyGoods are goods shipped to the client.
+	SupplyGoods = "goods"
+)
+
+// Keys of the legal notes in the notes configuration.
+const (
+	NoteExempt        = "vat_0"
+	NoteReverseCharge = "reverse_charge"
+	NoteIntraEUGoods  = "intra_eu_goods"
+	NoteExport        = "export"
+)
+
+var ErrUnknownSupply = errors.New("tax: unknown supply type")
+
+// DefaultNotes are the legal notes used when the configuration has none.
+var DefaultNotes = map[string]string{
+	NoteReverseCharge: "Reverse charge: VAT to be accounted for by the recipient, " +
+		"art. 44 and 196 of Council Directive 2006/112/EC.",
+	NoteIntraEUGoods: "Exempt intra-Community supply of goods, art. 138 of Council Directive 2006/112/EC.",
+	NoteExport:       "Not subject to EU VAT, place of supply outside the EU (Council Directive 2006/112/EC).",
+}
+
+// Scenario describes an invoice for the rules.
+type Scenario struct {
+	SellerCountry string
+	BuyerCountry  string
+	// Business is set when the client has a valid VAT number.
+	Business bool
+	Supply   string
+	// DomesticRate is the VAT rate the freelancer charges in their country.
+	DomesticRate float64
+	// OSS is set when the freelancer is registered in the One-Stop-Shop, or
+	// over its 10,000 EUR threshold, and charges the VAT of the country of
+	// the consumer.
+	OSS bool
+}
+
+// Decision is the VAT treatment of an invoice.
+type Decision struct {
+	Treatment string
+	Rate      float64
+	// Country is the country whose VAT is charged, empty when zero rated.
+	Country string
+	// Note is the key of the legal note, empty when none is needed.
+	Note string
+}
+
+// Decide applies the place of supply rules to a scenario. Countries are ISO
+// 3166-1 alpha-2 codes; invoices are taken as domestic when either is unknown.
+func Decide(s Scenario) (Decision, error) {
+	supply := s.Supply
+	if supply == "" {
+		supply = SupplyServices
+	}
+
+	if supply != SupplyServices && supply != SupplyElectronic && supply != SupplyGoods {
+		return Decision{}, fmt.Errorf("%w: %s", ErrUnknownSupply, s.Supply)
+	}
+
+	seller := strings.ToUpper(s.SellerCountry)
+	buyer := strings.ToUpper(s.BuyerCountry)
+
+	domestic := Decision{Treatment: TreatmentDomestic, Rate: s.DomesticRate, Country: seller}
+	if s.DomesticRate == 0 {
+		domestic = Decision{Treatment: TreatmentExempt, Note: NoteExempt}
+	}
+
+	switch {
+	case seller == "" || buyer == "" || buyer == seller:
+		return domestic, nil
+	case !IsEU(seller):
+		// Only the EU rules are known, sales abroad are not taxed here
+		return Decision{Treatment: TreatmentExport, Note: NoteExport}, nil
+	case !IsEU(buyer):
+		// Consumers outside the EU pay the VAT of the freelancer for
+		// services under the general rule (art. 45)
+		if !s.Business && supply == SupplyServices {
+			return domestic, nil
+		}
+
+		return Decision{Treatment: TreatmentExport, Note: NoteExport}, nil
+	case s.Business && supply == SupplyGoods:
+		return Decision{Treatment: TreatmentIntraEUGoods, Note: NoteIntraEUGoods}, nil
+	case s.Business:
+		return Decision{Treatment: TreatmentReverseCharge, Note: NoteReverseCharge}, nil
+	case supply == SupplyServices || !s.OSS:
+		return domestic, nil
+	}
+
+	return Decision{Treatment: TreatmentOSS, Rate: StandardRate(buyer), Country: buyer}, nil
+}
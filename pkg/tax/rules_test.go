@@ -0,0 +1,100 @@
+package tax
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario Scenario
+		want     Decision
+	}{
+		{
+			"domestic",
+			Scenario{SellerCountry: "ES", BuyerCountry: "ES", Business: true, DomesticRate: 21},
+			Decision{Treatment: TreatmentDomestic, Rate: 21, Country: "ES"},
+		},
+		{
+			"domestic exempt",
+			Scenario{SellerCountry: "ES", BuyerCountry: "ES", DomesticRate: 0},
+			Decision{Treatment: TreatmentExempt, Note: NoteExempt},
+		},
+		{
+			"unknown client country",
+			Scenario{SellerCountry: "ES", DomesticRate: 21},
+			Decision{Treatment: TreatmentDomestic, Rate: 21, Country: "ES"},
+		},
+		{
+			"EU business services",
+			Scenario{SellerCountry: "ES", BuyerCountry: "de", Business: true, DomesticRate: 21},
+			Decision{Treatment: TreatmentReverseCharge, Note: NoteReverseCharge},
+		},
+		{
+			"EU business goods",
+			Scenario{SellerCountry: "ES", BuyerCountry: "FR", Business: true, Supply: SupplyGoods, DomesticRate: 21},
+			Decision{Treatment: TreatmentIntraEUGoods, Note: NoteIntraEUGoods},
+		},
+		{
+			"EU consumer services",
+			Scenario{SellerCountry: "ES", BuyerCountry: "FR", DomesticRate: 21, OSS: true},
+			Decision{Treatment: TreatmentDomestic, Rate: 21, Country: "ES"},
+		},
+		{
+			"EU consumer electronic services under the threshold",
+			Scenario{SellerCountry: "ES", BuyerCountry: "FR", Supply: SupplyElectronic, DomesticRate: 21},
+			Decision{Treatment: TreatmentDomestic, Rate: 21, Country: "ES"},
+		},
+		{
+			"EU consumer electronic services in the OSS",
+			Scenario{SellerCountry: "ES", BuyerCountry: "FI", Supply: SupplyElectronic, DomesticRate: 21, OSS: true},
+			Decision{Treatment: TreatmentOSS, Rate: 25.5, Country: "FI"},
+		},
+		{
+			"consumer outside the EU",
+			Scenario{SellerCountry: "ES", BuyerCountry: "US", DomesticRate: 21},
+			Decision{Treatment: TreatmentDomestic, Rate: 21, Country: "ES"},
+		},
+		{
+			"business outside the EU",
+			Scenario{SellerCountry: "ES", BuyerCountry: "US", Business: true, DomesticRate: 21},
+			Decision{Treatment: TreatmentExport, Note: NoteExport},
+		},
+		{
+			"seller outside the EU",
+			Scenario{SellerCountry: "CH", BuyerCountry: "ES", DomesticRate: 8.1},
+			Decision{Treatment: TreatmentExport, Note: NoteExport},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decide(tt.scenario)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecideUnknownSupply(t *testing.T) {
+	_, err := Decide(Scenario{SellerCountry: "ES", BuyerCountry: "ES", Supply: "rent"})
+	if !errors.Is(err, ErrUnknownSupply) {
+		t.Errorf("got %v, want ErrUnknownSupply", err)
+	}
+}
+
+func TestEquivalenceSurcharge(t *testing.T) {
+	if rate, ok := EquivalenceSurcharge(21); !ok || rate != 5.2 {
+		t.Errorf("got %v, %v; want 5.2", rate, ok)
+	}
+
+	if _, ok := EquivalenceSurcharge(7); ok {
+		t.Error("got a surcharge for a 7% rate")
+	}
+}
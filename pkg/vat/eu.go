@@ -0,0 +1,334 @@
+package vat
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	nifLetters = "TRWAGMYFPDXBNJZSQVHLCKE"
+	cifLetters = "JABCDEFGHI"
+	ieLetters  = "WABCDEFGHIJKLMNOPQRSTUV"
+)
+
+// euValidators returns the validators of the EU member states, keyed by ISO
+// country code. Check digit algorithms follow the VIES documentation of each
+// tax administration.
+//
+//nolint:funlen //one entry per country
+func euValidators() map[string]Validator {
+	return map[string]Validator{
+		"AT": pattern(`U[0-9]{8}`, "U and 8 digits", checkAT),
+		"BE": ValidatorFunc(validateBE),
+		"BG": pattern(`[0-9]{9,10}`, "9 or 10 digits", checkBG),
+		"CY": pattern(`[0-59][0-9]{7}[A-Z]`, "8 digits and a letter", checkCY),
+		"CZ": pattern(`[0-9]{8,10}`, "8 to 10 digits", checkCZ),
+		"DE": pattern(`[1-9][0-9]{8}`, "9 digits not starting with 0", func(n string) error {
+			return checkDigit("ISO 7064 MOD 11,10", mod11x10(n[:8]), n[8:])
+		}),
+		"DK": pattern(`[1-9][0-9]{7}`, "8 digits not starting with 0", func(n string) error {
+			return checkZero("modulus 11", weighted(n, 2, 7, 6, 5, 4, 3, 2, 1)%11) //nolint:mnd //modulus
+		}),
+		"EE": pattern(`10[0-9]{7}`, "9 digits starting with 10", func(n string) error {
+			return checkDigit("weighted modulus 10", (10-weighted(n, 3, 7, 1, 3, 7, 1, 3, 7)%10)%10, n[8:]) //nolint:mnd //modulus
+		}),
+		"ES": pattern(`[0-9]{8}[A-Z]|[XYZKLM][0-9]{7}[A-Z]|[ABCDEFGHJNPQRSUVW][0-9]{7}[0-9A-J]`,
+			"8 digits and a letter, or a letter, 7 digits and a letter or digit", checkES),
+		"FI": pattern(`[0-9]{8}`, "8 digits", checkFI),
+		"FR": pattern(`[0-9A-HJ-NP-Z]{2}[0-9]{9}`, "2 letters or digits and 9 digits", checkFR),
+		"GR": pattern(`[0-9]{9}`, "9 digits", checkGR),
+		"HR": pattern(`[0-9]{11}`, "11 digits", func(n string) error {
+			return checkDigit("ISO 7064 MOD 11,10", mod11x10(n[:10]), n[10:])
+		}),
+		"HU": pattern(`[0-9]{8}`, "8 digits", func(n string) error {
+			return checkDigit("weighted modulus 10", (10-weighted(n, 9, 7, 3, 1, 9, 7, 3)%10)%10, n[7:]) //nolint:mnd //modulus
+		}),
+		"IE": pattern(`[0-9]{7}[A-W][A-IW]?|[0-9][A-Z+*][0-9]{5}[A-W]`,
+			"7 digits and 1 or 2 letters, or a digit, a letter, 5 digits and a letter", checkIE),
+		"IT": pattern(`[0-9]{11}`, "11 digits", func(n string) error {
+			return checkTrue("Luhn", luhn(n))
+		}),
+		"LT": pattern(`[0-9]{9}|[0-9]{12}`, "9 or 12 digits", checkLT),
+		"LU": pattern(`[0-9]{8}`, "8 digits", func(n string) error {
+			first, _ := strconv.Atoi(n[:6]) //nolint:errcheck //digits only
+
+			return checkNumber("modulus 89", first%89, n[6:]) //nolint:mnd //modulus
+		}),
+		"LV": pattern(`[0-9]{11}`, "11 digits", checkLV),
+		"MT": pattern(`[1-9][0-9]{7}`, "8 digits not starting with 0", func(n string) error {
+			return checkNumber("modulus 37", 37-weighted(n, 3, 4, 6, 7, 8, 9)%37, n[6:]) //nolint:mnd //modulus
+		}),
+		"NL": pattern(`[0-9]{9}B[0-9]{2}`, "9 digits, B and 2 digits", checkNL),
+		"PL": pattern(`[0-9]{10}`, "10 digits", func(n string) error {
+			return checkDigit("modulus 11", weighted(n, 6, 5, 7, 2, 3, 4, 5, 6, 7)%11, n[9:]) //nolint:mnd //modulus
+		}),
+		"PT": pattern(`[1-9][0-9]{8}`, "9 digits not starting with 0", func(n string) error {
+			return checkDigit("modulus 11", mod11Complement(weighted(n, 9, 8, 7, 6, 5, 4, 3, 2)), n[8:])
+		}),
+		"RO": pattern(`[1-9][0-9]{1,9}`, "2 to 10 digits not starting with 0", checkRO),
+		"SE": pattern(`[0-9]{10}01`, "12 digits ending in 01", func(n string) error {
+			return checkTrue("Luhn", luhn(n[:10]))
+		}),
+		"SI": pattern(`[1-9][0-9]{7}`, "8 digits not starting with 0", checkSI),
+		"SK": pattern(`[1-9][0-9]{9}`, "10 digits not starting with 0", func(n string) error {
+			number, _ := strconv.ParseInt(n, 10, 64) //nolint:errcheck //digits only
+
+			return checkZero("modulus 11", int(number%11)) //nolint:mnd //modulus
+		}),
+	}
+}
+
+func checkDigit(rule string, expected int, digit string) error {
+	if expected < 0 || expected > 9 || strconv.Itoa(expected) != digit[:1] {
+		return checksumError(rule)
+	}
+
+	return nil
+}
+
+func checkNumber(rule string, expected int, number string) error {
+	got, err := strconv.Atoi(number)
+	if err != nil || got != expected {
+		return checksumError(rule)
+	}
+
+	return nil
+}
+
+func checkZero(rule string, remainder int) error {
+	return checkTrue(rule, remainder == 0)
+}
+
+func checkTrue(rule string, ok bool) error {
+	if !ok {
+		return checksumError(rule)
+	}
+
+	return nil
+}
+
+// mod11Complement is the common "11 minus the remainder" check digit, 0 when
+// it would have two digits.
+func mod11Complement(sum int) int {
+	check := 11 - sum%11 //nolint:mnd //modulus
+	if check > 9 {       //nolint:mnd //single digit
+		return 0
+	}
+
+	return check
+}
+
+func checkAT(n string) error {
+	sum := 0
+
+	for i, d := range digits(n[1:8]) {
+		if i%2 == 1 {
+			d *= 2
+			d = d/10 + d%10 //nolint:mnd //sum of the digits
+		}
+
+		sum += d
+	}
+
+	return checkDigit("weighted modulus 10", (10-(sum+4)%10)%10, n[8:]) //nolint:mnd //algorithm constants
+}
+
+func validateBE(n string) error {
+	if len(n) == 9 { //nolint:mnd //numbers before 2007 had 9 digits
+		n = "0" + n
+	}
+
+	return pattern(`[01][0-9]{9}`, "10 digits starting with 0 or 1", func(n string) error {
+		first, _ := strconv.Atoi(n[:8]) //nolint:errcheck //digits only
+
+		return checkNumber("modulus 97", 97-first%97, n[8:]) //nolint:mnd //modulus
+	}).Validate(n)
+}
+
+func checkBG(n string) error {
+	if len(n) != 9 { //nolint:mnd //numbers of individuals are not checked
+		return nil
+	}
+
+	check := weighted(n, 1, 2, 3, 4, 5, 6, 7, 8) % 11 //nolint:mnd //modulus
+	if check == 10 {                                  //nolint:mnd //second pass
+		check = weighted(n, 3, 4, 5, 6, 7, 8, 9, 10) % 11 % 10 //nolint:mnd //modulus
+	}
+
+	return checkDigit("modulus 11", check, n[8:])
+}
+
+func checkCY(n string) error {
+	odd := [10]int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21}
+	sum := 0
+
+	for i, d := range digits(n[:8]) {
+		if i%2 == 0 {
+			d = odd[d]
+		}
+
+		sum += d
+	}
+
+	return checkTrue("check letter", n[8] == byte('A'+sum%26)) //nolint:mnd //letters
+}
+
+func checkCZ(n string) error {
+	if len(n) != 8 { //nolint:mnd //numbers of individuals are not checked
+		return nil
+	}
+
+	check := 11 - weighted(n, 8, 7, 6, 5, 4, 3, 2)%11 //nolint:mnd //modulus
+
+	return checkDigit("modulus 11", check%10, n[7:]) //nolint:mnd //10 gives 0 and 11 gives 1
+}
+
+func checkES(n string) error {
+	switch {
+	case n[0] >= '0' && n[0] <= '9':
+		number, _ := strconv.Atoi(n[:8]) //nolint:errcheck //digits only
+
+		return checkTrue("NIF check letter", n[8] == nifLetters[number%23]) //nolint:mnd //letters
+	case strings.ContainsRune("XYZ", rune(n[0])):
+		number, _ := strconv.Atoi(strconv.Itoa(strings.IndexByte("XYZ", n[0])) + n[1:8]) //nolint:errcheck //digits only
+
+		return checkTrue("NIE check letter", n[8] == nifLetters[number%23]) //nolint:mnd //letters
+	case strings.ContainsRune("KLM", rune(n[0])):
+		number, _ := strconv.Atoi(n[1:8]) //nolint:errcheck //digits only
+
+		return checkTrue("NIF check letter", n[8] == nifLetters[number%23]) //nolint:mnd //letters
+	}
+
+	sum := 0
+
+	for i, d := range digits(n[1:8]) {
+		if i%2 == 0 {
+			d *= 2
+			d = d/10 + d%10 //nolint:mnd //sum of the digits
+		}
+
+		sum += d
+	}
+
+	control := (10 - sum%10) % 10 //nolint:mnd //modulus
+	last := n[8]
+	digit, letter := byte('0'+control), cifLetters[control]
+
+	switch {
+	case strings.ContainsRune("ABEH", rune(n[0])):
+		return checkTrue("CIF control digit", last == digit)
+	case strings.ContainsRune("PQSNW", rune(n[0])):
+		return checkTrue("CIF control letter", last == letter)
+	default:
+		return checkTrue("CIF control character", last == digit || last == letter)
+	}
+}
+
+func checkFI(n string) error {
+	remainder := weighted(n, 7, 9, 10, 5, 8, 4, 2) % 11 //nolint:mnd //modulus
+	if remainder == 1 {
+		return checksumError("modulus 11")
+	}
+
+	check := 0
+	if remainder > 0 {
+		check = 11 - remainder //nolint:mnd //modulus
+	}
+
+	return checkDigit("modulus 11", check, n[7:])
+}
+
+func checkFR(n string) error {
+	key, err := strconv.Atoi(n[:2])
+	if err != nil {
+		return nil //nolint:nilerr //alphanumeric keys of new companies have no public algorithm
+	}
+
+	if !luhn(n[2:]) {
+		return checksumError("SIREN Luhn")
+	}
+
+	siren, _ := strconv.Atoi(n[2:]) //nolint:errcheck //digits only
+
+	return checkTrue("key modulus 97", key == (12+3*(siren%97))%97) //nolint:mnd //algorithm constants
+}
+
+func checkGR(n string) error {
+	return checkDigit("modulus 11", weighted(n, 256, 128, 64, 32, 16, 8, 4, 2)%11%10, n[8:]) //nolint:mnd //powers of 2
+}
+
+func checkIE(n string) error {
+	if n[1] < '0' || n[1] > '9' {
+		return nil // old format numbers are only checked by their format
+	}
+
+	sum := weighted(n, 8, 7, 6, 5, 4, 3, 2)
+	if len(n) == 9 && n[8] != 'W' { //nolint:mnd //second letter of numbers since 2013
+		sum += int(n[8]-'A'+1) * 9 //nolint:mnd //weight of the second letter
+	}
+
+	return checkTrue("check letter", n[7] == ieLetters[sum%23]) //nolint:mnd //letters
+}
+
+func checkLT(n string) error {
+	size := len(n) - 1
+	first, second := make([]int, size), make([]int, size)
+
+	for i := range size {
+		first[i] = 1 + i%9      //nolint:mnd //weights 1 to 9
+		second[i] = 1 + (i+2)%9 //nolint:mnd //weights 3 to 9 and 1 to 9
+	}
+
+	check := weighted(n, first...) % 11 //nolint:mnd //modulus
+	if check == 10 {                    //nolint:mnd //second pass
+		check = weighted(n, second...) % 11 % 10 //nolint:mnd //modulus
+	}
+
+	return checkDigit("modulus 11", check, n[size:])
+}
+
+func checkLV(n string) error {
+	if n[0] <= '3' {
+		return nil // numbers of individuals are only checked by their format
+	}
+
+	check := 3 - weighted(n, 9, 1, 4, 8, 3, 10, 2, 5, 7, 6)%11 //nolint:mnd //algorithm constants
+	if check == -1 {
+		return checksumError("modulus 11")
+	}
+
+	if check < -1 {
+		check += 11 //nolint:mnd //modulus
+	}
+
+	return checkDigit("modulus 11", check, n[10:])
+}
+
+func checkNL(n string) error {
+	// Numbers of sole proprietors since 2020 use modulus 97 on the whole
+	// number, with the letters of "NL" and "B" as numbers
+	numeric := strings.NewReplacer("B", "11").Replace("2321" + n)
+	if mod97(numeric) == 1 {
+		return nil
+	}
+
+	check := weighted(n, 9, 8, 7, 6, 5, 4, 3, 2) % 11 //nolint:mnd //modulus
+
+	return checkDigit("modulus 11", check, n[8:])
+}
+
+func checkRO(n string) error {
+	n = strings.Repeat("0", 10-len(n)) + n                         //nolint:mnd //padded to 10 digits
+	check := weighted(n, 7, 5, 3, 2, 1, 7, 5, 3, 2) * 10 % 11 % 10 //nolint:mnd //algorithm constants
+
+	return checkDigit("modulus 11", check, n[9:])
+}
+
+func checkSI(n string) error {
+	check := 11 - weighted(n, 8, 7, 6, 5, 4, 3, 2)%11 //nolint:mnd //modulus
+	if check == 11 {                                  //nolint:mnd //not allowed
+		return checksumError("modulus 11")
+	}
+
+	return checkDigit("modulus 11", check%10, n[7:]) //nolint:mnd //10 gives 0
+}
@@ -0,0 +1,56 @@
+package vat
+
+import (
+	"strconv"
+	"strings"
+)
+
+// einInvalidPrefixes are the EIN prefixes the IRS never assigned.
+const einInvalidPrefixes = " 00 07 08 09 17 18 19 28 29 49 69 70 78 79 89 96 97 "
+
+// otherValidators returns the validators of tax numbers outside the EU, keyed
+// by ISO country code.
+func otherValidators() map[string]Validator {
+	return map[string]Validator{
+		"CH": pattern(`E[0-9]{9}(MWST|TVA|IVA)?`, "E and 9 digits, then MWST, TVA or IVA or nothing", checkCH),
+		"GB": pattern(`[0-9]{9}|[0-9]{12}|GD[0-4][0-9]{2}|HA[5-9][0-9]{2}`, "9 or 12 digits, or GD or HA and 3 digits", checkGB),
+		"NO": pattern(`[89][0-9]{8}(MVA)?`, "9 digits starting with 8 or 9, then MVA or nothing", checkNO),
+		"US": pattern(`[0-9]{9}`, "9 digits", func(n string) error {
+			if strings.Contains(einInvalidPrefixes, " "+n[:2]+" ") {
+				return formatError("EIN prefix")
+			}
+
+			return nil
+		}),
+	}
+}
+
+func checkCH(n string) error {
+	check := 11 - weighted(n[1:], 5, 4, 3, 2, 7, 6, 5, 4)%11 //nolint:mnd //modulus
+	if check == 10 {                                         //nolint:mnd //not allowed
+		return checksumError("UID modulus 11")
+	}
+
+	return checkDigit("UID modulus 11", check%11, n[9:]) //nolint:mnd //11 gives 0
+}
+
+func checkGB(n string) error {
+	if n[0] == 'G' || n[0] == 'H' {
+		return nil // government departments and health authorities
+	}
+
+	last, _ := strconv.Atoi(n[7:9]) //nolint:errcheck //digits only
+	sum := weighted(n, 8, 7, 6, 5, 4, 3, 2) + last
+
+	// Numbers issued since 2010 add 55 to the sum
+	return checkTrue("modulus 97", sum%97 == 0 || (sum+55)%97 == 0) //nolint:mnd //algorithm constants
+}
+
+func checkNO(n string) error {
+	check := 11 - weighted(n, 3, 2, 7, 6, 5, 4, 3, 2)%11 //nolint:mnd //modulus
+	if check == 10 {                                     //nolint:mnd //not allowed
+		return checksumError("modulus 11")
+	}
+
+	return checkDigit("modulus 11", check%11, n[8:]) //nolint:mnd //11 gives 0
+}
@@ -0,0 +1,239 @@
+// Package vat validates VAT and tax identification numbers by their format and
+// the check digit algorithm of each country.
+package vat
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const countryCodeLength = 2
+
+var (
+	ErrFormat         = errors.New("format not valid")
+	ErrChecksum       = errors.New("check digit not valid")
+	ErrUnknownCountry = errors.New("country not supported")
+)
+
+// Error reports why a number is not valid. Err is ErrFormat, ErrChecksum or
+// ErrUnknownCountry, and Rule names the rule that failed.
+type Error struct {
+	VatID   string
+	Country string
+	Rule    string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("vat: %s: %s", e.VatID, e.Err)
+	}
+
+	return fmt.Sprintf("vat: %s: %s: %s", e.VatID, e.Rule, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Validator validates the number of a country, without the country prefix and
+// with spaces, dots and dashes removed.
+type Validator interface {
+	Validate(number string) error
+}
+
+// ValidatorFunc adapts a function to the Validator interface.
+type ValidatorFunc func(number string) error
+
+func (f ValidatorFunc) Validate(number string) error {
+	return f(number)
+}
+
+var (
+	mu         sync.RWMutex
+	validators = map[string]Validator{}
+)
+
+// Register sets the validator of a country, by its ISO 3166-1 alpha-2 code,
+// replacing the built-in one if any.
+func Register(country string, v Validator) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	validators[strings.ToUpper(country)] = v
+}
+
+// Validate checks a VAT number prefixed by its country code, such as
+// "ESB12345674" or "EL094259216". The returned error is an *Error.
+func Validate(vatID string) error {
+	number := Normalize(vatID)
+	country := Country(number)
+
+	if country == "" {
+		return &Error{VatID: vatID, Rule: "country prefix", Err: ErrFormat}
+	}
+
+	mu.RLock()
+	v, ok := validators[country]
+	mu.RUnlock()
+
+	if !ok {
+		return &Error{VatID: vatID, Country: country, Err: ErrUnknownCountry}
+	}
+
+	err := v.Validate(number[countryCodeLength:])
+	if err == nil {
+		return nil
+	}
+
+	var vatErr *Error
+	if errors.As(err, &vatErr) {
+		vatErr.VatID = vatID
+		vatErr.Country = country
+
+		return vatErr
+	}
+
+	return &Error{VatID: vatID, Country: country, Err: err}
+}
+
+// Normalize returns the number in upper case without spaces, dots and dashes.
+func Normalize(vatID string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", ".", "", "-", "").Replace(vatID))
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the prefix of a VAT number.
+// Greek numbers use the "EL" prefix for the "GR" country.
+func Country(vatID string) string {
+	vatID = strings.ToUpper(strings.TrimSpace(vatID))
+	if len(vatID) < countryCodeLength {
+		return ""
+	}
+
+	code := vatID[:countryCodeLength]
+
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+	}
+
+	if code == "EL" {
+		return "GR"
+	}
+
+	return code
+}
+
+func formatError(rule string) error {
+	return &Error{Rule: rule, Err: ErrFormat}
+}
+
+func checksumError(rule string) error {
+	return &Error{Rule: rule, Err: ErrChecksum}
+}
+
+// pattern returns a validator checking number against the anchored regular
+// expression expr and then running the check digit algorithms. format describes
+// expr for the error of the numbers not matching it.
+func pattern(expr, format string, checks ...func(string) error) Validator {
+	re := regexp.MustCompile(`^(?:` + expr + `)$`)
+
+	return ValidatorFunc(func(number string) error {
+		if !re.MatchString(number) {
+			return formatError("expected " + format)
+		}
+
+		for _, check := range checks {
+			err := check(number)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// digits returns the decimal digits of s, which shall only hold digits.
+func digits(s string) []int {
+	out := make([]int, len(s))
+	for i, r := range s {
+		out[i] = int(r - '0')
+	}
+
+	return out
+}
+
+// weighted returns the sum of the digits of s multiplied by weights.
+func weighted(s string, weights ...int) int {
+	sum := 0
+
+	for i, d := range digits(s[:len(weights)]) {
+		sum += d * weights[i]
+	}
+
+	return sum
+}
+
+// luhn reports whether s, digits only, passes the Luhn algorithm.
+func luhn(s string) bool {
+	sum := 0
+
+	for i, d := range digits(s) {
+		if (len(s)-i)%2 == 0 {
+			d *= 2
+			if d > 9 { //nolint:mnd //sum of the digits of d
+				d -= 9
+			}
+		}
+
+		sum += d
+	}
+
+	return sum%10 == 0
+}
+
+// mod11x10 computes the ISO 7064 MOD 11,10 check digit of s.
+func mod11x10(s string) int {
+	product := 10
+
+	for _, d := range digits(s) {
+		sum := (d + product) % 10 //nolint:mnd //algorithm constant
+		if sum == 0 {
+			sum = 10
+		}
+
+		product = (2 * sum) % 11 //nolint:mnd //algorithm constant
+	}
+
+	check := 11 - product
+	if check == 10 { //nolint:mnd //algorithm constant
+		check = 0
+	}
+
+	return check
+}
+
+// mod97 returns the remainder of the division of the decimal number s by 97.
+func mod97(s string) int {
+	remainder := 0
+	for _, d := range digits(s) {
+		remainder = (remainder*10 + d) % 97 //nolint:mnd //algorithm constant
+	}
+
+	return remainder
+}
+
+func init() {
+	for country, v := range euValidators() {
+		Register(country, v)
+	}
+
+	for country, v := range otherValidators() {
+		Register(country, v)
+	}
+}
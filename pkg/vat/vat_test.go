@@ -0,0 +1,88 @@
+package vat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateFormatError(t *testing.T) {
+	err := Validate("ES1234")
+
+	var vatErr *Error
+	if !errors.As(err, &vatErr) || !errors.Is(err, ErrFormat) {
+		t.Fatalf("got %v, want a format *Error", err)
+	}
+
+	if vatErr.Country != "ES" || strings.ContainsAny(vatErr.Rule, "[]{}|") {
+		t.Errorf("got country %q, rule %q; want ES and a readable rule", vatErr.Country, vatErr.Rule)
+	}
+
+	want := "vat: ES1234: expected 8 digits and a letter, or a letter, 7 digits and a letter or digit: format not valid"
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
+
+func TestValidateCheckDigits(t *testing.T) {
+	valid := []string{
+		"ATU13585627",
+		"DE136695976",
+		"EL094259216",
+		"ES12345678Z",
+		"ESB12345674",
+		"ESX1234567L",
+		"FR40303265045",
+		"IT00743110157",
+		"NL004495445B01",
+		"GB980780684",
+		"CHE-116.281.710",
+		"NO974760673MVA",
+	}
+
+	for _, vatID := range valid {
+		if err := Validate(vatID); err != nil {
+			t.Errorf("%s: %v", vatID, err)
+		}
+	}
+
+	invalid := []string{
+		"ATU13585626",
+		"DE136695975",
+		"EL094259215",
+		"ES12345678A",
+		"ESB12345675",
+		"FR41303265045",
+		"IT00743110158",
+		"NL004495446B01",
+		"GB980780685",
+		"CHE-116.281.711",
+	}
+
+	for _, vatID := range invalid {
+		if err := Validate(vatID); !errors.Is(err, ErrChecksum) {
+			t.Errorf("%s: got %v, want a checksum error", vatID, err)
+		}
+	}
+}
+
+func TestValidateUnknownCountry(t *testing.T) {
+	if err := Validate("ZZ123456"); !errors.Is(err, ErrUnknownCountry) {
+		t.Errorf("got %v, want ErrUnknownCountry", err)
+	}
+}
+
+func TestCountry(t *testing.T) {
+	tests := map[string]string{
+		"ESB12345674": "ES",
+		"el094259216": "GR",
+		"12345678Z":   "",
+		"E":           "",
+	}
+
+	for vatID, want := range tests {
+		if got := Country(vatID); got != want {
+			t.Errorf("Country(%q) = %q, want %q", vatID, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package verifactu
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// StatusCorrect and the other values are the states (EstadoRegistro) the
+	// AEAT gives to every submitted record.
+	StatusCorrect          = "Correcto"
+	StatusAcceptedWithErrs = "AceptadoConErrores"
+	StatusIncorrect        = "Incorrecto"
+
+	// DefaultEndpoint is the local stand-in started by "verifactu serve".
+	DefaultEndpoint = "http://localhost:8765/verifactu"
+
+	submitTimeout = 30 * time.Second
+)
+
+var ErrSubmission = errors.New("verifactu: submission failed")
+
+// Response is the answer of the AEAT to a submission.
+type Response struct {
+	CSV    string         `xml:"Body>RespuestaRegFactuSistemaFacturacion>CSV"`
+	Status string         `xml:"Body>RespuestaRegFactuSistemaFacturacion>EstadoEnvio"`
+	Lines  []ResponseLine `xml:"Body>RespuestaRegFactuSistemaFacturacion>RespuestaLinea"`
+	Fault  string         `xml:"Body>Fault>faultstring"`
+}
+
+// ResponseLine is the result of a single record.
+type ResponseLine struct {
+	InvoiceID   string `xml:"IDFactura>NumSerieFactura"`
+	Operation   string `xml:"Operacion>TipoOperacion"`
+	Status      string `xml:"EstadoRegistro"`
+	ErrorCode   string `xml:"CodigoErrorRegistro"`
+	Description string `xml:"DescripcionErrorRegistro"`
+}
+
+// Accepted reports whether the record was registered, possibly with minor
+// errors to correct later.
+func (l ResponseLine) Accepted() bool {
+	return l.Status == StatusCorrect || l.Status == StatusAcceptedWithErrs
+}
+
+// Submit posts a submission payload to endpoint and parses the answer.
+func Submit(ctx context.Context, endpoint string, payload []byte) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, submitTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSubmission, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{}
+
+	err = xml.Unmarshal(body, response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrSubmission, res.Status, err)
+	}
+
+	if response.Fault != "" {
+		return nil, fmt.Errorf("%w: %s", ErrSubmission, response.Fault)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrSubmission, res.Status)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,199 @@
+package verifactu
+
+import (
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	errorCodeHash  = "2000"
+	errorCodeChain = "2001"
+	csvBytes       = 8
+)
+
+// MockHandler is a local stand-in for the AEAT submission service. It checks
+// the hash of every record and that records are chained to the last accepted
+// one, but registers nothing.
+type MockHandler struct {
+	mu   sync.Mutex
+	last string
+}
+
+type mockRequest struct {
+	Records []struct {
+		Registration *struct {
+			ID struct {
+				Issuer string `xml:"IDEmisorFactura"`
+				Number string `xml:"NumSerieFactura"`
+				Date   string `xml:"FechaExpedicionFactura"`
+			} `xml:"IDFactura"`
+			InvoiceType string    `xml:"TipoFactura"`
+			TaxTotal    string    `xml:"CuotaTotal"`
+			Total       string    `xml:"ImporteTotal"`
+			Chain       mockChain `xml:"Encadenamiento"`
+			GeneratedAt string    `xml:"FechaHoraHusoGenRegistro"`
+			Hash        string    `xml:"Huella"`
+		} `xml:"RegistroAlta"`
+		Cancellation *struct {
+			ID struct {
+				Issuer string `xml:"IDEmisorFacturaAnulada"`
+				Number string `xml:"NumSerieFacturaAnulada"`
+				Date   string `xml:"FechaExpedicionFacturaAnulada"`
+			} `xml:"IDFactura"`
+			Chain       mockChain `xml:"Encadenamiento"`
+			GeneratedAt string    `xml:"FechaHoraHusoGenRegistro"`
+			Hash        string    `xml:"Huella"`
+		} `xml:"RegistroAnulacion"`
+	} `xml:"Body>RegFactuSistemaFacturacion>RegistroFactura"`
+}
+
+type mockChain struct {
+	First    string `xml:"PrimerRegistro"`
+	Previous string `xml:"RegistroAnterior>Huella"`
+}
+
+type mockLine struct {
+	Number      string
+	Operation   string
+	Status      string
+	ErrorCode   string
+	Description string
+}
+
+func (h *MockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := mockRequest{}
+
+	err = xml.Unmarshal(body, &req)
+	if err != nil {
+		writeFault(w, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	lines := make([]mockLine, 0, len(req.Records))
+
+	for _, rec := range req.Records {
+		var line mockLine
+
+		switch {
+		case rec.Registration != nil:
+			reg := rec.Registration
+			expected := registrationHash(reg.ID.Issuer, reg.ID.Number, reg.ID.Date, reg.InvoiceType,
+				reg.TaxTotal, reg.Total, reg.Chain.Previous, reg.GeneratedAt)
+			line = h.check(reg.ID.Number, "Alta", reg.Hash, expected, reg.Chain)
+		case rec.Cancellation != nil:
+			can := rec.Cancellation
+			expected := cancellationHash(can.ID.Issuer, can.ID.Number, can.ID.Date, can.Chain.Previous, can.GeneratedAt)
+			line = h.check(can.ID.Number, "Anulacion", can.Hash, expected, can.Chain)
+		default:
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	writeResponse(w, lines)
+}
+
+func (h *MockHandler) check(number, operation, hash, expected string, chain mockChain) mockLine {
+	line := mockLine{Number: number, Operation: operation, Status: StatusCorrect}
+
+	switch {
+	case hash != expected:
+		line.Status = StatusIncorrect
+		line.ErrorCode = errorCodeHash
+		line.Description = "La huella no es correcta"
+	case h.last != "" && chain.Previous != h.last, h.last == "" && chain.First == "" && chain.Previous == "":
+		line.Status = StatusIncorrect
+		line.ErrorCode = errorCodeChain
+		line.Description = "El registro no está encadenado al último registro aceptado"
+	default:
+		h.last = hash
+	}
+
+	return line
+}
+
+func writeResponse(w http.ResponseWriter, lines []mockLine) {
+	status := StatusCorrect
+	accepted := 0
+
+	for _, line := range lines {
+		if line.Status == StatusCorrect {
+			accepted++
+		}
+	}
+
+	switch {
+	case accepted == 0 && len(lines) > 0:
+		status = StatusIncorrect
+	case accepted < len(lines):
+		status = "ParcialmenteCorrecto"
+	}
+
+	out := &strings.Builder{}
+	out.WriteString(xml.Header)
+	out.WriteString(`<env:Envelope xmlns:env="` + nsSoap + `"><env:Body>`)
+	out.WriteString(`<tikR:RespuestaRegFactuSistemaFacturacion xmlns:tikR="` + nsResponse + `">`)
+	out.WriteString(`<tikR:CSV>` + mockCSV() + `</tikR:CSV>`)
+	out.WriteString(`<tikR:EstadoEnvio>` + status + `</tikR:EstadoEnvio>`)
+
+	for _, line := range lines {
+		out.WriteString(`<tikR:RespuestaLinea><tikR:IDFactura><NumSerieFactura>` + xmlText(line.Number) +
+			`</NumSerieFactura></tikR:IDFactura>`)
+		out.WriteString(`<tikR:Operacion><TipoOperacion>` + line.Operation + `</TipoOperacion></tikR:Operacion>`)
+		out.WriteString(`<tikR:EstadoRegistro>` + line.Status + `</tikR:EstadoRegistro>`)
+
+		if line.ErrorCode != "" {
+			out.WriteString(`<tikR:CodigoErrorRegistro>` + line.ErrorCode + `</tikR:CodigoErrorRegistro>`)
+			out.WriteString(`<tikR:DescripcionErrorRegistro>` + xmlText(line.Description) + `</tikR:DescripcionErrorRegistro>`)
+		}
+
+		out.WriteString(`</tikR:RespuestaLinea>`)
+	}
+
+	out.WriteString(`</tikR:RespuestaRegFactuSistemaFacturacion></env:Body></env:Envelope>`)
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	_, _ = io.WriteString(w, out.String()) //nolint:errcheck //nothing to do if the client is gone
+}
+
+func writeFault(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+
+	_, _ = fmt.Fprintf(w, `%s<env:Envelope xmlns:env="%s"><env:Body><env:Fault><faultcode>env:Client</faultcode>`+ //nolint:errcheck //idem
+		`<faultstring>%s</faultstring></env:Fault></env:Body></env:Envelope>`, xml.Header, nsSoap, xmlText(message))
+}
+
+func mockCSV() string {
+	buf := make([]byte, csvBytes)
+	_, _ = rand.Read(buf) //nolint:errcheck //never fails
+
+	return strings.ToUpper(fmt.Sprintf("%x", buf))
+}
+
+func xmlText(s string) string {
+	out := &strings.Builder{}
+	_ = xml.EscapeText(out, []byte(s)) //nolint:errcheck //writing to a builder never fails
+
+	return out.String()
+}
@@ -0,0 +1,209 @@
+// Package verifactu builds the invoice records required by the Spanish
+// Verifactu regulation (Real Decreto 1007/2023 and Orden HAC/1177/2024): hash
+// chained records, the verification QR code and the submission payload.
+package verifactu
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+const (
+	InvoiceTypeComplete   = "F1"
+	InvoiceTypeSimplified = "F2"
+	InvoiceTypeCorrective = "R1"
+
+	// QRURLProduction and QRURLTest are the AEAT services checking the QR
+	// code of an invoice.
+	QRURLProduction = "https://www2.agenciatributaria.gob.es/wlpl/TIKE-CONT/ValidarQR"
+	QRURLTest       = "https://prewww2.aeat.es/wlpl/TIKE-CONT/ValidarQR"
+
+	Legend = "VERI*FACTU"
+
+	dateFormat     = "02-01-2006"
+	dateTimeFormat = "2006-01-02T15:04:05-07:00"
+	countrySpain   = "ES"
+)
+
+var ErrNotSpanishIssuer = errors.New("verifactu: the issuer needs a Spanish tax number")
+
+// NewRegistration creates the registration record (registro de alta) of an
+// issued invoice, chained to previous, which is nil for the first record.
+func NewRegistration(invoice *model.Invoice, previous *model.VerifactuRecord, now time.Time) (*model.VerifactuRecord, error) {
+	issuer, err := IssuerID(invoice.From.VatID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := invoice.Totals()
+	sign := 1.
+
+	invoiceType := InvoiceTypeComplete
+
+	switch {
+	case invoice.IsCreditNote():
+		invoiceType = InvoiceTypeCorrective
+		sign = -1
+	case invoice.To.VatID == "":
+		invoiceType = InvoiceTypeSimplified
+	}
+
+	record := &model.VerifactuRecord{
+		Kind:        model.RecordRegistration,
+		IssuerID:    issuer,
+		InvoiceID:   invoice.ID,
+		InvoiceDate: invoice.Date,
+		InvoiceType: invoiceType,
+		TaxTotal:    sign * model.Round(totals.Vat+totals.Of(model.TaxKindSurcharge)),
+		Total:       sign * model.Round(totals.Subtotal+totals.Vat+totals.Of(model.TaxKindSurcharge)),
+		GeneratedAt: now,
+		Status:      model.RecordPending,
+	}
+
+	chain(record, previous)
+
+	return record, nil
+}
+
+// NewCancellation creates the cancellation record (registro de anulación) of
+// an invoice registered before.
+func NewCancellation(invoice *model.Invoice, previous *model.VerifactuRecord, now time.Time) (*model.VerifactuRecord, error) {
+	issuer, err := IssuerID(invoice.From.VatID)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &model.VerifactuRecord{
+		Kind:        model.RecordCancellation,
+		IssuerID:    issuer,
+		InvoiceID:   invoice.ID,
+		InvoiceDate: invoice.Date,
+		GeneratedAt: now,
+		Status:      model.RecordPending,
+	}
+
+	chain(record, previous)
+
+	return record, nil
+}
+
+func chain(record, previous *model.VerifactuRecord) {
+	record.Sequence = 1
+
+	if previous != nil {
+		record.Sequence = previous.Sequence + 1
+		record.Previous = previous.Ref()
+	}
+
+	record.Hash = Hash(record)
+}
+
+// Hash computes the SHA-256 fingerprint (huella) of a record as defined by
+// the AEAT: the record fields joined as a query string, including the hash of
+// the previous record.
+func Hash(record *model.VerifactuRecord) string {
+	previous := ""
+	if record.Previous != nil {
+		previous = record.Previous.Hash
+	}
+
+	if record.Kind == model.RecordCancellation {
+		return cancellationHash(record.IssuerID, record.InvoiceID, FormatDate(record.InvoiceDate),
+			previous, FormatDateTime(record.GeneratedAt))
+	}
+
+	return registrationHash(record.IssuerID, record.InvoiceID, FormatDate(record.InvoiceDate), record.InvoiceType,
+		FormatAmount(record.TaxTotal), FormatAmount(record.Total), previous, FormatDateTime(record.GeneratedAt))
+}
+
+func registrationHash(issuer, number, date, invoiceType, taxTotal, total, previous, generated string) string {
+	return fingerprint(
+		"IDEmisorFactura", issuer,
+		"NumSerieFactura", number,
+		"FechaExpedicionFactura", date,
+		"TipoFactura", invoiceType,
+		"CuotaTotal", taxTotal,
+		"ImporteTotal", total,
+		"Huella", previous,
+		"FechaHoraHusoGenRegistro", generated,
+	)
+}
+
+func cancellationHash(issuer, number, date, previous, generated string) string {
+	return fingerprint(
+		"IDEmisorFacturaAnulada", issuer,
+		"NumSerieFacturaAnulada", number,
+		"FechaExpedicionFacturaAnulada", date,
+		"Huella", previous,
+		"FechaHoraHusoGenRegistro", generated,
+	)
+}
+
+func fingerprint(pairs ...string) string {
+	fields := make([]string, 0, len(pairs)/2) //nolint:mnd //key value pairs
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fields = append(fields, pairs[i]+"="+strings.TrimSpace(pairs[i+1]))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "&")))
+
+	return strings.ToUpper(fmt.Sprintf("%x", sum))
+}
+
+// QRURL returns the URL encoded in the QR code printed on the invoice, which
+// lets the receiver check the invoice was registered.
+func QRURL(base string, record *model.VerifactuRecord) string {
+	values := []string{
+		"nif=" + url.QueryEscape(record.IssuerID),
+		"numserie=" + url.QueryEscape(record.InvoiceID),
+		"fecha=" + FormatDate(record.InvoiceDate),
+		"importe=" + FormatAmount(record.Total),
+	}
+
+	return base + "?" + strings.Join(values, "&")
+}
+
+// IssuerID returns the Spanish tax number of a VAT number, without the ES
+// prefix.
+func IssuerID(vatID string) (string, error) {
+	id := strings.ToUpper(strings.NewReplacer(" ", "", "-", "", ".", "").Replace(vatID))
+
+	if len(id) > len(countrySpain) && id[0] >= 'A' && id[0] <= 'Z' && id[1] >= 'A' && id[1] <= 'Z' {
+		if !strings.HasPrefix(id, countrySpain) {
+			return "", fmt.Errorf("%w: %s", ErrNotSpanishIssuer, vatID)
+		}
+
+		id = strings.TrimPrefix(id, countrySpain)
+	}
+
+	if id == "" {
+		return "", ErrNotSpanishIssuer
+	}
+
+	return id, nil
+}
+
+func FormatDate(t time.Time) string {
+	return t.Format(dateFormat)
+}
+
+func FormatDateTime(t time.Time) string {
+	return t.Format(dateTimeFormat)
+}
+
+func FormatAmount(v float64) string {
+	v = model.Round(v)
+	if v == 0 {
+		v = 0 // avoids "-0.00" on credit notes
+	}
+
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,102 @@
+package verifactu
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+)
+
+// The records and fingerprints of the examples in the AEAT specification of
+// the Verifactu hash (huella).
+func TestHashChain(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, cet)
+
+	first := &model.VerifactuRecord{
+		Kind:        model.RecordRegistration,
+		IssuerID:    "89890001K",
+		InvoiceID:   "12345678/G33",
+		InvoiceDate: date,
+		InvoiceType: InvoiceTypeComplete,
+		TaxTotal:    12.35,
+		Total:       123.45,
+		GeneratedAt: time.Date(2024, 1, 1, 19, 20, 30, 0, cet),
+	}
+	chain(first, nil)
+
+	second := &model.VerifactuRecord{
+		Kind:        model.RecordRegistration,
+		IssuerID:    "89890001K",
+		InvoiceID:   "12345679/G34",
+		InvoiceDate: date,
+		InvoiceType: InvoiceTypeComplete,
+		TaxTotal:    12.35,
+		Total:       123.45,
+		GeneratedAt: time.Date(2024, 1, 1, 19, 20, 35, 0, cet),
+	}
+	chain(second, first)
+
+	cancellation := &model.VerifactuRecord{
+		Kind:        model.RecordCancellation,
+		IssuerID:    "89890001K",
+		InvoiceID:   "12345679/G34",
+		InvoiceDate: date,
+		GeneratedAt: time.Date(2024, 1, 1, 19, 20, 40, 0, cet),
+	}
+	chain(cancellation, second)
+
+	wants := []string{
+		"3C464DAF61ACB827C65FDA19F352A4E3BDC2C640E9E9FC4CC058073F38F12F60",
+		"F7B94CFD8924EDFF273501B01EE5153E4CE8F259766F88CF6ACB8935802A2B97",
+		"177547C0D57AC74748561D054A9CEC14B4C4EA23D1BEFD6F2E69E3A388F90C68",
+	}
+
+	for n, record := range []*model.VerifactuRecord{first, second, cancellation} {
+		if record.Sequence != n+1 || record.Hash != wants[n] {
+			t.Errorf("record %d: got sequence %d and hash %s, want %d and %s", n, record.Sequence, record.Hash, n+1, wants[n])
+		}
+	}
+
+	if second.Previous.Hash != first.Hash || cancellation.Previous.InvoiceID != second.InvoiceID {
+		t.Errorf("records not chained: %+v, %+v", second.Previous, cancellation.Previous)
+	}
+}
+
+func TestNewRegistration(t *testing.T) {
+	invoice := model.NewInvoice("F25-001", 0, "EUR", "", "")
+	invoice.From.VatID = "ES12345678Z"
+	invoice.SetTaxes(21, 15, map[string]string{})
+	invoice.AddItem(model.Item{Description: "Consulting", Quantity: 1, Rate: 1000})
+
+	record, err := NewRegistration(invoice, nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The withholding is not part of the total reported to the AEAT
+	if record.IssuerID != "12345678Z" || record.InvoiceType != InvoiceTypeSimplified || record.TaxTotal != 210 || record.Total != 1210 {
+		t.Errorf("got %+v", record)
+	}
+
+	if record.Hash != Hash(record) {
+		t.Errorf("got hash %s, want %s", record.Hash, Hash(record))
+	}
+}
+
+func TestIssuerID(t *testing.T) {
+	if id, err := IssuerID("es 12345678-z"); err != nil || id != "12345678Z" {
+		t.Errorf("got %q, %v; want 12345678Z", id, err)
+	}
+
+	if _, err := IssuerID("DE136695976"); !errors.Is(err, ErrNotSpanishIssuer) {
+		t.Errorf("got %v, want ErrNotSpanishIssuer", err)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	if got := FormatAmount(-0.001); got != "0.00" {
+		t.Errorf("got %q, want 0.00", got)
+	}
+}
@@ -0,0 +1,328 @@
+package verifactu
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/einvoice"
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+const (
+	nsSoap     = "http://schemas.xmlsoap.org/soap/envelope/"
+	nsSumLR    = "https://www2.agenciatributaria.gob.es/static_files/common/internet/dep/aplicaciones/es/aeat/tike/cont/ws/SuministroLR.xsd"
+	nsSumInfo  = "https://www2.agenciatributaria.gob.es/static_files/common/internet/dep/aplicaciones/es/aeat/tike/cont/ws/SuministroInformacion.xsd"
+	nsResponse = "https://www2.agenciatributaria.gob.es/static_files/common/internet/dep/aplicaciones/es/aeat/tike/cont/ws/RespuestaSuministro.xsd"
+	idVersion  = "1.0"
+	hashSHA256 = "01"
+
+	taxVat            = "01"
+	regimeGeneral     = "01"
+	operationSubject  = "S1"
+	operationNotLocal = "N2"
+	exemptArticle20   = "E1"
+	otherIDVat        = "02"
+	correctiveByDiff  = "I"
+	yes               = "S"
+	no                = "N"
+	exemptIntraEU     = "E5"
+	maxDescription    = 500
+)
+
+// System describes the invoicing software, reported on every record.
+type System struct {
+	ProducerName string
+	ProducerID   string
+	Name         string
+	ID           string
+	Version      string
+	Installation string
+}
+
+// Entry is a record to submit. Invoice is needed for registrations only, and
+// CorrectedDate for registrations of credit notes.
+type Entry struct {
+	Record        *model.VerifactuRecord
+	Invoice       *model.Invoice
+	CorrectedDate time.Time
+}
+
+// Envelope is the SOAP request submitting records to the AEAT
+// (RegFactuSistemaFacturacion).
+type Envelope struct {
+	XMLName   xml.Name `xml:"soapenv:Envelope"`
+	XmlnsSoap string   `xml:"xmlns:soapenv,attr"`
+	XmlnsSum  string   `xml:"xmlns:sum,attr"`
+	XmlnsSum1 string   `xml:"xmlns:sum1,attr"`
+	Header    string   `xml:"soapenv:Header"`
+	Issuer    party    `xml:"soapenv:Body>sum:RegFactuSistemaFacturacion>sum:Cabecera>sum1:ObligadoEmision"`
+	Records   []record `xml:"soapenv:Body>sum:RegFactuSistemaFacturacion>sum:RegistroFactura"`
+}
+
+type party struct {
+	Name string `xml:"sum1:NombreRazon"`
+	NIF  string `xml:"sum1:NIF"`
+}
+
+type record struct {
+	Registration *registration `xml:"sum1:RegistroAlta,omitempty"`
+	Cancellation *cancellation `xml:"sum1:RegistroAnulacion,omitempty"`
+}
+
+type invoiceID struct {
+	Issuer string `xml:"sum1:IDEmisorFactura"`
+	Number string `xml:"sum1:NumSerieFactura"`
+	Date   string `xml:"sum1:FechaExpedicionFactura"`
+}
+
+type cancelledID struct {
+	Issuer string `xml:"sum1:IDEmisorFacturaAnulada"`
+	Number string `xml:"sum1:NumSerieFacturaAnulada"`
+	Date   string `xml:"sum1:FechaExpedicionFacturaAnulada"`
+}
+
+type registration struct {
+	IDVersion      string          `xml:"sum1:IDVersion"`
+	ID             invoiceID       `xml:"sum1:IDFactura"`
+	IssuerName     string          `xml:"sum1:NombreRazonEmisor"`
+	InvoiceType    string          `xml:"sum1:TipoFactura"`
+	CorrectiveType string          `xml:"sum1:TipoRectificativa,omitempty"`
+	Corrected      *invoiceID      `xml:"sum1:FacturasRectificadas>sum1:IDFacturaRectificada,omitempty"`
+	Description    string          `xml:"sum1:DescripcionOperacion"`
+	Recipients     []recipient     `xml:"sum1:Destinatarios>sum1:IDDestinatario,omitempty"`
+	Breakdown      []breakdownLine `xml:"sum1:Desglose>sum1:DetalleDesglose"`
+	TaxTotal       string          `xml:"sum1:CuotaTotal"`
+	Total          string          `xml:"sum1:ImporteTotal"`
+	Chain          chaining        `xml:"sum1:Encadenamiento"`
+	System         systemInfo      `xml:"sum1:SistemaInformatico"`
+	GeneratedAt    string          `xml:"sum1:FechaHoraHusoGenRegistro"`
+	HashType       string          `xml:"sum1:TipoHuella"`
+	Hash           string          `xml:"sum1:Huella"`
+}
+
+type cancellation struct {
+	IDVersion   string      `xml:"sum1:IDVersion"`
+	ID          cancelledID `xml:"sum1:IDFactura"`
+	Chain       chaining    `xml:"sum1:Encadenamiento"`
+	System      systemInfo  `xml:"sum1:SistemaInformatico"`
+	GeneratedAt string      `xml:"sum1:FechaHoraHusoGenRegistro"`
+	HashType    string      `xml:"sum1:TipoHuella"`
+	Hash        string      `xml:"sum1:Huella"`
+}
+
+type recipient struct {
+	Name    string   `xml:"sum1:NombreRazon"`
+	NIF     string   `xml:"sum1:NIF,omitempty"`
+	OtherID *otherID `xml:"sum1:IDOtro,omitempty"`
+}
+
+type otherID struct {
+	Country string `xml:"sum1:CodigoPais"`
+	Type    string `xml:"sum1:IDType"`
+	ID      string `xml:"sum1:ID"`
+}
+
+type breakdownLine struct {
+	Tax           string `xml:"sum1:Impuesto"`
+	Regime        string `xml:"sum1:ClaveRegimen"`
+	Operation     string `xml:"sum1:CalificacionOperacion,omitempty"`
+	Exemption     string `xml:"sum1:OperacionExenta,omitempty"`
+	Rate          string `xml:"sum1:TipoImpositivo,omitempty"`
+	TaxableBase   string `xml:"sum1:BaseImponibleOimporteNoSujeto"`
+	TaxCollected  string `xml:"sum1:CuotaRepercutida,omitempty"`
+	SurchargeRate string `xml:"sum1:TipoRecargoEquivalencia,omitempty"`
+	Surcharge     string `xml:"sum1:CuotaRecargoEquivalencia,omitempty"`
+}
+
+type chaining struct {
+	First    string    `xml:"sum1:PrimerRegistro,omitempty"`
+	Previous *previous `xml:"sum1:RegistroAnterior,omitempty"`
+}
+
+type previous struct {
+	invoiceID
+	Hash string `xml:"sum1:Huella"`
+}
+
+type systemInfo struct {
+	ProducerName  string `xml:"sum1:NombreRazon"`
+	ProducerID    string `xml:"sum1:NIF"`
+	Name          string `xml:"sum1:NombreSistemaInformatico"`
+	ID            string `xml:"sum1:IdSistemaInformatico"`
+	Version       string `xml:"sum1:Version"`
+	Installation  string `xml:"sum1:NumeroInstalacion"`
+	OnlyVerifactu string `xml:"sum1:TipoUsoPosibleSoloVerifactu"`
+	MultiIssuer   string `xml:"sum1:TipoUsoPosibleMultiOT"`
+	MultiIssuers  string `xml:"sum1:IndicadorMultiplesOT"`
+}
+
+// NewEnvelope builds the submission of entries on behalf of the issuer.
+func NewEnvelope(issuerName, issuerID string, system System, entries []Entry) *Envelope {
+	env := &Envelope{
+		XmlnsSoap: nsSoap,
+		XmlnsSum:  nsSumLR,
+		XmlnsSum1: nsSumInfo,
+		Issuer:    party{Name: issuerName, NIF: issuerID},
+	}
+
+	info := systemInfo{
+		ProducerName:  system.ProducerName,
+		ProducerID:    system.ProducerID,
+		Name:          system.Name,
+		ID:            system.ID,
+		Version:       system.Version,
+		Installation:  system.Installation,
+		OnlyVerifactu: yes,
+		MultiIssuer:   no,
+		MultiIssuers:  no,
+	}
+
+	for _, entry := range entries {
+		env.Records = append(env.Records, newRecord(entry, info))
+	}
+
+	return env
+}
+
+// Marshal serializes the envelope to XML.
+func (e *Envelope) Marshal() ([]byte, error) {
+	out, err := xml.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+func newRecord(entry Entry, info systemInfo) record {
+	r := entry.Record
+	chain := chaining{First: yes}
+
+	if r.Previous != nil {
+		chain = chaining{Previous: &previous{
+			invoiceID: invoiceID{
+				Issuer: r.Previous.IssuerID,
+				Number: r.Previous.InvoiceID,
+				Date:   FormatDate(r.Previous.InvoiceDate),
+			},
+			Hash: r.Previous.Hash,
+		}}
+	}
+
+	if r.Kind == model.RecordCancellation {
+		return record{Cancellation: &cancellation{
+			IDVersion:   idVersion,
+			ID:          cancelledID{Issuer: r.IssuerID, Number: r.InvoiceID, Date: FormatDate(r.InvoiceDate)},
+			Chain:       chain,
+			System:      info,
+			GeneratedAt: FormatDateTime(r.GeneratedAt),
+			HashType:    hashSHA256,
+			Hash:        r.Hash,
+		}}
+	}
+
+	invoice := entry.Invoice
+	reg := &registration{
+		IDVersion:   idVersion,
+		ID:          invoiceID{Issuer: r.IssuerID, Number: r.InvoiceID, Date: FormatDate(r.InvoiceDate)},
+		IssuerName:  issuerName(invoice),
+		InvoiceType: r.InvoiceType,
+		Description: description(invoice),
+		Breakdown:   []breakdownLine{breakdown(invoice, r)},
+		TaxTotal:    FormatAmount(r.TaxTotal),
+		Total:       FormatAmount(r.Total),
+		Chain:       chain,
+		System:      info,
+		GeneratedAt: FormatDateTime(r.GeneratedAt),
+		HashType:    hashSHA256,
+		Hash:        r.Hash,
+	}
+
+	if r.InvoiceType == InvoiceTypeCorrective {
+		reg.CorrectiveType = correctiveByDiff
+		reg.Corrected = &invoiceID{Issuer: r.IssuerID, Number: invoice.Corrects, Date: FormatDate(entry.CorrectedDate)}
+	}
+
+	if r.InvoiceType != InvoiceTypeSimplified {
+		reg.Recipients = []recipient{newRecipient(&invoice.To)}
+	}
+
+	return record{Registration: reg}
+}
+
+func breakdown(invoice *model.Invoice, r *model.VerifactuRecord) breakdownLine {
+	totals := invoice.Totals()
+	surcharge := totals.Of(model.TaxKindSurcharge)
+
+	sign := 1.
+	if invoice.IsCreditNote() {
+		sign = -1
+	}
+
+	line := breakdownLine{
+		Tax:         taxVat,
+		Regime:      regimeGeneral,
+		TaxableBase: FormatAmount(r.Total - r.TaxTotal),
+	}
+
+	switch category, _ := einvoice.VatCategory(invoice); category {
+	case einvoice.VatCategoryStandard, einvoice.VatCategoryZero:
+		line.Operation = operationSubject
+		line.Rate = FormatAmount(invoice.Tax.VatRate())
+		line.TaxCollected = FormatAmount(sign * totals.Vat)
+
+		if surcharge != 0 {
+			line.SurchargeRate = FormatAmount(invoice.Tax.Rate(model.TaxKindSurcharge))
+			line.Surcharge = FormatAmount(sign * surcharge)
+		}
+	case einvoice.VatCategoryExempt:
+		line.Exemption = exemptArticle20
+	case einvoice.VatCategoryIntraEU:
+		line.Exemption = exemptIntraEU
+	default:
+		line.Operation = operationNotLocal
+	}
+
+	return line
+}
+
+func newRecipient(client *model.Client) recipient {
+	country := vat.Country(client.VatID)
+	if country == "" || country == countrySpain {
+		id, _ := IssuerID(client.VatID) //nolint:errcheck //the id is known to be Spanish
+		return recipient{Name: client.Name, NIF: id}
+	}
+
+	return recipient{
+		Name:    client.Name,
+		OtherID: &otherID{Country: country, Type: otherIDVat, ID: strings.ReplaceAll(client.VatID, " ", "")},
+	}
+}
+
+func issuerName(invoice *model.Invoice) string {
+	if invoice.From.Company != "" {
+		return invoice.From.Company
+	}
+
+	return invoice.From.Name
+}
+
+func description(invoice *model.Invoice) string {
+	items := make([]string, 0, len(invoice.Items))
+	for _, item := range invoice.Items {
+		items = append(items, item.Description)
+	}
+
+	desc := strings.Join(items, "; ")
+	if desc == "" {
+		desc = invoice.ID
+	}
+
+	if len([]rune(desc)) > maxDescription {
+		desc = string([]rune(desc)[:maxDescription])
+	}
+
+	return desc
+}
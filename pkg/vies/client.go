@@ -0,0 +1,188 @@
+// Package vies checks VAT numbers against VIES, the VAT Information Exchange
+// System of the European Commission.
+package vies
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/model"
+	"github.com/Inmovilizame/invoiceling/pkg/tax"
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+const (
+	// ProductionEndpoint is the check-vat-number operation of the VIES REST
+	// API.
+	ProductionEndpoint = "https://ec.europa.eu/taxation_customs/vies/rest-api/check-vat-number"
+
+	// MockPath is the path of the check-vat-number operation in the local
+	// stand-in started by "vies serve".
+	MockPath = "/vies/check-vat-number"
+
+	checkTimeout = 20 * time.Second
+)
+
+var (
+	ErrNotMember = errors.New("vies: not an EU VAT number")
+	ErrCheck     = errors.New("vies: check failed")
+)
+
+// northernIreland is the VIES prefix of traders in Northern Ireland, which
+// stays in the EU VAT area for goods.
+const northernIreland = "XI"
+
+type request struct {
+	CountryCode              string `json:"countryCode"`
+	VatNumber                string `json:"vatNumber"`
+	RequesterMemberStateCode string `json:"requesterMemberStateCode,omitempty"`
+	RequesterNumber          string `json:"requesterNumber,omitempty"`
+}
+
+type response struct {
+	CountryCode       string    `json:"countryCode"`
+	VatNumber         string    `json:"vatNumber"`
+	RequestDate       time.Time `json:"requestDate"`
+	Valid             bool      `json:"valid"`
+	RequestIdentifier string    `json:"requestIdentifier"`
+	Name              string    `json:"name"`
+	Address           string    `json:"address"`
+	ActionSucceed     *bool     `json:"actionSucceed"`
+	ErrorWrappers     []struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	} `json:"errorWrappers"`
+}
+
+// Checker queries the VIES REST API at Endpoint. Requester is the VAT number
+// of the freelancer, needed to get a consultation number.
+type Checker struct {
+	Endpoint  string
+	Requester string
+}
+
+func NewChecker(endpoint, requester string) *Checker {
+	if endpoint == "" {
+		endpoint = ProductionEndpoint
+	}
+
+	return &Checker{
+		Endpoint:  endpoint,
+		Requester: requester,
+	}
+}
+
+// IsMember reports whether a VAT number belongs to a country in VIES.
+func IsMember(vatID string) bool {
+	country := vat.Country(vat.Normalize(vatID))
+
+	return tax.IsEU(country) || country == northernIreland
+}
+
+// Check asks VIES whether vatID is active for intra-community trade.
+func (c *Checker) Check(ctx context.Context, vatID string) (*model.ViesCheck, error) {
+	number := vat.Normalize(vatID)
+	if !IsMember(number) {
+		return nil, fmt.Errorf("%w: %s", ErrNotMember, vatID)
+	}
+
+	req := request{CountryCode: prefix(number), VatNumber: number[2:]}
+
+	if requester := vat.Normalize(c.Requester); IsMember(requester) {
+		req.RequesterMemberStateCode = prefix(requester)
+		req.RequesterNumber = requester[2:]
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.post(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	checkedAt := res.RequestDate
+	if checkedAt.IsZero() {
+		checkedAt = time.Now()
+	}
+
+	return &model.ViesCheck{
+		Valid:              res.Valid,
+		CheckedAt:          checkedAt,
+		ConsultationNumber: res.RequestIdentifier,
+		Name:               cleanField(res.Name),
+		Address:            cleanField(res.Address),
+	}, nil
+}
+
+func (c *Checker) post(ctx context.Context, payload []byte) (*response, error) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCheck, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &response{}
+
+	err = json.Unmarshal(body, out)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCheck, res.Status, err)
+	}
+
+	// Errors such as MS_UNAVAILABLE come with actionSucceed set to false
+	if len(out.ErrorWrappers) > 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrCheck, out.ErrorWrappers[0].Error, out.ErrorWrappers[0].Message)
+	}
+
+	if res.StatusCode != http.StatusOK || (out.ActionSucceed != nil && !*out.ActionSucceed) {
+		return nil, fmt.Errorf("%w: %s", ErrCheck, res.Status)
+	}
+
+	return out, nil
+}
+
+// prefix returns the VIES prefix of a normalized VAT number, EL for Greece.
+func prefix(number string) string {
+	country := vat.Country(number)
+	if country == "GR" {
+		return "EL"
+	}
+
+	return country
+}
+
+// cleanField drops the "---" VIES returns for data a member state does not
+// share.
+func cleanField(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "---" {
+		return ""
+	}
+
+	return s
+}
@@ -0,0 +1,161 @@
+package vies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer answers every request with status and body, passing the
+// decoded request to got.
+func newTestServer(t *testing.T, status int, body string, got *request) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got a %s request of %s, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		if got != nil {
+			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+				t.Error(err)
+			}
+		}
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body)) //nolint:errcheck //the test fails on the client side
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCheck(t *testing.T) {
+	var got request
+
+	srv := newTestServer(t, http.StatusOK, `{
+		"countryCode": "DE",
+		"vatNumber": "136695976",
+		"requestDate": "2025-03-14T10:30:00.000Z",
+		"valid": true,
+		"requestIdentifier": "WAPIAAAAA1B2C3D4",
+		"name": " Acme GmbH ",
+		"address": "---"
+	}`, &got)
+
+	check, err := NewChecker(srv.URL, "es 12345678-z").Check(context.Background(), "de 136 695 976")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := request{CountryCode: "DE", VatNumber: "136695976", RequesterMemberStateCode: "ES", RequesterNumber: "12345678Z"}
+	if got != want {
+		t.Errorf("got request %+v, want %+v", got, want)
+	}
+
+	if !check.Valid || !check.CheckedAt.Equal(time.Date(2025, 3, 14, 10, 30, 0, 0, time.UTC)) || check.ConsultationNumber != "WAPIAAAAA1B2C3D4" {
+		t.Errorf("got %+v, want a valid check of 2025-03-14 with its consultation number", check)
+	}
+
+	if check.Name != "Acme GmbH" || check.Address != "" {
+		t.Errorf("got name %q and address %q, want the name trimmed and no address", check.Name, check.Address)
+	}
+}
+
+func TestCheckWithoutRequester(t *testing.T) {
+	var got request
+
+	srv := newTestServer(t, http.StatusOK, `{"valid": false}`, &got)
+
+	check, err := NewChecker(srv.URL, "").Check(context.Background(), "EL094259216")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.CountryCode != "EL" || got.RequesterMemberStateCode != "" || got.RequesterNumber != "" {
+		t.Errorf("got request %+v, want the EL prefix and no requester", got)
+	}
+
+	if check.Valid || check.CheckedAt.IsZero() {
+		t.Errorf("got %+v, want an invalid check dated now", check)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"error wrapper", http.StatusOK, `{"actionSucceed": false, "errorWrappers": [{"error": "MS_UNAVAILABLE"}]}`},
+		{"action not succeeded", http.StatusOK, `{"actionSucceed": false}`},
+		{"server error", http.StatusInternalServerError, `{}`},
+		{"not JSON", http.StatusBadGateway, `<html>Bad gateway</html>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, tt.status, tt.body, nil)
+
+			_, err := NewChecker(srv.URL, "").Check(context.Background(), "DE136695976")
+			if !errors.Is(err, ErrCheck) {
+				t.Errorf("got %v, want ErrCheck", err)
+			}
+		})
+	}
+
+	if _, err := NewChecker("http://127.0.0.1:1", "").Check(context.Background(), "US123456789"); !errors.Is(err, ErrNotMember) {
+		t.Errorf("got %v, want ErrNotMember", err)
+	}
+
+	if _, err := NewChecker("http://127.0.0.1:1", "").Check(context.Background(), "DE136695976"); !errors.Is(err, ErrCheck) {
+		t.Errorf("got %v, want ErrCheck when VIES can not be reached", err)
+	}
+}
+
+func TestMockHandler(t *testing.T) {
+	srv := httptest.NewServer(&MockHandler{Inactive: map[string]bool{"FR40303265045": true}})
+	t.Cleanup(srv.Close)
+
+	checker := NewChecker(srv.URL+MockPath, "ES12345678Z")
+
+	tests := []struct {
+		vatID string
+		valid bool
+	}{
+		{"DE136695976", true},
+		{"DE136695975", false},
+		{"FR40303265045", false},
+	}
+
+	for _, tt := range tests {
+		check, err := checker.Check(context.Background(), tt.vatID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if check.Valid != tt.valid || !strings.HasPrefix(check.ConsultationNumber, "WAPI") || check.Name != "" {
+			t.Errorf("%s: got %+v, want valid %v with a consultation number", tt.vatID, check, tt.valid)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+MockPath, http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("got %s for a GET, want 405", res.Status)
+	}
+}
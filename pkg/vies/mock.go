@@ -0,0 +1,82 @@
+package vies
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Inmovilizame/invoiceling/pkg/vat"
+)
+
+const consultationBytes = 6
+
+// MockHandler is a local stand-in for the VIES REST API. Numbers passing the
+// check digit validation are reported active, except those in Inactive.
+type MockHandler struct {
+	Inactive map[string]bool
+}
+
+func (h *MockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := request{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.CountryCode == "" || req.VatNumber == "" {
+		writeError(w, "INVALID_INPUT")
+		return
+	}
+
+	number := vat.Normalize(req.CountryCode + req.VatNumber)
+	if !IsMember(number) {
+		writeError(w, "INVALID_INPUT")
+		return
+	}
+
+	res := response{
+		CountryCode: req.CountryCode,
+		VatNumber:   req.VatNumber,
+		RequestDate: time.Now(),
+		Valid:       vat.Validate(number) == nil && !h.Inactive[number],
+		Name:        "---",
+		Address:     "---",
+	}
+
+	if req.RequesterNumber != "" {
+		res.RequestIdentifier = mockConsultationNumber()
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func writeError(w http.ResponseWriter, code string) {
+	succeed := false
+
+	res := response{ActionSucceed: &succeed}
+	res.ErrorWrappers = append(res.ErrorWrappers, struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}{Error: code})
+
+	writeJSON(w, http.StatusBadRequest, res)
+}
+
+func writeJSON(w http.ResponseWriter, status int, res response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(res) //nolint:errcheck //nothing to do if the client is gone
+}
+
+func mockConsultationNumber() string {
+	buf := make([]byte, consultationBytes)
+	_, _ = rand.Read(buf) //nolint:errcheck //never fails
+
+	return "WAPI" + strings.ToUpper(fmt.Sprintf("%x", buf))
+}